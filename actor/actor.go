@@ -0,0 +1,27 @@
+// Package actor carries the identity of whoever makes a request through a
+// context, so services can record who changed what.
+package actor
+
+import "context"
+
+// System is the actor of changes made by the server itself, e.g. by CLI
+// commands or background jobs.
+const System = "system"
+
+// Unknown is recorded when a request carries no actor.
+const Unknown = "unknown"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying actor.
+func NewContext(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, contextKey{}, actor)
+}
+
+// FromContext returns the actor stored in ctx, or Unknown.
+func FromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(contextKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return Unknown
+}
@@ -1,8 +1,14 @@
 package application
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"sync"
+
+	"github.com/sergey4qb/mf1-test/config"
 	"github.com/sergey4qb/mf1-test/delivery/grpc"
+	"github.com/sergey4qb/mf1-test/delivery/http"
 	"github.com/sergey4qb/mf1-test/repository"
 	"github.com/sergey4qb/mf1-test/services"
 )
@@ -11,31 +17,143 @@ type Application struct {
 	repo     repository.Repository
 	services services.Services
 	grpc     *grpc.Server
+	// http serves the JWKS document; nil unless an HTTP address is set.
+	http *http.Server
+
+	// The outbox and webhook dispatchers run from Run until Stop cancels
+	// ctx; dispatched is closed once both have returned.
+	ctx        context.Context
+	cancel     context.CancelFunc
+	mu         sync.Mutex
+	dispatched chan struct{}
+}
+
+type options struct {
+	cfg          *config.Config
+	listener     net.Listener
+	httpListener net.Listener
+}
+
+type Option func(*options)
+
+// WithConfig replaces the configuration loaded from the environment.
+func WithConfig(cfg *config.Config) Option {
+	return func(o *options) {
+		o.cfg = cfg
+	}
 }
 
-func New() (*Application, error) {
-	repo, err := repository.New()
+// WithListener serves gRPC on listener instead of opening the configured
+// address.
+func WithListener(listener net.Listener) Option {
+	return func(o *options) {
+		o.listener = listener
+	}
+}
+
+// WithHTTPListener serves HTTP on listener instead of the configured
+// address.
+func WithHTTPListener(listener net.Listener) Option {
+	return func(o *options) {
+		o.httpListener = listener
+	}
+}
+
+func New(opts ...Option) (*Application, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.cfg == nil {
+		o.cfg = config.LoadConfig()
+	}
+
+	repo, err := repository.New(o.cfg)
 	if err != nil {
 		return nil, fmt.Errorf("Error initializing repo: %v", err)
 	}
 
-	svcs, err := services.New(repo)
+	svcs, err := services.New(o.cfg, repo)
 	if err != nil {
 		return nil, fmt.Errorf("Error initializing services: %v", err)
 	}
 
-	grpcSrv, err := grpc.New(svcs)
+	var grpcSrv *grpc.Server
+	if o.listener != nil {
+		grpcSrv, err = grpc.NewWithListener(o.listener, svcs)
+	} else {
+		grpcSrv, err = grpc.New(o.cfg, svcs)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("Error initializing grpc server: %v", err)
 	}
 
+	var httpSrv *http.Server
+	switch {
+	case o.httpListener != nil:
+		httpSrv, err = http.NewWithListener(o.httpListener, svcs)
+	case o.cfg.HTTPAddress != "":
+		httpSrv, err = http.New(o.cfg.HTTPAddress, svcs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error initializing http server: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Application{
 		repo:     repo,
 		services: svcs,
 		grpc:     grpcSrv,
+		http:     httpSrv,
+		ctx:      ctx,
+		cancel:   cancel,
 	}, nil
 }
 
+// Run serves and publishes user events until Stop is called or a server
+// fails.
 func (app *Application) Run() error {
-	return app.grpc.Start()
+	done := make(chan struct{})
+	app.mu.Lock()
+	app.dispatched = done
+	app.mu.Unlock()
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for _, run := range []func(context.Context){
+			app.services.GetOutbox().Run,
+			app.services.GetWebhookDispatcher().Run,
+		} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				run(app.ctx)
+			}()
+		}
+		wg.Wait()
+	}()
+
+	if app.http == nil {
+		return app.grpc.Start()
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- app.http.Start() }()
+	go func() { errs <- app.grpc.Start() }()
+	return <-errs
+}
+
+func (app *Application) Stop() {
+	if app.http != nil {
+		app.http.Stop()
+	}
+	app.grpc.Stop()
+	app.cancel()
+
+	app.mu.Lock()
+	done := app.dispatched
+	app.mu.Unlock()
+	if done != nil {
+		<-done
+	}
 }
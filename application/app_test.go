@@ -0,0 +1,1504 @@
+package application_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"mime/quotedprintable"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sergey4qb/mf1-test/application/apptest"
+	"github.com/sergey4qb/mf1-test/config"
+	grpcdelivery "github.com/sergey4qb/mf1-test/delivery/grpc"
+	httpdelivery "github.com/sergey4qb/mf1-test/delivery/http"
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+	"github.com/sergey4qb/mf1-test/repository/audit"
+	"github.com/sergey4qb/mf1-test/services/webhook"
+	"github.com/sergey4qb/mf1-test/totp"
+)
+
+func assertCode(t *testing.T, want codes.Code, err error) {
+	t.Helper()
+	if want == codes.OK {
+		assert.NoError(t, err)
+		return
+	}
+	assert.Equal(t, want, status.Code(err), "unexpected status: %v", err)
+}
+
+func TestCreateUser(t *testing.T) {
+	env := apptest.Start(t)
+
+	tests := []struct {
+		name string
+		req  *pb.CreateUserRequest
+		code codes.Code
+	}{
+		{name: "success", req: &pb.CreateUserRequest{Name: "Test", Email: "test@example.com"}, code: codes.OK},
+		{name: "empty name", req: &pb.CreateUserRequest{Email: "test@example.com"}, code: codes.InvalidArgument},
+		{name: "empty email", req: &pb.CreateUserRequest{Name: "Test"}, code: codes.InvalidArgument},
+		{name: "invalid email", req: &pb.CreateUserRequest{Name: "Test", Email: "invalid-email"}, code: codes.InvalidArgument},
+		{name: "display name in email", req: &pb.CreateUserRequest{Name: "Test", Email: "Test <other@example.com>"}, code: codes.InvalidArgument},
+		{name: "email in use", req: &pb.CreateUserRequest{Name: "Other", Email: "TEST@Example.com"}, code: codes.AlreadyExists},
+		{name: "id not accepted by default", req: &pb.CreateUserRequest{Name: "Test", Email: "test@example.com", Id: uuid.NewString()}, code: codes.InvalidArgument},
+		{name: "malformed id", req: &pb.CreateUserRequest{Name: "Test", Email: "test@example.com", Id: "legacy-1"}, code: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := env.Users.CreateUser(context.Background(), tt.req)
+			assertCode(t, tt.code, err)
+			if tt.code != codes.OK {
+				return
+			}
+
+			_, err = uuid.Parse(resp.GetUser().GetId())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.req.GetName(), resp.GetUser().GetName())
+			assert.Equal(t, tt.req.GetEmail(), resp.GetUser().GetEmail())
+		})
+	}
+}
+
+func TestCreateUser_PersistsToDataDir(t *testing.T) {
+	env := apptest.Start(t)
+	u := env.CreateUser(t, "Persisted", "persisted@example.com")
+
+	data, err := os.ReadFile(env.Config.UsersFilePath())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), u.GetId())
+}
+
+func TestGetUser(t *testing.T) {
+	env := apptest.Start(t)
+	existing := env.CreateUser(t, "Existing", "existing@example.com")
+
+	tests := []struct {
+		name string
+		id   string
+		code codes.Code
+	}{
+		{name: "success", id: existing.GetId(), code: codes.OK},
+		{name: "unknown id", id: uuid.NewString(), code: codes.NotFound},
+		{name: "malformed id", id: "not-a-uuid", code: codes.InvalidArgument},
+		{name: "empty id", id: "", code: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := env.Users.GetUser(context.Background(), &pb.GetUserRequest{Id: tt.id})
+			assertCode(t, tt.code, err)
+			if tt.code == codes.OK {
+				assert.Equal(t, existing.GetEmail(), resp.GetUser().GetEmail())
+			}
+		})
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	env := apptest.Start(t)
+
+	resp, err := env.Users.ListUsers(context.Background(), &pb.ListUsersRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, resp.GetUsers())
+	assert.Empty(t, resp.GetNextPageToken())
+
+	var created []string
+	for i := 0; i < 5; i++ {
+		created = append(created, env.CreateUser(t, "User", fmt.Sprintf("user%d@example.com", i)).GetId())
+	}
+
+	resp, err = env.Users.ListUsers(context.Background(), &pb.ListUsersRequest{})
+	require.NoError(t, err)
+	assert.Len(t, resp.GetUsers(), 5)
+
+	var listed []string
+	token := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5, "pagination does not terminate")
+		resp, err := env.Users.ListUsers(context.Background(), &pb.ListUsersRequest{PageSize: 2, PageToken: token})
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(resp.GetUsers()), 2)
+		for _, u := range resp.GetUsers() {
+			listed = append(listed, u.GetId())
+		}
+		if resp.GetNextPageToken() == "" {
+			break
+		}
+		token = resp.GetNextPageToken()
+	}
+	assert.Equal(t, created, listed)
+
+	tests := []struct {
+		name string
+		req  *pb.ListUsersRequest
+		code codes.Code
+	}{
+		{name: "invalid page token", req: &pb.ListUsersRequest{PageToken: "???"}, code: codes.InvalidArgument},
+		{name: "negative page size", req: &pb.ListUsersRequest{PageSize: -1}, code: codes.InvalidArgument},
+		{name: "unknown order", req: &pb.ListUsersRequest{OrderBy: "email"}, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.Users.ListUsers(context.Background(), tt.req)
+			assertCode(t, tt.code, err)
+		})
+	}
+}
+
+func TestListUsers_NameQuery(t *testing.T) {
+	env := apptest.Start(t)
+	env.CreateUser(t, "Renée Dubois", "renee@example.com")
+	env.CreateUser(t, "Adam Smith", "adam@example.com")
+	env.CreateUser(t, "RENEE ARNAUD", "arnaud@example.com")
+
+	resp, err := env.Users.ListUsers(context.Background(), &pb.ListUsersRequest{NameQuery: "renee", OrderBy: "name"})
+	require.NoError(t, err)
+
+	var got []string
+	for _, u := range resp.GetUsers() {
+		got = append(got, u.GetName())
+	}
+	assert.Equal(t, []string{"RENEE ARNAUD", "Renée Dubois"}, got)
+}
+
+func TestSuspendAndReactivateUser(t *testing.T) {
+	env := apptest.Start(t)
+	u := env.CreateUser(t, "Jane", "jane@example.com")
+	env.CreateUser(t, "Joe", "joe@example.com")
+	assert.Equal(t, pb.UserStatus_USER_STATUS_ACTIVE, u.GetStatus())
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), grpcdelivery.ActorHeader, "alice")
+	_, err := env.Users.SuspendUser(ctx, &pb.SuspendUserRequest{Id: u.GetId()})
+	assertCode(t, codes.InvalidArgument, err)
+
+	suspended, err := env.Users.SuspendUser(ctx, &pb.SuspendUserRequest{Id: u.GetId(), Reason: "spam"})
+	require.NoError(t, err)
+	assert.Equal(t, pb.UserStatus_USER_STATUS_SUSPENDED, suspended.GetUser().GetStatus())
+	assert.Equal(t, "spam", suspended.GetUser().GetStatusReason())
+	assert.Equal(t, "alice", suspended.GetUser().GetStatusChangedBy())
+	assert.NotNil(t, suspended.GetUser().GetStatusChangedAt())
+
+	_, err = env.Users.SuspendUser(ctx, &pb.SuspendUserRequest{Id: u.GetId(), Reason: "again"})
+	assertCode(t, codes.FailedPrecondition, err)
+
+	list, err := env.Users.ListUsers(context.Background(), &pb.ListUsersRequest{
+		Statuses: []pb.UserStatus{pb.UserStatus_USER_STATUS_SUSPENDED},
+	})
+	require.NoError(t, err)
+	require.Len(t, list.GetUsers(), 1)
+	assert.Equal(t, u.GetId(), list.GetUsers()[0].GetId())
+
+	_, err = env.Users.ListUsers(context.Background(), &pb.ListUsersRequest{
+		Statuses: []pb.UserStatus{pb.UserStatus_USER_STATUS_UNSPECIFIED},
+	})
+	assertCode(t, codes.InvalidArgument, err)
+
+	reactivated, err := env.Users.ReactivateUser(ctx, &pb.ReactivateUserRequest{Id: u.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, pb.UserStatus_USER_STATUS_ACTIVE, reactivated.GetUser().GetStatus())
+
+	_, err = env.Users.ReactivateUser(ctx, &pb.ReactivateUserRequest{Id: u.GetId()})
+	assertCode(t, codes.FailedPrecondition, err)
+	_, err = env.Users.ReactivateUser(ctx, &pb.ReactivateUserRequest{Id: uuid.NewString()})
+	assertCode(t, codes.NotFound, err)
+}
+
+func TestEmailVerification(t *testing.T) {
+	mailFile := filepath.Join(t.TempDir(), "mail.txt")
+	env := apptest.Start(t, func(cfg *config.Config) {
+		cfg.Mailer = "file"
+		cfg.MailFile = mailFile
+	})
+	u := env.CreateUser(t, "Jane", "jane@example.com")
+	assert.False(t, u.GetEmailVerified())
+	ctx := context.Background()
+
+	sent, err := env.Users.SendVerificationEmail(ctx, &pb.SendVerificationEmailRequest{Id: u.GetId()})
+	require.NoError(t, err)
+	assert.True(t, sent.GetExpireTime().AsTime().After(time.Now()))
+
+	f, err := os.Open(mailFile)
+	require.NoError(t, err)
+	defer f.Close()
+	mail, err := io.ReadAll(quotedprintable.NewReader(f))
+	require.NoError(t, err)
+	assert.Contains(t, string(mail), "To: jane@example.com")
+	token := regexp.MustCompile(`[A-Za-z0-9_-]{80,}`).FindString(string(mail))
+	require.NotEmpty(t, token)
+
+	_, err = env.Users.VerifyEmail(ctx, &pb.VerifyEmailRequest{Token: "forged"})
+	assertCode(t, codes.InvalidArgument, err)
+
+	verified, err := env.Users.VerifyEmail(ctx, &pb.VerifyEmailRequest{Token: token})
+	require.NoError(t, err)
+	assert.True(t, verified.GetUser().GetEmailVerified())
+
+	_, err = env.Users.VerifyEmail(ctx, &pb.VerifyEmailRequest{Token: token})
+	assertCode(t, codes.FailedPrecondition, err)
+	_, err = env.Users.SendVerificationEmail(ctx, &pb.SendVerificationEmailRequest{Id: u.GetId()})
+	assertCode(t, codes.FailedPrecondition, err)
+
+	updated, err := env.Users.UpdateUser(ctx, &pb.UpdateUserRequest{
+		Id:         u.GetId(),
+		Email:      "jane.doe@example.com",
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"email"}},
+	})
+	require.NoError(t, err)
+	assert.False(t, updated.GetUser().GetEmailVerified())
+}
+
+func TestPasswordsAndAuthenticate(t *testing.T) {
+	env := apptest.Start(t, func(cfg *config.Config) { cfg.LoginMaxFailures = 2 })
+	u := env.CreateUser(t, "Jane", "jane@example.com")
+	assert.False(t, u.GetHasPassword())
+	ctx := context.Background()
+
+	_, err := env.Users.SetPassword(ctx, &pb.SetPasswordRequest{Id: u.GetId(), Password: "short"})
+	assertCode(t, codes.InvalidArgument, err)
+	_, err = env.Users.SetPassword(ctx, &pb.SetPasswordRequest{Id: u.GetId(), Password: "a long and unusual passphrase"})
+	require.NoError(t, err)
+
+	resp, err := env.Users.Authenticate(ctx, &pb.AuthenticateRequest{Email: "jane@example.com", Password: "a long and unusual passphrase"})
+	require.NoError(t, err)
+	assert.Equal(t, u.GetId(), resp.GetUser().GetId())
+	assert.True(t, resp.GetUser().GetHasPassword())
+
+	_, err = env.Users.Authenticate(ctx, &pb.AuthenticateRequest{Email: "nobody@example.com", Password: "a long and unusual passphrase"})
+	assertCode(t, codes.Unauthenticated, err)
+
+	_, err = env.Users.ChangePassword(ctx, &pb.ChangePasswordRequest{Id: u.GetId(), CurrentPassword: "wrong", NewPassword: "another fine passphrase"})
+	assertCode(t, codes.Unauthenticated, err)
+	_, err = env.Users.Authenticate(ctx, &pb.AuthenticateRequest{Email: "jane@example.com", Password: "wrong again"})
+	assertCode(t, codes.Unauthenticated, err)
+	// A locked out user looks like an unknown email.
+	_, err = env.Users.Authenticate(ctx, &pb.AuthenticateRequest{Email: "jane@example.com", Password: "a long and unusual passphrase"})
+	assertCode(t, codes.Unauthenticated, err)
+	assert.Equal(t, "invalid email or password", status.Convert(err).Message())
+
+	// An administrator's reset lifts the lockout.
+	_, err = env.Users.SetPassword(ctx, &pb.SetPasswordRequest{Id: u.GetId(), Password: "another fine passphrase"})
+	require.NoError(t, err)
+	_, err = env.Users.Authenticate(ctx, &pb.AuthenticateRequest{Email: "jane@example.com", Password: "another fine passphrase"})
+	require.NoError(t, err)
+}
+
+func TestSessionTokens(t *testing.T) {
+	env := apptest.Start(t, func(cfg *config.Config) { cfg.HTTPAddress = "127.0.0.1:0" })
+	u := env.CreateUser(t, "Jane", "jane@example.com")
+	ctx := context.Background()
+	_, err := env.Users.SetPassword(ctx, &pb.SetPasswordRequest{Id: u.GetId(), Password: "a long and unusual passphrase"})
+	require.NoError(t, err)
+
+	login := func() *pb.Tokens {
+		t.Helper()
+		resp, err := env.Users.Authenticate(ctx, &pb.AuthenticateRequest{Email: "jane@example.com", Password: "a long and unusual passphrase"})
+		require.NoError(t, err)
+		return resp.GetTokens()
+	}
+	tokens := login()
+	assert.Equal(t, "Bearer", tokens.GetTokenType())
+
+	// The access token verifies against the published keys.
+	keys := fetchJWKS(t, env.HTTPURL+httpdelivery.JWKSPath)
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(tokens.GetAccessToken(), claims, func(tok *jwt.Token) (any, error) {
+		return keys[tok.Header["kid"].(string)], nil
+	}, jwt.WithValidMethods([]string{"ES256"}))
+	require.NoError(t, err)
+	assert.Equal(t, u.GetId(), claims["sub"])
+
+	refreshed, err := env.Users.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: tokens.GetRefreshToken()})
+	require.NoError(t, err)
+	assert.NotEqual(t, tokens.GetRefreshToken(), refreshed.GetTokens().GetRefreshToken())
+
+	// Replaying a used refresh token revokes the whole session.
+	_, err = env.Users.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: tokens.GetRefreshToken()})
+	assertCode(t, codes.Unauthenticated, err)
+	_, err = env.Users.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: refreshed.GetTokens().GetRefreshToken()})
+	assertCode(t, codes.Unauthenticated, err)
+
+	tokens = login()
+	_, err = env.Users.RevokeToken(ctx, &pb.RevokeTokenRequest{RefreshToken: tokens.GetRefreshToken()})
+	require.NoError(t, err)
+	_, err = env.Users.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: tokens.GetRefreshToken()})
+	assertCode(t, codes.Unauthenticated, err)
+
+	// A password change ends every session.
+	tokens = login()
+	_, err = env.Users.ChangePassword(ctx, &pb.ChangePasswordRequest{
+		Id:              u.GetId(),
+		CurrentPassword: "a long and unusual passphrase",
+		NewPassword:     "another fine passphrase",
+	})
+	require.NoError(t, err)
+	_, err = env.Users.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: tokens.GetRefreshToken()})
+	assertCode(t, codes.Unauthenticated, err)
+}
+
+func TestMFALogin(t *testing.T) {
+	env := apptest.Start(t)
+	u := env.CreateUser(t, "Jane", "jane@example.com")
+	ctx := context.Background()
+	_, err := env.Users.SetPassword(ctx, &pb.SetPasswordRequest{Id: u.GetId(), Password: "a long and unusual passphrase"})
+	require.NoError(t, err)
+
+	enrollment, err := env.Users.EnrollMFA(ctx, &pb.EnrollMFARequest{Id: u.GetId()})
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.GetProvisioningUri(), "otpauth://totp/")
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(enrollment.GetSecret())
+	require.NoError(t, err)
+	counter := totp.Counter(time.Now())
+
+	confirmed, err := env.Users.ConfirmMFA(ctx, &pb.ConfirmMFARequest{Id: u.GetId(), Code: totp.Code(secret, counter)})
+	require.NoError(t, err)
+	recovery := confirmed.GetRecoveryCodes()
+	require.NotEmpty(t, recovery)
+
+	login := func() *pb.AuthenticateResponse {
+		t.Helper()
+		resp, err := env.Users.Authenticate(ctx, &pb.AuthenticateRequest{Email: "jane@example.com", Password: "a long and unusual passphrase"})
+		require.NoError(t, err)
+		return resp
+	}
+	resp := login()
+	assert.True(t, resp.GetMfaRequired())
+	assert.Nil(t, resp.GetUser(), "nothing is returned before the second factor")
+	assert.Nil(t, resp.GetTokens())
+
+	_, err = env.Users.VerifyMFA(ctx, &pb.VerifyMFARequest{MfaToken: resp.GetMfaToken(), Code: totp.Code(secret, counter)})
+	assertCode(t, codes.Unauthenticated, err)
+
+	// The next period's code is within the allowed drift.
+	verified, err := env.Users.VerifyMFA(ctx, &pb.VerifyMFARequest{MfaToken: resp.GetMfaToken(), Code: totp.Code(secret, counter+1)})
+	require.NoError(t, err)
+	assert.True(t, verified.GetUser().GetMfaEnabled())
+	assert.NotEmpty(t, verified.GetTokens().GetAccessToken())
+
+	verified, err = env.Users.VerifyMFA(ctx, &pb.VerifyMFARequest{MfaToken: login().GetMfaToken(), Code: recovery[0]})
+	require.NoError(t, err)
+	assert.NotEmpty(t, verified.GetTokens().GetRefreshToken())
+
+	_, err = env.Users.DisableMFA(ctx, &pb.DisableMFARequest{Id: u.GetId()})
+	require.NoError(t, err)
+	assert.False(t, login().GetMfaRequired())
+}
+
+// fetchJWKS returns the published P-256 keys by key ID.
+func fetchJWKS(t *testing.T, url string) map[string]*ecdsa.PublicKey {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&set))
+
+	keys := map[string]*ecdsa.PublicKey{}
+	for _, k := range set.Keys {
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		require.NoError(t, err)
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		require.NoError(t, err)
+		keys[k.Kid] = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	}
+	return keys
+}
+
+func TestUpdateUser_FieldMask(t *testing.T) {
+	env := apptest.Start(t)
+	resp, err := env.Users.CreateUser(context.Background(), &pb.CreateUserRequest{
+		Name:     "Jane",
+		Email:    "jane@example.com",
+		Phone:    "+14155550100",
+		Locale:   "en-US",
+		TimeZone: "America/New_York",
+	})
+	require.NoError(t, err)
+	id := resp.GetUser().GetId()
+
+	// Only masked fields change; the empty phone clears it and the locale in
+	// the request is ignored because it is not listed.
+	updated, err := env.Users.UpdateUser(context.Background(), &pb.UpdateUserRequest{
+		Id:         id,
+		TimeZone:   "Europe/Berlin",
+		Locale:     "de-DE",
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"phone", "time_zone"}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, updated.GetUser().GetPhone())
+	assert.Equal(t, "Europe/Berlin", updated.GetUser().GetTimeZone())
+	assert.Equal(t, "en-US", updated.GetUser().GetLocale())
+
+	_, err = env.Users.UpdateUser(context.Background(), &pb.UpdateUserRequest{
+		Id:         id,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"nickname"}},
+	})
+	assertCode(t, codes.InvalidArgument, err)
+
+	_, err = env.Users.UpdateUser(context.Background(), &pb.UpdateUserRequest{
+		Id:         id,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+	})
+	assertCode(t, codes.InvalidArgument, err)
+}
+
+func TestUserHistory(t *testing.T) {
+	env := apptest.Start(t)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), grpcdelivery.ActorHeader, "support")
+	u := env.CreateUser(t, "Jane", "jane@example.com")
+	id := u.GetId()
+
+	_, err := env.Users.UpdateUser(ctx, &pb.UpdateUserRequest{Id: id, Email: "jane.doe@example.com"})
+	require.NoError(t, err)
+	_, err = env.Users.SetPassword(ctx, &pb.SetPasswordRequest{Id: id, Password: "correct horse battery"})
+	require.NoError(t, err)
+
+	list, err := env.Users.ListUserRevisions(ctx, &pb.ListUserRevisionsRequest{UserId: id})
+	require.NoError(t, err)
+	require.Len(t, list.GetRevisions(), 2, "password changes are not recorded")
+	changed := list.GetRevisions()[1]
+	assert.Equal(t, pb.RevisionAction_REVISION_ACTION_UPDATE, changed.GetAction())
+	assert.Equal(t, "support", changed.GetActor())
+	require.Len(t, changed.GetChanges(), 1)
+	assert.Equal(t, "email", changed.GetChanges()[0].GetField())
+	assert.Equal(t, "jane@example.com", changed.GetChanges()[0].GetOldValue().GetStringValue())
+
+	// What was the email when the user was created?
+	past, err := env.Users.GetUser(ctx, &pb.GetUserRequest{Id: id, AsOf: list.GetRevisions()[0].GetCreateTime()})
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", past.GetUser().GetEmail())
+	past, err = env.Users.GetUser(ctx, &pb.GetUserRequest{Id: id, Revision: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "jane.doe@example.com", past.GetUser().GetEmail())
+	assert.False(t, past.GetUser().GetHasPassword(), "past versions carry no credentials")
+
+	_, err = env.Users.GetUser(ctx, &pb.GetUserRequest{Id: id, AsOf: timestamppb.New(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))})
+	assertCode(t, codes.NotFound, err)
+	_, err = env.Users.GetUser(ctx, &pb.GetUserRequest{Id: id, Revision: 9})
+	assertCode(t, codes.NotFound, err)
+	_, err = env.Users.GetUser(ctx, &pb.GetUserRequest{Id: id, Revision: 1, AsOf: timestamppb.Now()})
+	assertCode(t, codes.InvalidArgument, err)
+
+	reverted, err := env.Users.RevertUser(ctx, &pb.RevertUserRequest{Id: id, Revision: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", reverted.GetUser().GetEmail())
+	assert.True(t, reverted.GetUser().GetHasPassword(), "reverting keeps credentials")
+
+	list, err = env.Users.ListUserRevisions(ctx, &pb.ListUserRevisionsRequest{UserId: id})
+	require.NoError(t, err)
+	require.Len(t, list.GetRevisions(), 3)
+	assert.Equal(t, int64(1), list.GetRevisions()[2].GetRevertedFrom())
+
+	_, err = env.Users.DeleteUser(ctx, &pb.DeleteUserRequest{Id: id})
+	require.NoError(t, err)
+	_, err = env.Users.GetUser(ctx, &pb.GetUserRequest{Id: id, Revision: 4})
+	assertCode(t, codes.NotFound, err)
+	past, err = env.Users.GetUser(ctx, &pb.GetUserRequest{Id: id, Revision: 3})
+	require.NoError(t, err, "deleted users keep their history")
+	assert.Equal(t, "jane@example.com", past.GetUser().GetEmail())
+	_, err = env.Users.RevertUser(ctx, &pb.RevertUserRequest{Id: id, Revision: 4})
+	assertCode(t, codes.InvalidArgument, err)
+}
+
+func TestAttributes(t *testing.T) {
+	schema := filepath.Join(t.TempDir(), "attributes.json")
+	require.NoError(t, os.WriteFile(schema, []byte(`{
+		"properties": {
+			"plan": {"type": "string", "enum": ["free", "pro"], "indexed": true},
+			"seats": {"type": "integer"}
+		}
+	}`), 0644))
+	env := apptest.Start(t, func(cfg *config.Config) { cfg.AttributeSchemaFile = schema })
+
+	created, err := env.Users.CreateUser(context.Background(), &pb.CreateUserRequest{
+		Name:       "Jane",
+		Email:      "jane@example.com",
+		Attributes: map[string]*structpb.Value{"plan": structpb.NewStringValue("pro"), "seats": structpb.NewNumberValue(5)},
+	})
+	require.NoError(t, err)
+	env.CreateUser(t, "John", "john@example.com")
+
+	resp, err := env.Users.ListUsers(context.Background(), &pb.ListUsersRequest{
+		Attributes: map[string]*structpb.Value{"plan": structpb.NewStringValue("pro")},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.GetUsers(), 1)
+	assert.Equal(t, float64(5), resp.GetUsers()[0].GetAttributes()["seats"].GetNumberValue())
+
+	_, err = env.Users.ListUsers(context.Background(), &pb.ListUsersRequest{
+		Attributes: map[string]*structpb.Value{"seats": structpb.NewNumberValue(5)},
+	})
+	assertCode(t, codes.InvalidArgument, err)
+
+	updated, err := env.Users.UpdateUser(context.Background(), &pb.UpdateUserRequest{
+		Id:         created.GetUser().GetId(),
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"attributes.seats"}},
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, updated.GetUser().GetAttributes(), "seats")
+	assert.Equal(t, "pro", updated.GetUser().GetAttributes()["plan"].GetStringValue())
+
+	_, err = env.Users.CreateUser(context.Background(), &pb.CreateUserRequest{
+		Name:       "Bad",
+		Email:      "bad@example.com",
+		Attributes: map[string]*structpb.Value{"plan": structpb.NewStringValue("gold")},
+	})
+	assertCode(t, codes.InvalidArgument, err)
+}
+
+func TestUpdateUser(t *testing.T) {
+	env := apptest.Start(t)
+	existing := env.CreateUser(t, "Original", "original@example.com")
+
+	tests := []struct {
+		name      string
+		req       *pb.UpdateUserRequest
+		code      codes.Code
+		wantName  string
+		wantEmail string
+	}{
+		{
+			name:      "update name",
+			req:       &pb.UpdateUserRequest{Id: existing.GetId(), Name: "Renamed"},
+			code:      codes.OK,
+			wantName:  "Renamed",
+			wantEmail: "original@example.com",
+		},
+		{
+			name:      "update email",
+			req:       &pb.UpdateUserRequest{Id: existing.GetId(), Email: "changed@example.com"},
+			code:      codes.OK,
+			wantName:  "Renamed",
+			wantEmail: "changed@example.com",
+		},
+		{name: "invalid email", req: &pb.UpdateUserRequest{Id: existing.GetId(), Email: "bad"}, code: codes.InvalidArgument},
+		{name: "unknown id", req: &pb.UpdateUserRequest{Id: uuid.NewString(), Name: "Nobody"}, code: codes.NotFound},
+		{name: "malformed id", req: &pb.UpdateUserRequest{Id: "42", Name: "Nobody"}, code: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := env.Users.UpdateUser(context.Background(), tt.req)
+			assertCode(t, tt.code, err)
+			if tt.code != codes.OK {
+				return
+			}
+			assert.Equal(t, tt.wantName, resp.GetUser().GetName())
+			assert.Equal(t, tt.wantEmail, resp.GetUser().GetEmail())
+
+			got, err := env.Users.GetUser(context.Background(), &pb.GetUserRequest{Id: existing.GetId()})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEmail, got.GetUser().GetEmail())
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	env := apptest.Start(t)
+	existing := env.CreateUser(t, "Doomed", "doomed@example.com")
+
+	tests := []struct {
+		name string
+		id   string
+		code codes.Code
+	}{
+		{name: "success", id: existing.GetId(), code: codes.OK},
+		{name: "already deleted", id: existing.GetId(), code: codes.NotFound},
+		{name: "unknown id", id: uuid.NewString(), code: codes.NotFound},
+		{name: "malformed id", id: "nope", code: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.Users.DeleteUser(context.Background(), &pb.DeleteUserRequest{Id: tt.id})
+			assertCode(t, tt.code, err)
+		})
+	}
+
+	_, err := env.Users.GetUser(context.Background(), &pb.GetUserRequest{Id: existing.GetId()})
+	assertCode(t, codes.NotFound, err)
+}
+
+func TestGroups(t *testing.T) {
+	env := apptest.Start(t, func(cfg *config.Config) {
+		cfg.GroupMaxMembers = 2
+	})
+	ctx := context.Background()
+	jane := env.CreateUser(t, "Jane", "jane@example.com")
+	john := env.CreateUser(t, "John", "john@example.com")
+	mary := env.CreateUser(t, "Mary", "mary@example.com")
+
+	created, err := env.Groups.CreateGroup(ctx, &pb.CreateGroupRequest{Name: "Platform", Description: "Runs the platform"})
+	require.NoError(t, err)
+	group := created.GetGroup()
+	_, err = env.Groups.CreateGroup(ctx, &pb.CreateGroupRequest{Name: "platform"})
+	assertCode(t, codes.AlreadyExists, err)
+
+	for _, u := range []*pb.User{jane, john} {
+		_, err = env.Groups.AddMember(ctx, &pb.AddMemberRequest{GroupId: group.GetId(), UserId: u.GetId()})
+		require.NoError(t, err)
+	}
+	_, err = env.Groups.AddMember(ctx, &pb.AddMemberRequest{GroupId: group.GetId(), UserId: jane.GetId()})
+	assertCode(t, codes.AlreadyExists, err)
+	_, err = env.Groups.AddMember(ctx, &pb.AddMemberRequest{GroupId: group.GetId(), UserId: mary.GetId()})
+	assertCode(t, codes.ResourceExhausted, err)
+	_, err = env.Groups.AddMember(ctx, &pb.AddMemberRequest{GroupId: group.GetId(), UserId: uuid.NewString()})
+	assertCode(t, codes.NotFound, err)
+
+	first, err := env.Groups.ListMembers(ctx, &pb.ListMembersRequest{GroupId: group.GetId(), PageSize: 1})
+	require.NoError(t, err)
+	require.Len(t, first.GetMembers(), 1)
+	assert.Equal(t, jane.GetId(), first.GetMembers()[0].GetUserId())
+	second, err := env.Groups.ListMembers(ctx, &pb.ListMembersRequest{GroupId: group.GetId(), PageSize: 1, PageToken: first.GetNextPageToken()})
+	require.NoError(t, err)
+	require.Len(t, second.GetMembers(), 1)
+	assert.Equal(t, john.GetId(), second.GetMembers()[0].GetUserId())
+	assert.Empty(t, second.GetNextPageToken())
+
+	groups, err := env.Groups.ListUserGroups(ctx, &pb.ListUserGroupsRequest{UserId: jane.GetId()})
+	require.NoError(t, err)
+	require.Len(t, groups.GetGroups(), 1)
+	assert.Equal(t, "Platform", groups.GetGroups()[0].GetName())
+
+	// Deleting a user frees its place in the group.
+	_, err = env.Users.DeleteUser(ctx, &pb.DeleteUserRequest{Id: jane.GetId()})
+	require.NoError(t, err)
+	members, err := env.Groups.ListMembers(ctx, &pb.ListMembersRequest{GroupId: group.GetId()})
+	require.NoError(t, err)
+	require.Len(t, members.GetMembers(), 1)
+	assert.Equal(t, john.GetId(), members.GetMembers()[0].GetUserId())
+	_, err = env.Groups.AddMember(ctx, &pb.AddMemberRequest{GroupId: group.GetId(), UserId: mary.GetId()})
+	require.NoError(t, err)
+
+	_, err = env.Groups.RemoveMember(ctx, &pb.RemoveMemberRequest{GroupId: group.GetId(), UserId: john.GetId()})
+	require.NoError(t, err)
+	_, err = env.Groups.RemoveMember(ctx, &pb.RemoveMemberRequest{GroupId: group.GetId(), UserId: john.GetId()})
+	assertCode(t, codes.NotFound, err)
+
+	renamed, err := env.Groups.UpdateGroup(ctx, &pb.UpdateGroupRequest{Id: group.GetId(), Name: proto.String("Infrastructure")})
+	require.NoError(t, err)
+	assert.Equal(t, "Infrastructure", renamed.GetGroup().GetName())
+	assert.Equal(t, "Runs the platform", renamed.GetGroup().GetDescription(), "unset fields are kept")
+
+	_, err = env.Groups.DeleteGroup(ctx, &pb.DeleteGroupRequest{Id: group.GetId()})
+	require.NoError(t, err)
+	_, err = env.Groups.GetGroup(ctx, &pb.GetGroupRequest{Id: group.GetId()})
+	assertCode(t, codes.NotFound, err)
+	groups, err = env.Groups.ListUserGroups(ctx, &pb.ListUserGroupsRequest{UserId: mary.GetId()})
+	require.NoError(t, err)
+	assert.Empty(t, groups.GetGroups())
+}
+
+func TestListGroups(t *testing.T) {
+	env := apptest.Start(t)
+	ctx := context.Background()
+	created, err := env.Organizations.CreateOrganization(ctx, &pb.CreateOrganizationRequest{Name: "Acme"})
+	require.NoError(t, err)
+	acme := created.GetOrganization().GetId()
+	for _, name := range []string{"Platform", "Finance"} {
+		_, err := env.Groups.CreateGroup(ctx, &pb.CreateGroupRequest{Name: name})
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name         string
+		organization string
+		groups       []string
+		code         codes.Code
+	}{
+		{name: "default organization", groups: []string{"Platform", "Finance"}, code: codes.OK},
+		{name: "other organization", organization: acme, code: codes.OK},
+		{name: "unknown organization", organization: uuid.NewString(), code: codes.InvalidArgument},
+		{name: "malformed organization", organization: "not-a-uuid", code: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(ctx, grpcdelivery.OrganizationHeader, tt.organization)
+			resp, err := env.Groups.ListGroups(ctx, &pb.ListGroupsRequest{})
+			assertCode(t, tt.code, err)
+			var names []string
+			for _, g := range resp.GetGroups() {
+				names = append(names, g.GetName())
+			}
+			assert.Equal(t, tt.groups, names)
+		})
+	}
+}
+
+func TestRoles(t *testing.T) {
+	env := apptest.Start(t)
+	ctx := context.Background()
+	jane := env.CreateUser(t, "Jane", "jane@example.com")
+	john := env.CreateUser(t, "John", "john@example.com")
+	allowed := func(u *pb.User, permission string) bool {
+		t.Helper()
+		resp, err := env.Roles.CheckPermission(ctx, &pb.CheckPermissionRequest{UserId: u.GetId(), Permission: permission})
+		require.NoError(t, err)
+		return resp.GetAllowed()
+	}
+
+	_, err := env.Roles.CreateRole(ctx, &pb.CreateRoleRequest{Name: "Broken", Permissions: []string{"Documents:Read"}})
+	assertCode(t, codes.InvalidArgument, err)
+	created, err := env.Roles.CreateRole(ctx, &pb.CreateRoleRequest{Name: "Editor", Permissions: []string{"documents:write", "documents:read"}})
+	require.NoError(t, err)
+	editor := created.GetRole()
+	assert.Equal(t, []string{"documents:read", "documents:write"}, editor.GetPermissions())
+	created, err = env.Roles.CreateRole(ctx, &pb.CreateRoleRequest{Name: "Billing", Permissions: []string{"billing:*"}})
+	require.NoError(t, err)
+	billing := created.GetRole()
+
+	group, err := env.Groups.CreateGroup(ctx, &pb.CreateGroupRequest{Name: "Finance"})
+	require.NoError(t, err)
+	groupID := group.GetGroup().GetId()
+
+	_, err = env.Roles.AssignRole(ctx, &pb.AssignRoleRequest{RoleId: editor.GetId(), Principal: &pb.AssignRoleRequest_UserId{UserId: jane.GetId()}})
+	require.NoError(t, err)
+	_, err = env.Roles.AssignRole(ctx, &pb.AssignRoleRequest{RoleId: editor.GetId(), Principal: &pb.AssignRoleRequest_UserId{UserId: jane.GetId()}})
+	assertCode(t, codes.AlreadyExists, err)
+	_, err = env.Roles.AssignRole(ctx, &pb.AssignRoleRequest{RoleId: billing.GetId(), Principal: &pb.AssignRoleRequest_GroupId{GroupId: groupID}})
+	require.NoError(t, err)
+	_, err = env.Roles.AssignRole(ctx, &pb.AssignRoleRequest{RoleId: billing.GetId()})
+	assertCode(t, codes.InvalidArgument, err)
+
+	assert.True(t, allowed(jane, "documents:read"))
+	assert.False(t, allowed(jane, "billing:refund"))
+	assert.False(t, allowed(john, "documents:read"))
+
+	// Joining the group grants its roles right away, despite the cache.
+	_, err = env.Groups.AddMember(ctx, &pb.AddMemberRequest{GroupId: groupID, UserId: jane.GetId()})
+	require.NoError(t, err)
+	assert.True(t, allowed(jane, "billing:invoices:refund"))
+
+	effective, err := env.Roles.ListEffectivePermissions(ctx, &pb.ListEffectivePermissionsRequest{UserId: jane.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"billing:*", "documents:read", "documents:write"}, effective.GetPermissions())
+	assert.Len(t, effective.GetRoles(), 2)
+
+	_, err = env.Roles.UpdateRole(ctx, &pb.UpdateRoleRequest{Id: editor.GetId(), Permissions: &pb.PermissionList{Values: []string{"documents:read"}}})
+	require.NoError(t, err)
+	assert.False(t, allowed(jane, "documents:write"))
+
+	_, err = env.Groups.DeleteGroup(ctx, &pb.DeleteGroupRequest{Id: groupID})
+	require.NoError(t, err)
+	assert.False(t, allowed(jane, "billing:refund"))
+	assignments, err := env.Roles.ListRoleAssignments(ctx, &pb.ListRoleAssignmentsRequest{RoleId: billing.GetId()})
+	require.NoError(t, err)
+	assert.Empty(t, assignments.GetAssignments(), "deleting a group removes its assignments")
+
+	_, err = env.Roles.DeleteRole(ctx, &pb.DeleteRoleRequest{Id: editor.GetId()})
+	require.NoError(t, err)
+	assert.False(t, allowed(jane, "documents:read"))
+
+	_, err = env.Roles.CheckPermission(ctx, &pb.CheckPermissionRequest{UserId: uuid.NewString(), Permission: "documents:read"})
+	assertCode(t, codes.NotFound, err)
+}
+
+func TestUnassignRole(t *testing.T) {
+	env := apptest.Start(t)
+	ctx := context.Background()
+	jane := env.CreateUser(t, "Jane", "jane@example.com")
+	john := env.CreateUser(t, "John", "john@example.com")
+	created, err := env.Roles.CreateRole(ctx, &pb.CreateRoleRequest{Name: "Editor", Permissions: []string{"documents:write"}})
+	require.NoError(t, err)
+	editor := created.GetRole().GetId()
+	group, err := env.Groups.CreateGroup(ctx, &pb.CreateGroupRequest{Name: "Writers"})
+	require.NoError(t, err)
+	groupID := group.GetGroup().GetId()
+	_, err = env.Roles.AssignRole(ctx, &pb.AssignRoleRequest{RoleId: editor, Principal: &pb.AssignRoleRequest_UserId{UserId: jane.GetId()}})
+	require.NoError(t, err)
+	_, err = env.Roles.AssignRole(ctx, &pb.AssignRoleRequest{RoleId: editor, Principal: &pb.AssignRoleRequest_GroupId{GroupId: groupID}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		req  *pb.UnassignRoleRequest
+		code codes.Code
+	}{
+		{name: "user", req: &pb.UnassignRoleRequest{RoleId: editor, Principal: &pb.UnassignRoleRequest_UserId{UserId: jane.GetId()}}, code: codes.OK},
+		{name: "group", req: &pb.UnassignRoleRequest{RoleId: editor, Principal: &pb.UnassignRoleRequest_GroupId{GroupId: groupID}}, code: codes.OK},
+		{name: "not assigned", req: &pb.UnassignRoleRequest{RoleId: editor, Principal: &pb.UnassignRoleRequest_UserId{UserId: john.GetId()}}, code: codes.NotFound},
+		{name: "unknown role", req: &pb.UnassignRoleRequest{RoleId: uuid.NewString(), Principal: &pb.UnassignRoleRequest_UserId{UserId: john.GetId()}}, code: codes.NotFound},
+		{name: "malformed role id", req: &pb.UnassignRoleRequest{RoleId: "not-a-uuid", Principal: &pb.UnassignRoleRequest_UserId{UserId: john.GetId()}}, code: codes.InvalidArgument},
+		{name: "malformed user id", req: &pb.UnassignRoleRequest{RoleId: editor, Principal: &pb.UnassignRoleRequest_UserId{UserId: "not-a-uuid"}}, code: codes.InvalidArgument},
+		{name: "malformed group id", req: &pb.UnassignRoleRequest{RoleId: editor, Principal: &pb.UnassignRoleRequest_GroupId{GroupId: "not-a-uuid"}}, code: codes.InvalidArgument},
+		{name: "no principal", req: &pb.UnassignRoleRequest{RoleId: editor}, code: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.Roles.UnassignRole(ctx, tt.req)
+			assertCode(t, tt.code, err)
+		})
+	}
+
+	assignments, err := env.Roles.ListRoleAssignments(ctx, &pb.ListRoleAssignmentsRequest{RoleId: editor})
+	require.NoError(t, err)
+	assert.Empty(t, assignments.GetAssignments())
+	allowed, err := env.Roles.CheckPermission(ctx, &pb.CheckPermissionRequest{UserId: jane.GetId(), Permission: "documents:write"})
+	require.NoError(t, err)
+	assert.False(t, allowed.GetAllowed())
+}
+
+func TestListRoles(t *testing.T) {
+	env := apptest.Start(t)
+	ctx := context.Background()
+	created, err := env.Organizations.CreateOrganization(ctx, &pb.CreateOrganizationRequest{Name: "Acme"})
+	require.NoError(t, err)
+	acme := created.GetOrganization().GetId()
+	for _, name := range []string{"Editor", "Billing"} {
+		_, err := env.Roles.CreateRole(ctx, &pb.CreateRoleRequest{Name: name})
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name         string
+		organization string
+		roles        []string
+		code         codes.Code
+	}{
+		{name: "default organization", roles: []string{"Editor", "Billing"}, code: codes.OK},
+		{name: "other organization", organization: acme, code: codes.OK},
+		{name: "unknown organization", organization: uuid.NewString(), code: codes.InvalidArgument},
+		{name: "malformed organization", organization: "not-a-uuid", code: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(ctx, grpcdelivery.OrganizationHeader, tt.organization)
+			resp, err := env.Roles.ListRoles(ctx, &pb.ListRolesRequest{})
+			assertCode(t, tt.code, err)
+			var names []string
+			for _, r := range resp.GetRoles() {
+				names = append(names, r.GetName())
+			}
+			assert.Equal(t, tt.roles, names)
+		})
+	}
+}
+
+func TestOrganizations(t *testing.T) {
+	env := apptest.Start(t, func(cfg *config.Config) {
+		cfg.EventsPollInterval = 10 * time.Millisecond
+	})
+	ctx := context.Background()
+
+	created, err := env.Organizations.CreateOrganization(ctx, &pb.CreateOrganizationRequest{Name: "Acme"})
+	require.NoError(t, err)
+	org := created.GetOrganization()
+	_, err = env.Organizations.CreateOrganization(ctx, &pb.CreateOrganizationRequest{Name: "ACME"})
+	assertCode(t, codes.AlreadyExists, err)
+	acme := metadata.AppendToOutgoingContext(ctx, grpcdelivery.OrganizationHeader, org.GetId())
+
+	// Emails are unique per organization.
+	def := env.CreateUser(t, "Jane", "jane@example.com")
+	resp, err := env.Users.CreateUser(acme, &pb.CreateUserRequest{Name: "Jane", Email: "jane@example.com"})
+	require.NoError(t, err)
+	scoped := resp.GetUser()
+	_, err = env.Users.CreateUser(acme, &pb.CreateUserRequest{Name: "Jane", Email: "JANE@example.com"})
+	assertCode(t, codes.AlreadyExists, err)
+
+	// Neither organization sees the other's users.
+	_, err = env.Users.GetUser(acme, &pb.GetUserRequest{Id: def.GetId()})
+	assertCode(t, codes.NotFound, err)
+	_, err = env.Users.GetUser(ctx, &pb.GetUserRequest{Id: scoped.GetId()})
+	assertCode(t, codes.NotFound, err)
+	list, err := env.Users.ListUsers(acme, &pb.ListUsersRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetUsers(), 1)
+	assert.Equal(t, scoped.GetId(), list.GetUsers()[0].GetId())
+
+	data, err := os.ReadFile(filepath.Join(env.Config.DataDir, "organizations", org.GetId(), "users.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), scoped.GetId())
+
+	for _, id := range []string{"not-a-uuid", uuid.NewString()} {
+		bad := metadata.AppendToOutgoingContext(ctx, grpcdelivery.OrganizationHeader, id)
+		_, err = env.Users.ListUsers(bad, &pb.ListUsersRequest{})
+		assertCode(t, codes.InvalidArgument, err)
+	}
+
+	_, err = env.Organizations.DeleteOrganization(ctx, &pb.DeleteOrganizationRequest{Id: org.GetId()})
+	assertCode(t, codes.FailedPrecondition, err)
+	_, err = env.Users.DeleteUser(acme, &pb.DeleteUserRequest{Id: scoped.GetId()})
+	require.NoError(t, err)
+	// The organization goes once the deletion of its user is published.
+	assert.Eventually(t, func() bool {
+		_, err := env.Organizations.DeleteOrganization(ctx, &pb.DeleteOrganizationRequest{Id: org.GetId()})
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = env.Organizations.GetOrganization(ctx, &pb.GetOrganizationRequest{Id: org.GetId()})
+	assertCode(t, codes.NotFound, err)
+}
+
+func TestUpdateOrganization(t *testing.T) {
+	env := apptest.Start(t)
+	ctx := context.Background()
+	created, err := env.Organizations.CreateOrganization(ctx, &pb.CreateOrganizationRequest{Name: "Acme"})
+	require.NoError(t, err)
+	acme := created.GetOrganization().GetId()
+	_, err = env.Organizations.CreateOrganization(ctx, &pb.CreateOrganizationRequest{Name: "Globex"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		id      string
+		newName *string
+		want    string
+		code    codes.Code
+	}{
+		{name: "rename", id: acme, newName: proto.String("Acme Corp"), want: "Acme Corp", code: codes.OK},
+		{name: "name unset", id: acme, want: "Acme Corp", code: codes.OK},
+		{name: "name taken", id: acme, newName: proto.String("GLOBEX"), code: codes.AlreadyExists},
+		{name: "empty name", id: acme, newName: proto.String(" "), code: codes.InvalidArgument},
+		{name: "unknown id", id: uuid.NewString(), newName: proto.String("Initech"), code: codes.NotFound},
+		{name: "malformed id", id: "not-a-uuid", newName: proto.String("Initech"), code: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := env.Organizations.UpdateOrganization(ctx, &pb.UpdateOrganizationRequest{Id: tt.id, Name: tt.newName})
+			assertCode(t, tt.code, err)
+			if tt.code == codes.OK {
+				assert.Equal(t, tt.want, resp.GetOrganization().GetName())
+			}
+		})
+	}
+
+	got, err := env.Organizations.GetOrganization(ctx, &pb.GetOrganizationRequest{Id: acme})
+	require.NoError(t, err)
+	assert.Equal(t, "Acme Corp", got.GetOrganization().GetName(), "failed updates change nothing")
+}
+
+func TestListOrganizations(t *testing.T) {
+	env := apptest.Start(t)
+	ctx := context.Background()
+
+	resp, err := env.Organizations.ListOrganizations(ctx, &pb.ListOrganizationsRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, resp.GetOrganizations())
+
+	var ids []string
+	for _, name := range []string{"Acme", "Globex"} {
+		created, err := env.Organizations.CreateOrganization(ctx, &pb.CreateOrganizationRequest{Name: name})
+		require.NoError(t, err)
+		ids = append(ids, created.GetOrganization().GetId())
+	}
+
+	tests := []struct {
+		name         string
+		organization string
+		code         codes.Code
+	}{
+		{name: "default organization", code: codes.OK},
+		// Organizations are not scoped to the one a call acts for.
+		{name: "other organization", organization: ids[0], code: codes.OK},
+		{name: "unknown organization", organization: uuid.NewString(), code: codes.InvalidArgument},
+		{name: "malformed organization", organization: "not-a-uuid", code: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(ctx, grpcdelivery.OrganizationHeader, tt.organization)
+			resp, err := env.Organizations.ListOrganizations(ctx, &pb.ListOrganizationsRequest{})
+			assertCode(t, tt.code, err)
+			if tt.code != codes.OK {
+				return
+			}
+			var got []string
+			for _, org := range resp.GetOrganizations() {
+				got = append(got, org.GetId())
+			}
+			assert.Equal(t, ids, got)
+		})
+	}
+}
+
+func TestAudit(t *testing.T) {
+	env := apptest.Start(t)
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		grpcdelivery.ActorHeader, "support",
+		grpcdelivery.RequestIDHeader, "req-42",
+	)
+	u := env.CreateUser(t, "Jane", "jane@example.com")
+	id := u.GetId()
+
+	var header metadata.MD
+	_, err := env.Users.GetUser(ctx, &pb.GetUserRequest{Id: id}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"req-42"}, header.Get(grpcdelivery.RequestIDHeader))
+	_, err = env.Users.UpdateUser(ctx, &pb.UpdateUserRequest{Id: id, Name: "Janet"})
+	require.NoError(t, err)
+	_, err = env.Users.DeleteUser(ctx, &pb.DeleteUserRequest{Id: uuid.NewString()})
+	assertCode(t, codes.NotFound, err)
+
+	// Calls without a request ID are given one.
+	header = nil
+	_, err = env.Users.ListUsers(context.Background(), &pb.ListUsersRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Len(t, header.Get(grpcdelivery.RequestIDHeader), 1)
+	generated := header.Get(grpcdelivery.RequestIDHeader)[0]
+
+	list, err := env.Audit.ListAuditEntries(ctx, &pb.ListAuditEntriesRequest{Target: id})
+	require.NoError(t, err)
+	require.Len(t, list.GetEntries(), 3)
+	created, viewed, updated := list.GetEntries()[0], list.GetEntries()[1], list.GetEntries()[2]
+	assert.Equal(t, "user.create", created.GetAction())
+	assert.Equal(t, "unknown", created.GetActor())
+	assert.Equal(t, "user.view", viewed.GetAction())
+	assert.Equal(t, "support", viewed.GetActor())
+	assert.Equal(t, "req-42", viewed.GetRequestId())
+	assert.NotEmpty(t, viewed.GetPeer())
+	assert.Equal(t, created.GetAfterHash(), viewed.GetAfterHash())
+	assert.Equal(t, "user.update", updated.GetAction())
+	assert.Equal(t, created.GetAfterHash(), updated.GetBeforeHash())
+	assert.NotEqual(t, updated.GetBeforeHash(), updated.GetAfterHash())
+
+	list, err = env.Audit.ListAuditEntries(ctx, &pb.ListAuditEntriesRequest{Actor: "support", PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, list.GetEntries(), 2)
+	list, err = env.Audit.ListAuditEntries(ctx, &pb.ListAuditEntriesRequest{Actor: "support", PageSize: 2, PageToken: list.GetNextPageToken()})
+	require.NoError(t, err)
+	require.Len(t, list.GetEntries(), 1)
+	assert.Equal(t, "user.delete", list.GetEntries()[0].GetAction())
+	assert.Equal(t, pb.AuditOutcome_AUDIT_OUTCOME_FAILURE, list.GetEntries()[0].GetOutcome())
+	assert.Empty(t, list.GetNextPageToken())
+
+	list, err = env.Audit.ListAuditEntries(ctx, &pb.ListAuditEntriesRequest{Actor: "unknown"})
+	require.NoError(t, err)
+	last := list.GetEntries()[len(list.GetEntries())-1]
+	assert.Equal(t, "user.list", last.GetAction())
+	assert.Equal(t, generated, last.GetRequestId())
+
+	// The log on disk verifies, and stops verifying once an entry is edited.
+	path := filepath.Join(env.Config.DataDir, "audit.log")
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	n, head, err := audit.Verify(f)
+	f.Close()
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, last.GetHash(), head)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	edited := regexp.MustCompile(`"actor":"support"`).ReplaceAll(data, []byte(`"actor":"nobody"`))
+	require.NoError(t, os.WriteFile(path, edited, 0644))
+	f, err = os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	_, _, err = audit.Verify(f)
+	assert.ErrorIs(t, err, audit.ErrTampered)
+}
+
+func TestAudit_ActorFromAccessToken(t *testing.T) {
+	env := apptest.Start(t)
+	ctx := context.Background()
+	u := env.CreateUser(t, "Jane", "jane@example.com")
+	_, err := env.Users.SetPassword(ctx, &pb.SetPasswordRequest{Id: u.GetId(), Password: "a long and unusual passphrase"})
+	require.NoError(t, err)
+	resp, err := env.Users.Authenticate(ctx, &pb.AuthenticateRequest{Email: "jane@example.com", Password: "a long and unusual passphrase"})
+	require.NoError(t, err)
+
+	// A forged actor header does not override the subject of the token.
+	forged := metadata.AppendToOutgoingContext(ctx,
+		"authorization", "Bearer "+resp.GetTokens().GetAccessToken(),
+		grpcdelivery.ActorHeader, "mallory",
+	)
+	_, err = env.Users.GetUser(forged, &pb.GetUserRequest{Id: u.GetId()})
+	require.NoError(t, err)
+
+	list, err := env.Audit.ListAuditEntries(ctx, &pb.ListAuditEntriesRequest{Actor: u.GetId()})
+	require.NoError(t, err)
+	require.Len(t, list.GetEntries(), 1)
+	assert.Equal(t, "user.view", list.GetEntries()[0].GetAction())
+	list, err = env.Audit.ListAuditEntries(ctx, &pb.ListAuditEntriesRequest{Actor: "mallory"})
+	require.NoError(t, err)
+	assert.Empty(t, list.GetEntries())
+}
+
+func TestEvents(t *testing.T) {
+	var mu sync.Mutex
+	var posted []model.Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event model.Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		mu.Lock()
+		posted = append(posted, event)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	eventsFile := filepath.Join(t.TempDir(), "events.jsonl")
+	env := apptest.Start(t, func(cfg *config.Config) {
+		cfg.EventsWebhookURL = receiver.URL
+		cfg.EventsFile = eventsFile
+		cfg.EventsPollInterval = 10 * time.Millisecond
+	})
+	ctx := context.Background()
+
+	u := env.CreateUser(t, "Jane", "jane@example.com")
+	_, err := env.Users.UpdateUser(ctx, &pb.UpdateUserRequest{Id: u.GetId(), Name: "Janet"})
+	require.NoError(t, err)
+	_, err = env.Users.DeleteUser(ctx, &pb.DeleteUserRequest{Id: u.GetId()})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(posted) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	var types []model.EventType
+	for _, event := range posted {
+		types = append(types, event.Type)
+		assert.Equal(t, u.GetId(), event.UserID.String())
+	}
+	assert.Equal(t, []model.EventType{model.EventUserCreated, model.EventUserUpdated, model.EventUserDeleted}, types)
+	assert.Equal(t, "Janet", posted[1].User.Name)
+
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(eventsFile)
+		return err == nil && strings.Count(string(data), "\n") == 3
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	var received []model.Event
+	var secret string
+	respond := http.StatusServiceUnavailable
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		if err := webhook.Verify(secret, r.Header, body, time.Now(), time.Minute); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var event model.Event
+		assert.NoError(t, json.Unmarshal(body, &event))
+		received = append(received, event)
+		w.WriteHeader(respond)
+	}))
+	defer receiver.Close()
+
+	env := apptest.Start(t, func(cfg *config.Config) {
+		cfg.EventsPollInterval = 10 * time.Millisecond
+		cfg.WebhookDisableAfter = 3
+	})
+	ctx := context.Background()
+
+	_, err := env.Webhooks.CreateWebhook(ctx, &pb.CreateWebhookRequest{Url: "ftp://partner.example.com"})
+	assertCode(t, codes.InvalidArgument, err)
+
+	created, err := env.Webhooks.CreateWebhook(ctx, &pb.CreateWebhookRequest{
+		Url:        receiver.URL,
+		EventTypes: []string{string(model.EventUserCreated)},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, created.GetSecret())
+	mu.Lock()
+	secret = created.GetSecret()
+	mu.Unlock()
+	id := created.GetWebhook().GetId()
+
+	// The receiver fails every delivery until the webhook is disabled.
+	for _, name := range []string{"Jane", "Joan", "Jill"} {
+		env.CreateUser(t, name, strings.ToLower(name)+"@example.com")
+	}
+	require.Eventually(t, func() bool {
+		got, err := env.Webhooks.GetWebhook(ctx, &pb.GetWebhookRequest{Id: id})
+		require.NoError(t, err)
+		return !got.GetWebhook().GetEnabled()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	respond = http.StatusNoContent
+	mu.Unlock()
+	enabled := true
+	_, err = env.Webhooks.UpdateWebhook(ctx, &pb.UpdateWebhookRequest{Id: id, Enabled: &enabled})
+	require.NoError(t, err)
+
+	u := env.CreateUser(t, "John", "john@example.com")
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 4
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	last := received[3]
+	mu.Unlock()
+	assert.Equal(t, u.GetId(), last.UserID.String())
+
+	resp, err := env.Webhooks.ListWebhookDeliveries(ctx, &pb.ListWebhookDeliveriesRequest{WebhookId: id})
+	require.NoError(t, err)
+	deliveries := resp.GetDeliveries()
+	require.Len(t, deliveries, 4)
+	for _, d := range deliveries[:3] {
+		assert.False(t, d.GetSuccess())
+		assert.Equal(t, int32(http.StatusServiceUnavailable), d.GetStatusCode())
+	}
+	assert.True(t, deliveries[3].GetSuccess())
+	assert.Equal(t, last.ID.String(), deliveries[3].GetEventId())
+
+	got, err := env.Webhooks.GetWebhook(ctx, &pb.GetWebhookRequest{Id: id})
+	require.NoError(t, err)
+	assert.True(t, got.GetWebhook().GetEnabled())
+	assert.Zero(t, got.GetWebhook().GetFailures())
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	env := apptest.Start(t)
+	ctx := context.Background()
+	created, err := env.Organizations.CreateOrganization(ctx, &pb.CreateOrganizationRequest{Name: "Acme"})
+	require.NoError(t, err)
+	acme := metadata.AppendToOutgoingContext(ctx, grpcdelivery.OrganizationHeader, created.GetOrganization().GetId())
+	create := func(ctx context.Context) string {
+		t.Helper()
+		resp, err := env.Webhooks.CreateWebhook(ctx, &pb.CreateWebhookRequest{Url: "https://partner.example.com/hooks"})
+		require.NoError(t, err)
+		return resp.GetWebhook().GetId()
+	}
+	id, scoped := create(ctx), create(acme)
+
+	tests := []struct {
+		name string
+		id   string
+		code codes.Code
+	}{
+		{name: "success", id: id, code: codes.OK},
+		{name: "already deleted", id: id, code: codes.NotFound},
+		{name: "other organization", id: scoped, code: codes.NotFound},
+		{name: "unknown id", id: uuid.NewString(), code: codes.NotFound},
+		{name: "malformed id", id: "not-a-uuid", code: codes.InvalidArgument},
+		{name: "empty id", id: "", code: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.Webhooks.DeleteWebhook(ctx, &pb.DeleteWebhookRequest{Id: tt.id})
+			assertCode(t, tt.code, err)
+		})
+	}
+
+	_, err = env.Webhooks.GetWebhook(ctx, &pb.GetWebhookRequest{Id: id})
+	assertCode(t, codes.NotFound, err)
+	_, err = env.Webhooks.GetWebhook(acme, &pb.GetWebhookRequest{Id: scoped})
+	require.NoError(t, err)
+}
+
+func TestListWebhooks(t *testing.T) {
+	env := apptest.Start(t)
+	ctx := context.Background()
+	created, err := env.Organizations.CreateOrganization(ctx, &pb.CreateOrganizationRequest{Name: "Acme"})
+	require.NoError(t, err)
+	acme := created.GetOrganization().GetId()
+	urls := []string{"https://partner.example.com/hooks", "https://crm.example.com/hooks"}
+	for _, url := range urls {
+		_, err := env.Webhooks.CreateWebhook(ctx, &pb.CreateWebhookRequest{Url: url})
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name         string
+		organization string
+		urls         []string
+		code         codes.Code
+	}{
+		{name: "default organization", urls: urls, code: codes.OK},
+		{name: "other organization", organization: acme, code: codes.OK},
+		{name: "unknown organization", organization: uuid.NewString(), code: codes.InvalidArgument},
+		{name: "malformed organization", organization: "not-a-uuid", code: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(ctx, grpcdelivery.OrganizationHeader, tt.organization)
+			resp, err := env.Webhooks.ListWebhooks(ctx, &pb.ListWebhooksRequest{})
+			assertCode(t, tt.code, err)
+			var got []string
+			for _, w := range resp.GetWebhooks() {
+				got = append(got, w.GetUrl())
+			}
+			assert.Equal(t, tt.urls, got)
+		})
+	}
+}
+
+func TestPrivacy(t *testing.T) {
+	env := apptest.Start(t)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), grpcdelivery.ActorHeader, "dpo")
+	resp, err := env.Users.CreateUser(metadata.AppendToOutgoingContext(ctx, grpcdelivery.IdempotencyKeyHeader, "create-jane"),
+		&pb.CreateUserRequest{Name: "Jane", Email: "jane@example.com"})
+	require.NoError(t, err)
+	id := resp.GetUser().GetId()
+
+	created, err := env.Groups.CreateGroup(ctx, &pb.CreateGroupRequest{Name: "Platform"})
+	require.NoError(t, err)
+	_, err = env.Groups.AddMember(ctx, &pb.AddMemberRequest{GroupId: created.GetGroup().GetId(), UserId: id})
+	require.NoError(t, err)
+	_, err = env.Users.SetPassword(ctx, &pb.SetPasswordRequest{Id: id, Password: "a long and unusual passphrase"})
+	require.NoError(t, err)
+	login, err := env.Users.Authenticate(ctx, &pb.AuthenticateRequest{Email: "jane@example.com", Password: "a long and unusual passphrase"})
+	require.NoError(t, err)
+
+	exported, err := env.Users.ExportUserData(ctx, &pb.ExportUserDataRequest{Id: id})
+	require.NoError(t, err)
+	var export model.DataExport
+	require.NoError(t, json.Unmarshal(exported.GetData(), &export))
+	assert.Equal(t, id, export.UserID.String())
+	for _, store := range []string{"users", "groups", "history", "refresh_tokens", "audit"} {
+		assert.Contains(t, export.Data, store)
+	}
+	assert.Contains(t, string(exported.GetData()), "jane@example.com")
+	assert.NotContains(t, string(exported.GetData()), "password_hash", "exports carry no credentials")
+
+	erased, err := env.Users.EraseUser(ctx, &pb.EraseUserRequest{Id: id})
+	require.NoError(t, err)
+	tombstone := erased.GetTombstone()
+	assert.Equal(t, id, tombstone.GetUserId())
+	assert.Equal(t, "dpo", tombstone.GetActor())
+	assert.Equal(t, int32(1), tombstone.GetErased()["users"])
+	assert.Equal(t, int32(1), tombstone.GetErased()["groups"])
+	assert.Equal(t, int32(1), tombstone.GetErased()["refresh_tokens"])
+	assert.Equal(t, int32(1), tombstone.GetErased()["idempotency"])
+
+	_, err = env.Users.GetUser(ctx, &pb.GetUserRequest{Id: id})
+	assertCode(t, codes.NotFound, err)
+	_, err = env.Users.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: login.GetTokens().GetRefreshToken()})
+	assertCode(t, codes.Unauthenticated, err)
+
+	// Nothing on disk names the user any more, but its erasure is traceable.
+	err = filepath.WalkDir(env.Config.DataDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "jane@example.com", path)
+		return nil
+	})
+	require.NoError(t, err)
+
+	exported, err = env.Users.ExportUserData(ctx, &pb.ExportUserDataRequest{Id: id})
+	require.NoError(t, err)
+	export = model.DataExport{}
+	require.NoError(t, json.Unmarshal(exported.GetData(), &export))
+	assert.NotContains(t, export.Data, "users")
+	require.Len(t, export.Tombstones, 1)
+	assert.Equal(t, "dpo", export.Tombstones[0].Actor)
+
+	again, err := env.Users.EraseUser(ctx, &pb.EraseUserRequest{Id: id})
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(tombstone, again.GetTombstone()), "erasing again returns the first tombstone")
+
+	_, err = env.Users.EraseUser(ctx, &pb.EraseUserRequest{Id: uuid.NewString()})
+	assertCode(t, codes.NotFound, err)
+	_, err = env.Users.ExportUserData(ctx, &pb.ExportUserDataRequest{Id: "not-a-uuid"})
+	assertCode(t, codes.InvalidArgument, err)
+
+	list, err := env.Audit.ListAuditEntries(ctx, &pb.ListAuditEntriesRequest{Target: id, Actor: "dpo"})
+	require.NoError(t, err)
+	var actions []string
+	for _, e := range list.GetEntries() {
+		actions = append(actions, e.GetAction())
+	}
+	assert.Subset(t, actions, []string{"user.export", "user.delete", "user.erase"})
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	env := apptest.Start(t)
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), grpcdelivery.IdempotencyKeyHeader, key)
+	}
+	req := &pb.CreateUserRequest{Name: "Retried", Email: "retried@example.com"}
+
+	first, err := env.Users.CreateUser(withKey("create-1"), req)
+	require.NoError(t, err)
+
+	var header metadata.MD
+	replay, err := env.Users.CreateUser(withKey("create-1"), req, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, first.GetUser().GetId(), replay.GetUser().GetId())
+	assert.Equal(t, []string{"true"}, header.Get(grpcdelivery.IdempotentReplayHeader))
+
+	list, err := env.Users.ListUsers(context.Background(), &pb.ListUsersRequest{})
+	require.NoError(t, err)
+	assert.Len(t, list.GetUsers(), 1, "replayed create must not add a user")
+
+	_, err = env.Users.CreateUser(withKey("create-1"), &pb.CreateUserRequest{Name: "Other", Email: "other@example.com"})
+	assertCode(t, codes.FailedPrecondition, err)
+
+	_, err = env.Users.CreateUser(withKey("create-2"), &pb.CreateUserRequest{Name: "Bad", Email: "bad"})
+	assertCode(t, codes.InvalidArgument, err)
+	_, err = env.Users.CreateUser(withKey("create-2"), &pb.CreateUserRequest{Name: "Fixed", Email: "fixed@example.com"})
+	assert.NoError(t, err, "failed calls do not consume the key")
+
+	del := &pb.DeleteUserRequest{Id: first.GetUser().GetId()}
+	_, err = env.Users.DeleteUser(withKey("delete-1"), del)
+	require.NoError(t, err)
+	_, err = env.Users.DeleteUser(withKey("delete-1"), del)
+	assert.NoError(t, err, "replayed delete returns the original success")
+	_, err = env.Users.DeleteUser(context.Background(), del)
+	assertCode(t, codes.NotFound, err)
+}
+
+func TestCreateUser_ReportsAllViolations(t *testing.T) {
+	env := apptest.Start(t)
+
+	_, err := env.Users.CreateUser(context.Background(), &pb.CreateUserRequest{Email: "not-an-email"})
+	assertCode(t, codes.InvalidArgument, err)
+
+	var fields []string
+	for _, detail := range status.Convert(err).Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				fields = append(fields, v.GetField())
+			}
+		}
+	}
+	assert.Equal(t, []string{"name", "email"}, fields)
+}
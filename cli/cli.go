@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sergey4qb/mf1-test/config"
+	"github.com/sergey4qb/mf1-test/repository"
+	"github.com/sergey4qb/mf1-test/services"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(cfg *config.Config, args []string) error
+}
+
+var commands = []command{
+	{name: "serve", summary: "start the gRPC server", run: runServe},
+	{name: "migrate", summary: "upgrade the storage schema in place", run: runMigrate},
+	{name: "import", summary: "create users from a CSV or JSONL file", run: runImport},
+	{name: "export", summary: "write all users as CSV or JSONL", run: runExport},
+	{name: "verify", summary: "check the store for duplicate IDs, invalid emails and malformed records", run: runVerify},
+	{name: "verify-audit", summary: "check the audit log for altered or removed entries", run: runVerifyAudit},
+}
+
+var errUnknownCommand = errors.New("unknown command")
+
+// Run executes the subcommand named by args[0]. Without arguments the server is
+// started, which keeps the container entrypoint unchanged.
+func Run(args []string) error {
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage(os.Stdout)
+		return nil
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(config.LoadConfig(), args[1:])
+		}
+	}
+
+	usage(os.Stderr)
+	return fmt.Errorf("%w: %s", errUnknownCommand, name)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: app <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+func newServices(cfg *config.Config) (services.Services, error) {
+	repo, err := repository.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("Error initializing repo: %v", err)
+	}
+
+	return services.New(cfg, repo)
+}
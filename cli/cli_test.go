@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	userservice "github.com/sergey4qb/mf1-test/services/user"
+)
+
+func TestVerifyRecords(t *testing.T) {
+	records := []json.RawMessage{
+		json.RawMessage(`{"id":"1b4e28ba-2fa1-11d2-883f-0016d3cca427","name":"Valid","email":"valid@example.com"}`),
+		json.RawMessage(`{"id":"1b4e28ba-2fa1-11d2-883f-0016d3cca427","name":"Dup","email":"dup@example.com"}`),
+		json.RawMessage(`{"id":"2b4e28ba-2fa1-11d2-883f-0016d3cca427","name":"Bad","email":"not-an-email"}`),
+		json.RawMessage(`{"id":42}`),
+		json.RawMessage(`{"name":"No ID","email":"noid@example.com"}`),
+		json.RawMessage(`{"id":"3b4e28ba-2fa1-11d2-883f-0016d3cca427","name":"Same","email":"Valid@Example.com"}`),
+		json.RawMessage(`{"id":"4b4e28ba-2fa1-11d2-883f-0016d3cca427","name":"Gone","email":"gone@example.com","status":"banned"}`),
+	}
+
+	issues := verifyRecords(records, userservice.DefaultRules())
+	assert.Len(t, issues, 6)
+	assert.Equal(t, 2, issues[0].record)
+	assert.Contains(t, issues[0].msg, "duplicate id")
+	assert.Equal(t, 3, issues[1].record)
+	assert.Equal(t, 4, issues[2].record)
+	assert.Contains(t, issues[2].msg, "malformed")
+	assert.Equal(t, 5, issues[3].record)
+	assert.Contains(t, issues[3].msg, "missing id")
+	assert.Equal(t, 6, issues[4].record)
+	assert.Contains(t, issues[4].msg, "duplicate email, first seen in record 1")
+	assert.Equal(t, 7, issues[5].record)
+	assert.Contains(t, issues[5].msg, `unknown status "banned"`)
+}
+
+func TestReadCSV(t *testing.T) {
+	in := "Email,Name\nalice@example.com,Alice\nbob@example.com,Bob\n"
+
+	records, err := readCSV(strings.NewReader(in))
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "Alice", records[0].user.Name)
+	assert.Equal(t, "alice@example.com", records[0].user.Email)
+	assert.Equal(t, 3, records[1].line)
+}
+
+func TestReadCSV_ProfileColumns(t *testing.T) {
+	in := "name,email,phone,time_zone,attributes\n" +
+		"Alice,alice@example.com,+14155550100,Europe/Berlin,\"{\"\"plan\"\":\"\"pro\"\"}\"\n"
+
+	records, err := readCSV(strings.NewReader(in))
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, map[string]any{"plan": "pro"}, records[0].user.Attributes)
+	assert.Equal(t, "+14155550100", records[0].user.Phone)
+	assert.Equal(t, "Europe/Berlin", records[0].user.TimeZone)
+	assert.Empty(t, records[0].user.Locale)
+}
+
+func TestReadCSV_MissingColumn(t *testing.T) {
+	_, err := readCSV(strings.NewReader("name\nAlice\n"))
+	assert.ErrorIs(t, err, errMissingColumn)
+}
+
+func TestReadJSONL(t *testing.T) {
+	in := `{"name":"Alice","email":"alice@example.com"}
+
+not json
+`
+
+	records, err := readJSONL(strings.NewReader(in))
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.NoError(t, records[0].err)
+	assert.Equal(t, "Alice", records[0].user.Name)
+	assert.Error(t, records[1].err)
+	assert.Equal(t, 3, records[1].line)
+}
+
+func TestReadJSONL_DropsCredentials(t *testing.T) {
+	in := `{"name":"Alice","email":"alice@example.com",` +
+		`"password_hash":"$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$aGFzaA",` +
+		`"mfa":{"secret":"c2VjcmV0"},"locked_until":"2030-01-01T00:00:00Z",` +
+		`"login_failures":["2024-01-01T00:00:00Z"]}
+`
+
+	records, err := readJSONL(strings.NewReader(in))
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.NoError(t, records[0].err)
+	assert.Equal(t, "Alice", records[0].user.Name)
+	assert.Empty(t, records[0].user.PasswordHash)
+	assert.Nil(t, records[0].user.MFA)
+	assert.Nil(t, records[0].user.LockedUntil)
+	assert.Empty(t, records[0].user.LoginFailures)
+}
+
+func TestResolveFormat(t *testing.T) {
+	f, err := resolveFormat("", "users.JSONL")
+	assert.NoError(t, err)
+	assert.Equal(t, formatJSONL, f)
+
+	f, err = resolveFormat("csv", "-")
+	assert.NoError(t, err)
+	assert.Equal(t, formatCSV, f)
+
+	_, err = resolveFormat("", "users.xml")
+	assert.ErrorIs(t, err, errUnknownFormat)
+}
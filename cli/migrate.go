@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/sergey4qb/mf1-test/config"
+	"github.com/sergey4qb/mf1-test/repository/user"
+)
+
+func runMigrate(cfg *config.Config, args []string) error {
+	if err := newFlagSet("migrate").Parse(args); err != nil {
+		return err
+	}
+
+	// Every organization keeps its users in a file of its own.
+	paths, err := user.TenantFiles(cfg.UsersFilePath())
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		from, err := user.Migrate(path)
+		if err != nil {
+			return fmt.Errorf("migrate %s: %w", path, err)
+		}
+
+		if from == user.CurrentSchemaVersion {
+			fmt.Printf("%s is already at schema version %d\n", path, from)
+			continue
+		}
+		fmt.Printf("%s migrated from schema version %d to %d\n", path, from, user.CurrentSchemaVersion)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package cli
+
+import (
+	"github.com/sergey4qb/mf1-test/application"
+	"github.com/sergey4qb/mf1-test/config"
+)
+
+func runServe(cfg *config.Config, args []string) error {
+	if err := newFlagSet("serve").Parse(args); err != nil {
+		return err
+	}
+
+	app, err := application.New(application.WithConfig(cfg))
+	if err != nil {
+		return err
+	}
+
+	return app.Run()
+}
@@ -0,0 +1,341 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sergey4qb/mf1-test/actor"
+	"github.com/sergey4qb/mf1-test/config"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/services"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+const (
+	formatCSV   = "csv"
+	formatJSONL = "jsonl"
+)
+
+var (
+	errUnknownFormat     = errors.New("unknown format, expected csv or jsonl")
+	errMissingColumn     = errors.New("missing required column")
+	errImportIncomplete  = errors.New("some records were not imported")
+	errMissingImportFile = errors.New("import needs an input file, use - for stdin")
+)
+
+var csvHeader = []string{"id", "name", "email", "given_name", "family_name", "phone", "locale", "time_zone", "avatar_url", "attributes", "status", "email_verified"}
+
+// importRecord is one input row together with its line number for reporting.
+type importRecord struct {
+	line int
+	user model.User
+	err  error
+}
+
+func runImport(cfg *config.Config, args []string) error {
+	fs := newFlagSet("import")
+	format := fs.String("format", "", "input format: csv or jsonl (default: from file extension)")
+	keepIDs := fs.Bool("keep-ids", false, "keep user IDs from the input, requires USER_ID_STRATEGY=provided")
+	org := fs.String("org", "", "ID of the organization to import into (default: the default organization)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errMissingImportFile
+	}
+
+	path := fs.Arg(0)
+	f, err := resolveFormat(*format, path)
+	if err != nil {
+		return err
+	}
+
+	in, err := openInput(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var records []importRecord
+	switch f {
+	case formatCSV:
+		records, err = readCSV(in)
+	case formatJSONL:
+		records, err = readJSONL(in)
+	}
+	if err != nil {
+		return err
+	}
+
+	svcs, err := newServices(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, err := organizationContext(svcs, *org)
+	if err != nil {
+		return err
+	}
+	imported, failed := 0, 0
+	for _, rec := range records {
+		if rec.err == nil {
+			u := rec.user
+			if !*keepIDs {
+				u.ID = uuid.Nil
+			}
+			rec.err = svcs.GetUser().Create(ctx, &u)
+		}
+		if rec.err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "line %d: %v\n", rec.line, rec.err)
+			continue
+		}
+		imported++
+	}
+
+	fmt.Printf("imported %d users, %d failed\n", imported, failed)
+	if failed > 0 {
+		return errImportIncomplete
+	}
+	return nil
+}
+
+// organizationContext returns a context acting for the organization with the
+// given ID, or for the default one when id is empty. Changes made with it are
+// attributed to actor.System.
+func organizationContext(svcs services.Services, id string) (context.Context, error) {
+	ctx := actor.NewContext(context.Background(), actor.System)
+	if id == "" {
+		return ctx, nil
+	}
+	org, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid organization id %q", id)
+	}
+	if _, err := svcs.GetOrganization().GetByID(ctx, org); err != nil {
+		return nil, fmt.Errorf("organization %s: %w", org, err)
+	}
+	return tenant.NewContext(ctx, org), nil
+}
+
+func runExport(cfg *config.Config, args []string) error {
+	fs := newFlagSet("export")
+	format := fs.String("format", "", "output format: csv or jsonl (default: from file extension, csv for stdout)")
+	org := fs.String("org", "", "ID of the organization to export (default: the default organization)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	path := "-"
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	if *format == "" && path == "-" {
+		*format = formatCSV
+	}
+	f, err := resolveFormat(*format, path)
+	if err != nil {
+		return err
+	}
+
+	svcs, err := newServices(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, err := organizationContext(svcs, *org)
+	if err != nil {
+		return err
+	}
+	users, err := svcs.GetUser().GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	// Exports are meant to be shared; credentials stay in the store.
+	for i := range users {
+		users[i] = users[i].WithoutCredentials()
+	}
+
+	out := io.WriteCloser(os.Stdout)
+	if path != "-" {
+		out, err = os.Create(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch f {
+	case formatCSV:
+		err = writeCSV(out, users)
+	case formatJSONL:
+		err = writeJSONL(out, users)
+	}
+	if path != "-" {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func resolveFormat(format, path string) (string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	switch strings.ToLower(format) {
+	case formatCSV:
+		return formatCSV, nil
+	case formatJSONL, "ndjson":
+		return formatJSONL, nil
+	}
+	return "", errUnknownFormat
+}
+
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+func readCSV(r io.Reader) ([]importRecord, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "email"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: %s", errMissingColumn, required)
+		}
+	}
+
+	var records []importRecord
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		if err != nil {
+			records = append(records, importRecord{line: line, err: err})
+			continue
+		}
+
+		column := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return field(row, i)
+			}
+			return ""
+		}
+		rec := importRecord{
+			line: line,
+			user: model.User{
+				Name:       column("name"),
+				Email:      column("email"),
+				GivenName:  column("given_name"),
+				FamilyName: column("family_name"),
+				Phone:      column("phone"),
+				Locale:     column("locale"),
+				TimeZone:   column("time_zone"),
+				AvatarURL:  column("avatar_url"),
+				Status:     model.UserStatus(column("status")),
+			},
+		}
+		if i, ok := columns["id"]; ok && field(row, i) != "" {
+			rec.user.ID, rec.err = uuid.Parse(field(row, i))
+		}
+		if raw := column("email_verified"); raw != "" && rec.err == nil {
+			if rec.user.EmailVerified, err = strconv.ParseBool(raw); err != nil {
+				rec.err = fmt.Errorf("email_verified: %w", err)
+			}
+		}
+		if raw := column("attributes"); raw != "" && rec.err == nil {
+			if err := json.Unmarshal([]byte(raw), &rec.user.Attributes); err != nil {
+				rec.err = fmt.Errorf("attributes: %w", err)
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func readJSONL(r io.Reader) ([]importRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var records []importRecord
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		rec := importRecord{line: line}
+		rec.err = json.Unmarshal([]byte(text), &rec.user)
+		// Exports never carry credentials, so any found in the input were
+		// put there by hand and must not let someone log in as the user.
+		rec.user = rec.user.WithoutCredentials()
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+func writeCSV(w io.Writer, users []model.User) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, u := range users {
+		var attributes []byte
+		if len(u.Attributes) > 0 {
+			var err error
+			if attributes, err = json.Marshal(u.Attributes); err != nil {
+				return err
+			}
+		}
+		row := []string{u.ID.String(), u.Name, u.Email, u.GivenName, u.FamilyName, u.Phone, u.Locale, u.TimeZone, u.AvatarURL, string(attributes), string(u.Status), strconv.FormatBool(u.EmailVerified)}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeJSONL(w io.Writer, users []model.User) error {
+	enc := json.NewEncoder(w)
+	for _, u := range users {
+		if err := enc.Encode(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func field(row []string, i int) string {
+	if i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sergey4qb/mf1-test/config"
+	"github.com/sergey4qb/mf1-test/email"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	userservice "github.com/sergey4qb/mf1-test/services/user"
+)
+
+var errStoreInconsistent = errors.New("store integrity check failed")
+
+type issue struct {
+	record int
+	id     string
+	msg    string
+}
+
+func (i issue) String() string {
+	if i.id == "" {
+		return fmt.Sprintf("record %d: %s", i.record, i.msg)
+	}
+	return fmt.Sprintf("record %d (id %s): %s", i.record, i.id, i.msg)
+}
+
+func runVerify(cfg *config.Config, args []string) error {
+	if err := newFlagSet("verify").Parse(args); err != nil {
+		return err
+	}
+
+	rules, err := userservice.LoadRules(cfg.ValidationRulesFile)
+	if err != nil {
+		return err
+	}
+	attributes, err := userservice.LoadAttributeSchema(cfg.AttributeSchemaFile)
+	if err != nil {
+		return err
+	}
+	validator := userservice.Chain(rules, attributes)
+
+	// Every organization keeps its users in a file of its own; emails only
+	// have to be unique within one.
+	paths, err := user.TenantFiles(cfg.UsersFilePath())
+	if err != nil {
+		return err
+	}
+	total := 0
+	for _, path := range paths {
+		version, records, err := user.ReadRawRecords(path)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+
+		issues := verifyRecords(records, validator)
+		if version != user.CurrentSchemaVersion {
+			fmt.Printf("note: %s uses schema version %d, run migrate to upgrade to %d\n", path, version, user.CurrentSchemaVersion)
+		}
+		for _, i := range issues {
+			fmt.Printf("%s: %s\n", path, i)
+		}
+		fmt.Printf("%s: checked %d records, found %d issues\n", path, len(records), len(issues))
+		total += len(issues)
+	}
+
+	if total > 0 {
+		return errStoreInconsistent
+	}
+	return nil
+}
+
+// verifyRecords reports malformed records, duplicate IDs, emails shared by
+// several users, unknown statuses and users that would be rejected by
+// validator.
+func verifyRecords(records []json.RawMessage, validator userservice.Validator) []issue {
+	var issues []issue
+	firstSeen := map[uuid.UUID]int{}
+	emailSeen := map[string]int{}
+
+	for i, raw := range records {
+		n := i + 1
+
+		var u model.User
+		if err := json.Unmarshal(raw, &u); err != nil {
+			issues = append(issues, issue{record: n, msg: "malformed record: " + err.Error()})
+			continue
+		}
+		if u.ID == uuid.Nil {
+			issues = append(issues, issue{record: n, msg: "malformed record: missing id"})
+			continue
+		}
+
+		id := u.ID.String()
+		if first, ok := firstSeen[u.ID]; ok {
+			issues = append(issues, issue{record: n, id: id, msg: fmt.Sprintf("duplicate id, first seen in record %d", first)})
+		} else {
+			firstSeen[u.ID] = n
+		}
+
+		if canonical := canonicalEmail(u); canonical != "" {
+			if first, ok := emailSeen[canonical]; ok {
+				issues = append(issues, issue{record: n, id: id, msg: fmt.Sprintf("duplicate email, first seen in record %d", first)})
+			} else {
+				emailSeen[canonical] = n
+			}
+		}
+
+		if u.Status != "" && !userservice.ValidStatus(u.Status) {
+			issues = append(issues, issue{record: n, id: id, msg: fmt.Sprintf("unknown status %q", u.Status)})
+		}
+
+		if err := validator.Validate(&u); err != nil {
+			issues = append(issues, issue{record: n, id: id, msg: err.Error()})
+		}
+	}
+	return issues
+}
+
+// canonicalEmail returns the stored canonical email of u, deriving it for
+// records written before it was stored.
+func canonicalEmail(u model.User) string {
+	if u.EmailCanonical != "" {
+		return u.EmailCanonical
+	}
+	addr, err := email.Parse(u.Email)
+	if err != nil {
+		return ""
+	}
+	return addr.Canonical(email.CanonicalOptions{})
+}
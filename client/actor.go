@@ -0,0 +1,15 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const actorHeader = "x-actor"
+
+// WithActor returns a context naming who makes the calls, e.g. an operator's
+// login. The server records it with status changes.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, actorHeader, actor)
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// attributeValues converts attributes for the wire. Values may be any type
+// structpb.NewValue accepts; nil is sent as null.
+func attributeValues(attributes map[string]any) (map[string]*structpb.Value, error) {
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]*structpb.Value, len(attributes))
+	for key, v := range attributes {
+		value, err := structpb.NewValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %q: %w", key, err)
+		}
+		out[key] = value
+	}
+	return out, nil
+}
+
+func fromValues(values map[string]*structpb.Value) map[string]any {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(values))
+	for key, v := range values {
+		out[key] = v.AsInterface()
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+const requestIDHeader = "x-request-id"
+
+// WithRequestID returns a context sending id with the calls, so they can be
+// found in the audit log. The server makes one up for calls without.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, requestIDHeader, id)
+}
+
+var fromProtoOutcomes = map[pb.AuditOutcome]model.AuditOutcome{
+	pb.AuditOutcome_AUDIT_OUTCOME_SUCCESS: model.AuditSuccess,
+	pb.AuditOutcome_AUDIT_OUTCOME_FAILURE: model.AuditFailure,
+}
+
+// ListAuditEntries returns the audit entries on target made by actor, oldest
+// first. Empty arguments match every entry.
+func (c *Client) ListAuditEntries(ctx context.Context, target, actor string) ([]model.AuditEntry, error) {
+	var out []model.AuditEntry
+	req := &pb.ListAuditEntriesRequest{Target: target, Actor: actor, PageSize: 1000}
+	for {
+		var resp *pb.ListAuditEntriesResponse
+		err := c.retry.do(ctx, func(ctx context.Context) error {
+			var err error
+			resp, err = c.audit.ListAuditEntries(ctx, req)
+			return err
+		})
+		if err != nil {
+			return nil, fromStatus(err)
+		}
+
+		for _, e := range resp.GetEntries() {
+			out = append(out, fromProtoAuditEntry(e))
+		}
+		if resp.GetNextPageToken() == "" {
+			return out, nil
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+}
+
+func fromProtoAuditEntry(e *pb.AuditEntry) model.AuditEntry {
+	return model.AuditEntry{
+		Sequence:   e.GetSequence(),
+		At:         e.GetTime().AsTime(),
+		Actor:      e.GetActor(),
+		Action:     e.GetAction(),
+		Target:     e.GetTarget(),
+		BeforeHash: e.GetBeforeHash(),
+		AfterHash:  e.GetAfterHash(),
+		RequestID:  e.GetRequestId(),
+		Peer:       e.GetPeer(),
+		Outcome:    fromProtoOutcomes[e.GetOutcome()],
+		Error:      e.GetError(),
+		Hash:       e.GetHash(),
+	}
+}
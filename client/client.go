@@ -0,0 +1,366 @@
+// Package client is a typed Go SDK for the UserService, GroupService,
+// RoleService, OrganizationService, AuditService and WebhookService gRPC
+// APIs. It mirrors services/user.User, services/group.Group,
+// services/role.Role, services/organization.Organization,
+// services/audit.Audit and services/webhook.Webhook so consumers work with
+// model types and uuid.UUID instead of generated messages.
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+type Client struct {
+	conn          *grpc.ClientConn
+	users         pb.UserServiceClient
+	groups        pb.GroupServiceClient
+	roles         pb.RoleServiceClient
+	organizations pb.OrganizationServiceClient
+	audit         pb.AuditServiceClient
+	webhooks      pb.WebhookServiceClient
+	retry         RetryPolicy
+}
+
+// New connects to the UserService at target, e.g. "localhost:8080".
+func New(target string, opts ...Option) (*Client, error) {
+	o := &options{retry: DefaultRetryPolicy}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	conn, err := grpc.NewClient(target, o.grpcDialOptions()...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		conn:          conn,
+		users:         pb.NewUserServiceClient(conn),
+		groups:        pb.NewGroupServiceClient(conn),
+		roles:         pb.NewRoleServiceClient(conn),
+		organizations: pb.NewOrganizationServiceClient(conn),
+		audit:         pb.NewAuditServiceClient(conn),
+		webhooks:      pb.NewWebhookServiceClient(conn),
+		retry:         o.retry,
+	}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Create stores user and sets its server assigned ID. A non-nil user.ID is
+// sent along and only accepted by servers using the "provided" ID strategy.
+func (c *Client) Create(ctx context.Context, user *model.User) error {
+	req := &pb.CreateUserRequest{
+		Name:       user.Name,
+		Email:      user.Email,
+		GivenName:  user.GivenName,
+		FamilyName: user.FamilyName,
+		Phone:      user.Phone,
+		Locale:     user.Locale,
+		TimeZone:   user.TimeZone,
+		AvatarUrl:  user.AvatarURL,
+	}
+	if user.ID != uuid.Nil {
+		req.Id = user.ID.String()
+	}
+
+	values, err := attributeValues(user.Attributes)
+	if err != nil {
+		return err
+	}
+	req.Attributes = values
+
+	resp, err := c.users.CreateUser(ctx, req)
+	if err != nil {
+		return fromStatus(err)
+	}
+
+	created, err := fromProto(resp.GetUser())
+	if err != nil {
+		return err
+	}
+	*user = *created
+	return nil
+}
+
+func (c *Client) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	var resp *pb.GetUserResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.users.GetUser(ctx, &pb.GetUserRequest{Id: id.String()})
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	return fromProto(resp.GetUser())
+}
+
+// GetAll drains ListUsers page by page.
+func (c *Client) GetAll(ctx context.Context) ([]model.User, error) {
+	var users []model.User
+	it := c.ListUsers(ctx, 0)
+	for it.Next() {
+		users = append(users, *it.User())
+	}
+	return users, it.Err()
+}
+
+// ListUsers returns an iterator fetching pageSize users per request. A zero
+// pageSize lets the server return everything at once.
+func (c *Client) ListUsers(ctx context.Context, pageSize int, opts ...ListOption) *UserIterator {
+	req := &pb.ListUsersRequest{PageSize: int32(pageSize)}
+	for _, opt := range opts {
+		opt(req)
+	}
+	return &UserIterator{ctx: ctx, client: c, req: req}
+}
+
+// ListOption narrows or orders the users returned by ListUsers.
+type ListOption func(*pb.ListUsersRequest)
+
+// MatchingName keeps users whose name contains query, ignoring case and
+// accents.
+func MatchingName(query string) ListOption {
+	return func(req *pb.ListUsersRequest) {
+		req.NameQuery = query
+	}
+}
+
+// WithAttribute keeps users whose indexed attribute key equals value, or for
+// array attributes contains it.
+func WithAttribute(key string, value any) ListOption {
+	return func(req *pb.ListUsersRequest) {
+		v, err := structpb.NewValue(value)
+		if err != nil {
+			// Sent as null, which the server rejects as a filter value.
+			v = structpb.NewNullValue()
+		}
+		if req.Attributes == nil {
+			req.Attributes = map[string]*structpb.Value{}
+		}
+		req.Attributes[key] = v
+	}
+}
+
+// WithStatus keeps users in any of statuses. Repeated options add to the set.
+func WithStatus(statuses ...model.UserStatus) ListOption {
+	return func(req *pb.ListUsersRequest) {
+		for _, st := range statuses {
+			req.Statuses = append(req.Statuses, statusToProto(st))
+		}
+	}
+}
+
+// OrderByName lists users by name instead of creation order.
+func OrderByName() ListOption {
+	return func(req *pb.ListUsersRequest) {
+		req.OrderBy = "name"
+	}
+}
+
+// Update applies the non-nil fields of dto; optional profile fields set to ""
+// are cleared. An empty name or email is rejected locally.
+func (c *Client) Update(ctx context.Context, dto *dto.UpdateUserDTO) (*model.User, error) {
+	if dto.Name != nil && *dto.Name == "" {
+		return nil, &Error{Code: codes.InvalidArgument, Message: "name cannot be empty"}
+	}
+	if dto.Email != nil && *dto.Email == "" {
+		return nil, &Error{Code: codes.InvalidArgument, Message: "email cannot be empty"}
+	}
+
+	req := &pb.UpdateUserRequest{Id: dto.ID.String(), UpdateMask: &fieldmaskpb.FieldMask{}}
+	for _, f := range []struct {
+		path   string
+		value  *string
+		target *string
+	}{
+		{"name", dto.Name, &req.Name},
+		{"email", dto.Email, &req.Email},
+		{"given_name", dto.GivenName, &req.GivenName},
+		{"family_name", dto.FamilyName, &req.FamilyName},
+		{"phone", dto.Phone, &req.Phone},
+		{"locale", dto.Locale, &req.Locale},
+		{"time_zone", dto.TimeZone, &req.TimeZone},
+		{"avatar_url", dto.AvatarURL, &req.AvatarUrl},
+	} {
+		if f.value != nil {
+			*f.target = *f.value
+			req.UpdateMask.Paths = append(req.UpdateMask.Paths, f.path)
+		}
+	}
+	if dto.ReplaceAttributes {
+		req.UpdateMask.Paths = append(req.UpdateMask.Paths, "attributes")
+	} else {
+		var paths []string
+		for key := range dto.Attributes {
+			paths = append(paths, "attributes."+key)
+		}
+		// Sorted so retries with an idempotency key send identical requests.
+		sort.Strings(paths)
+		req.UpdateMask.Paths = append(req.UpdateMask.Paths, paths...)
+	}
+	values, err := attributeValues(dto.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	req.Attributes = values
+
+	var resp *pb.UpdateUserResponse
+	err = c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.users.UpdateUser(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	return fromProto(resp.GetUser())
+}
+
+func (c *Client) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := c.users.DeleteUser(ctx, &pb.DeleteUserRequest{Id: id.String()})
+	return fromStatus(err)
+}
+
+// Suspend blocks an active user. reason is required and recorded with the
+// change; suspending a user that is not active fails with
+// codes.FailedPrecondition.
+func (c *Client) Suspend(ctx context.Context, id uuid.UUID, reason string) (*model.User, error) {
+	resp, err := c.users.SuspendUser(ctx, &pb.SuspendUserRequest{Id: id.String(), Reason: reason})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProto(resp.GetUser())
+}
+
+// Reactivate makes a suspended or pending user active again.
+func (c *Client) Reactivate(ctx context.Context, id uuid.UUID, reason string) (*model.User, error) {
+	resp, err := c.users.ReactivateUser(ctx, &pb.ReactivateUserRequest{Id: id.String(), Reason: reason})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProto(resp.GetUser())
+}
+
+// SendVerificationEmail mails the user a single-use verification token and
+// returns when it expires.
+func (c *Client) SendVerificationEmail(ctx context.Context, id uuid.UUID) (time.Time, error) {
+	resp, err := c.users.SendVerificationEmail(ctx, &pb.SendVerificationEmailRequest{Id: id.String()})
+	if err != nil {
+		return time.Time{}, fromStatus(err)
+	}
+	return resp.GetExpireTime().AsTime(), nil
+}
+
+// VerifyEmail consumes a token sent by SendVerificationEmail and returns the
+// verified user.
+func (c *Client) VerifyEmail(ctx context.Context, token string) (*model.User, error) {
+	resp, err := c.users.VerifyEmail(ctx, &pb.VerifyEmailRequest{Token: token})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProto(resp.GetUser())
+}
+
+// SetPassword sets the user's password without the current one. Passwords
+// breaking the server's policy fail with ErrInvalidArgument.
+func (c *Client) SetPassword(ctx context.Context, id uuid.UUID, password string) error {
+	_, err := c.users.SetPassword(ctx, &pb.SetPasswordRequest{Id: id.String(), Password: password})
+	return fromStatus(err)
+}
+
+// ChangePassword replaces the password after the server checked current. A
+// wrong current password fails with ErrUnauthenticated.
+func (c *Client) ChangePassword(ctx context.Context, id uuid.UUID, current, password string) error {
+	_, err := c.users.ChangePassword(ctx, &pb.ChangePasswordRequest{
+		Id:              id.String(),
+		CurrentPassword: current,
+		NewPassword:     password,
+	})
+	return fromStatus(err)
+}
+
+// Authenticate returns the user with email and the tokens of a new session
+// if password matches. It fails with ErrUnauthenticated for unknown emails,
+// wrong passwords and locked out users, and with ErrPermissionDenied when the
+// user is not active. Users with MFA enabled get
+// a *MFARequiredError to continue with VerifyMFA. Failed attempts are never
+// retried.
+func (c *Client) Authenticate(ctx context.Context, email, password string) (*model.User, *Tokens, error) {
+	resp, err := c.users.Authenticate(ctx, &pb.AuthenticateRequest{Email: email, Password: password})
+	if err != nil {
+		return nil, nil, fromStatus(err)
+	}
+	if resp.GetMfaRequired() {
+		return nil, nil, &MFARequiredError{Token: resp.GetMfaToken(), ExpiresAt: resp.GetMfaExpireTime().AsTime()}
+	}
+	u, err := fromProto(resp.GetUser())
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, fromProtoTokens(resp.GetTokens()), nil
+}
+
+func (c *Client) listPage(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	var resp *pb.ListUsersResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.users.ListUsers(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return resp, nil
+}
+
+func fromProto(u *pb.User) (*model.User, error) {
+	if u == nil {
+		return nil, errMalformedResponse
+	}
+	id, err := uuid.Parse(u.GetId())
+	if err != nil {
+		return nil, fmt.Errorf("%w: user id %q", errMalformedResponse, u.GetId())
+	}
+
+	user := &model.User{
+		ID:            id,
+		Name:          u.GetName(),
+		Email:         u.GetEmail(),
+		EmailVerified: u.GetEmailVerified(),
+		GivenName:     u.GetGivenName(),
+		FamilyName:    u.GetFamilyName(),
+		Phone:         u.GetPhone(),
+		Locale:        u.GetLocale(),
+		TimeZone:      u.GetTimeZone(),
+		AvatarURL:     u.GetAvatarUrl(),
+		Attributes:    fromValues(u.GetAttributes()),
+		Status:        statusFromProto(u.GetStatus()),
+	}
+	if u.GetStatusChangedAt() != nil {
+		user.StatusChange = &model.StatusChange{
+			Reason: u.GetStatusReason(),
+			Actor:  u.GetStatusChangedBy(),
+			At:     u.GetStatusChangedAt().AsTime(),
+		}
+	}
+	return user, nil
+}
@@ -0,0 +1,307 @@
+package client
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+type fakeServer struct {
+	pb.UnimplementedUserServiceServer
+	users        []*pb.User
+	failures     int
+	getCalls     int
+	createCalls  int
+	authMetadata []string
+	actors       []string
+	refreshCalls int
+}
+
+func (s *fakeServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	s.createCalls++
+	if s.failures > 0 {
+		s.failures--
+		return nil, status.Error(codes.Unavailable, "try again")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.authMetadata = md.Get("authorization")
+
+	u := &pb.User{Id: uuid.NewString(), Name: req.GetName(), Email: req.GetEmail()}
+	s.users = append(s.users, u)
+	return &pb.CreateUserResponse{User: u}, nil
+}
+
+func (s *fakeServer) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	s.refreshCalls++
+	if s.failures > 0 {
+		s.failures--
+		return nil, status.Error(codes.Unavailable, "try again")
+	}
+	if req.GetRefreshToken() != "refresh-1" {
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+	}
+	return &pb.RefreshTokenResponse{Tokens: &pb.Tokens{
+		AccessToken:       "access-2",
+		TokenType:         "Bearer",
+		ExpireTime:        timestamppb.New(time.Unix(1700000000, 0)),
+		RefreshToken:      "refresh-2",
+		RefreshExpireTime: timestamppb.New(time.Unix(1700086400, 0)),
+	}}, nil
+}
+
+func (s *fakeServer) Authenticate(ctx context.Context, req *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+	return &pb.AuthenticateResponse{
+		MfaRequired:   true,
+		MfaToken:      "challenge",
+		MfaExpireTime: timestamppb.New(time.Unix(1700000300, 0)),
+	}, nil
+}
+
+func (s *fakeServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	s.getCalls++
+	if s.failures > 0 {
+		s.failures--
+		return nil, status.Error(codes.Unavailable, "try again")
+	}
+	for _, u := range s.users {
+		if u.GetId() == req.GetId() {
+			return &pb.GetUserResponse{User: u}, nil
+		}
+	}
+	return nil, status.Error(codes.NotFound, "user not found")
+}
+
+func (s *fakeServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	offset := 0
+	if req.GetPageToken() != "" {
+		offset, _ = strconv.Atoi(req.GetPageToken())
+	}
+	end := len(s.users)
+	if req.GetPageSize() > 0 && offset+int(req.GetPageSize()) < end {
+		end = offset + int(req.GetPageSize())
+	}
+	resp := &pb.ListUsersResponse{Users: s.users[offset:end]}
+	if end < len(s.users) {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	return resp, nil
+}
+
+func (s *fakeServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
+	st, _ := status.New(codes.InvalidArgument, "email: invalid format email").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "email", Description: "invalid format email"}},
+	})
+	return nil, st.Err()
+}
+
+func (s *fakeServer) SuspendUser(ctx context.Context, req *pb.SuspendUserRequest) (*pb.SuspendUserResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.actors = md.Get(actorHeader)
+	for _, u := range s.users {
+		if u.GetId() != req.GetId() {
+			continue
+		}
+		if u.GetStatus() == pb.UserStatus_USER_STATUS_SUSPENDED {
+			return nil, status.Error(codes.FailedPrecondition, "invalid status transition")
+		}
+		u.Status = pb.UserStatus_USER_STATUS_SUSPENDED
+		u.StatusReason = req.GetReason()
+		u.StatusChangedBy = s.actors[0]
+		u.StatusChangedAt = timestamppb.Now()
+		return &pb.SuspendUserResponse{User: u}, nil
+	}
+	return nil, status.Error(codes.NotFound, "user not found")
+}
+
+func newTestClient(t *testing.T, srv *fakeServer, opts ...Option) *Client {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	pb.RegisterUserServiceServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	opts = append([]Option{
+		WithInsecure(),
+		WithRetry(RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			Multiplier:     2,
+			RetryableCodes: []codes.Code{codes.Unavailable},
+		}),
+		WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		})),
+	}, opts...)
+
+	c, err := New("passthrough:///bufnet", opts...)
+	assert.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClient_CreateAndGet(t *testing.T) {
+	srv := &fakeServer{}
+	c := newTestClient(t, srv, WithToken("secret"))
+
+	u := &model.User{Name: "Test", Email: "test@example.com"}
+	assert.NoError(t, c.Create(context.Background(), u))
+	assert.NotEqual(t, uuid.Nil, u.ID)
+	assert.Equal(t, []string{"Bearer secret"}, srv.authMetadata)
+
+	got, err := c.GetByID(context.Background(), u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, *u, *got)
+}
+
+func TestClient_GetByID_NotFound(t *testing.T) {
+	c := newTestClient(t, &fakeServer{})
+
+	_, err := c.GetByID(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	var clientErr *Error
+	assert.ErrorAs(t, err, &clientErr)
+	assert.Equal(t, codes.NotFound, clientErr.Code)
+}
+
+func TestClient_Update_InvalidArgument(t *testing.T) {
+	c := newTestClient(t, &fakeServer{})
+
+	email := "bad"
+	_, err := c.Update(context.Background(), &dto.UpdateUserDTO{ID: uuid.New(), Email: &email})
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+
+	var clientErr *Error
+	assert.ErrorAs(t, err, &clientErr)
+	assert.Equal(t, []FieldViolation{{Field: "email", Description: "invalid format email"}}, clientErr.Violations)
+
+	empty := ""
+	_, err = c.Update(context.Background(), &dto.UpdateUserDTO{ID: uuid.New(), Name: &empty})
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestClient_RetriesIdempotentCalls(t *testing.T) {
+	srv := &fakeServer{users: []*pb.User{{Id: uuid.NewString(), Name: "A", Email: "a@example.com"}}}
+	c := newTestClient(t, srv)
+
+	srv.failures = 2
+	_, err := c.GetByID(context.Background(), uuid.MustParse(srv.users[0].GetId()))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, srv.getCalls)
+
+	srv.failures = 3
+	srv.getCalls = 0
+	_, err = c.GetByID(context.Background(), uuid.MustParse(srv.users[0].GetId()))
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 3, srv.getCalls)
+}
+
+func TestClient_DoesNotRetryCreate(t *testing.T) {
+	srv := &fakeServer{failures: 1}
+	c := newTestClient(t, srv)
+
+	err := c.Create(context.Background(), &model.User{Name: "Test", Email: "test@example.com"})
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 1, srv.createCalls)
+}
+
+func TestClient_ListUsersIterator(t *testing.T) {
+	srv := &fakeServer{}
+	for i := 0; i < 7; i++ {
+		srv.users = append(srv.users, &pb.User{Id: uuid.NewString(), Name: "User", Email: "user@example.com"})
+	}
+	c := newTestClient(t, srv)
+
+	var ids []string
+	it := c.ListUsers(context.Background(), 3)
+	for it.Next() {
+		ids = append(ids, it.User().ID.String())
+	}
+	assert.NoError(t, it.Err())
+	assert.Len(t, ids, 7)
+	assert.Equal(t, srv.users[6].GetId(), ids[6])
+
+	all, err := c.GetAll(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, all, 7)
+}
+
+func TestClient_MalformedID(t *testing.T) {
+	srv := &fakeServer{users: []*pb.User{{Id: "not-a-uuid"}}}
+	c := newTestClient(t, srv)
+
+	it := c.ListUsers(context.Background(), 0)
+	assert.False(t, it.Next())
+	assert.ErrorIs(t, it.Err(), errMalformedResponse)
+}
+
+func TestClient_Suspend(t *testing.T) {
+	srv := &fakeServer{}
+	c := newTestClient(t, srv)
+
+	u := &model.User{Name: "Test", Email: "test@example.com"}
+	assert.NoError(t, c.Create(context.Background(), u))
+
+	ctx := WithActor(context.Background(), "alice")
+	got, err := c.Suspend(ctx, u.ID, "spam")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, srv.actors)
+	assert.Equal(t, model.StatusSuspended, got.Status)
+	if assert.NotNil(t, got.StatusChange) {
+		assert.Equal(t, "spam", got.StatusChange.Reason)
+		assert.Equal(t, "alice", got.StatusChange.Actor)
+	}
+
+	_, err = c.Suspend(ctx, u.ID, "again")
+	assert.ErrorIs(t, err, ErrFailedPrecondition)
+}
+
+func TestClient_Refresh(t *testing.T) {
+	srv := &fakeServer{}
+	c := newTestClient(t, srv)
+
+	tokens, err := c.Refresh(context.Background(), "refresh-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "access-2", tokens.AccessToken)
+	assert.Equal(t, "refresh-2", tokens.RefreshToken)
+	assert.True(t, tokens.ExpiresAt.Equal(time.Unix(1700000000, 0)))
+
+	_, err = c.Refresh(context.Background(), "refresh-2")
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	// A refresh token works once, so failures are not retried.
+	srv.failures, srv.refreshCalls = 1, 0
+	_, err = c.Refresh(context.Background(), "refresh-1")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 1, srv.refreshCalls)
+}
+
+func TestClient_AuthenticateMFARequired(t *testing.T) {
+	c := newTestClient(t, &fakeServer{})
+
+	_, _, err := c.Authenticate(context.Background(), "jane@example.com", "a long and unusual passphrase")
+	assert.ErrorIs(t, err, ErrMFARequired)
+	var mfaErr *MFARequiredError
+	if assert.ErrorAs(t, err, &mfaErr) {
+		assert.Equal(t, "challenge", mfaErr.Token)
+		assert.True(t, mfaErr.ExpiresAt.Equal(time.Unix(1700000300, 0)))
+	}
+}
@@ -0,0 +1,249 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+// CreateGroup stores group and sets its server assigned ID and creation
+// time. Names are unique ignoring case and accents; a taken name fails with
+// ErrAlreadyExists.
+func (c *Client) CreateGroup(ctx context.Context, group *model.Group) error {
+	resp, err := c.groups.CreateGroup(ctx, &pb.CreateGroupRequest{
+		Name:        group.Name,
+		Description: group.Description,
+	})
+	if err != nil {
+		return fromStatus(err)
+	}
+
+	created, err := fromProtoGroup(resp.GetGroup())
+	if err != nil {
+		return err
+	}
+	*group = *created
+	return nil
+}
+
+func (c *Client) GetGroup(ctx context.Context, id uuid.UUID) (*model.Group, error) {
+	var resp *pb.GetGroupResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.groups.GetGroup(ctx, &pb.GetGroupRequest{Id: id.String()})
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProtoGroup(resp.GetGroup())
+}
+
+// ListGroups returns every group in creation order.
+func (c *Client) ListGroups(ctx context.Context) ([]model.Group, error) {
+	var resp *pb.ListGroupsResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.groups.ListGroups(ctx, &pb.ListGroupsRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProtoGroups(resp.GetGroups())
+}
+
+// UpdateGroup changes the non-nil fields of a group.
+func (c *Client) UpdateGroup(ctx context.Context, dto *dto.UpdateGroupDTO) (*model.Group, error) {
+	req := &pb.UpdateGroupRequest{
+		Id:          dto.ID.String(),
+		Name:        dto.Name,
+		Description: dto.Description,
+	}
+	var resp *pb.UpdateGroupResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.groups.UpdateGroup(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProtoGroup(resp.GetGroup())
+}
+
+// DeleteGroup removes a group and its memberships.
+func (c *Client) DeleteGroup(ctx context.Context, id uuid.UUID) error {
+	_, err := c.groups.DeleteGroup(ctx, &pb.DeleteGroupRequest{Id: id.String()})
+	return fromStatus(err)
+}
+
+// AddMember puts a user in a group. It fails with ErrAlreadyExists for
+// members and with ErrResourceExhausted when the group or the user reached
+// the server's membership limit, which is why it is never retried.
+func (c *Client) AddMember(ctx context.Context, groupID, userID uuid.UUID) (*model.Membership, error) {
+	resp, err := c.groups.AddMember(ctx, &pb.AddMemberRequest{
+		GroupId: groupID.String(),
+		UserId:  userID.String(),
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProtoMembership(resp.GetMembership())
+}
+
+func (c *Client) RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	_, err := c.groups.RemoveMember(ctx, &pb.RemoveMemberRequest{
+		GroupId: groupID.String(),
+		UserId:  userID.String(),
+	})
+	return fromStatus(err)
+}
+
+// ListMembers returns an iterator over a group's members in the order they
+// were added, fetching pageSize members per request. A zero pageSize lets
+// the server return everything at once.
+func (c *Client) ListMembers(ctx context.Context, groupID uuid.UUID, pageSize int) *MemberIterator {
+	return &MemberIterator{ctx: ctx, client: c, groupID: groupID.String(), pageSize: int32(pageSize)}
+}
+
+// ListUserGroups returns the groups a user is a member of.
+func (c *Client) ListUserGroups(ctx context.Context, userID uuid.UUID) ([]model.Group, error) {
+	var resp *pb.ListUserGroupsResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.groups.ListUserGroups(ctx, &pb.ListUserGroupsRequest{UserId: userID.String()})
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProtoGroups(resp.GetGroups())
+}
+
+// MemberIterator walks ListMembers results like UserIterator walks users.
+type MemberIterator struct {
+	ctx      context.Context
+	client   *Client
+	groupID  string
+	pageSize int32
+
+	page      []model.Membership
+	pos       int
+	nextToken string
+	started   bool
+	current   *model.Membership
+	err       error
+}
+
+// Next advances to the next member and reports whether there is one.
+func (it *MemberIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	for it.pos >= len(it.page) {
+		if it.started && it.nextToken == "" {
+			return false
+		}
+		if !it.fetch() {
+			return false
+		}
+	}
+
+	it.current = &it.page[it.pos]
+	it.pos++
+	return true
+}
+
+// Membership returns the membership Next advanced to.
+func (it *MemberIterator) Membership() *model.Membership {
+	return it.current
+}
+
+// Err returns the first error met while fetching pages.
+func (it *MemberIterator) Err() error {
+	return it.err
+}
+
+func (it *MemberIterator) fetch() bool {
+	req := &pb.ListMembersRequest{GroupId: it.groupID, PageSize: it.pageSize, PageToken: it.nextToken}
+	var resp *pb.ListMembersResponse
+	err := it.client.retry.do(it.ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = it.client.groups.ListMembers(ctx, req)
+		return err
+	})
+	if err != nil {
+		it.err = fromStatus(err)
+		return false
+	}
+
+	page := make([]model.Membership, 0, len(resp.GetMembers()))
+	for _, pm := range resp.GetMembers() {
+		m, err := fromProtoMembership(pm)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		page = append(page, *m)
+	}
+
+	it.started = true
+	it.page = page
+	it.pos = 0
+	it.nextToken = resp.GetNextPageToken()
+	return true
+}
+
+func fromProtoGroup(g *pb.Group) (*model.Group, error) {
+	if g == nil {
+		return nil, errMalformedResponse
+	}
+	id, err := uuid.Parse(g.GetId())
+	if err != nil {
+		return nil, fmt.Errorf("%w: group id %q", errMalformedResponse, g.GetId())
+	}
+	return &model.Group{
+		ID:          id,
+		Name:        g.GetName(),
+		Description: g.GetDescription(),
+		CreatedAt:   g.GetCreateTime().AsTime(),
+	}, nil
+}
+
+func fromProtoGroups(groups []*pb.Group) ([]model.Group, error) {
+	out := make([]model.Group, 0, len(groups))
+	for _, pg := range groups {
+		g, err := fromProtoGroup(pg)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *g)
+	}
+	return out, nil
+}
+
+func fromProtoMembership(m *pb.Membership) (*model.Membership, error) {
+	if m == nil {
+		return nil, errMalformedResponse
+	}
+	groupID, err := uuid.Parse(m.GetGroupId())
+	if err != nil {
+		return nil, fmt.Errorf("%w: group id %q", errMalformedResponse, m.GetGroupId())
+	}
+	userID, err := uuid.Parse(m.GetUserId())
+	if err != nil {
+		return nil, fmt.Errorf("%w: user id %q", errMalformedResponse, m.GetUserId())
+	}
+	return &model.Membership{
+		GroupID: groupID,
+		UserID:  userID,
+		AddedAt: m.GetAddTime().AsTime(),
+	}, nil
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+var fromProtoActions = map[pb.RevisionAction]model.RevisionAction{
+	pb.RevisionAction_REVISION_ACTION_CREATE: model.RevisionCreate,
+	pb.RevisionAction_REVISION_ACTION_UPDATE: model.RevisionUpdate,
+	pb.RevisionAction_REVISION_ACTION_DELETE: model.RevisionDelete,
+}
+
+// GetByIDAsOf returns a user as it was at t. It fails with ErrNotFound when
+// the user did not exist then. Past versions carry no credentials.
+func (c *Client) GetByIDAsOf(ctx context.Context, id uuid.UUID, t time.Time) (*model.User, error) {
+	return c.getPast(ctx, &pb.GetUserRequest{Id: id.String(), AsOf: timestamppb.New(t)})
+}
+
+// GetByIDAtRevision returns a user as saved by one of its revisions.
+func (c *Client) GetByIDAtRevision(ctx context.Context, id uuid.UUID, revision int64) (*model.User, error) {
+	return c.getPast(ctx, &pb.GetUserRequest{Id: id.String(), Revision: revision})
+}
+
+func (c *Client) getPast(ctx context.Context, req *pb.GetUserRequest) (*model.User, error) {
+	var resp *pb.GetUserResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.users.GetUser(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProto(resp.GetUser())
+}
+
+// ListRevisions returns every revision of a user, oldest first.
+func (c *Client) ListRevisions(ctx context.Context, userID uuid.UUID) ([]model.UserRevision, error) {
+	var out []model.UserRevision
+	req := &pb.ListUserRevisionsRequest{UserId: userID.String(), PageSize: 100}
+	for {
+		var resp *pb.ListUserRevisionsResponse
+		err := c.retry.do(ctx, func(ctx context.Context) error {
+			var err error
+			resp, err = c.users.ListUserRevisions(ctx, req)
+			return err
+		})
+		if err != nil {
+			return nil, fromStatus(err)
+		}
+
+		for _, pr := range resp.GetRevisions() {
+			rev, err := fromProtoRevision(pr)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, *rev)
+		}
+		if resp.GetNextPageToken() == "" {
+			return out, nil
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+}
+
+// RevertUser restores the profile fields and attributes of a revision. The
+// restore is recorded as a new revision.
+func (c *Client) RevertUser(ctx context.Context, id uuid.UUID, revision int64) (*model.User, error) {
+	resp, err := c.users.RevertUser(ctx, &pb.RevertUserRequest{Id: id.String(), Revision: revision})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProto(resp.GetUser())
+}
+
+func fromProtoRevision(r *pb.UserRevision) (*model.UserRevision, error) {
+	if r == nil {
+		return nil, errMalformedResponse
+	}
+	userID, err := uuid.Parse(r.GetUserId())
+	if err != nil {
+		return nil, fmt.Errorf("%w: user id %q", errMalformedResponse, r.GetUserId())
+	}
+
+	rev := &model.UserRevision{
+		UserID:       userID,
+		Number:       r.GetRevision(),
+		Action:       fromProtoActions[r.GetAction()],
+		Actor:        r.GetActor(),
+		At:           r.GetCreateTime().AsTime(),
+		RevertedFrom: r.GetRevertedFrom(),
+	}
+	for _, c := range r.GetChanges() {
+		rev.Changes = append(rev.Changes, model.FieldChange{
+			Field: c.GetField(),
+			Old:   c.GetOldValue().AsInterface(),
+			New:   c.GetNewValue().AsInterface(),
+		})
+	}
+	if r.GetUser() != nil {
+		if rev.User, err = fromProto(r.GetUser()); err != nil {
+			return nil, err
+		}
+	}
+	return rev, nil
+}
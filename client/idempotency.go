@@ -0,0 +1,17 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const idempotencyKeyHeader = "idempotency-key"
+
+// WithIdempotencyKey returns a context that makes the mutating calls, e.g.
+// Create, Update and Delete, safe to retry: the server answers repeated calls with the same key with the
+// result of the first one. Reusing a key for a different request fails with
+// ErrFailedPrecondition.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, idempotencyKeyHeader, key)
+}
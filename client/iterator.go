@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+// UserIterator walks ListUsers results, fetching pages lazily:
+//
+//	it := c.ListUsers(ctx, 100)
+//	for it.Next() {
+//		u := it.User()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type UserIterator struct {
+	ctx    context.Context
+	client *Client
+	req    *pb.ListUsersRequest
+
+	page      []model.User
+	pos       int
+	nextToken string
+	started   bool
+	current   *model.User
+	err       error
+}
+
+// Next advances to the next user and reports whether there is one.
+func (it *UserIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	for it.pos >= len(it.page) {
+		if it.started && it.nextToken == "" {
+			return false
+		}
+		if !it.fetch() {
+			return false
+		}
+	}
+
+	it.current = &it.page[it.pos]
+	it.pos++
+	return true
+}
+
+// User returns the user Next advanced to.
+func (it *UserIterator) User() *model.User {
+	return it.current
+}
+
+// Err returns the first error met while fetching pages.
+func (it *UserIterator) Err() error {
+	return it.err
+}
+
+func (it *UserIterator) fetch() bool {
+	req := proto.Clone(it.req).(*pb.ListUsersRequest)
+	req.PageToken = it.nextToken
+
+	resp, err := it.client.listPage(it.ctx, req)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	page := make([]model.User, 0, len(resp.GetUsers()))
+	for _, pu := range resp.GetUsers() {
+		u, err := fromProto(pu)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		page = append(page, *u)
+	}
+
+	it.started = true
+	it.page = page
+	it.pos = 0
+	it.nextToken = resp.GetNextPageToken()
+	return true
+}
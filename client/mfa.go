@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+// ErrMFARequired matches the *MFARequiredError Authenticate returns for users
+// with MFA enabled.
+var ErrMFARequired = errors.New("a second factor is required")
+
+// MFARequiredError means the password was right and the login continues with
+// VerifyMFA before ExpiresAt.
+type MFARequiredError struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+func (e *MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (e *MFARequiredError) Is(target error) bool {
+	return target == ErrMFARequired
+}
+
+// MFAEnrollment is a secret to add to an authenticator app, typed in as
+// Secret or scanned from URI shown as a QR code.
+type MFAEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// EnrollMFA starts a TOTP enrollment for the user. It fails with
+// ErrFailedPrecondition when MFA is already enabled.
+func (c *Client) EnrollMFA(ctx context.Context, id uuid.UUID) (*MFAEnrollment, error) {
+	resp, err := c.users.EnrollMFA(ctx, &pb.EnrollMFARequest{Id: id.String()})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return &MFAEnrollment{Secret: resp.GetSecret(), URI: resp.GetProvisioningUri()}, nil
+}
+
+// ConfirmMFA enables the pending enrollment with a first code and returns
+// the recovery codes.
+func (c *Client) ConfirmMFA(ctx context.Context, id uuid.UUID, code string) ([]string, error) {
+	resp, err := c.users.ConfirmMFA(ctx, &pb.ConfirmMFARequest{Id: id.String(), Code: code})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return resp.GetRecoveryCodes(), nil
+}
+
+// DisableMFA removes the user's enrollment.
+func (c *Client) DisableMFA(ctx context.Context, id uuid.UUID) error {
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		_, err := c.users.DisableMFA(ctx, &pb.DisableMFARequest{Id: id.String()})
+		return err
+	})
+	return fromStatus(err)
+}
+
+// VerifyMFA completes a login that failed with *MFARequiredError, using a
+// TOTP or recovery code. Codes work once, so the call is never retried.
+func (c *Client) VerifyMFA(ctx context.Context, token, code string) (*model.User, *Tokens, error) {
+	resp, err := c.users.VerifyMFA(ctx, &pb.VerifyMFARequest{MfaToken: token, Code: code})
+	if err != nil {
+		return nil, nil, fromStatus(err)
+	}
+	u, err := fromProto(resp.GetUser())
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, fromProtoTokens(resp.GetTokens()), nil
+}
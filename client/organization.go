@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+const organizationHeader = "x-organization"
+
+// WithOrganization returns a context whose calls act for an organization:
+// users are created in it and only its users can be read. Calls without it
+// act for the server's default organization, or for the organization of the
+// access token passed to WithToken.
+func WithOrganization(ctx context.Context, id uuid.UUID) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, organizationHeader, id.String())
+}
+
+// CreateOrganization stores org and sets its server assigned ID and creation
+// time. A taken name fails with ErrAlreadyExists.
+func (c *Client) CreateOrganization(ctx context.Context, org *model.Organization) error {
+	resp, err := c.organizations.CreateOrganization(ctx, &pb.CreateOrganizationRequest{Name: org.Name})
+	if err != nil {
+		return fromStatus(err)
+	}
+
+	created, err := fromProtoOrganization(resp.GetOrganization())
+	if err != nil {
+		return err
+	}
+	*org = *created
+	return nil
+}
+
+func (c *Client) GetOrganization(ctx context.Context, id uuid.UUID) (*model.Organization, error) {
+	var resp *pb.GetOrganizationResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.organizations.GetOrganization(ctx, &pb.GetOrganizationRequest{Id: id.String()})
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProtoOrganization(resp.GetOrganization())
+}
+
+// ListOrganizations returns every organization in creation order.
+func (c *Client) ListOrganizations(ctx context.Context) ([]model.Organization, error) {
+	var resp *pb.ListOrganizationsResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.organizations.ListOrganizations(ctx, &pb.ListOrganizationsRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	out := make([]model.Organization, 0, len(resp.GetOrganizations()))
+	for _, po := range resp.GetOrganizations() {
+		org, err := fromProtoOrganization(po)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *org)
+	}
+	return out, nil
+}
+
+// UpdateOrganization changes the non-nil fields of an organization.
+func (c *Client) UpdateOrganization(ctx context.Context, dto *dto.UpdateOrganizationDTO) (*model.Organization, error) {
+	req := &pb.UpdateOrganizationRequest{Id: dto.ID.String(), Name: dto.Name}
+	var resp *pb.UpdateOrganizationResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.organizations.UpdateOrganization(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProtoOrganization(resp.GetOrganization())
+}
+
+// DeleteOrganization removes an organization. It fails with
+// ErrFailedPrecondition while the organization has users.
+func (c *Client) DeleteOrganization(ctx context.Context, id uuid.UUID) error {
+	_, err := c.organizations.DeleteOrganization(ctx, &pb.DeleteOrganizationRequest{Id: id.String()})
+	return fromStatus(err)
+}
+
+func fromProtoOrganization(o *pb.Organization) (*model.Organization, error) {
+	if o == nil {
+		return nil, errMalformedResponse
+	}
+	id, err := uuid.Parse(o.GetId())
+	if err != nil {
+		return nil, fmt.Errorf("%w: organization id %q", errMalformedResponse, o.GetId())
+	}
+	return &model.Organization{
+		ID:        id,
+		Name:      o.GetName(),
+		CreatedAt: o.GetCreateTime().AsTime(),
+	}, nil
+}
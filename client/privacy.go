@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+// ExportUserData returns everything stored about a user as the JSON form of
+// a model.DataExport. It fails with ErrNotFound when nothing is stored and
+// the user was never erased.
+func (c *Client) ExportUserData(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	var resp *pb.ExportUserDataResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.users.ExportUserData(ctx, &pb.ExportUserDataRequest{Id: id.String()})
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return resp.GetData(), nil
+}
+
+// EraseUser deletes a user and removes or pseudonymizes its data in every
+// store, returning the tombstone of the erasure. Erasing is safe to retry:
+// once nothing is left, the tombstone of the last erasure is returned.
+func (c *Client) EraseUser(ctx context.Context, id uuid.UUID) (*model.Tombstone, error) {
+	var resp *pb.EraseUserResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.users.EraseUser(ctx, &pb.EraseUserRequest{Id: id.String()})
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProtoTombstone(resp.GetTombstone())
+}
+
+func fromProtoTombstone(t *pb.Tombstone) (*model.Tombstone, error) {
+	if t == nil {
+		return nil, errMalformedResponse
+	}
+	userID, err := uuid.Parse(t.GetUserId())
+	if err != nil {
+		return nil, fmt.Errorf("%w: user id %q", errMalformedResponse, t.GetUserId())
+	}
+
+	erased := make(map[string]int, len(t.GetErased()))
+	for store, n := range t.GetErased() {
+		erased[store] = int(n)
+	}
+	return &model.Tombstone{
+		UserID:    userID,
+		ErasedAt:  t.GetEraseTime().AsTime(),
+		Actor:     t.GetActor(),
+		RequestID: t.GetRequestId(),
+		Erased:    erased,
+	}, nil
+}
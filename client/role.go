@@ -0,0 +1,238 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+// CreateRole stores role and sets its server assigned ID, creation time and
+// normalised permissions. A taken name fails with ErrAlreadyExists and a
+// malformed permission with ErrInvalidArgument.
+func (c *Client) CreateRole(ctx context.Context, role *model.Role) error {
+	resp, err := c.roles.CreateRole(ctx, &pb.CreateRoleRequest{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+	})
+	if err != nil {
+		return fromStatus(err)
+	}
+
+	created, err := fromProtoRole(resp.GetRole())
+	if err != nil {
+		return err
+	}
+	*role = *created
+	return nil
+}
+
+func (c *Client) GetRole(ctx context.Context, id uuid.UUID) (*model.Role, error) {
+	var resp *pb.GetRoleResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.roles.GetRole(ctx, &pb.GetRoleRequest{Id: id.String()})
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProtoRole(resp.GetRole())
+}
+
+// ListRoles returns every role in creation order.
+func (c *Client) ListRoles(ctx context.Context) ([]model.Role, error) {
+	var resp *pb.ListRolesResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.roles.ListRoles(ctx, &pb.ListRolesRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProtoRoles(resp.GetRoles())
+}
+
+// UpdateRole changes the non-nil fields of a role; non-nil Permissions
+// replace the whole set.
+func (c *Client) UpdateRole(ctx context.Context, dto *dto.UpdateRoleDTO) (*model.Role, error) {
+	req := &pb.UpdateRoleRequest{
+		Id:          dto.ID.String(),
+		Name:        dto.Name,
+		Description: dto.Description,
+	}
+	if dto.Permissions != nil {
+		req.Permissions = &pb.PermissionList{Values: *dto.Permissions}
+	}
+	var resp *pb.UpdateRoleResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.roles.UpdateRole(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProtoRole(resp.GetRole())
+}
+
+// DeleteRole removes a role and its assignments.
+func (c *Client) DeleteRole(ctx context.Context, id uuid.UUID) error {
+	_, err := c.roles.DeleteRole(ctx, &pb.DeleteRoleRequest{Id: id.String()})
+	return fromStatus(err)
+}
+
+// AssignRole grants a role to a user or, with model.PrincipalGroup, to every
+// member of a group. It fails with ErrAlreadyExists when the role is already
+// assigned.
+func (c *Client) AssignRole(ctx context.Context, roleID uuid.UUID, principal model.Principal) (*model.RoleAssignment, error) {
+	req := &pb.AssignRoleRequest{RoleId: roleID.String()}
+	if principal.Kind == model.PrincipalGroup {
+		req.Principal = &pb.AssignRoleRequest_GroupId{GroupId: principal.ID.String()}
+	} else {
+		req.Principal = &pb.AssignRoleRequest_UserId{UserId: principal.ID.String()}
+	}
+	resp, err := c.roles.AssignRole(ctx, req)
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProtoAssignment(resp.GetAssignment())
+}
+
+func (c *Client) UnassignRole(ctx context.Context, roleID uuid.UUID, principal model.Principal) error {
+	req := &pb.UnassignRoleRequest{RoleId: roleID.String()}
+	if principal.Kind == model.PrincipalGroup {
+		req.Principal = &pb.UnassignRoleRequest_GroupId{GroupId: principal.ID.String()}
+	} else {
+		req.Principal = &pb.UnassignRoleRequest_UserId{UserId: principal.ID.String()}
+	}
+	_, err := c.roles.UnassignRole(ctx, req)
+	return fromStatus(err)
+}
+
+// ListRoleAssignments returns the assignments of a role in the order they
+// were made.
+func (c *Client) ListRoleAssignments(ctx context.Context, roleID uuid.UUID) ([]model.RoleAssignment, error) {
+	var resp *pb.ListRoleAssignmentsResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.roles.ListRoleAssignments(ctx, &pb.ListRoleAssignmentsRequest{RoleId: roleID.String()})
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	out := make([]model.RoleAssignment, 0, len(resp.GetAssignments()))
+	for _, pa := range resp.GetAssignments() {
+		a, err := fromProtoAssignment(pa)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *a)
+	}
+	return out, nil
+}
+
+// CheckPermission reports whether a role of the user, assigned directly or
+// through a group, grants permission.
+func (c *Client) CheckPermission(ctx context.Context, userID uuid.UUID, permission string) (bool, error) {
+	var resp *pb.CheckPermissionResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.roles.CheckPermission(ctx, &pb.CheckPermissionRequest{
+			UserId:     userID.String(),
+			Permission: permission,
+		})
+		return err
+	})
+	if err != nil {
+		return false, fromStatus(err)
+	}
+	return resp.GetAllowed(), nil
+}
+
+// ListEffectivePermissions returns the roles that apply to a user and the
+// permissions they grant.
+func (c *Client) ListEffectivePermissions(ctx context.Context, userID uuid.UUID) (*dto.EffectivePermissions, error) {
+	var resp *pb.ListEffectivePermissionsResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.roles.ListEffectivePermissions(ctx, &pb.ListEffectivePermissionsRequest{UserId: userID.String()})
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	roles, err := fromProtoRoles(resp.GetRoles())
+	if err != nil {
+		return nil, err
+	}
+	return &dto.EffectivePermissions{Permissions: resp.GetPermissions(), Roles: roles}, nil
+}
+
+func fromProtoRole(r *pb.Role) (*model.Role, error) {
+	if r == nil {
+		return nil, errMalformedResponse
+	}
+	id, err := uuid.Parse(r.GetId())
+	if err != nil {
+		return nil, fmt.Errorf("%w: role id %q", errMalformedResponse, r.GetId())
+	}
+	return &model.Role{
+		ID:          id,
+		Name:        r.GetName(),
+		Description: r.GetDescription(),
+		Permissions: r.GetPermissions(),
+		CreatedAt:   r.GetCreateTime().AsTime(),
+	}, nil
+}
+
+func fromProtoRoles(roles []*pb.Role) ([]model.Role, error) {
+	out := make([]model.Role, 0, len(roles))
+	for _, pr := range roles {
+		r, err := fromProtoRole(pr)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, nil
+}
+
+func fromProtoAssignment(a *pb.RoleAssignment) (*model.RoleAssignment, error) {
+	if a == nil {
+		return nil, errMalformedResponse
+	}
+	roleID, err := uuid.Parse(a.GetRoleId())
+	if err != nil {
+		return nil, fmt.Errorf("%w: role id %q", errMalformedResponse, a.GetRoleId())
+	}
+
+	var principal model.Principal
+	switch p := a.GetPrincipal().(type) {
+	case *pb.RoleAssignment_UserId:
+		principal.Kind = model.PrincipalUser
+		principal.ID, err = uuid.Parse(p.UserId)
+	case *pb.RoleAssignment_GroupId:
+		principal.Kind = model.PrincipalGroup
+		principal.ID, err = uuid.Parse(p.GroupId)
+	default:
+		return nil, fmt.Errorf("%w: assignment without principal", errMalformedResponse)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: principal id", errMalformedResponse)
+	}
+	return &model.RoleAssignment{
+		RoleID:     roleID,
+		Principal:  principal,
+		AssignedAt: a.GetAssignTime().AsTime(),
+	}, nil
+}
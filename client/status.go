@@ -0,0 +1,37 @@
+package client
+
+import (
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+var statuses = []struct {
+	model model.UserStatus
+	proto pb.UserStatus
+}{
+	{model.StatusPending, pb.UserStatus_USER_STATUS_PENDING},
+	{model.StatusActive, pb.UserStatus_USER_STATUS_ACTIVE},
+	{model.StatusSuspended, pb.UserStatus_USER_STATUS_SUSPENDED},
+	{model.StatusDisabled, pb.UserStatus_USER_STATUS_DISABLED},
+}
+
+// statusToProto maps unknown statuses to UNSPECIFIED, which the server
+// rejects as a filter.
+func statusToProto(s model.UserStatus) pb.UserStatus {
+	for _, st := range statuses {
+		if st.model == s {
+			return st.proto
+		}
+	}
+	return pb.UserStatus_USER_STATUS_UNSPECIFIED
+}
+
+// statusFromProto maps statuses unknown to this client to "".
+func statusFromProto(s pb.UserStatus) model.UserStatus {
+	for _, st := range statuses {
+		if st.proto == s {
+			return st.model
+		}
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+// Tokens are the tokens of a session. AccessToken is a JWT to send as a
+// bearer token; RefreshToken gets new tokens once it expires.
+type Tokens struct {
+	AccessToken      string
+	TokenType        string
+	ExpiresAt        time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// Refresh exchanges a refresh token for new tokens of the same session. A
+// refresh token works once, so the call is never retried: replaying one the
+// server already exchanged would end the session. It fails with
+// ErrUnauthenticated once the session is over.
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	resp, err := c.users.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: refreshToken})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProtoTokens(resp.GetTokens()), nil
+}
+
+// Revoke ends the session refreshToken belongs to.
+func (c *Client) Revoke(ctx context.Context, refreshToken string) error {
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		_, err := c.users.RevokeToken(ctx, &pb.RevokeTokenRequest{RefreshToken: refreshToken})
+		return err
+	})
+	return fromStatus(err)
+}
+
+func fromProtoTokens(t *pb.Tokens) *Tokens {
+	return &Tokens{
+		AccessToken:      t.GetAccessToken(),
+		TokenType:        t.GetTokenType(),
+		ExpiresAt:        t.GetExpireTime().AsTime(),
+		RefreshToken:     t.GetRefreshToken(),
+		RefreshExpiresAt: t.GetRefreshExpireTime().AsTime(),
+	}
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+// CreateWebhook subscribes w to the events of the caller's organization and
+// sets its server assigned ID, creation time and, unless one was set,
+// generated secret. The secret is not returned by later calls.
+func (c *Client) CreateWebhook(ctx context.Context, w *model.Webhook) error {
+	resp, err := c.webhooks.CreateWebhook(ctx, &pb.CreateWebhookRequest{
+		Url:        w.URL,
+		EventTypes: toProtoEventTypes(w.EventTypes),
+		Secret:     w.Secret,
+	})
+	if err != nil {
+		return fromStatus(err)
+	}
+
+	created, err := fromProtoWebhook(resp.GetWebhook())
+	if err != nil {
+		return err
+	}
+	created.Secret = resp.GetSecret()
+	*w = *created
+	return nil
+}
+
+func (c *Client) GetWebhook(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	var resp *pb.GetWebhookResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.webhooks.GetWebhook(ctx, &pb.GetWebhookRequest{Id: id.String()})
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProtoWebhook(resp.GetWebhook())
+}
+
+// ListWebhooks returns the webhooks of the caller's organization in
+// creation order.
+func (c *Client) ListWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	var resp *pb.ListWebhooksResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.webhooks.ListWebhooks(ctx, &pb.ListWebhooksRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	out := make([]model.Webhook, 0, len(resp.GetWebhooks()))
+	for _, pw := range resp.GetWebhooks() {
+		w, err := fromProtoWebhook(pw)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *w)
+	}
+	return out, nil
+}
+
+// UpdateWebhook changes the non-nil fields of a webhook.
+func (c *Client) UpdateWebhook(ctx context.Context, dto *dto.UpdateWebhookDTO) (*model.Webhook, error) {
+	req := &pb.UpdateWebhookRequest{
+		Id:      dto.ID.String(),
+		Url:     dto.URL,
+		Secret:  dto.Secret,
+		Enabled: dto.Enabled,
+	}
+	if dto.EventTypes != nil {
+		req.EventTypes = &pb.EventTypeList{Values: toProtoEventTypes(*dto.EventTypes)}
+	}
+	var resp *pb.UpdateWebhookResponse
+	err := c.retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.webhooks.UpdateWebhook(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromProtoWebhook(resp.GetWebhook())
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	_, err := c.webhooks.DeleteWebhook(ctx, &pb.DeleteWebhookRequest{Id: id.String()})
+	return fromStatus(err)
+}
+
+// ListWebhookDeliveries returns the logged delivery attempts of a webhook,
+// oldest first.
+func (c *Client) ListWebhookDeliveries(ctx context.Context, id uuid.UUID) ([]model.WebhookAttempt, error) {
+	var out []model.WebhookAttempt
+	req := &pb.ListWebhookDeliveriesRequest{WebhookId: id.String(), PageSize: 1000}
+	for {
+		var resp *pb.ListWebhookDeliveriesResponse
+		err := c.retry.do(ctx, func(ctx context.Context) error {
+			var err error
+			resp, err = c.webhooks.ListWebhookDeliveries(ctx, req)
+			return err
+		})
+		if err != nil {
+			return nil, fromStatus(err)
+		}
+
+		for _, d := range resp.GetDeliveries() {
+			attempt, err := fromProtoWebhookDelivery(id, d)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, attempt)
+		}
+		if resp.GetNextPageToken() == "" {
+			return out, nil
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+}
+
+func fromProtoWebhook(w *pb.Webhook) (*model.Webhook, error) {
+	if w == nil {
+		return nil, errMalformedResponse
+	}
+	id, err := uuid.Parse(w.GetId())
+	if err != nil {
+		return nil, fmt.Errorf("%w: webhook id %q", errMalformedResponse, w.GetId())
+	}
+
+	out := &model.Webhook{
+		ID:        id,
+		URL:       w.GetUrl(),
+		Failures:  int(w.GetFailures()),
+		CreatedAt: w.GetCreateTime().AsTime(),
+	}
+	for _, t := range w.GetEventTypes() {
+		out.EventTypes = append(out.EventTypes, model.EventType(t))
+	}
+	if w.GetDisableTime() != nil {
+		at := w.GetDisableTime().AsTime()
+		out.DisabledAt = &at
+	}
+	return out, nil
+}
+
+func fromProtoWebhookDelivery(webhookID uuid.UUID, d *pb.WebhookDelivery) (model.WebhookAttempt, error) {
+	deliveryID, err := uuid.Parse(d.GetDeliveryId())
+	if err != nil {
+		return model.WebhookAttempt{}, fmt.Errorf("%w: delivery id %q", errMalformedResponse, d.GetDeliveryId())
+	}
+	eventID, err := uuid.Parse(d.GetEventId())
+	if err != nil {
+		return model.WebhookAttempt{}, fmt.Errorf("%w: event id %q", errMalformedResponse, d.GetEventId())
+	}
+
+	return model.WebhookAttempt{
+		Seq:        d.GetSequence(),
+		WebhookID:  webhookID,
+		DeliveryID: deliveryID,
+		EventID:    eventID,
+		EventType:  model.EventType(d.GetEventType()),
+		Attempt:    int(d.GetAttempt()),
+		At:         d.GetTime().AsTime(),
+		StatusCode: int(d.GetStatusCode()),
+		Duration:   d.GetDuration().AsDuration(),
+		Error:      d.GetError(),
+	}, nil
+}
+
+func toProtoEventTypes(types []model.EventType) []string {
+	var out []string
+	for _, t := range types {
+		out = append(out, string(t))
+	}
+	return out
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// attrFlag collects repeated -attr key=value flags. Values that parse as
+// JSON keep their type (42, true, null, ["a","b"]); anything else is a string.
+type attrFlag map[string]any
+
+func (a attrFlag) String() string {
+	return fmt.Sprint(map[string]any(a))
+}
+
+func (a attrFlag) Set(s string) error {
+	key, raw, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("want key=value, got %q", s)
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		value = raw
+	}
+	a[key] = value
+	return nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import "fmt"
+
+func runAudit(e *env, args []string) error {
+	fs, output := newFlagSet("audit")
+	target := fs.String("target", "", "only entries on this user ID")
+	actor := fs.String("actor", "", "only entries made by this actor")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("%w: unexpected argument %q", errUsage, positional[0])
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	entries, err := e.client.ListAuditEntries(ctx, *target, *actor)
+	if err != nil {
+		return err
+	}
+	return printAuditEntries(e.stdout, *output, entries)
+}
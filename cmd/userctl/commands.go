@@ -0,0 +1,631 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/client"
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+var (
+	errUsage     = errors.New("invalid usage")
+	errCancelled = errors.New("cancelled")
+	// errDenied makes check-permission exit non-zero for scripts.
+	errDenied = errors.New("permission denied")
+)
+
+// env is what every command gets to work with.
+type env struct {
+	client  *client.Client
+	timeout time.Duration
+	stdin   io.Reader
+	stdout  io.Writer
+	// actor is sent with every call so the server can record who made it.
+	actor string
+	// organization scopes every call unless it is uuid.Nil.
+	organization uuid.UUID
+}
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(e *env, args []string) error
+}
+
+var commands = []command{
+	{name: "create", usage: "create -name NAME -email EMAIL [-id ID] [profile flags]", summary: "create a user", run: runCreate},
+	{name: "get", usage: "get ID [-as-of TIME | -revision N]", summary: "show a user, now or as it was earlier", run: runGet},
+	{name: "history", usage: "history ID [-o table|json|csv]", summary: "list the revisions of a user", run: runHistory},
+	{name: "revert", usage: "revert ID -revision N", summary: "restore a user's profile and attributes from a revision", run: runRevert},
+	{name: "list", usage: "list [-o table|json|csv] [-page-size N] [-name QUERY] [-attr KEY=VALUE] [-status STATUS] [-sort name]", summary: "list users", run: runList},
+	{name: "update", usage: "update ID [-name NAME] [-email EMAIL] [profile flags]", summary: "change a user's fields, an empty profile flag or -attr KEY=null clears it", run: runUpdate},
+	{name: "suspend", usage: "suspend ID -reason REASON", summary: "block an active user without deleting it", run: runSuspend},
+	{name: "reactivate", usage: "reactivate ID [-reason REASON]", summary: "make a suspended or pending user active", run: runReactivate},
+	{name: "send-verification", usage: "send-verification ID", summary: "mail the user an email verification token", run: runSendVerification},
+	{name: "verify-email", usage: "verify-email TOKEN", summary: "verify a user's email with a mailed token", run: runVerifyEmail},
+	{name: "set-password", usage: "set-password ID", summary: "set a user's password, read from stdin", run: runSetPassword},
+	{name: "enroll-mfa", usage: "enroll-mfa ID", summary: "set up TOTP for a user, confirmed with a code read from stdin", run: runEnrollMFA},
+	{name: "disable-mfa", usage: "disable-mfa ID", summary: "remove a user's TOTP enrollment", run: runDisableMFA},
+	{name: "delete", usage: "delete ID [-yes]", summary: "delete a user after confirmation", run: runDelete},
+	{name: "export", usage: "export ID", summary: "print everything stored about a user as JSON", run: runExport},
+	{name: "erase", usage: "erase ID [-yes]", summary: "erase a user and its data in every store after confirmation, leaving a tombstone", run: runErase},
+	{name: "groups", usage: "groups [-o table|json|csv]", summary: "list groups", run: runGroups},
+	{name: "group-create", usage: "group-create -name NAME [-description TEXT]", summary: "create a group", run: runGroupCreate},
+	{name: "group-delete", usage: "group-delete GROUP_ID [-yes]", summary: "delete a group and its memberships after confirmation", run: runGroupDelete},
+	{name: "group-add", usage: "group-add GROUP_ID USER_ID", summary: "add a user to a group", run: runGroupAdd},
+	{name: "group-remove", usage: "group-remove GROUP_ID USER_ID", summary: "remove a user from a group", run: runGroupRemove},
+	{name: "group-members", usage: "group-members GROUP_ID [-o table|json|csv] [-page-size N]", summary: "list the members of a group", run: runGroupMembers},
+	{name: "user-groups", usage: "user-groups ID [-o table|json|csv]", summary: "list the groups a user is in", run: runUserGroups},
+	{name: "roles", usage: "roles [-o table|json|csv]", summary: "list roles", run: runRoles},
+	{name: "role-create", usage: "role-create -name NAME [-description TEXT] [-permissions P1,P2]", summary: "create a role", run: runRoleCreate},
+	{name: "role-delete", usage: "role-delete ROLE_ID [-yes]", summary: "delete a role and its assignments after confirmation", run: runRoleDelete},
+	{name: "role-assign", usage: "role-assign ROLE_ID -user USER_ID | -group GROUP_ID", summary: "assign a role to a user or a group", run: runRoleAssign},
+	{name: "role-unassign", usage: "role-unassign ROLE_ID -user USER_ID | -group GROUP_ID", summary: "take a role away from a user or a group", run: runRoleUnassign},
+	{name: "permissions", usage: "permissions ID [-o table|json|csv]", summary: "list the roles that apply to a user, directly or through groups", run: runPermissions},
+	{name: "check-permission", usage: "check-permission ID PERMISSION", summary: "check whether a user has a permission, exiting non-zero if not", run: runCheckPermission},
+	{name: "orgs", usage: "orgs [-o table|json|csv]", summary: "list organizations", run: runOrganizations},
+	{name: "org-create", usage: "org-create -name NAME", summary: "create an organization", run: runOrganizationCreate},
+	{name: "org-delete", usage: "org-delete ORG_ID [-yes]", summary: "delete an organization without users after confirmation", run: runOrganizationDelete},
+	{name: "webhooks", usage: "webhooks [-o table|json|csv]", summary: "list webhooks", run: runWebhooks},
+	{name: "webhook-create", usage: "webhook-create -url URL [-events TYPE1,TYPE2] [-secret SECRET]", summary: "subscribe a URL to user events", run: runWebhookCreate},
+	{name: "webhook-delete", usage: "webhook-delete WEBHOOK_ID [-yes]", summary: "delete a webhook and its delivery log after confirmation", run: runWebhookDelete},
+	{name: "webhook-deliveries", usage: "webhook-deliveries WEBHOOK_ID [-o table|json|csv]", summary: "list the delivery attempts of a webhook", run: runWebhookDeliveries},
+	{name: "audit", usage: "audit [-target ID] [-actor NAME] [-o table|json|csv]", summary: "list who viewed or changed users", run: runAudit},
+	{name: "watch", usage: "watch [-interval 2s]", summary: "print users as they are added, changed or removed", run: runWatch},
+}
+
+func (e *env) context() (context.Context, context.CancelFunc) {
+	ctx := context.Background()
+	if e.actor != "" {
+		ctx = client.WithActor(ctx, e.actor)
+	}
+	if e.organization != uuid.Nil {
+		ctx = client.WithOrganization(ctx, e.organization)
+	}
+	return context.WithTimeout(ctx, e.timeout)
+}
+
+func newFlagSet(cmd string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	output := fs.String("o", outputTable, "output format: table, json or csv")
+	return fs, output
+}
+
+// parseArgs accepts flags both before and after the positional ID argument.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func parseID(fs *flag.FlagSet, args []string) (uuid.UUID, error) {
+	return parseIDOf(fs, args, "user")
+}
+
+// parseIDOf reads the single ID argument of commands on a kind of object,
+// e.g. "group".
+func parseIDOf(fs *flag.FlagSet, args []string, kind string) (uuid.UUID, error) {
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if len(positional) != 1 {
+		return uuid.Nil, fmt.Errorf("%w: expected exactly one %s ID", errUsage, kind)
+	}
+	id, err := uuid.Parse(positional[0])
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %q is not a valid %s ID", errUsage, positional[0], kind)
+	}
+	return id, nil
+}
+
+func runCreate(e *env, args []string) error {
+	fs, output := newFlagSet("create")
+	name := fs.String("name", "", "user name")
+	email := fs.String("email", "", "user email")
+	id := fs.String("id", "", "user ID, only accepted by servers with the provided ID strategy")
+	profile := addProfileFlags(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	u := &model.User{
+		Name:       *name,
+		Email:      *email,
+		GivenName:  *profile.givenName,
+		FamilyName: *profile.familyName,
+		Phone:      *profile.phone,
+		Locale:     *profile.locale,
+		TimeZone:   *profile.timeZone,
+		AvatarURL:  *profile.avatarURL,
+		Attributes: profile.attributes,
+	}
+	if *id != "" {
+		parsed, err := uuid.Parse(*id)
+		if err != nil {
+			return fmt.Errorf("%w: %q is not a valid user ID", errUsage, *id)
+		}
+		u.ID = parsed
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	if err := e.client.Create(ctx, u); err != nil {
+		return err
+	}
+	return printUser(e.stdout, *output, u)
+}
+
+func runGet(e *env, args []string) error {
+	fs, output := newFlagSet("get")
+	asOf := fs.String("as-of", "", "show the user as it was at this RFC 3339 time")
+	revision := fs.Int64("revision", 0, "show the user as saved by this revision")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+	if *asOf != "" && *revision != 0 {
+		return fmt.Errorf("%w: -as-of and -revision cannot be combined", errUsage)
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	var u *model.User
+	switch {
+	case *asOf != "":
+		t, perr := time.Parse(time.RFC3339, *asOf)
+		if perr != nil {
+			return fmt.Errorf("%w: -as-of must be an RFC 3339 time", errUsage)
+		}
+		u, err = e.client.GetByIDAsOf(ctx, id, t)
+	case *revision != 0:
+		u, err = e.client.GetByIDAtRevision(ctx, id, *revision)
+	default:
+		u, err = e.client.GetByID(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+	return printUser(e.stdout, *output, u)
+}
+
+func runList(e *env, args []string) error {
+	fs, output := newFlagSet("list")
+	pageSize := fs.Int("page-size", 100, "users fetched per request")
+	query := fs.String("name", "", "only users whose name contains this, ignoring case and accents")
+	sort := fs.String("sort", "", `"name" to sort by name instead of creation order`)
+	attributes := attrFlag{}
+	fs.Var(attributes, "attr", "only users whose indexed attribute has this value, as key=value, repeatable")
+	status := fs.String("status", "", "only users in these statuses, comma separated, e.g. suspended,disabled")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	var opts []client.ListOption
+	if *query != "" {
+		opts = append(opts, client.MatchingName(*query))
+	}
+	for key, value := range attributes {
+		opts = append(opts, client.WithAttribute(key, value))
+	}
+	if *status != "" {
+		var statuses []model.UserStatus
+		for _, st := range strings.Split(*status, ",") {
+			statuses = append(statuses, model.UserStatus(strings.TrimSpace(st)))
+		}
+		opts = append(opts, client.WithStatus(statuses...))
+	}
+	switch *sort {
+	case "":
+	case "name":
+		opts = append(opts, client.OrderByName())
+	default:
+		return fmt.Errorf("unknown sort %q, want name", *sort)
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	var users []model.User
+	it := e.client.ListUsers(ctx, *pageSize, opts...)
+	for it.Next() {
+		users = append(users, *it.User())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	return printUsers(e.stdout, *output, users)
+}
+
+// profileFlags are the optional profile fields shared by create and update.
+type profileFlags struct {
+	givenName  *string
+	familyName *string
+	phone      *string
+	locale     *string
+	timeZone   *string
+	avatarURL  *string
+	attributes attrFlag
+}
+
+func addProfileFlags(fs *flag.FlagSet) *profileFlags {
+	p := &profileFlags{
+		givenName:  fs.String("given-name", "", "given name"),
+		familyName: fs.String("family-name", "", "family name"),
+		phone:      fs.String("phone", "", "phone number in E.164 format, e.g. +14155550100"),
+		locale:     fs.String("locale", "", "BCP 47 language tag, e.g. en-US"),
+		timeZone:   fs.String("time-zone", "", "IANA time zone, e.g. Europe/Berlin"),
+		avatarURL:  fs.String("avatar-url", "", "http or https URL of the avatar image"),
+		attributes: attrFlag{},
+	}
+	fs.Var(p.attributes, "attr", "custom attribute as key=value, repeatable")
+	return p
+}
+
+func runUpdate(e *env, args []string) error {
+	fs, output := newFlagSet("update")
+	name := fs.String("name", "", "new user name")
+	email := fs.String("email", "", "new user email")
+	profile := addProfileFlags(fs)
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	update := &dto.UpdateUserDTO{ID: id}
+	set := 0
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			update.Name = name
+		case "email":
+			update.Email = email
+		case "given-name":
+			update.GivenName = profile.givenName
+		case "family-name":
+			update.FamilyName = profile.familyName
+		case "phone":
+			update.Phone = profile.phone
+		case "locale":
+			update.Locale = profile.locale
+		case "time-zone":
+			update.TimeZone = profile.timeZone
+		case "avatar-url":
+			update.AvatarURL = profile.avatarURL
+		case "attr":
+			update.Attributes = profile.attributes
+		default:
+			return
+		}
+		set++
+	})
+	if set == 0 {
+		return fmt.Errorf("%w: nothing to update, pass -name, -email or a profile flag", errUsage)
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	u, err := e.client.Update(ctx, update)
+	if err != nil {
+		return err
+	}
+	return printUser(e.stdout, *output, u)
+}
+
+func runSuspend(e *env, args []string) error {
+	fs, output := newFlagSet("suspend")
+	reason := fs.String("reason", "", "why the user is suspended, required")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*reason) == "" {
+		return fmt.Errorf("%w: -reason is required", errUsage)
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	u, err := e.client.Suspend(ctx, id, *reason)
+	if err != nil {
+		return err
+	}
+	return printUser(e.stdout, *output, u)
+}
+
+func runReactivate(e *env, args []string) error {
+	fs, output := newFlagSet("reactivate")
+	reason := fs.String("reason", "", "why the user is reactivated")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	u, err := e.client.Reactivate(ctx, id, *reason)
+	if err != nil {
+		return err
+	}
+	return printUser(e.stdout, *output, u)
+}
+
+func runSendVerification(e *env, args []string) error {
+	fs, _ := newFlagSet("send-verification")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	expiresAt, err := e.client.SendVerificationEmail(ctx, id)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "sent verification email to %s, valid until %s\n", id, expiresAt.Local().Format(time.RFC3339))
+	return nil
+}
+
+func runVerifyEmail(e *env, args []string) error {
+	fs, output := newFlagSet("verify-email")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("%w: expected exactly one token", errUsage)
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	u, err := e.client.VerifyEmail(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	return printUser(e.stdout, *output, u)
+}
+
+// runSetPassword reads the password from stdin rather than a flag so it
+// stays out of shell history and process listings.
+func runSetPassword(e *env, args []string) error {
+	fs, _ := newFlagSet("set-password")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(e.stdout, "New password: ")
+	line, err := bufio.NewReader(e.stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return fmt.Errorf("%w: no password on stdin", errUsage)
+	}
+	pw := strings.TrimRight(line, "\r\n")
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	if err := e.client.SetPassword(ctx, id, pw); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "\npassword set for %s\n", id)
+	return nil
+}
+
+// runEnrollMFA shows the new secret, then reads the first code from stdin to
+// confirm it, so the secret is never enabled without a working app.
+func runEnrollMFA(e *env, args []string) error {
+	fs, _ := newFlagSet("enroll-mfa")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	enrollment, err := e.client.EnrollMFA(ctx, id)
+	cancel()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "Add this secret to an authenticator app:\n\n  %s\n  %s\n\nCode: ", enrollment.Secret, enrollment.URI)
+	line, err := bufio.NewReader(e.stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return fmt.Errorf("%w: no code on stdin", errUsage)
+	}
+
+	ctx, cancel = e.context()
+	defer cancel()
+
+	codes, err := e.client.ConfirmMFA(ctx, id, strings.TrimSpace(line))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "\nMFA enabled for %s. Keep these recovery codes, each works once:\n\n", id)
+	for _, code := range codes {
+		fmt.Fprintf(e.stdout, "  %s\n", code)
+	}
+	return nil
+}
+
+func runDisableMFA(e *env, args []string) error {
+	fs, _ := newFlagSet("disable-mfa")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	if err := e.client.DisableMFA(ctx, id); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "MFA disabled for %s\n", id)
+	return nil
+}
+
+func runDelete(e *env, args []string) error {
+	fs, _ := newFlagSet("delete")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	u, err := e.client.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !*yes {
+		fmt.Fprintf(e.stdout, "Delete user %s (%s, %s)? [y/N] ", u.ID, u.Name, u.Email)
+		answer, _ := bufio.NewReader(e.stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			return errCancelled
+		}
+	}
+
+	if err := e.client.Delete(ctx, id); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "deleted %s\n", id)
+	return nil
+}
+
+// runWatch polls ListUsers because the API has no change stream, and prints
+// the difference between consecutive snapshots until interrupted.
+func runWatch(e *env, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	interval := fs.Duration("interval", 2*time.Second, "polling interval")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	var previous map[uuid.UUID]model.User
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		current, err := e.snapshot(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if previous != nil {
+			printChanges(e.stdout, previous, current)
+		} else {
+			fmt.Fprintf(e.stdout, "watching %d users, press Ctrl-C to stop\n", len(current))
+		}
+		previous = current
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *env) snapshot(parent context.Context) (map[uuid.UUID]model.User, error) {
+	ctx, cancel := context.WithTimeout(parent, e.timeout)
+	defer cancel()
+
+	users := map[uuid.UUID]model.User{}
+	it := e.client.ListUsers(ctx, 500)
+	for it.Next() {
+		users[it.User().ID] = *it.User()
+	}
+	return users, it.Err()
+}
+
+func printChanges(w io.Writer, previous, current map[uuid.UUID]model.User) {
+	now := time.Now().Format(time.RFC3339)
+	for id, u := range current {
+		old, ok := previous[id]
+		switch {
+		case !ok:
+			fmt.Fprintf(w, "%s ADDED   %s %s <%s>\n", now, id, u.Name, u.Email)
+		case !reflect.DeepEqual(old, u):
+			fmt.Fprintf(w, "%s UPDATED %s %s <%s>\n", now, id, u.Name, u.Email)
+		}
+	}
+	for id, u := range previous {
+		if _, ok := current[id]; !ok {
+			fmt.Fprintf(w, "%s DELETED %s %s <%s>\n", now, id, u.Name, u.Email)
+		}
+	}
+}
+
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: userctl [-config FILE] [-profile NAME] [-addr HOST:PORT] <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-45s %s\n", cmd.usage, cmd.summary)
+	}
+}
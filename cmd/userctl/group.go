@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+func runGroups(e *env, args []string) error {
+	fs, output := newFlagSet("groups")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	groups, err := e.client.ListGroups(ctx)
+	if err != nil {
+		return err
+	}
+	return printGroups(e.stdout, *output, groups)
+}
+
+func runGroupCreate(e *env, args []string) error {
+	fs, output := newFlagSet("group-create")
+	name := fs.String("name", "", "group name")
+	description := fs.String("description", "", "group description")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	g := &model.Group{Name: *name, Description: *description}
+	if err := e.client.CreateGroup(ctx, g); err != nil {
+		return err
+	}
+	return printGroups(e.stdout, *output, []model.Group{*g})
+}
+
+func runGroupDelete(e *env, args []string) error {
+	fs, _ := newFlagSet("group-delete")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	id, err := parseIDOf(fs, args, "group")
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	g, err := e.client.GetGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !*yes {
+		fmt.Fprintf(e.stdout, "Delete group %s (%s) and its memberships? [y/N] ", g.ID, g.Name)
+		answer, _ := bufio.NewReader(e.stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			return errCancelled
+		}
+	}
+
+	if err := e.client.DeleteGroup(ctx, id); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "deleted group %s\n", id)
+	return nil
+}
+
+func runGroupAdd(e *env, args []string) error {
+	fs, _ := newFlagSet("group-add")
+	groupID, userID, err := parseMembership(fs, args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	if _, err := e.client.AddMember(ctx, groupID, userID); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "added %s to group %s\n", userID, groupID)
+	return nil
+}
+
+func runGroupRemove(e *env, args []string) error {
+	fs, _ := newFlagSet("group-remove")
+	groupID, userID, err := parseMembership(fs, args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	if err := e.client.RemoveMember(ctx, groupID, userID); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "removed %s from group %s\n", userID, groupID)
+	return nil
+}
+
+func runGroupMembers(e *env, args []string) error {
+	fs, output := newFlagSet("group-members")
+	pageSize := fs.Int("page-size", 100, "members fetched per request")
+	id, err := parseIDOf(fs, args, "group")
+	if err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	var members []model.Membership
+	it := e.client.ListMembers(ctx, id, *pageSize)
+	for it.Next() {
+		members = append(members, *it.Membership())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	return printMembers(e.stdout, *output, members)
+}
+
+func runUserGroups(e *env, args []string) error {
+	fs, output := newFlagSet("user-groups")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	groups, err := e.client.ListUserGroups(ctx, id)
+	if err != nil {
+		return err
+	}
+	return printGroups(e.stdout, *output, groups)
+}
+
+// parseMembership reads the GROUP_ID USER_ID arguments of membership
+// commands.
+func parseMembership(fs *flag.FlagSet, args []string) (uuid.UUID, uuid.UUID, error) {
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	if len(positional) != 2 {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("%w: expected a group ID and a user ID", errUsage)
+	}
+	var ids [2]uuid.UUID
+	for i, arg := range positional {
+		id, err := uuid.Parse(arg)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, fmt.Errorf("%w: %q is not a valid ID", errUsage, arg)
+		}
+		ids[i] = id
+	}
+	return ids[0], ids[1], nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import "fmt"
+
+func runHistory(e *env, args []string) error {
+	fs, output := newFlagSet("history")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	revisions, err := e.client.ListRevisions(ctx, id)
+	if err != nil {
+		return err
+	}
+	return printRevisions(e.stdout, *output, revisions)
+}
+
+func runRevert(e *env, args []string) error {
+	fs, output := newFlagSet("revert")
+	revision := fs.Int64("revision", 0, "revision to restore")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+	if *revision < 1 {
+		return fmt.Errorf("%w: -revision is required", errUsage)
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	u, err := e.client.RevertUser(ctx, id, *revision)
+	if err != nil {
+		return err
+	}
+	return printUser(e.stdout, *output, u)
+}
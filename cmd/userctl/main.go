@@ -0,0 +1,94 @@
+// Command userctl manages users on a running server over gRPC.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"os/user"
+	"syscall"
+	"time"
+
+	"github.com/sergey4qb/mf1-test/client"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "userctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("userctl", flag.ContinueOnError)
+	fs.Usage = func() { usage(os.Stderr) }
+	configPath := fs.String("config", defaultProfilePath(), "profile file")
+	profileName := fs.String("profile", "", "profile to use (default: the file's current profile)")
+	addr := fs.String("addr", "", "server address, overrides the profile")
+	org := fs.String("org", "", "organization ID to act for, overrides the profile")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout per request")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		usage(os.Stderr)
+		return errUsage
+	}
+
+	cmd, ok := lookupCommand(fs.Arg(0))
+	if !ok {
+		usage(os.Stderr)
+		return errUsage
+	}
+
+	p, err := loadProfile(*configPath, *profileName)
+	if err != nil {
+		return err
+	}
+	if *addr != "" {
+		p.Address = *addr
+	}
+	if p.Address == "" {
+		return errNoAddress
+	}
+	if *org != "" {
+		p.Organization = *org
+	}
+	organization, err := p.organization()
+	if err != nil {
+		return err
+	}
+
+	opts, err := p.clientOptions()
+	if err != nil {
+		return err
+	}
+	c, err := client.New(p.Address, opts...)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	return cmd.run(&env{
+		client:       c,
+		timeout:      *timeout,
+		stdin:        os.Stdin,
+		stdout:       os.Stdout,
+		actor:        currentLogin(),
+		organization: organization,
+	}, fs.Args()[1:])
+}
+
+// currentLogin names the operator in status changes.
+func currentLogin() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
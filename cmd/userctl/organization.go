@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+func runOrganizations(e *env, args []string) error {
+	fs, output := newFlagSet("orgs")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	orgs, err := e.client.ListOrganizations(ctx)
+	if err != nil {
+		return err
+	}
+	return printOrganizations(e.stdout, *output, orgs)
+}
+
+func runOrganizationCreate(e *env, args []string) error {
+	fs, output := newFlagSet("org-create")
+	name := fs.String("name", "", "organization name")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	org := &model.Organization{Name: *name}
+	if err := e.client.CreateOrganization(ctx, org); err != nil {
+		return err
+	}
+	return printOrganizations(e.stdout, *output, []model.Organization{*org})
+}
+
+func runOrganizationDelete(e *env, args []string) error {
+	fs, _ := newFlagSet("org-delete")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	id, err := parseIDOf(fs, args, "organization")
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	org, err := e.client.GetOrganization(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !*yes {
+		fmt.Fprintf(e.stdout, "Delete organization %s (%s)? [y/N] ", org.ID, org.Name)
+		answer, _ := bufio.NewReader(e.stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			return errCancelled
+		}
+	}
+
+	if err := e.client.DeleteOrganization(ctx, id); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "deleted organization %s\n", id)
+	return nil
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputCSV   = "csv"
+)
+
+var errUnknownOutput = errors.New("unknown output format, expected table, json or csv")
+
+func validateOutput(format string) error {
+	switch format {
+	case outputTable, outputJSON, outputCSV:
+		return nil
+	}
+	return errUnknownOutput
+}
+
+func printUsers(w io.Writer, format string, users []model.User) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if users == nil {
+			users = []model.User{}
+		}
+		return enc.Encode(users)
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"id", "name", "email", "status"}); err != nil {
+			return err
+		}
+		for _, u := range users {
+			if err := cw.Write([]string{u.ID.String(), u.Name, u.Email, string(u.Status)}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tEMAIL\tSTATUS")
+		for _, u := range users {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", u.ID, u.Name, u.Email, u.Status)
+		}
+		return tw.Flush()
+	}
+	return errUnknownOutput
+}
+
+func printUser(w io.Writer, format string, u *model.User) error {
+	if format == outputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(u)
+	}
+	return printUsers(w, format, []model.User{*u})
+}
+
+func printGroups(w io.Writer, format string, groups []model.Group) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if groups == nil {
+			groups = []model.Group{}
+		}
+		return enc.Encode(groups)
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"id", "name", "description"}); err != nil {
+			return err
+		}
+		for _, g := range groups {
+			if err := cw.Write([]string{g.ID.String(), g.Name, g.Description}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tDESCRIPTION")
+		for _, g := range groups {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", g.ID, g.Name, g.Description)
+		}
+		return tw.Flush()
+	}
+	return errUnknownOutput
+}
+
+func printOrganizations(w io.Writer, format string, orgs []model.Organization) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if orgs == nil {
+			orgs = []model.Organization{}
+		}
+		return enc.Encode(orgs)
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"id", "name", "created_at"}); err != nil {
+			return err
+		}
+		for _, o := range orgs {
+			if err := cw.Write([]string{o.ID.String(), o.Name, o.CreatedAt.Format(time.RFC3339)}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tCREATED")
+		for _, o := range orgs {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", o.ID, o.Name, o.CreatedAt.Format(time.RFC3339))
+		}
+		return tw.Flush()
+	}
+	return errUnknownOutput
+}
+
+func printRoles(w io.Writer, format string, roles []model.Role) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if roles == nil {
+			roles = []model.Role{}
+		}
+		return enc.Encode(roles)
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"id", "name", "permissions", "description"}); err != nil {
+			return err
+		}
+		for _, r := range roles {
+			if err := cw.Write([]string{r.ID.String(), r.Name, strings.Join(r.Permissions, ","), r.Description}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tPERMISSIONS\tDESCRIPTION")
+		for _, r := range roles {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.ID, r.Name, strings.Join(r.Permissions, ","), r.Description)
+		}
+		return tw.Flush()
+	}
+	return errUnknownOutput
+}
+
+func printRevisions(w io.Writer, format string, revisions []model.UserRevision) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if revisions == nil {
+			revisions = []model.UserRevision{}
+		}
+		return enc.Encode(revisions)
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"revision", "action", "actor", "at", "changed_fields"}); err != nil {
+			return err
+		}
+		for _, r := range revisions {
+			record := []string{strconv.FormatInt(r.Number, 10), string(r.Action), r.Actor, r.At.Format(time.RFC3339), changedFields(r)}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "REV\tACTION\tACTOR\tAT\tCHANGED")
+		for _, r := range revisions {
+			action := string(r.Action)
+			if r.RevertedFrom != 0 {
+				action = fmt.Sprintf("%s (revert to %d)", action, r.RevertedFrom)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", r.Number, action, r.Actor, r.At.Format(time.RFC3339), changedFields(r))
+		}
+		return tw.Flush()
+	}
+	return errUnknownOutput
+}
+
+func printAuditEntries(w io.Writer, format string, entries []model.AuditEntry) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if entries == nil {
+			entries = []model.AuditEntry{}
+		}
+		return enc.Encode(entries)
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"seq", "at", "actor", "action", "target", "outcome", "error", "request_id", "peer"}); err != nil {
+			return err
+		}
+		for _, a := range entries {
+			record := []string{strconv.FormatInt(a.Sequence, 10), a.At.Format(time.RFC3339), a.Actor, a.Action, a.Target,
+				string(a.Outcome), a.Error, a.RequestID, a.Peer}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "SEQ\tAT\tACTOR\tACTION\tTARGET\tOUTCOME\tREQUEST")
+		for _, a := range entries {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", a.Sequence, a.At.Format(time.RFC3339), a.Actor, a.Action, a.Target, a.Outcome, a.RequestID)
+		}
+		return tw.Flush()
+	}
+	return errUnknownOutput
+}
+
+func printWebhooks(w io.Writer, format string, webhooks []model.Webhook) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if webhooks == nil {
+			webhooks = []model.Webhook{}
+		}
+		return enc.Encode(webhooks)
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"id", "url", "event_types", "enabled", "failures", "created_at", "secret"}); err != nil {
+			return err
+		}
+		for _, h := range webhooks {
+			record := []string{h.ID.String(), h.URL, eventTypes(h), strconv.FormatBool(h.DisabledAt == nil),
+				strconv.Itoa(h.Failures), h.CreatedAt.Format(time.RFC3339), h.Secret}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tURL\tEVENTS\tENABLED\tFAILURES\tCREATED")
+		for _, h := range webhooks {
+			events := eventTypes(h)
+			if events == "" {
+				events = "all"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%d\t%s\n", h.ID, h.URL, events, h.DisabledAt == nil, h.Failures, h.CreatedAt.Format(time.RFC3339))
+		}
+		return tw.Flush()
+	}
+	return errUnknownOutput
+}
+
+// eventTypes joins the event types a webhook receives.
+func eventTypes(h model.Webhook) string {
+	types := make([]string, len(h.EventTypes))
+	for i, t := range h.EventTypes {
+		types[i] = string(t)
+	}
+	return strings.Join(types, ",")
+}
+
+func printWebhookAttempts(w io.Writer, format string, attempts []model.WebhookAttempt) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if attempts == nil {
+			attempts = []model.WebhookAttempt{}
+		}
+		return enc.Encode(attempts)
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"seq", "at", "delivery_id", "event_id", "event_type", "attempt", "status_code", "duration", "error"}); err != nil {
+			return err
+		}
+		for _, a := range attempts {
+			record := []string{strconv.FormatInt(a.Seq, 10), a.At.Format(time.RFC3339), a.DeliveryID.String(), a.EventID.String(),
+				string(a.EventType), strconv.Itoa(a.Attempt), strconv.Itoa(a.StatusCode), a.Duration.String(), a.Error}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "SEQ\tAT\tEVENT\tTYPE\tATTEMPT\tSTATUS\tDURATION\tERROR")
+		for _, a := range attempts {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%d\t%s\t%s\n", a.Seq, a.At.Format(time.RFC3339), a.EventID, a.EventType,
+				a.Attempt, a.StatusCode, a.Duration.Round(time.Millisecond), a.Error)
+		}
+		return tw.Flush()
+	}
+	return errUnknownOutput
+}
+
+// changedFields joins the names of the fields a revision changed.
+func changedFields(r model.UserRevision) string {
+	fields := make([]string, len(r.Changes))
+	for i, c := range r.Changes {
+		fields[i] = c.Field
+	}
+	return strings.Join(fields, ",")
+}
+
+// erasedCounts formats the counts of a tombstone as store=N pairs sorted
+// by store.
+func erasedCounts(erased map[string]int) string {
+	stores := make([]string, 0, len(erased))
+	for store := range erased {
+		stores = append(stores, store)
+	}
+	slices.Sort(stores)
+	for i, store := range stores {
+		stores[i] = fmt.Sprintf("%s=%d", store, erased[store])
+	}
+	return strings.Join(stores, ", ")
+}
+
+func printMembers(w io.Writer, format string, members []model.Membership) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if members == nil {
+			members = []model.Membership{}
+		}
+		return enc.Encode(members)
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"user_id", "added_at"}); err != nil {
+			return err
+		}
+		for _, m := range members {
+			if err := cw.Write([]string{m.UserID.String(), m.AddedAt.Format(time.RFC3339)}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "USER ID\tADDED")
+		for _, m := range members {
+			fmt.Fprintf(tw, "%s\t%s\n", m.UserID, m.AddedAt.Format(time.RFC3339))
+		}
+		return tw.Flush()
+	}
+	return errUnknownOutput
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+func runExport(e *env, args []string) error {
+	fs, _ := newFlagSet("export")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	data, err := e.client.ExportUserData(ctx, id)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+	_, err = out.WriteTo(e.stdout)
+	return err
+}
+
+func runErase(e *env, args []string) error {
+	fs, _ := newFlagSet("erase")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if !*yes {
+		fmt.Fprintf(e.stdout, "Erase all data about user %s? This cannot be undone. [y/N] ", id)
+		answer, _ := bufio.NewReader(e.stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			return errCancelled
+		}
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	t, err := e.client.EraseUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "erased %s at %s: %s\n", id, t.ErasedAt.Format(time.RFC3339), erasedCounts(t.Erased))
+	return nil
+}
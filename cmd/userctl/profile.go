@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/client"
+)
+
+var (
+	errProfileNotFound = errors.New("profile not found")
+	errNoAddress       = errors.New("no server address, set it in the profile or pass -addr")
+	errBadCAFile       = errors.New("no certificates found in CA file")
+	errBadOrganization = errors.New("organization is not a valid ID")
+)
+
+// profileFile is the on-disk configuration, e.g.
+//
+//	{
+//	  "current": "local",
+//	  "profiles": {
+//	    "local": {"address": "localhost:8080", "insecure": true},
+//	    "prod": {"address": "users.example.com:443", "token_file": "~/.userctl-token"}
+//	  }
+//	}
+type profileFile struct {
+	Current  string             `json:"current"`
+	Profiles map[string]profile `json:"profiles"`
+}
+
+type profile struct {
+	Address    string `json:"address"`
+	Insecure   bool   `json:"insecure,omitempty"`
+	Token      string `json:"token,omitempty"`
+	TokenFile  string `json:"token_file,omitempty"`
+	CAFile     string `json:"ca_file,omitempty"`
+	ServerName string `json:"server_name,omitempty"`
+	// Organization is the ID of the organization commands act for; empty
+	// means the server's default one.
+	Organization string `json:"organization,omitempty"`
+}
+
+func defaultProfilePath() string {
+	if path := os.Getenv("USERCTL_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "userctl.json"
+	}
+	return filepath.Join(dir, "userctl", "config.json")
+}
+
+// loadProfile returns the named profile, or the file's current one when name
+// is empty. A missing file yields an empty profile so -addr alone is enough.
+func loadProfile(path, name string) (profile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && name == "" {
+		return profile{}, nil
+	}
+	if err != nil {
+		return profile{}, err
+	}
+
+	var f profileFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return profile{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	if name == "" {
+		name = f.Current
+	}
+	if name == "" && len(f.Profiles) == 1 {
+		for _, p := range f.Profiles {
+			return p, nil
+		}
+	}
+	p, ok := f.Profiles[name]
+	if !ok {
+		return profile{}, fmt.Errorf("%w: %q in %s", errProfileNotFound, name, path)
+	}
+	return p, nil
+}
+
+// organization parses the profile's organization, uuid.Nil when it has none.
+func (p profile) organization() (uuid.UUID, error) {
+	if p.Organization == "" {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.Parse(p.Organization)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %q", errBadOrganization, p.Organization)
+	}
+	return id, nil
+}
+
+func (p profile) clientOptions() ([]client.Option, error) {
+	var opts []client.Option
+
+	if p.Insecure {
+		opts = append(opts, client.WithInsecure())
+	} else {
+		cfg := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: p.ServerName}
+		if p.CAFile != "" {
+			pem, err := os.ReadFile(expandHome(p.CAFile))
+			if err != nil {
+				return nil, err
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, errBadCAFile
+			}
+			cfg.RootCAs = pool
+		}
+		opts = append(opts, client.WithTLS(cfg))
+	}
+
+	token := p.Token
+	if p.TokenFile != "" {
+		data, err := os.ReadFile(expandHome(p.TokenFile))
+		if err != nil {
+			return nil, err
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		opts = append(opts, client.WithToken(token))
+	}
+
+	return opts, nil
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+func runRoles(e *env, args []string) error {
+	fs, output := newFlagSet("roles")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	roles, err := e.client.ListRoles(ctx)
+	if err != nil {
+		return err
+	}
+	return printRoles(e.stdout, *output, roles)
+}
+
+func runRoleCreate(e *env, args []string) error {
+	fs, output := newFlagSet("role-create")
+	name := fs.String("name", "", "role name")
+	description := fs.String("description", "", "role description")
+	permissions := fs.String("permissions", "", `comma-separated permissions, e.g. "documents:read,billing:*"`)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	r := &model.Role{Name: *name, Description: *description, Permissions: splitList(*permissions)}
+	if err := e.client.CreateRole(ctx, r); err != nil {
+		return err
+	}
+	return printRoles(e.stdout, *output, []model.Role{*r})
+}
+
+func runRoleDelete(e *env, args []string) error {
+	fs, _ := newFlagSet("role-delete")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	id, err := parseIDOf(fs, args, "role")
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	r, err := e.client.GetRole(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !*yes {
+		fmt.Fprintf(e.stdout, "Delete role %s (%s) and its assignments? [y/N] ", r.ID, r.Name)
+		answer, _ := bufio.NewReader(e.stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			return errCancelled
+		}
+	}
+
+	if err := e.client.DeleteRole(ctx, id); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "deleted role %s\n", id)
+	return nil
+}
+
+func runRoleAssign(e *env, args []string) error {
+	fs, _ := newFlagSet("role-assign")
+	roleID, principal, err := parseAssignment(fs, args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	if _, err := e.client.AssignRole(ctx, roleID, principal); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "assigned role %s to %s %s\n", roleID, principal.Kind, principal.ID)
+	return nil
+}
+
+func runRoleUnassign(e *env, args []string) error {
+	fs, _ := newFlagSet("role-unassign")
+	roleID, principal, err := parseAssignment(fs, args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	if err := e.client.UnassignRole(ctx, roleID, principal); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "unassigned role %s from %s %s\n", roleID, principal.Kind, principal.ID)
+	return nil
+}
+
+func runPermissions(e *env, args []string) error {
+	fs, output := newFlagSet("permissions")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	effective, err := e.client.ListEffectivePermissions(ctx, id)
+	if err != nil {
+		return err
+	}
+	return printRoles(e.stdout, *output, effective.Roles)
+}
+
+func runCheckPermission(e *env, args []string) error {
+	fs, _ := newFlagSet("check-permission")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("%w: expected a user ID and a permission", errUsage)
+	}
+	id, err := uuid.Parse(positional[0])
+	if err != nil {
+		return fmt.Errorf("%w: %q is not a valid user ID", errUsage, positional[0])
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	allowed, err := e.client.CheckPermission(ctx, id, positional[1])
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		fmt.Fprintf(e.stdout, "denied: %s does not have %s\n", id, positional[1])
+		return errDenied
+	}
+	fmt.Fprintf(e.stdout, "allowed: %s has %s\n", id, positional[1])
+	return nil
+}
+
+// parseAssignment reads the ROLE_ID argument and the -user or -group flag
+// of assignment commands.
+func parseAssignment(fs *flag.FlagSet, args []string) (uuid.UUID, model.Principal, error) {
+	userID := fs.String("user", "", "ID of the user")
+	groupID := fs.String("group", "", "ID of the group")
+	roleID, err := parseIDOf(fs, args, "role")
+	if err != nil {
+		return uuid.Nil, model.Principal{}, err
+	}
+
+	var principal model.Principal
+	switch {
+	case *userID != "" && *groupID == "":
+		principal.Kind = model.PrincipalUser
+		principal.ID, err = uuid.Parse(*userID)
+	case *groupID != "" && *userID == "":
+		principal.Kind = model.PrincipalGroup
+		principal.ID, err = uuid.Parse(*groupID)
+	default:
+		return uuid.Nil, model.Principal{}, fmt.Errorf("%w: expected either -user or -group", errUsage)
+	}
+	if err != nil {
+		return uuid.Nil, model.Principal{}, fmt.Errorf("%w: invalid %s ID", errUsage, principal.Kind)
+	}
+	return roleID, principal, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
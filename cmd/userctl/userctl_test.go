@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"current":"local","profiles":{
+		"local":{"address":"localhost:8080","insecure":true},
+		"prod":{"address":"users.example.com:443","token":"secret"}
+	}}`
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0600))
+
+	p, err := loadProfile(path, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:8080", p.Address)
+	assert.True(t, p.Insecure)
+
+	p, err = loadProfile(path, "prod")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", p.Token)
+
+	opts, err := p.clientOptions()
+	assert.NoError(t, err)
+	assert.Len(t, opts, 2)
+
+	_, err = loadProfile(path, "staging")
+	assert.ErrorIs(t, err, errProfileNotFound)
+}
+
+func TestLoadProfile_MissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.json")
+
+	p, err := loadProfile(path, "")
+	assert.NoError(t, err)
+	assert.Empty(t, p.Address)
+
+	_, err = loadProfile(path, "prod")
+	assert.Error(t, err)
+}
+
+func TestPrintUsers(t *testing.T) {
+	users := []model.User{{ID: uuid.MustParse("1b4e28ba-2fa1-11d2-883f-0016d3cca427"), Name: "Alice", Email: "alice@example.com", Status: model.StatusActive}}
+
+	var buf bytes.Buffer
+	assert.NoError(t, printUsers(&buf, outputCSV, users))
+	assert.Equal(t, "id,name,email,status\n1b4e28ba-2fa1-11d2-883f-0016d3cca427,Alice,alice@example.com,active\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, printUsers(&buf, outputTable, users))
+	assert.Contains(t, buf.String(), "Alice  alice@example.com  active")
+
+	buf.Reset()
+	assert.NoError(t, printUsers(&buf, outputJSON, nil))
+	assert.Equal(t, "[]\n", buf.String())
+
+	assert.ErrorIs(t, printUsers(&buf, "xml", users), errUnknownOutput)
+}
+
+func TestPrintChanges(t *testing.T) {
+	kept := model.User{ID: uuid.New(), Name: "Kept", Email: "kept@example.com"}
+	changed := model.User{ID: uuid.New(), Name: "Old", Email: "changed@example.com"}
+	removed := model.User{ID: uuid.New(), Name: "Removed", Email: "removed@example.com"}
+	added := model.User{ID: uuid.New(), Name: "Added", Email: "added@example.com"}
+
+	previous := map[uuid.UUID]model.User{kept.ID: kept, changed.ID: changed, removed.ID: removed}
+	renamed := changed
+	renamed.Name = "New"
+	current := map[uuid.UUID]model.User{kept.ID: kept, renamed.ID: renamed, added.ID: added}
+
+	var buf bytes.Buffer
+	printChanges(&buf, previous, current)
+	out := buf.String()
+	assert.Contains(t, out, "ADDED   "+added.ID.String())
+	assert.Contains(t, out, "UPDATED "+changed.ID.String()+" New")
+	assert.Contains(t, out, "DELETED "+removed.ID.String())
+	assert.NotContains(t, out, kept.ID.String())
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+func runWebhooks(e *env, args []string) error {
+	fs, output := newFlagSet("webhooks")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	webhooks, err := e.client.ListWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+	return printWebhooks(e.stdout, *output, webhooks)
+}
+
+func runWebhookCreate(e *env, args []string) error {
+	fs, output := newFlagSet("webhook-create")
+	url := fs.String("url", "", "endpoint the events are posted to")
+	events := fs.String("events", "", "comma-separated event types, e.g. UserCreated,UserDeleted; empty receives all")
+	secret := fs.String("secret", "", "signing secret; empty generates one")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	w := &model.Webhook{URL: *url, Secret: *secret}
+	for _, t := range splitList(*events) {
+		w.EventTypes = append(w.EventTypes, model.EventType(t))
+	}
+	if err := e.client.CreateWebhook(ctx, w); err != nil {
+		return err
+	}
+	if err := printWebhooks(e.stdout, *output, []model.Webhook{*w}); err != nil {
+		return err
+	}
+	// JSON and CSV carry the secret in a field of their own.
+	if *output == outputTable {
+		fmt.Fprintf(e.stdout, "\nKeep this signing secret, it is not shown again:\n\n  %s\n", w.Secret)
+	}
+	return nil
+}
+
+func runWebhookDelete(e *env, args []string) error {
+	fs, _ := newFlagSet("webhook-delete")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	id, err := parseIDOf(fs, args, "webhook")
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	w, err := e.client.GetWebhook(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !*yes {
+		fmt.Fprintf(e.stdout, "Delete webhook %s (%s)? [y/N] ", w.ID, w.URL)
+		answer, _ := bufio.NewReader(e.stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			return errCancelled
+		}
+	}
+
+	if err := e.client.DeleteWebhook(ctx, id); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "deleted webhook %s\n", id)
+	return nil
+}
+
+func runWebhookDeliveries(e *env, args []string) error {
+	fs, output := newFlagSet("webhook-deliveries")
+	id, err := parseIDOf(fs, args, "webhook")
+	if err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	attempts, err := e.client.ListWebhookDeliveries(ctx, id)
+	if err != nil {
+		return err
+	}
+	return printWebhookAttempts(e.stdout, *output, attempts)
+}
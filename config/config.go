@@ -1,15 +1,155 @@
 package config
 
 import (
+	"errors"
 	"log"
+	"math"
 	"os"
+	"path/filepath"
+	"strconv"
 	"sync"
+	"time"
+)
+
+const (
+	defaultDataDir        = "."
+	defaultIdempotencyTTL = 24 * time.Hour
+	usersFileName         = "users.json"
+	idempotencyFileName   = "idempotency.json"
+
+	verificationFileName        = "verification.json"
+	defaultVerificationTokenTTL = 24 * time.Hour
+	defaultMailer               = "stdout"
+	defaultMailFrom             = "noreply@localhost"
+
+	tokenFileName             = "tokens.json"
+	defaultTokenIssuer        = "mf1-test"
+	defaultAccessTokenTTL     = 15 * time.Minute
+	defaultRefreshTokenTTL    = 30 * 24 * time.Hour
+	defaultSigningKeyRotation = 30 * 24 * time.Hour
+
+	groupsFileName = "groups.json"
+
+	rolesFileName              = "roles.json"
+	organizationsFileName      = "organizations.json"
+	historyFileName            = "history.json"
+	auditFileName              = "audit.log"
+	deadLettersFileName        = "dead_letters.json"
+	webhooksFileName           = "webhooks.json"
+	tombstonesFileName         = "tombstones.json"
+	defaultEventsNATSSubject   = "users"
+	defaultPermissionCacheSize = 10000
 )
 
 type Config struct {
 	GRPCProtocol string
 	GRPCAddress  string
 	GRPCPort     string
+
+	DataDir string
+
+	// ValidationRulesFile optionally adds per-deployment validation rules,
+	// see services/user.LoadRules.
+	ValidationRulesFile string
+
+	// AttributeSchemaFile declares the custom attributes users may carry,
+	// see services/user.AttributeSchema.
+	AttributeSchemaFile string
+
+	// UserIDStrategy is v4, v7 or provided, see services/user.IDStrategy.
+	UserIDStrategy string
+
+	// IdempotencyTTL is how long responses to calls made with an
+	// idempotency key are kept for replay.
+	IdempotencyTTL time.Duration
+
+	// EmailFoldGmail treats Gmail addresses that differ only in dots or a
+	// "+tag" as the same mailbox for uniqueness.
+	EmailFoldGmail bool
+
+	// VerificationSecret signs email verification tokens. When empty a
+	// random secret is used, so tokens do not survive a restart.
+	VerificationSecret string
+	// VerificationTokenTTL is how long a verification token stays valid.
+	VerificationTokenTTL time.Duration
+	// VerificationURL, when set, is sent as a link with the token appended
+	// as the "token" query parameter.
+	VerificationURL string
+
+	// Mailer is stdout, file or smtp. MailFile is the file the file mailer
+	// appends to; the SMTP settings are used by the smtp mailer.
+	Mailer       string
+	MailFrom     string
+	MailFile     string
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// PasswordMinLength overrides the policy's minimum password length.
+	PasswordMinLength int
+	// Argon2 parameters for new password hashes; zero keeps the default.
+	// Existing hashes are upgraded on the next successful login.
+	PasswordArgon2Memory      uint32
+	PasswordArgon2Iterations  uint32
+	PasswordArgon2Parallelism int
+
+	// LoginMaxFailures failed logins within LoginFailureWindow lock a user
+	// out for LoginLockoutDuration; zero keeps the defaults.
+	LoginMaxFailures     int
+	LoginFailureWindow   time.Duration
+	LoginLockoutDuration time.Duration
+
+	// TokenIssuer and TokenAudience are the iss and aud claims of access
+	// tokens; no aud claim is set when TokenAudience is empty.
+	TokenIssuer   string
+	TokenAudience string
+	// AccessTokenTTL and RefreshTokenTTL are how long issued tokens stay
+	// valid. A refresh token is replaced by a new one on every use.
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// SigningKeyRotation is how long a key signs access tokens before a new
+	// one takes over.
+	SigningKeyRotation time.Duration
+
+	// MFAEncryptionKey seals TOTP secrets and signs MFA login tokens. When
+	// empty a random key is used, so enrollments do not survive a restart.
+	MFAEncryptionKey string
+	// MFAIssuer labels entries in authenticator apps; it defaults to
+	// TokenIssuer.
+	MFAIssuer string
+
+	// GroupMaxMembers caps the members of a group and UserMaxGroups the
+	// groups a user can be in; zero means no cap.
+	GroupMaxMembers int
+	UserMaxGroups   int
+
+	// PermissionCacheSize is how many users' effective permissions are kept
+	// in memory; zero turns the cache off.
+	PermissionCacheSize int
+
+	// EventsWebhookURL, EventsNATSURL and EventsFile add the sinks user
+	// events are published to, see services/outbox, besides the webhooks
+	// subscribed through the API. EventsNATSSubject prefixes the NATS
+	// subjects.
+	EventsWebhookURL  string
+	EventsNATSURL     string
+	EventsNATSSubject string
+	EventsFile        string
+	// EventsPollInterval is how often pending events and webhook
+	// deliveries are looked for and EventsMaxAttempts after how many failed
+	// deliveries an event becomes a dead letter; zero keeps the defaults.
+	EventsPollInterval time.Duration
+	EventsMaxAttempts  int
+
+	// WebhookMaxAttempts is after how many failed attempts a webhook
+	// delivery is given up and WebhookDisableAfter after how many failed
+	// attempts in a row a webhook is disabled; zero keeps the defaults.
+	WebhookMaxAttempts  int
+	WebhookDisableAfter int
+
+	// HTTPAddress, when set, serves the signing keys as a JWKS document at
+	// /.well-known/jwks.json, e.g. ":8080".
+	HTTPAddress string
 }
 
 var (
@@ -23,17 +163,169 @@ func LoadConfig() *Config {
 			GRPCProtocol: os.Getenv("GRPC_PROTOCOL"),
 			GRPCAddress:  os.Getenv("GRPC_ADDRESS"),
 			GRPCPort:     os.Getenv("GRPC_PORT"),
+			DataDir:      os.Getenv("DATA_DIR"),
+
+			ValidationRulesFile: os.Getenv("VALIDATION_RULES_FILE"),
+			AttributeSchemaFile: os.Getenv("ATTRIBUTE_SCHEMA_FILE"),
+			UserIDStrategy:      os.Getenv("USER_ID_STRATEGY"),
+
+			VerificationSecret: os.Getenv("VERIFICATION_SECRET"),
+			VerificationURL:    os.Getenv("VERIFICATION_URL"),
+
+			Mailer:       os.Getenv("MAILER"),
+			MailFrom:     os.Getenv("MAIL_FROM"),
+			MailFile:     os.Getenv("MAIL_FILE"),
+			SMTPAddr:     os.Getenv("SMTP_ADDR"),
+			SMTPUsername: os.Getenv("SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+
+			TokenIssuer:   os.Getenv("TOKEN_ISSUER"),
+			TokenAudience: os.Getenv("TOKEN_AUDIENCE"),
+			HTTPAddress:   os.Getenv("HTTP_ADDRESS"),
+
+			MFAEncryptionKey: os.Getenv("MFA_ENCRYPTION_KEY"),
+			MFAIssuer:        os.Getenv("MFA_ISSUER"),
+
+			EventsWebhookURL:  os.Getenv("EVENTS_WEBHOOK_URL"),
+			EventsNATSURL:     os.Getenv("EVENTS_NATS_URL"),
+			EventsNATSSubject: os.Getenv("EVENTS_NATS_SUBJECT"),
+			EventsFile:        os.Getenv("EVENTS_FILE"),
+		}
+		if cfg.DataDir == "" {
+			cfg.DataDir = defaultDataDir
 		}
-		if cfg.GRPCProtocol == "" {
-			log.Fatalf("ERROR: GRPC_PROTOCOL not passed")
+		cfg.IdempotencyTTL = durationEnv("IDEMPOTENCY_TTL", defaultIdempotencyTTL)
+		cfg.EmailFoldGmail = boolEnv("EMAIL_FOLD_GMAIL")
+		cfg.VerificationTokenTTL = durationEnv("VERIFICATION_TOKEN_TTL", defaultVerificationTokenTTL)
+		cfg.PasswordMinLength = intEnv("PASSWORD_MIN_LENGTH", 0)
+		cfg.PasswordArgon2Memory = uint32(intEnv("PASSWORD_ARGON2_MEMORY", 0))
+		cfg.PasswordArgon2Iterations = uint32(intEnv("PASSWORD_ARGON2_ITERATIONS", 0))
+		cfg.PasswordArgon2Parallelism = intEnv("PASSWORD_ARGON2_PARALLELISM", 0)
+		cfg.LoginMaxFailures = intEnv("LOGIN_MAX_FAILURES", 0)
+		cfg.LoginFailureWindow = durationEnv("LOGIN_FAILURE_WINDOW", 0)
+		cfg.LoginLockoutDuration = durationEnv("LOGIN_LOCKOUT_DURATION", 0)
+		cfg.AccessTokenTTL = durationEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+		cfg.RefreshTokenTTL = durationEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+		cfg.SigningKeyRotation = durationEnv("SIGNING_KEY_ROTATION", defaultSigningKeyRotation)
+		cfg.GroupMaxMembers = intEnv("GROUP_MAX_MEMBERS", 0)
+		cfg.UserMaxGroups = intEnv("USER_MAX_GROUPS", 0)
+		cfg.PermissionCacheSize = intEnv("PERMISSION_CACHE_SIZE", defaultPermissionCacheSize)
+		cfg.EventsPollInterval = durationEnv("EVENTS_POLL_INTERVAL", 0)
+		cfg.EventsMaxAttempts = intEnv("EVENTS_MAX_ATTEMPTS", 0)
+		cfg.WebhookMaxAttempts = intEnv("WEBHOOK_MAX_ATTEMPTS", 0)
+		cfg.WebhookDisableAfter = intEnv("WEBHOOK_DISABLE_AFTER", 0)
+		if cfg.TokenIssuer == "" {
+			cfg.TokenIssuer = defaultTokenIssuer
 		}
-		if cfg.GRPCAddress == "" {
-			log.Fatalf("ERROR: GRPC_ADDRESS not passed")
+		if cfg.Mailer == "" {
+			cfg.Mailer = defaultMailer
 		}
-		if cfg.GRPCPort == "" {
-			log.Fatalf("ERROR: GRPC_PORT not passed")
+		if cfg.MailFrom == "" {
+			cfg.MailFrom = defaultMailFrom
+		}
+		if cfg.EventsNATSSubject == "" {
+			cfg.EventsNATSSubject = defaultEventsNATSSubject
 		}
 	})
 
 	return cfg
 }
+
+// ValidateGRPC reports missing listener settings. Only the serve command needs
+// them, so they are checked separately from loading.
+func (c *Config) ValidateGRPC() error {
+	if c.GRPCProtocol == "" {
+		return errors.New("GRPC_PROTOCOL not passed")
+	}
+	if c.GRPCAddress == "" {
+		return errors.New("GRPC_ADDRESS not passed")
+	}
+	if c.GRPCPort == "" {
+		return errors.New("GRPC_PORT not passed")
+	}
+	return nil
+}
+
+func (c *Config) UsersFilePath() string {
+	return filepath.Join(c.DataDir, usersFileName)
+}
+
+func (c *Config) IdempotencyFilePath() string {
+	return filepath.Join(c.DataDir, idempotencyFileName)
+}
+
+func (c *Config) VerificationFilePath() string {
+	return filepath.Join(c.DataDir, verificationFileName)
+}
+
+func (c *Config) TokenFilePath() string {
+	return filepath.Join(c.DataDir, tokenFileName)
+}
+
+func (c *Config) GroupsFilePath() string {
+	return filepath.Join(c.DataDir, groupsFileName)
+}
+
+func (c *Config) RolesFilePath() string {
+	return filepath.Join(c.DataDir, rolesFileName)
+}
+
+func (c *Config) OrganizationsFilePath() string {
+	return filepath.Join(c.DataDir, organizationsFileName)
+}
+
+func (c *Config) HistoryFilePath() string {
+	return filepath.Join(c.DataDir, historyFileName)
+}
+
+func (c *Config) AuditFilePath() string {
+	return filepath.Join(c.DataDir, auditFileName)
+}
+
+func (c *Config) DeadLettersFilePath() string {
+	return filepath.Join(c.DataDir, deadLettersFileName)
+}
+
+func (c *Config) WebhooksFilePath() string {
+	return filepath.Join(c.DataDir, webhooksFileName)
+}
+
+func (c *Config) TombstonesFilePath() string {
+	return filepath.Join(c.DataDir, tombstonesFileName)
+}
+
+func durationEnv(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Fatalf("ERROR: %s must be a positive duration, got %q", name, value)
+	}
+	return d
+}
+
+func intEnv(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > math.MaxUint32 {
+		log.Fatalf("ERROR: %s must be a non-negative integer, got %q", name, value)
+	}
+	return n
+}
+
+func boolEnv(name string) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("ERROR: %s must be a boolean, got %q", name, value)
+	}
+	return b
+}
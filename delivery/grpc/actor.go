@@ -0,0 +1,28 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/sergey4qb/mf1-test/actor"
+)
+
+// ActorHeader is the metadata key naming who makes a call. It is recorded
+// with status changes and in the audit log, and trusted as sent unless the
+// call carries an access token: its subject is recorded instead, see
+// tenantInterceptor.
+const ActorHeader = "x-actor"
+
+// actorInterceptor puts the caller named in ActorHeader into the context.
+func actorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(ActorHeader); len(values) > 0 && values[0] != "" {
+				ctx = actor.NewContext(ctx, values[0])
+			}
+		}
+		return handler(ctx, req)
+	}
+}
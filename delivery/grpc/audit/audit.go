@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+	"github.com/sergey4qb/mf1-test/services/audit"
+)
+
+type AuditServiceServer struct {
+	pb.UnimplementedAuditServiceServer
+	auditService audit.Audit
+}
+
+func NewAuditServer(auditService audit.Audit) *AuditServiceServer {
+	return &AuditServiceServer{auditService: auditService}
+}
+
+func (s *AuditServiceServer) ListAuditEntries(ctx context.Context, req *pb.ListAuditEntriesRequest) (*pb.ListAuditEntriesResponse, error) {
+	page, err := s.auditService.List(ctx, &dto.ListAuditEntriesDTO{
+		Target:    req.GetTarget(),
+		Actor:     req.GetActor(),
+		PageSize:  int(req.GetPageSize()),
+		PageToken: req.GetPageToken(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	out := make([]*pb.AuditEntry, 0, len(page.Entries))
+	for i := range page.Entries {
+		out = append(out, toProto(&page.Entries[i]))
+	}
+	return &pb.ListAuditEntriesResponse{Entries: out, NextPageToken: page.NextPageToken}, nil
+}
+
+func toProto(entry *model.AuditEntry) *pb.AuditEntry {
+	return &pb.AuditEntry{
+		Sequence:   entry.Sequence,
+		Time:       timestamppb.New(entry.At),
+		Actor:      entry.Actor,
+		Action:     entry.Action,
+		Target:     entry.Target,
+		BeforeHash: entry.BeforeHash,
+		AfterHash:  entry.AfterHash,
+		RequestId:  entry.RequestID,
+		Peer:       entry.Peer,
+		Outcome:    toProtoOutcome(entry.Outcome),
+		Error:      entry.Error,
+		Hash:       entry.Hash,
+	}
+}
+
+func toProtoOutcome(outcome model.AuditOutcome) pb.AuditOutcome {
+	switch outcome {
+	case model.AuditSuccess:
+		return pb.AuditOutcome_AUDIT_OUTCOME_SUCCESS
+	case model.AuditFailure:
+		return pb.AuditOutcome_AUDIT_OUTCOME_FAILURE
+	default:
+		return pb.AuditOutcome_AUDIT_OUTCOME_UNSPECIFIED
+	}
+}
@@ -0,0 +1,44 @@
+package group
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sergey4qb/mf1-test/services/group"
+)
+
+var (
+	errInvalidGroupID = status.Error(codes.InvalidArgument, "invalid group id")
+	errInvalidUserID  = status.Error(codes.InvalidArgument, "invalid user id")
+)
+
+// toStatus translates service errors into gRPC status errors.
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, group.ErrNotFound),
+		errors.Is(err, group.ErrUserNotFound),
+		errors.Is(err, group.ErrNotMember):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, group.ErrAlreadyExists),
+		errors.Is(err, group.ErrNameTaken),
+		errors.Is(err, group.ErrAlreadyMember):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, group.ErrGroupFull), errors.Is(err, group.ErrTooManyGroups):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, group.ErrValidation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,176 @@
+package group
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+	"github.com/sergey4qb/mf1-test/services/group"
+)
+
+type GroupServiceServer struct {
+	pb.UnimplementedGroupServiceServer
+	groupService group.Group
+}
+
+func NewGroupServer(groupService group.Group) *GroupServiceServer {
+	return &GroupServiceServer{groupService: groupService}
+}
+
+func (s *GroupServiceServer) CreateGroup(ctx context.Context, req *pb.CreateGroupRequest) (*pb.CreateGroupResponse, error) {
+	g := &model.Group{
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+	}
+	if err := s.groupService.Create(ctx, g); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.CreateGroupResponse{Group: toProto(g)}, nil
+}
+
+func (s *GroupServiceServer) GetGroup(ctx context.Context, req *pb.GetGroupRequest) (*pb.GetGroupResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidGroupID
+	}
+	g, err := s.groupService.GetByID(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.GetGroupResponse{Group: toProto(g)}, nil
+}
+
+func (s *GroupServiceServer) ListGroups(ctx context.Context, req *pb.ListGroupsRequest) (*pb.ListGroupsResponse, error) {
+	groups, err := s.groupService.GetAll(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ListGroupsResponse{Groups: toProtoGroups(groups)}, nil
+}
+
+func (s *GroupServiceServer) UpdateGroup(ctx context.Context, req *pb.UpdateGroupRequest) (*pb.UpdateGroupResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidGroupID
+	}
+	g, err := s.groupService.Update(ctx, &dto.UpdateGroupDTO{
+		ID:          id,
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.UpdateGroupResponse{Group: toProto(g)}, nil
+}
+
+func (s *GroupServiceServer) DeleteGroup(ctx context.Context, req *pb.DeleteGroupRequest) (*pb.DeleteGroupResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidGroupID
+	}
+	if err := s.groupService.Delete(ctx, id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.DeleteGroupResponse{}, nil
+}
+
+func (s *GroupServiceServer) AddMember(ctx context.Context, req *pb.AddMemberRequest) (*pb.AddMemberResponse, error) {
+	groupID, userID, err := parseMembership(req.GetGroupId(), req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.groupService.AddMember(ctx, groupID, userID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.AddMemberResponse{Membership: toProtoMembership(m)}, nil
+}
+
+func (s *GroupServiceServer) RemoveMember(ctx context.Context, req *pb.RemoveMemberRequest) (*pb.RemoveMemberResponse, error) {
+	groupID, userID, err := parseMembership(req.GetGroupId(), req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.groupService.RemoveMember(ctx, groupID, userID); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.RemoveMemberResponse{}, nil
+}
+
+func (s *GroupServiceServer) ListMembers(ctx context.Context, req *pb.ListMembersRequest) (*pb.ListMembersResponse, error) {
+	id, err := uuid.Parse(req.GetGroupId())
+	if err != nil {
+		return nil, errInvalidGroupID
+	}
+	page, err := s.groupService.ListMembers(ctx, &dto.ListMembersDTO{
+		GroupID:   id,
+		PageSize:  int(req.GetPageSize()),
+		PageToken: req.GetPageToken(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	var members []*pb.Membership
+	for i := range page.Members {
+		members = append(members, toProtoMembership(&page.Members[i]))
+	}
+	return &pb.ListMembersResponse{
+		Members:       members,
+		NextPageToken: page.NextPageToken,
+	}, nil
+}
+
+func (s *GroupServiceServer) ListUserGroups(ctx context.Context, req *pb.ListUserGroupsRequest) (*pb.ListUserGroupsResponse, error) {
+	id, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, errInvalidUserID
+	}
+	groups, err := s.groupService.ListUserGroups(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ListUserGroupsResponse{Groups: toProtoGroups(groups)}, nil
+}
+
+func parseMembership(group, user string) (uuid.UUID, uuid.UUID, error) {
+	groupID, err := uuid.Parse(group)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errInvalidGroupID
+	}
+	userID, err := uuid.Parse(user)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errInvalidUserID
+	}
+	return groupID, userID, nil
+}
+
+func toProto(g *model.Group) *pb.Group {
+	return &pb.Group{
+		Id:          g.ID.String(),
+		Name:        g.Name,
+		Description: g.Description,
+		CreateTime:  timestamppb.New(g.CreatedAt),
+	}
+}
+
+func toProtoGroups(groups []model.Group) []*pb.Group {
+	var out []*pb.Group
+	for i := range groups {
+		out = append(out, toProto(&groups[i]))
+	}
+	return out
+}
+
+func toProtoMembership(m *model.Membership) *pb.Membership {
+	return &pb.Membership{
+		GroupId: m.GroupID.String(),
+		UserId:  m.UserID.String(),
+		AddTime: timestamppb.New(m.AddedAt),
+	}
+}
@@ -8,7 +8,12 @@ import (
 
 	"google.golang.org/grpc"
 
+	"github.com/sergey4qb/mf1-test/delivery/grpc/audit"
+	"github.com/sergey4qb/mf1-test/delivery/grpc/group"
+	"github.com/sergey4qb/mf1-test/delivery/grpc/organization"
+	"github.com/sergey4qb/mf1-test/delivery/grpc/role"
 	"github.com/sergey4qb/mf1-test/delivery/grpc/user"
+	"github.com/sergey4qb/mf1-test/delivery/grpc/webhook"
 
 	pb "github.com/sergey4qb/mf1-test/proto/pb"
 )
@@ -18,16 +23,33 @@ type Server struct {
 	netListener net.Listener
 }
 
-func New(services services.Services) (*Server, error) {
+func New(cfg *config.Config, services services.Services) (*Server, error) {
+	if err := cfg.ValidateGRPC(); err != nil {
+		return nil, err
+	}
+
 	listener, err := net.Listen(
-		config.LoadConfig().GRPCProtocol,
-		config.LoadConfig().GRPCAddress+":"+config.LoadConfig().GRPCPort,
+		cfg.GRPCProtocol,
+		cfg.GRPCAddress+":"+cfg.GRPCPort,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	grpcServer := grpc.NewServer()
+	return NewWithListener(listener, services)
+}
+
+// NewWithListener serves on an existing listener, e.g. an in-memory one in
+// tests.
+func NewWithListener(listener net.Listener, services services.Services) (*Server, error) {
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			requestInterceptor(),
+			actorInterceptor(),
+			tenantInterceptor(services.GetOrganization(), services.GetToken()),
+			idempotencyInterceptor(services.GetIdempotency()),
+		),
+	)
 
 	srv := &Server{
 		Server:      grpcServer,
@@ -40,8 +62,23 @@ func New(services services.Services) (*Server, error) {
 }
 
 func (s *Server) registerServices(services services.Services) {
-	userServiceServer := user.NewUserServer(services.GetUser())
+	userServiceServer := user.NewUserServer(services.GetUser(), services.GetVerification(), services.GetToken(), services.GetHistory(), services.GetPrivacy())
 	pb.RegisterUserServiceServer(s.Server, userServiceServer)
+
+	groupServiceServer := group.NewGroupServer(services.GetGroup())
+	pb.RegisterGroupServiceServer(s.Server, groupServiceServer)
+
+	roleServiceServer := role.NewRoleServer(services.GetRole())
+	pb.RegisterRoleServiceServer(s.Server, roleServiceServer)
+
+	organizationServiceServer := organization.NewOrganizationServer(services.GetOrganization())
+	pb.RegisterOrganizationServiceServer(s.Server, organizationServiceServer)
+
+	auditServiceServer := audit.NewAuditServer(services.GetAudit())
+	pb.RegisterAuditServiceServer(s.Server, auditServiceServer)
+
+	webhookServiceServer := webhook.NewWebhookServer(services.GetWebhook())
+	pb.RegisterWebhookServiceServer(s.Server, webhookServiceServer)
 }
 
 func (s *Server) Start() error {
@@ -49,3 +86,7 @@ func (s *Server) Start() error {
 
 	return s.Server.Serve(s.netListener)
 }
+
+func (s *Server) Stop() {
+	s.Server.GracefulStop()
+}
@@ -0,0 +1,157 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+	"github.com/sergey4qb/mf1-test/services/idempotency"
+)
+
+const (
+	// IdempotencyKeyHeader is the metadata key clients set to make a
+	// mutating call safe to retry.
+	IdempotencyKeyHeader = "idempotency-key"
+	// IdempotentReplayHeader is set on responses served from a stored result.
+	IdempotentReplayHeader = "idempotent-replayed"
+)
+
+// mutatingMethods accept an idempotency key; other methods ignore it.
+// Password calls are left out because the stored request fingerprint is a
+// fast hash that would make the passwords in them easy to brute force, and
+// token and MFA calls because stored responses would keep live tokens, TOTP
+// secrets or recovery codes. Webhook creation and updates are left out for
+// the signing secrets they carry.
+var mutatingMethods = map[string]bool{
+	pb.UserService_CreateUser_FullMethodName:                 true,
+	pb.UserService_UpdateUser_FullMethodName:                 true,
+	pb.UserService_DeleteUser_FullMethodName:                 true,
+	pb.UserService_SuspendUser_FullMethodName:                true,
+	pb.UserService_ReactivateUser_FullMethodName:             true,
+	pb.UserService_SendVerificationEmail_FullMethodName:      true,
+	pb.UserService_VerifyEmail_FullMethodName:                true,
+	pb.UserService_RevertUser_FullMethodName:                 true,
+	pb.GroupService_CreateGroup_FullMethodName:               true,
+	pb.GroupService_UpdateGroup_FullMethodName:               true,
+	pb.GroupService_DeleteGroup_FullMethodName:               true,
+	pb.GroupService_AddMember_FullMethodName:                 true,
+	pb.GroupService_RemoveMember_FullMethodName:              true,
+	pb.RoleService_CreateRole_FullMethodName:                 true,
+	pb.RoleService_UpdateRole_FullMethodName:                 true,
+	pb.RoleService_DeleteRole_FullMethodName:                 true,
+	pb.RoleService_AssignRole_FullMethodName:                 true,
+	pb.RoleService_UnassignRole_FullMethodName:               true,
+	pb.OrganizationService_CreateOrganization_FullMethodName: true,
+	pb.OrganizationService_UpdateOrganization_FullMethodName: true,
+	pb.OrganizationService_DeleteOrganization_FullMethodName: true,
+	pb.WebhookService_DeleteWebhook_FullMethodName:           true,
+}
+
+// idempotencyInterceptor answers repeated calls that carry the same
+// idempotency key with the response of the first successful call.
+func idempotencyInterceptor(svc idempotency.Idempotency) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !mutatingMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		key := idempotencyKey(ctx)
+		if key == "" {
+			return handler(ctx, req)
+		}
+
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+		fingerprint, err := requestFingerprint(msg)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		var out any
+		stored, replayed, err := svc.Do(ctx, key, info.FullMethod, fingerprint, func() (*idempotency.Response, error) {
+			var err error
+			out, err = handler(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			return encodeResponse(out)
+		})
+		switch {
+		case errors.Is(err, idempotency.ErrKeyReused):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case errors.Is(err, idempotency.ErrInvalidKey):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case err != nil:
+			if _, ok := status.FromError(err); ok {
+				return nil, err
+			}
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		if !replayed {
+			return out, nil
+		}
+
+		_ = grpc.SetHeader(ctx, metadata.Pairs(IdempotentReplayHeader, "true"))
+		return decodeResponse(stored)
+	}
+}
+
+func idempotencyKey(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(IdempotencyKeyHeader)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func requestFingerprint(req proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func encodeResponse(out any) (*idempotency.Response, error) {
+	msg, ok := out.(proto.Message)
+	if !ok {
+		return nil, status.Error(codes.Internal, "response is not a protobuf message")
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &idempotency.Response{
+		Type: string(msg.ProtoReflect().Descriptor().FullName()),
+		Data: data,
+	}, nil
+}
+
+func decodeResponse(stored *idempotency.Response) (any, error) {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(stored.Type))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "decode stored response: %v", err)
+	}
+	msg := mt.New().Interface()
+	if err := proto.Unmarshal(stored.Data, msg); err != nil {
+		return nil, status.Errorf(codes.Internal, "decode stored response: %v", err)
+	}
+	return msg, nil
+}
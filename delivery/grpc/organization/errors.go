@@ -0,0 +1,37 @@
+package organization
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sergey4qb/mf1-test/services/organization"
+)
+
+var errInvalidOrganizationID = status.Error(codes.InvalidArgument, "invalid organization id")
+
+// toStatus translates service errors into gRPC status errors.
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, organization.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, organization.ErrAlreadyExists), errors.Is(err, organization.ErrNameTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, organization.ErrNotEmpty), errors.Is(err, organization.ErrPendingEvents):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, organization.ErrValidation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,86 @@
+package organization
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+	"github.com/sergey4qb/mf1-test/services/organization"
+)
+
+type OrganizationServiceServer struct {
+	pb.UnimplementedOrganizationServiceServer
+	organizationService organization.Organization
+}
+
+func NewOrganizationServer(organizationService organization.Organization) *OrganizationServiceServer {
+	return &OrganizationServiceServer{organizationService: organizationService}
+}
+
+func (s *OrganizationServiceServer) CreateOrganization(ctx context.Context, req *pb.CreateOrganizationRequest) (*pb.CreateOrganizationResponse, error) {
+	org := &model.Organization{Name: req.GetName()}
+	if err := s.organizationService.Create(ctx, org); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.CreateOrganizationResponse{Organization: toProto(org)}, nil
+}
+
+func (s *OrganizationServiceServer) GetOrganization(ctx context.Context, req *pb.GetOrganizationRequest) (*pb.GetOrganizationResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidOrganizationID
+	}
+	org, err := s.organizationService.GetByID(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.GetOrganizationResponse{Organization: toProto(org)}, nil
+}
+
+func (s *OrganizationServiceServer) ListOrganizations(ctx context.Context, req *pb.ListOrganizationsRequest) (*pb.ListOrganizationsResponse, error) {
+	orgs, err := s.organizationService.GetAll(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	var out []*pb.Organization
+	for i := range orgs {
+		out = append(out, toProto(&orgs[i]))
+	}
+	return &pb.ListOrganizationsResponse{Organizations: out}, nil
+}
+
+func (s *OrganizationServiceServer) UpdateOrganization(ctx context.Context, req *pb.UpdateOrganizationRequest) (*pb.UpdateOrganizationResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidOrganizationID
+	}
+	org, err := s.organizationService.Update(ctx, &dto.UpdateOrganizationDTO{ID: id, Name: req.Name})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.UpdateOrganizationResponse{Organization: toProto(org)}, nil
+}
+
+func (s *OrganizationServiceServer) DeleteOrganization(ctx context.Context, req *pb.DeleteOrganizationRequest) (*pb.DeleteOrganizationResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidOrganizationID
+	}
+	if err := s.organizationService.Delete(ctx, id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.DeleteOrganizationResponse{}, nil
+}
+
+func toProto(org *model.Organization) *pb.Organization {
+	return &pb.Organization{
+		Id:         org.ID.String(),
+		Name:       org.Name,
+		CreateTime: timestamppb.New(org.CreatedAt),
+	}
+}
@@ -0,0 +1,43 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/sergey4qb/mf1-test/request"
+)
+
+// RequestIDHeader is the metadata key correlating a call with logs and audit
+// entries. Calls without one are given a random ID; either way it is sent
+// back in the response header.
+const RequestIDHeader = "x-request-id"
+
+// maxRequestIDLength bounds the IDs taken from callers.
+const maxRequestIDLength = 128
+
+// requestInterceptor puts the request ID and the caller's address into the
+// context.
+func requestInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var call request.Info
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(RequestIDHeader); len(values) > 0 && len(values[0]) <= maxRequestIDLength {
+				call.ID = values[0]
+			}
+		}
+		if call.ID == "" {
+			call.ID = uuid.NewString()
+		}
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			call.Peer = p.Addr.String()
+		}
+
+		// Failing to send the header must not fail the call.
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, call.ID))
+		return handler(request.NewContext(ctx, call), req)
+	}
+}
@@ -0,0 +1,45 @@
+package role
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sergey4qb/mf1-test/services/role"
+)
+
+var (
+	errInvalidRoleID    = status.Error(codes.InvalidArgument, "invalid role id")
+	errInvalidUserID    = status.Error(codes.InvalidArgument, "invalid user id")
+	errInvalidGroupID   = status.Error(codes.InvalidArgument, "invalid group id")
+	errMissingPrincipal = status.Error(codes.InvalidArgument, "either user_id or group_id is required")
+)
+
+// toStatus translates service errors into gRPC status errors.
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, role.ErrNotFound),
+		errors.Is(err, role.ErrUserNotFound),
+		errors.Is(err, role.ErrGroupNotFound),
+		errors.Is(err, role.ErrNotAssigned):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, role.ErrAlreadyExists),
+		errors.Is(err, role.ErrNameTaken),
+		errors.Is(err, role.ErrAlreadyAssigned):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, role.ErrValidation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,214 @@
+package role
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+	"github.com/sergey4qb/mf1-test/services/role"
+)
+
+type RoleServiceServer struct {
+	pb.UnimplementedRoleServiceServer
+	roleService role.Role
+}
+
+func NewRoleServer(roleService role.Role) *RoleServiceServer {
+	return &RoleServiceServer{roleService: roleService}
+}
+
+func (s *RoleServiceServer) CreateRole(ctx context.Context, req *pb.CreateRoleRequest) (*pb.CreateRoleResponse, error) {
+	r := &model.Role{
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		Permissions: req.GetPermissions(),
+	}
+	if err := s.roleService.Create(ctx, r); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.CreateRoleResponse{Role: toProto(r)}, nil
+}
+
+func (s *RoleServiceServer) GetRole(ctx context.Context, req *pb.GetRoleRequest) (*pb.GetRoleResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidRoleID
+	}
+	r, err := s.roleService.GetByID(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.GetRoleResponse{Role: toProto(r)}, nil
+}
+
+func (s *RoleServiceServer) ListRoles(ctx context.Context, req *pb.ListRolesRequest) (*pb.ListRolesResponse, error) {
+	roles, err := s.roleService.GetAll(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ListRolesResponse{Roles: toProtoRoles(roles)}, nil
+}
+
+func (s *RoleServiceServer) UpdateRole(ctx context.Context, req *pb.UpdateRoleRequest) (*pb.UpdateRoleResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidRoleID
+	}
+	update := &dto.UpdateRoleDTO{
+		ID:          id,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if req.Permissions != nil {
+		permissions := req.GetPermissions().GetValues()
+		update.Permissions = &permissions
+	}
+	r, err := s.roleService.Update(ctx, update)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.UpdateRoleResponse{Role: toProto(r)}, nil
+}
+
+func (s *RoleServiceServer) DeleteRole(ctx context.Context, req *pb.DeleteRoleRequest) (*pb.DeleteRoleResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidRoleID
+	}
+	if err := s.roleService.Delete(ctx, id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.DeleteRoleResponse{}, nil
+}
+
+func (s *RoleServiceServer) AssignRole(ctx context.Context, req *pb.AssignRoleRequest) (*pb.AssignRoleResponse, error) {
+	roleID, principal, err := parseAssignment(req)
+	if err != nil {
+		return nil, err
+	}
+	a, err := s.roleService.Assign(ctx, roleID, principal)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.AssignRoleResponse{Assignment: toProtoAssignment(a)}, nil
+}
+
+func (s *RoleServiceServer) UnassignRole(ctx context.Context, req *pb.UnassignRoleRequest) (*pb.UnassignRoleResponse, error) {
+	roleID, principal, err := parseAssignment(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.roleService.Unassign(ctx, roleID, principal); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.UnassignRoleResponse{}, nil
+}
+
+func (s *RoleServiceServer) ListRoleAssignments(ctx context.Context, req *pb.ListRoleAssignmentsRequest) (*pb.ListRoleAssignmentsResponse, error) {
+	id, err := uuid.Parse(req.GetRoleId())
+	if err != nil {
+		return nil, errInvalidRoleID
+	}
+	assignments, err := s.roleService.ListAssignments(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	var out []*pb.RoleAssignment
+	for i := range assignments {
+		out = append(out, toProtoAssignment(&assignments[i]))
+	}
+	return &pb.ListRoleAssignmentsResponse{Assignments: out}, nil
+}
+
+func (s *RoleServiceServer) CheckPermission(ctx context.Context, req *pb.CheckPermissionRequest) (*pb.CheckPermissionResponse, error) {
+	id, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, errInvalidUserID
+	}
+	allowed, err := s.roleService.CheckPermission(ctx, id, req.GetPermission())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.CheckPermissionResponse{Allowed: allowed}, nil
+}
+
+func (s *RoleServiceServer) ListEffectivePermissions(ctx context.Context, req *pb.ListEffectivePermissionsRequest) (*pb.ListEffectivePermissionsResponse, error) {
+	id, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, errInvalidUserID
+	}
+	effective, err := s.roleService.EffectivePermissions(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ListEffectivePermissionsResponse{
+		Permissions: effective.Permissions,
+		Roles:       toProtoRoles(effective.Roles),
+	}, nil
+}
+
+// assignmentRequest is what AssignRoleRequest and UnassignRoleRequest have
+// in common.
+type assignmentRequest interface {
+	GetRoleId() string
+	GetUserId() string
+	GetGroupId() string
+}
+
+func parseAssignment(req assignmentRequest) (uuid.UUID, model.Principal, error) {
+	roleID, err := uuid.Parse(req.GetRoleId())
+	if err != nil {
+		return uuid.Nil, model.Principal{}, errInvalidRoleID
+	}
+	switch {
+	case req.GetUserId() != "":
+		id, err := uuid.Parse(req.GetUserId())
+		if err != nil {
+			return uuid.Nil, model.Principal{}, errInvalidUserID
+		}
+		return roleID, model.Principal{Kind: model.PrincipalUser, ID: id}, nil
+	case req.GetGroupId() != "":
+		id, err := uuid.Parse(req.GetGroupId())
+		if err != nil {
+			return uuid.Nil, model.Principal{}, errInvalidGroupID
+		}
+		return roleID, model.Principal{Kind: model.PrincipalGroup, ID: id}, nil
+	}
+	return uuid.Nil, model.Principal{}, errMissingPrincipal
+}
+
+func toProto(r *model.Role) *pb.Role {
+	return &pb.Role{
+		Id:          r.ID.String(),
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: r.Permissions,
+		CreateTime:  timestamppb.New(r.CreatedAt),
+	}
+}
+
+func toProtoRoles(roles []model.Role) []*pb.Role {
+	var out []*pb.Role
+	for i := range roles {
+		out = append(out, toProto(&roles[i]))
+	}
+	return out
+}
+
+func toProtoAssignment(a *model.RoleAssignment) *pb.RoleAssignment {
+	out := &pb.RoleAssignment{
+		RoleId:     a.RoleID.String(),
+		AssignTime: timestamppb.New(a.AssignedAt),
+	}
+	if a.Principal.Kind == model.PrincipalGroup {
+		out.Principal = &pb.RoleAssignment_GroupId{GroupId: a.Principal.ID.String()}
+	} else {
+		out.Principal = &pb.RoleAssignment_UserId{UserId: a.Principal.ID.String()}
+	}
+	return out
+}
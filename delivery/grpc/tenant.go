@@ -0,0 +1,109 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/sergey4qb/mf1-test/actor"
+	"github.com/sergey4qb/mf1-test/services/organization"
+	"github.com/sergey4qb/mf1-test/services/token"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+// OrganizationHeader is the metadata key naming the organization a call acts
+// for. Calls naming none act for tenant.Default.
+const OrganizationHeader = "x-organization"
+
+var (
+	errInvalidOrganization  = status.Error(codes.InvalidArgument, "invalid organization id")
+	errUnknownOrganization  = status.Error(codes.InvalidArgument, "unknown organization")
+	errOrganizationMismatch = status.Error(codes.PermissionDenied, "the access token belongs to another organization")
+)
+
+// tenantInterceptor puts the organization a call acts for into the context.
+// It is taken from the org_id claim of a bearer access token issued by this
+// server, or else from OrganizationHeader. Tokens the server did not issue
+// are ignored, as they may be meant for a proxy in front of it. The subject
+// of a verified token also replaces the actor named in ActorHeader, so the
+// audit log records who the server knows made the call.
+func tenantInterceptor(orgs organization.Organization, tokens token.Tokens) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		claims, err := accessClaims(ctx, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if claims != nil {
+			ctx = actor.NewContext(ctx, claims.Subject)
+		}
+		org, err := requestOrganization(ctx, claims)
+		if err != nil {
+			return nil, err
+		}
+		if org == tenant.Default {
+			return handler(ctx, req)
+		}
+
+		_, err = orgs.GetByID(ctx, org)
+		switch {
+		case errors.Is(err, organization.ErrNotFound):
+			return nil, errUnknownOrganization
+		case err != nil:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return handler(tenant.NewContext(ctx, org), req)
+	}
+}
+
+// accessClaims returns the claims of the bearer access token of a call, or
+// nil when it carries none the server issued.
+func accessClaims(ctx context.Context, tokens token.Tokens) (*token.Claims, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, nil
+	}
+	bearer, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		return nil, nil
+	}
+	claims, err := tokens.VerifyAccessToken(ctx, bearer)
+	if errors.Is(err, token.ErrInvalidAccessToken) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return claims, nil
+}
+
+func requestOrganization(ctx context.Context, claims *token.Claims) (uuid.UUID, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	header := tenant.Default
+	if values := md.Get(OrganizationHeader); len(values) > 0 && values[0] != "" {
+		id, err := uuid.Parse(values[0])
+		if err != nil {
+			return uuid.Nil, errInvalidOrganization
+		}
+		header = id
+	}
+
+	if claims == nil {
+		return header, nil
+	}
+	org, err := claims.Organization()
+	if err != nil {
+		return uuid.Nil, errInvalidOrganization
+	}
+	if header != tenant.Default && header != org {
+		return uuid.Nil, errOrganizationMismatch
+	}
+	return org, nil
+}
@@ -0,0 +1,33 @@
+package user
+
+import (
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// fromValues converts attributes to the JSON types the service stores. A
+// null value becomes nil, which updates treat as removal.
+func fromValues(values map[string]*structpb.Value) map[string]any {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(values))
+	for key, v := range values {
+		out[key] = v.AsInterface()
+	}
+	return out
+}
+
+// toValues converts stored attributes. Stored values are JSON types, which
+// structpb always accepts.
+func toValues(attributes map[string]any) map[string]*structpb.Value {
+	if len(attributes) == 0 {
+		return nil
+	}
+	out := make(map[string]*structpb.Value, len(attributes))
+	for key, v := range attributes {
+		if value, err := structpb.NewValue(v); err == nil {
+			out[key] = value
+		}
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package user
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sergey4qb/mf1-test/services/history"
+	"github.com/sergey4qb/mf1-test/services/token"
+	"github.com/sergey4qb/mf1-test/services/user"
+	"github.com/sergey4qb/mf1-test/services/verification"
+)
+
+var errInvalidID = status.Error(codes.InvalidArgument, "invalid user id")
+
+// toStatus translates service errors into gRPC status errors.
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, user.ErrNotFound), errors.Is(err, history.ErrRevisionNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, user.ErrAlreadyExists), errors.Is(err, user.ErrEmailTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, user.ErrInvalidTransition),
+		errors.Is(err, user.ErrMFAUnavailable),
+		errors.Is(err, user.ErrMFAEnabled),
+		errors.Is(err, user.ErrMFANotEnrolled),
+		errors.Is(err, verification.ErrTokenExpired),
+		errors.Is(err, verification.ErrTokenUsed),
+		errors.Is(err, verification.ErrEmailChanged),
+		errors.Is(err, verification.ErrAlreadyVerified):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, user.ErrInvalidCredentials),
+		errors.Is(err, user.ErrInvalidMFAToken),
+		errors.Is(err, token.ErrInvalidToken),
+		errors.Is(err, token.ErrTokenReused):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, user.ErrAccountLocked):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, user.ErrAccountInactive):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, verification.ErrInvalidToken), errors.Is(err, history.ErrValidation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, user.ErrValidation):
+		return validationStatus(err)
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// validationStatus attaches every violation as a BadRequest field violation
+// so clients can show all problems at once.
+func validationStatus(err error) error {
+	st := status.New(codes.InvalidArgument, err.Error())
+
+	var verr *user.ValidationError
+	if !errors.As(err, &verr) {
+		return st.Err()
+	}
+
+	br := &errdetails.BadRequest{}
+	for _, v := range verr.Violations {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Message,
+		})
+	}
+	if detailed, derr := st.WithDetails(br); derr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -0,0 +1,110 @@
+package user
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+	"github.com/sergey4qb/mf1-test/services/history"
+)
+
+var errAsOfAndRevision = status.Error(codes.InvalidArgument, "as_of and revision cannot both be set")
+
+var toProtoActions = map[model.RevisionAction]pb.RevisionAction{
+	model.RevisionCreate: pb.RevisionAction_REVISION_ACTION_CREATE,
+	model.RevisionUpdate: pb.RevisionAction_REVISION_ACTION_UPDATE,
+	model.RevisionDelete: pb.RevisionAction_REVISION_ACTION_DELETE,
+}
+
+func (s *UserServiceServer) ListUserRevisions(ctx context.Context, req *pb.ListUserRevisionsRequest) (*pb.ListUserRevisionsResponse, error) {
+	id, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, errInvalidID
+	}
+	page, err := s.history.List(ctx, &dto.ListRevisionsDTO{
+		UserID:    id,
+		PageSize:  int(req.GetPageSize()),
+		PageToken: req.GetPageToken(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.ListUserRevisionsResponse{NextPageToken: page.NextPageToken}
+	for i := range page.Revisions {
+		resp.Revisions = append(resp.Revisions, toProtoRevision(&page.Revisions[i]))
+	}
+	return resp, nil
+}
+
+func (s *UserServiceServer) RevertUser(ctx context.Context, req *pb.RevertUserRequest) (*pb.RevertUserResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidID
+	}
+	u, err := s.history.Revert(ctx, id, req.GetRevision())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.RevertUserResponse{User: toProto(u)}, nil
+}
+
+// getPastUser answers GetUser calls with as_of or revision set.
+func (s *UserServiceServer) getPastUser(ctx context.Context, id uuid.UUID, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	if req.GetAsOf() != nil && req.GetRevision() != 0 {
+		return nil, errAsOfAndRevision
+	}
+
+	var rev *model.UserRevision
+	var err error
+	if req.GetAsOf() != nil {
+		rev, err = s.history.AsOf(ctx, id, req.GetAsOf().AsTime())
+	} else {
+		rev, err = s.history.Get(ctx, id, req.GetRevision())
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if rev.User == nil {
+		return nil, toStatus(history.ErrNotFound)
+	}
+	return &pb.GetUserResponse{User: toProto(rev.User)}, nil
+}
+
+func toProtoRevision(rev *model.UserRevision) *pb.UserRevision {
+	out := &pb.UserRevision{
+		Revision:     rev.Number,
+		UserId:       rev.UserID.String(),
+		Action:       toProtoActions[rev.Action],
+		Actor:        rev.Actor,
+		CreateTime:   timestamppb.New(rev.At),
+		RevertedFrom: rev.RevertedFrom,
+	}
+	for _, c := range rev.Changes {
+		out.Changes = append(out.Changes, &pb.FieldChange{
+			Field:    c.Field,
+			OldValue: toValue(c.Old),
+			NewValue: toValue(c.New),
+		})
+	}
+	if rev.User != nil {
+		out.User = toProto(rev.User)
+	}
+	return out
+}
+
+// toValue converts a recorded value, which is a JSON type; nil becomes null.
+func toValue(v any) *structpb.Value {
+	value, err := structpb.NewValue(v)
+	if err != nil {
+		return structpb.NewNullValue()
+	}
+	return value
+}
@@ -0,0 +1,78 @@
+package user
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+	"github.com/sergey4qb/mf1-test/services/user"
+)
+
+// updateDTO selects the fields an update changes. With an update mask exactly
+// the listed fields are set, so they can be cleared; without one only
+// non-empty fields are, which keeps older clients working.
+func updateDTO(id uuid.UUID, req *pb.UpdateUserRequest) (*dto.UpdateUserDTO, error) {
+	update := &dto.UpdateUserDTO{ID: id}
+	attributes := fromValues(req.GetAttributes())
+
+	if mask := req.GetUpdateMask(); mask != nil {
+		for _, path := range mask.GetPaths() {
+			if path == user.FieldAttributes {
+				update.Attributes = attributes
+				update.ReplaceAttributes = true
+				continue
+			}
+			if key, ok := strings.CutPrefix(path, user.FieldAttributes+"."); ok && key != "" {
+				if update.Attributes == nil {
+					update.Attributes = map[string]any{}
+				}
+				update.Attributes[key] = attributes[key]
+				continue
+			}
+
+			value, target := updateField(req, update, path)
+			if target == nil {
+				return nil, status.Errorf(codes.InvalidArgument, "unknown update_mask path %q", path)
+			}
+			*target = &value
+		}
+		return update, nil
+	}
+
+	for _, field := range user.Fields {
+		value, target := updateField(req, update, field)
+		if value != "" {
+			*target = &value
+		}
+	}
+	update.Attributes = attributes
+	return update, nil
+}
+
+// updateField returns the request value of field and the DTO field it goes
+// to, or a nil target for unknown fields.
+func updateField(req *pb.UpdateUserRequest, update *dto.UpdateUserDTO, field string) (string, **string) {
+	switch field {
+	case user.FieldName:
+		return req.GetName(), &update.Name
+	case user.FieldEmail:
+		return req.GetEmail(), &update.Email
+	case user.FieldGivenName:
+		return req.GetGivenName(), &update.GivenName
+	case user.FieldFamilyName:
+		return req.GetFamilyName(), &update.FamilyName
+	case user.FieldPhone:
+		return req.GetPhone(), &update.Phone
+	case user.FieldLocale:
+		return req.GetLocale(), &update.Locale
+	case user.FieldTimeZone:
+		return req.GetTimeZone(), &update.TimeZone
+	case user.FieldAvatarURL:
+		return req.GetAvatarUrl(), &update.AvatarURL
+	}
+	return "", nil
+}
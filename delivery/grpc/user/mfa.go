@@ -0,0 +1,56 @@
+package user
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+func (s *UserServiceServer) EnrollMFA(ctx context.Context, req *pb.EnrollMFARequest) (*pb.EnrollMFAResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidID
+	}
+	enrollment, err := s.userService.EnrollMFA(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.EnrollMFAResponse{Secret: enrollment.Secret, ProvisioningUri: enrollment.URI}, nil
+}
+
+func (s *UserServiceServer) ConfirmMFA(ctx context.Context, req *pb.ConfirmMFARequest) (*pb.ConfirmMFAResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidID
+	}
+	codes, err := s.userService.ConfirmMFA(ctx, id, req.GetCode())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ConfirmMFAResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *UserServiceServer) DisableMFA(ctx context.Context, req *pb.DisableMFARequest) (*pb.DisableMFAResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidID
+	}
+	if err := s.userService.DisableMFA(ctx, id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.DisableMFAResponse{}, nil
+}
+
+func (s *UserServiceServer) VerifyMFA(ctx context.Context, req *pb.VerifyMFARequest) (*pb.VerifyMFAResponse, error) {
+	u, err := s.userService.VerifyMFA(ctx, req.GetMfaToken(), req.GetCode())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	pair, err := s.tokens.Issue(ctx, u)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.VerifyMFAResponse{User: toProto(u), Tokens: toProtoTokens(pair)}, nil
+}
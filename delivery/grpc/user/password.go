@@ -0,0 +1,61 @@
+package user
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+func (s *UserServiceServer) SetPassword(ctx context.Context, req *pb.SetPasswordRequest) (*pb.SetPasswordResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidID
+	}
+	if err := s.userService.SetPassword(ctx, id, req.GetPassword()); err != nil {
+		return nil, toStatus(err)
+	}
+	if err := s.tokens.RevokeUser(ctx, id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.SetPasswordResponse{}, nil
+}
+
+func (s *UserServiceServer) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidID
+	}
+	if err := s.userService.ChangePassword(ctx, id, req.GetCurrentPassword(), req.GetNewPassword()); err != nil {
+		return nil, toStatus(err)
+	}
+	if err := s.tokens.RevokeUser(ctx, id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ChangePasswordResponse{}, nil
+}
+
+func (s *UserServiceServer) Authenticate(ctx context.Context, req *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+	u, err := s.userService.Authenticate(ctx, req.GetEmail(), req.GetPassword())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if u.MFA.Enabled() {
+		challenge, expiresAt, err := s.userService.IssueMFAChallenge(ctx, u.ID)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		return &pb.AuthenticateResponse{
+			MfaRequired:   true,
+			MfaToken:      challenge,
+			MfaExpireTime: timestamppb.New(expiresAt),
+		}, nil
+	}
+	pair, err := s.tokens.Issue(ctx, u)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.AuthenticateResponse{User: toProto(u), Tokens: toProtoTokens(pair)}, nil
+}
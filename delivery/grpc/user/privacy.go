@@ -0,0 +1,54 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+func (s *UserServiceServer) ExportUserData(ctx context.Context, req *pb.ExportUserDataRequest) (*pb.ExportUserDataResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidID
+	}
+	export, err := s.privacy.Export(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	data, err := json.Marshal(export)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ExportUserDataResponse{Data: data}, nil
+}
+
+func (s *UserServiceServer) EraseUser(ctx context.Context, req *pb.EraseUserRequest) (*pb.EraseUserResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidID
+	}
+	t, err := s.privacy.Erase(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.EraseUserResponse{Tombstone: toProtoTombstone(t)}, nil
+}
+
+func toProtoTombstone(t *model.Tombstone) *pb.Tombstone {
+	erased := make(map[string]int32, len(t.Erased))
+	for store, n := range t.Erased {
+		erased[store] = int32(n)
+	}
+	return &pb.Tombstone{
+		UserId:    t.UserID.String(),
+		EraseTime: timestamppb.New(t.ErasedAt),
+		Actor:     t.Actor,
+		RequestId: t.RequestID,
+		Erased:    erased,
+	}
+}
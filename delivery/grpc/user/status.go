@@ -0,0 +1,82 @@
+package user
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+var statusToProto = map[model.UserStatus]pb.UserStatus{
+	model.StatusPending:   pb.UserStatus_USER_STATUS_PENDING,
+	model.StatusActive:    pb.UserStatus_USER_STATUS_ACTIVE,
+	model.StatusSuspended: pb.UserStatus_USER_STATUS_SUSPENDED,
+	model.StatusDisabled:  pb.UserStatus_USER_STATUS_DISABLED,
+}
+
+var statusFromProto = map[pb.UserStatus]model.UserStatus{
+	pb.UserStatus_USER_STATUS_PENDING:   model.StatusPending,
+	pb.UserStatus_USER_STATUS_ACTIVE:    model.StatusActive,
+	pb.UserStatus_USER_STATUS_SUSPENDED: model.StatusSuspended,
+	pb.UserStatus_USER_STATUS_DISABLED:  model.StatusDisabled,
+}
+
+func (s *UserServiceServer) SuspendUser(ctx context.Context, req *pb.SuspendUserRequest) (*pb.SuspendUserResponse, error) {
+	u, err := s.changeStatus(ctx, req.GetId(), model.StatusSuspended, req.GetReason())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SuspendUserResponse{User: toProto(u)}, nil
+}
+
+func (s *UserServiceServer) ReactivateUser(ctx context.Context, req *pb.ReactivateUserRequest) (*pb.ReactivateUserResponse, error) {
+	u, err := s.changeStatus(ctx, req.GetId(), model.StatusActive, req.GetReason())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ReactivateUserResponse{User: toProto(u)}, nil
+}
+
+func (s *UserServiceServer) changeStatus(ctx context.Context, rawID string, to model.UserStatus, reason string) (*model.User, error) {
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return nil, errInvalidID
+	}
+	u, err := s.userService.ChangeStatus(ctx, &dto.ChangeStatusDTO{ID: id, To: to, Reason: reason})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return u, nil
+}
+
+// fromProtoStatuses converts a status filter; UNSPECIFIED and unknown values
+// are rejected.
+func fromProtoStatuses(statuses []pb.UserStatus) ([]model.UserStatus, error) {
+	var out []model.UserStatus
+	for _, ps := range statuses {
+		ms, ok := statusFromProto[ps]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid status filter %v", ps)
+		}
+		out = append(out, ms)
+	}
+	return out, nil
+}
+
+func setStatus(out *pb.User, u *model.User) {
+	out.Status = statusToProto[u.Status]
+	if u.Status == "" {
+		out.Status = pb.UserStatus_USER_STATUS_ACTIVE
+	}
+	if c := u.StatusChange; c != nil {
+		out.StatusReason = c.Reason
+		out.StatusChangedBy = c.Actor
+		out.StatusChangedAt = timestamppb.New(c.At)
+	}
+}
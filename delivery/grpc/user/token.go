@@ -0,0 +1,35 @@
+package user
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+	"github.com/sergey4qb/mf1-test/services/token"
+)
+
+func (s *UserServiceServer) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	pair, err := s.tokens.Refresh(ctx, req.GetRefreshToken())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.RefreshTokenResponse{Tokens: toProtoTokens(pair)}, nil
+}
+
+func (s *UserServiceServer) RevokeToken(ctx context.Context, req *pb.RevokeTokenRequest) (*pb.RevokeTokenResponse, error) {
+	if err := s.tokens.Revoke(ctx, req.GetRefreshToken()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.RevokeTokenResponse{}, nil
+}
+
+func toProtoTokens(pair *token.Pair) *pb.Tokens {
+	return &pb.Tokens{
+		AccessToken:       pair.AccessToken,
+		TokenType:         "Bearer",
+		ExpireTime:        timestamppb.New(pair.AccessExpiresAt),
+		RefreshToken:      pair.RefreshToken,
+		RefreshExpireTime: timestamppb.New(pair.RefreshExpiresAt),
+	}
+}
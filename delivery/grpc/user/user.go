@@ -4,7 +4,11 @@ import (
 	"context"
 	"github.com/google/uuid"
 	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/services/history"
+	"github.com/sergey4qb/mf1-test/services/privacy"
+	"github.com/sergey4qb/mf1-test/services/token"
 	"github.com/sergey4qb/mf1-test/services/user"
+	"github.com/sergey4qb/mf1-test/services/verification"
 
 	"github.com/sergey4qb/mf1-test/model"
 	pb "github.com/sergey4qb/mf1-test/proto/pb"
@@ -12,52 +16,78 @@ import (
 
 type UserServiceServer struct {
 	pb.UnimplementedUserServiceServer
-	userService user.User
+	userService  user.User
+	verification verification.Verification
+	tokens       token.Tokens
+	history      history.History
+	privacy      privacy.Privacy
 }
 
-func NewUserServer(userService user.User) *UserServiceServer {
+func NewUserServer(userService user.User, verification verification.Verification, tokens token.Tokens, history history.History, privacy privacy.Privacy) *UserServiceServer {
 	return &UserServiceServer{
-		userService: userService,
+		userService:  userService,
+		verification: verification,
+		tokens:       tokens,
+		history:      history,
+		privacy:      privacy,
 	}
 }
 
 func (s *UserServiceServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
 	u := &model.User{
-		Name:  req.GetName(),
-		Email: req.GetEmail(),
+		Name:       req.GetName(),
+		Email:      req.GetEmail(),
+		GivenName:  req.GetGivenName(),
+		FamilyName: req.GetFamilyName(),
+		Phone:      req.GetPhone(),
+		Locale:     req.GetLocale(),
+		TimeZone:   req.GetTimeZone(),
+		AvatarURL:  req.GetAvatarUrl(),
+		Attributes: fromValues(req.GetAttributes()),
+	}
+	if req.GetId() != "" {
+		id, err := uuid.Parse(req.GetId())
+		if err != nil {
+			return nil, errInvalidID
+		}
+		u.ID = id
 	}
 
 	if err := s.userService.Create(ctx, u); err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 
 	resp := &pb.CreateUserResponse{
-		User: &pb.User{
-			Id:    u.ID.String(),
-			Name:  u.Name,
-			Email: u.Email,
-		},
+		User: toProto(u),
 	}
 	return resp, nil
 }
 
 func (s *UserServiceServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
-	users, err := s.userService.GetAll(ctx)
+	statuses, err := fromProtoStatuses(req.GetStatuses())
 	if err != nil {
 		return nil, err
 	}
+	page, err := s.userService.List(ctx, &dto.ListUsersDTO{
+		PageSize:   int(req.GetPageSize()),
+		PageToken:  req.GetPageToken(),
+		NameQuery:  req.GetNameQuery(),
+		OrderBy:    req.GetOrderBy(),
+		Attributes: fromValues(req.GetAttributes()),
+		Statuses:   statuses,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
 
 	var pbUsers []*pb.User
-	for _, u := range users {
-		pbUsers = append(pbUsers, &pb.User{
-			Id:    u.ID.String(),
-			Name:  u.Name,
-			Email: u.Email,
-		})
+	for i := range page.Users {
+		pbUsers = append(pbUsers, toProto(&page.Users[i]))
 	}
 
 	resp := &pb.ListUsersResponse{
-		Users: pbUsers,
+		Users:         pbUsers,
+		NextPageToken: page.NextPageToken,
 	}
 
 	return resp, nil
@@ -66,51 +96,35 @@ func (s *UserServiceServer) ListUsers(ctx context.Context, req *pb.ListUsersRequ
 func (s *UserServiceServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
 	id, err := uuid.Parse(req.GetId())
 	if err != nil {
-		return nil, err
+		return nil, errInvalidID
+	}
+	if req.GetAsOf() != nil || req.GetRevision() != 0 {
+		return s.getPastUser(ctx, id, req)
 	}
 	u, err := s.userService.GetByID(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 	resp := &pb.GetUserResponse{
-		User: &pb.User{
-			Id:    u.ID.String(),
-			Name:  u.Name,
-			Email: u.Email,
-		},
+		User: toProto(u),
 	}
 	return resp, nil
 }
 func (s *UserServiceServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
 	id, err := uuid.Parse(req.GetId())
 	if err != nil {
-		return nil, err
-	}
-	var namePtr *string
-	if req.GetName() != "" {
-		name := req.GetName()
-		namePtr = &name
+		return nil, errInvalidID
 	}
-	var emailPtr *string
-	if req.GetEmail() != "" {
-		email := req.GetEmail()
-		emailPtr = &email
-	}
-	dto := &dto.UpdateUserDTO{
-		ID:    id,
-		Name:  namePtr,
-		Email: emailPtr,
+	dto, err := updateDTO(id, req)
+	if err != nil {
+		return nil, err
 	}
 	updatedUser, err := s.userService.Update(ctx, dto)
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 	resp := &pb.UpdateUserResponse{
-		User: &pb.User{
-			Id:    updatedUser.ID.String(),
-			Name:  updatedUser.Name,
-			Email: updatedUser.Email,
-		},
+		User: toProto(updatedUser),
 	}
 	return resp, nil
 }
@@ -118,11 +132,31 @@ func (s *UserServiceServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRe
 func (s *UserServiceServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
 	id, err := uuid.Parse(req.GetId())
 	if err != nil {
-		return nil, err
+		return nil, errInvalidID
 	}
 	if err := s.userService.Delete(ctx, id); err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 	resp := &pb.DeleteUserResponse{}
 	return resp, nil
 }
+
+func toProto(u *model.User) *pb.User {
+	out := &pb.User{
+		Id:            u.ID.String(),
+		Name:          u.Name,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		HasPassword:   u.PasswordHash != "",
+		MfaEnabled:    u.MFA.Enabled(),
+		GivenName:     u.GivenName,
+		FamilyName:    u.FamilyName,
+		Phone:         u.Phone,
+		Locale:        u.Locale,
+		TimeZone:      u.TimeZone,
+		AvatarUrl:     u.AvatarURL,
+		Attributes:    toValues(u.Attributes),
+	}
+	setStatus(out, u)
+	return out
+}
@@ -0,0 +1,30 @@
+package user
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+)
+
+func (s *UserServiceServer) SendVerificationEmail(ctx context.Context, req *pb.SendVerificationEmailRequest) (*pb.SendVerificationEmailResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidID
+	}
+	expiresAt, err := s.verification.Send(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.SendVerificationEmailResponse{ExpireTime: timestamppb.New(expiresAt)}, nil
+}
+
+func (s *UserServiceServer) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	u, err := s.verification.Verify(ctx, req.GetToken())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.VerifyEmailResponse{User: toProto(u)}, nil
+}
@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	pb "github.com/sergey4qb/mf1-test/proto/pb"
+	"github.com/sergey4qb/mf1-test/services/webhook"
+)
+
+type WebhookServiceServer struct {
+	pb.UnimplementedWebhookServiceServer
+	webhookService webhook.Webhook
+}
+
+func NewWebhookServer(webhookService webhook.Webhook) *WebhookServiceServer {
+	return &WebhookServiceServer{webhookService: webhookService}
+}
+
+func (s *WebhookServiceServer) CreateWebhook(ctx context.Context, req *pb.CreateWebhookRequest) (*pb.CreateWebhookResponse, error) {
+	w := &model.Webhook{
+		URL:        req.GetUrl(),
+		EventTypes: fromProtoEventTypes(req.GetEventTypes()),
+		Secret:     req.GetSecret(),
+	}
+	if err := s.webhookService.Create(ctx, w); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.CreateWebhookResponse{Webhook: toProto(w), Secret: w.Secret}, nil
+}
+
+func (s *WebhookServiceServer) GetWebhook(ctx context.Context, req *pb.GetWebhookRequest) (*pb.GetWebhookResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidWebhookID
+	}
+	w, err := s.webhookService.GetByID(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.GetWebhookResponse{Webhook: toProto(w)}, nil
+}
+
+func (s *WebhookServiceServer) ListWebhooks(ctx context.Context, req *pb.ListWebhooksRequest) (*pb.ListWebhooksResponse, error) {
+	webhooks, err := s.webhookService.GetAll(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	var out []*pb.Webhook
+	for i := range webhooks {
+		out = append(out, toProto(&webhooks[i]))
+	}
+	return &pb.ListWebhooksResponse{Webhooks: out}, nil
+}
+
+func (s *WebhookServiceServer) UpdateWebhook(ctx context.Context, req *pb.UpdateWebhookRequest) (*pb.UpdateWebhookResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidWebhookID
+	}
+
+	update := &dto.UpdateWebhookDTO{
+		ID:      id,
+		URL:     req.Url,
+		Secret:  req.Secret,
+		Enabled: req.Enabled,
+	}
+	if req.EventTypes != nil {
+		types := fromProtoEventTypes(req.GetEventTypes().GetValues())
+		update.EventTypes = &types
+	}
+	w, err := s.webhookService.Update(ctx, update)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.UpdateWebhookResponse{Webhook: toProto(w)}, nil
+}
+
+func (s *WebhookServiceServer) DeleteWebhook(ctx context.Context, req *pb.DeleteWebhookRequest) (*pb.DeleteWebhookResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, errInvalidWebhookID
+	}
+	if err := s.webhookService.Delete(ctx, id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.DeleteWebhookResponse{}, nil
+}
+
+func (s *WebhookServiceServer) ListWebhookDeliveries(ctx context.Context, req *pb.ListWebhookDeliveriesRequest) (*pb.ListWebhookDeliveriesResponse, error) {
+	id, err := uuid.Parse(req.GetWebhookId())
+	if err != nil {
+		return nil, errInvalidWebhookID
+	}
+	page, err := s.webhookService.ListAttempts(ctx, &dto.ListWebhookAttemptsDTO{
+		WebhookID: id,
+		PageSize:  int(req.GetPageSize()),
+		PageToken: req.GetPageToken(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	out := make([]*pb.WebhookDelivery, 0, len(page.Attempts))
+	for i := range page.Attempts {
+		out = append(out, toProtoDelivery(&page.Attempts[i]))
+	}
+	return &pb.ListWebhookDeliveriesResponse{Deliveries: out, NextPageToken: page.NextPageToken}, nil
+}
+
+// toProto leaves the secret out; it is only returned on creation.
+func toProto(w *model.Webhook) *pb.Webhook {
+	out := &pb.Webhook{
+		Id:         w.ID.String(),
+		Url:        w.URL,
+		Enabled:    w.DisabledAt == nil,
+		Failures:   int32(w.Failures),
+		CreateTime: timestamppb.New(w.CreatedAt),
+	}
+	for _, t := range w.EventTypes {
+		out.EventTypes = append(out.EventTypes, string(t))
+	}
+	if w.DisabledAt != nil {
+		out.DisableTime = timestamppb.New(*w.DisabledAt)
+	}
+	return out
+}
+
+func toProtoDelivery(a *model.WebhookAttempt) *pb.WebhookDelivery {
+	return &pb.WebhookDelivery{
+		Sequence:   a.Seq,
+		DeliveryId: a.DeliveryID.String(),
+		EventId:    a.EventID.String(),
+		EventType:  string(a.EventType),
+		Attempt:    int32(a.Attempt),
+		Time:       timestamppb.New(a.At),
+		StatusCode: int32(a.StatusCode),
+		Duration:   durationpb.New(a.Duration),
+		Success:    a.Succeeded(),
+		Error:      a.Error,
+	}
+}
+
+func fromProtoEventTypes(values []string) []model.EventType {
+	var types []model.EventType
+	for _, v := range values {
+		types = append(types, model.EventType(v))
+	}
+	return types
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/sergey4qb/mf1-test/services"
+	"github.com/sergey4qb/mf1-test/services/token"
+)
+
+// JWKSPath is where the public signing keys are served.
+const JWKSPath = "/.well-known/jwks.json"
+
+// shutdownTimeout bounds how long Stop waits for requests in flight.
+const shutdownTimeout = 5 * time.Second
+
+type Server struct {
+	Server      *http.Server
+	netListener net.Listener
+}
+
+func New(address string, services services.Services) (*Server, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithListener(listener, services)
+}
+
+// NewWithListener serves on an existing listener, e.g. one on a random port
+// in tests.
+func NewWithListener(listener net.Listener, services services.Services) (*Server, error) {
+	mux := http.NewServeMux()
+	mux.Handle("GET "+JWKSPath, jwksHandler(services.GetToken()))
+
+	return &Server{
+		Server:      &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second},
+		netListener: listener,
+	}, nil
+}
+
+// jwksHandler serves the keys access tokens are verified with. A rotated key
+// signs tokens as soon as it exists, so clients should refetch the document
+// when they meet an unknown key ID rather than wait for the cache to expire.
+func jwksHandler(tokens token.Tokens) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		set, err := tokens.JWKS(r.Context())
+		if err != nil {
+			http.Error(w, "failed to load signing keys", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/jwk-set+json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		if err := json.NewEncoder(w).Encode(set); err != nil {
+			log.Printf("write JWKS: %v", err)
+		}
+	})
+}
+
+func (s *Server) Start() error {
+	log.Printf("HTTP started on %s", s.netListener.Addr().String())
+
+	err := s.Server.Serve(s.netListener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *Server) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := s.Server.Shutdown(ctx); err != nil {
+		log.Printf("HTTP shutdown: %v", err)
+	}
+}
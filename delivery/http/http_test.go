@@ -0,0 +1,36 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/repository/token"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	tokenservice "github.com/sergey4qb/mf1-test/services/token"
+)
+
+func TestJWKSHandler(t *testing.T) {
+	repo, err := token.New(filepath.Join(t.TempDir(), "tokens.json"))
+	require.NoError(t, err)
+	handler := jwksHandler(tokenservice.New(user.NewMemory(), repo))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, JWKSPath, nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/jwk-set+json", rec.Header().Get("Content-Type"))
+
+	var set tokenservice.JWKS
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &set))
+	require.Len(t, set.Keys, 1)
+	assert.Equal(t, "EC", set.Keys[0].KeyType)
+	assert.Equal(t, "P-256", set.Keys[0].Curve)
+	assert.NotEmpty(t, set.Keys[0].KeyID)
+	assert.NotContains(t, rec.Body.String(), `"d"`, "private parts are never published")
+}
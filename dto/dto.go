@@ -1,9 +1,153 @@
 package dto
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"github.com/sergey4qb/mf1-test/model"
+)
 
+// UpdateUserDTO changes the non-nil fields of a user. Optional profile fields
+// are cleared by setting them to "".
 type UpdateUserDTO struct {
 	ID    uuid.UUID
 	Name  *string
 	Email *string
+
+	GivenName  *string
+	FamilyName *string
+	Phone      *string
+	Locale     *string
+	TimeZone   *string
+	AvatarURL  *string
+
+	// Attributes sets custom attributes; a nil value removes the key. With
+	// ReplaceAttributes the user keeps exactly these attributes.
+	Attributes        map[string]any
+	ReplaceAttributes bool
+}
+
+// ListUsersDTO selects one page of users. A zero PageSize returns every user
+// after PageToken. NameQuery keeps users whose name contains it, ignoring case
+// and accents; OrderBy is empty for creation order or "name". Attributes keeps
+// users whose indexed attributes equal, or for arrays contain, every value.
+// Statuses keeps users in any of the given statuses.
+type ListUsersDTO struct {
+	PageSize   int
+	PageToken  string
+	NameQuery  string
+	OrderBy    string
+	Attributes map[string]any
+	Statuses   []model.UserStatus
+}
+
+// ChangeStatusDTO moves a user to another lifecycle status.
+type ChangeStatusDTO struct {
+	ID     uuid.UUID
+	To     model.UserStatus
+	Reason string
+}
+
+// MFAEnrollment is what a user needs to add a TOTP secret to an
+// authenticator app: the base32 secret to type in and the otpauth:// URI to
+// show as a QR code.
+type MFAEnrollment struct {
+	Secret string
+	URI    string
+}
+
+type UsersPage struct {
+	Users         []model.User
+	NextPageToken string
+}
+
+// UpdateGroupDTO changes the non-nil fields of a group.
+type UpdateGroupDTO struct {
+	ID          uuid.UUID
+	Name        *string
+	Description *string
+}
+
+// ListMembersDTO selects one page of a group's members, in the order they
+// were added. A zero PageSize returns every member after PageToken.
+type ListMembersDTO struct {
+	GroupID   uuid.UUID
+	PageSize  int
+	PageToken string
+}
+
+type MembersPage struct {
+	Members       []model.Membership
+	NextPageToken string
+}
+
+// ListRevisionsDTO selects one page of a user's revisions, oldest first. A
+// zero PageSize returns every revision after PageToken.
+type ListRevisionsDTO struct {
+	UserID    uuid.UUID
+	PageSize  int
+	PageToken string
+}
+
+type RevisionsPage struct {
+	Revisions     []model.UserRevision
+	NextPageToken string
+}
+
+// UpdateRoleDTO changes the non-nil fields of a role; Permissions replaces
+// the whole set.
+type UpdateRoleDTO struct {
+	ID          uuid.UUID
+	Name        *string
+	Description *string
+	Permissions *[]string
+}
+
+// EffectivePermissions is what a user may do: the roles assigned to it
+// directly or through its groups, and the sorted union of their permissions.
+type EffectivePermissions struct {
+	Permissions []string
+	Roles       []model.Role
+}
+
+// UpdateOrganizationDTO changes the non-nil fields of an organization.
+type UpdateOrganizationDTO struct {
+	ID   uuid.UUID
+	Name *string
+}
+
+// ListAuditEntriesDTO selects one page of audit entries, oldest first. Empty
+// Target and Actor match every entry; a zero PageSize returns the default
+// page size.
+type ListAuditEntriesDTO struct {
+	Target    string
+	Actor     string
+	PageSize  int
+	PageToken string
+}
+
+type AuditPage struct {
+	Entries       []model.AuditEntry
+	NextPageToken string
+}
+
+// UpdateWebhookDTO changes the non-nil fields of a webhook; EventTypes
+// replaces the whole set. Enabling a webhook clears its failures.
+type UpdateWebhookDTO struct {
+	ID         uuid.UUID
+	URL        *string
+	EventTypes *[]model.EventType
+	Secret     *string
+	Enabled    *bool
+}
+
+// ListWebhookAttemptsDTO selects one page of the delivery attempts of a
+// webhook, oldest first. A zero PageSize returns the default page size.
+type ListWebhookAttemptsDTO struct {
+	WebhookID uuid.UUID
+	PageSize  int
+	PageToken string
+}
+
+type WebhookAttemptsPage struct {
+	Attempts      []model.WebhookAttempt
+	NextPageToken string
 }
@@ -0,0 +1,190 @@
+// Package email parses and normalizes email addresses. Parsing follows
+// RFC 5322 addr-spec syntax via net/mail, with RFC 6532 UTF-8 local parts and
+// internationalized domains normalized through IDNA.
+package email
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	maxLocalLength   = 64
+	maxAddressLength = 254
+)
+
+var (
+	ErrInvalid         = errors.New("invalid email address")
+	ErrDisplayName     = errors.New("email address must not include a display name")
+	ErrLocalTooLong    = errors.New("email local part is longer than 64 bytes")
+	ErrAddressTooLong  = errors.New("email address is longer than 254 bytes")
+	ErrInvalidDomain   = errors.New("invalid email domain")
+	ErrAddressLiteral  = errors.New("email domains must be names, not address literals")
+	ErrUnqualifiedHost = errors.New("email domain must be fully qualified")
+)
+
+var profile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.Transitional(false),
+	idna.VerifyDNSLength(true),
+)
+
+// Address is a parsed addr-spec.
+type Address struct {
+	// Local is the unquoted local part.
+	Local string
+	// Domain is the lowercase Unicode form of the domain.
+	Domain string
+	// ASCIIDomain is the IDNA (punycode) form of the domain.
+	ASCIIDomain string
+}
+
+// Parse accepts a bare address such as "jane@example.com",
+// "\"jane doe\"@example.com" or "josé@bücher.de". Display names and angle
+// brackets are rejected because a user record holds one address.
+func Parse(s string) (*Address, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s, "<>") || !utf8.ValidString(s) {
+		return nil, ErrInvalid
+	}
+
+	parsed, err := mail.ParseAddress(s)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	if parsed.Name != "" {
+		return nil, ErrDisplayName
+	}
+
+	at := strings.LastIndex(parsed.Address, "@")
+	if at <= 0 || at == len(parsed.Address)-1 {
+		return nil, ErrInvalid
+	}
+	local, domain := parsed.Address[:at], parsed.Address[at+1:]
+
+	if len(local) > maxLocalLength {
+		return nil, ErrLocalTooLong
+	}
+	if strings.HasPrefix(domain, "[") {
+		return nil, ErrAddressLiteral
+	}
+
+	ascii, err := NormalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(ascii, ".") {
+		return nil, ErrUnqualifiedHost
+	}
+	if tld := ascii[strings.LastIndex(ascii, ".")+1:]; strings.Trim(tld, "0123456789") == "" {
+		return nil, ErrInvalidDomain
+	}
+	unicode, err := profile.ToUnicode(ascii)
+	if err != nil {
+		return nil, ErrInvalidDomain
+	}
+
+	a := &Address{Local: local, Domain: unicode, ASCIIDomain: ascii}
+	if len(a.ASCII()) > maxAddressLength {
+		return nil, ErrAddressTooLong
+	}
+	return a, nil
+}
+
+// NormalizeDomain returns the lowercase ASCII (punycode) form of domain, or
+// an error when it is not a valid domain name.
+func NormalizeDomain(domain string) (string, error) {
+	ascii, err := profile.ToASCII(strings.TrimSpace(domain))
+	if err != nil {
+		return "", ErrInvalidDomain
+	}
+	return strings.ToLower(strings.TrimSuffix(ascii, ".")), nil
+}
+
+// String is the display form: the local part as entered, quoted only when
+// needed, and the Unicode domain in lowercase.
+func (a *Address) String() string {
+	return quoteLocal(a.Local) + "@" + a.Domain
+}
+
+// ASCII is the form with a punycode domain, as used on the wire.
+func (a *Address) ASCII() string {
+	return quoteLocal(a.Local) + "@" + a.ASCIIDomain
+}
+
+// CanonicalOptions tune Canonical.
+type CanonicalOptions struct {
+	// FoldGmail drops dots and "+tag" suffixes from Gmail local parts, which
+	// Gmail ignores when delivering.
+	FoldGmail bool
+}
+
+var gmailDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+}
+
+// Canonical returns the key used to detect two spellings of the same mailbox:
+// lowercase local part and ASCII domain, optionally with Gmail folding.
+func (a *Address) Canonical(opts CanonicalOptions) string {
+	local := strings.ToLower(a.Local)
+	domain := a.ASCIIDomain
+
+	if opts.FoldGmail && gmailDomains[domain] {
+		if plus := strings.IndexByte(local, '+'); plus >= 0 {
+			local = local[:plus]
+		}
+		local = strings.ReplaceAll(local, ".", "")
+		domain = "gmail.com"
+	}
+
+	return quoteLocal(local) + "@" + domain
+}
+
+// quoteLocal returns local unchanged when it is a valid dot-atom and as a
+// quoted string otherwise.
+func quoteLocal(local string) string {
+	if isDotAtom(local) {
+		return local
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range local {
+		if r == '"' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func isDotAtom(s string) bool {
+	if s == "" || s[0] == '.' || s[len(s)-1] == '.' || strings.Contains(s, "..") {
+		return false
+	}
+	for _, r := range s {
+		if r != '.' && !isAtext(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isAtext reports whether r may appear unquoted in an atom, including UTF-8
+// characters as allowed by RFC 6532.
+func isAtext(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case r >= utf8.RuneSelf:
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-/=?^_`{|}~", r)
+}
@@ -0,0 +1,122 @@
+package email
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		display   string
+		canonical string
+	}{
+		{name: "plain", input: "jane@example.com", display: "jane@example.com", canonical: "jane@example.com"},
+		{name: "mixed case", input: "Jane.Doe@Example.COM", display: "Jane.Doe@example.com", canonical: "jane.doe@example.com"},
+		{name: "surrounding space", input: "  jane@example.com ", display: "jane@example.com", canonical: "jane@example.com"},
+		{name: "plus tag", input: "jane+news@example.com", display: "jane+news@example.com", canonical: "jane+news@example.com"},
+		{name: "quoted local", input: `"jane doe"@example.com`, display: `"jane doe"@example.com`, canonical: `"jane doe"@example.com`},
+		{name: "quoted dot-atom", input: `"jane"@example.com`, display: "jane@example.com", canonical: "jane@example.com"},
+		{name: "unicode local", input: "josé@example.com", display: "josé@example.com", canonical: "josé@example.com"},
+		{name: "idn domain", input: "info@Bücher.de", display: "info@bücher.de", canonical: "info@xn--bcher-kva.de"},
+		{name: "punycode domain", input: "info@xn--bcher-kva.de", display: "info@bücher.de", canonical: "info@xn--bcher-kva.de"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := Parse(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.display, addr.String())
+			assert.Equal(t, tt.canonical, addr.Canonical(CanonicalOptions{}))
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   error
+	}{
+		{name: "empty", input: "", err: ErrInvalid},
+		{name: "no at", input: "invalid-email", err: ErrInvalid},
+		{name: "double dot", input: "a..b@example.com", err: ErrInvalid},
+		{name: "two ats", input: "a@b@example.com", err: ErrInvalid},
+		{name: "display name", input: "Jane <jane@example.com>", err: ErrInvalid},
+		{name: "bare angle brackets", input: "<jane@example.com>", err: ErrInvalid},
+		{name: "unqualified host", input: "jane@localhost", err: ErrUnqualifiedHost},
+		{name: "address literal", input: "jane@[127.0.0.1]", err: ErrAddressLiteral},
+		{name: "numeric tld", input: "jane@10.0.0.1", err: ErrInvalidDomain},
+		{name: "bad label", input: "jane@-example.com", err: ErrInvalidDomain},
+		{name: "long local", input: strings.Repeat("a", 65) + "@example.com", err: ErrLocalTooLong},
+		{name: "long address", input: strings.Repeat("a", 64) + "@" + strings.Repeat(strings.Repeat("b", 50)+".", 4) + "com", err: ErrAddressTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestCanonical_FoldGmail(t *testing.T) {
+	fold := CanonicalOptions{FoldGmail: true}
+
+	for _, input := range []string{"jane.doe@gmail.com", "Jane.Doe+news@Gmail.com", "janedoe@googlemail.com"} {
+		addr, err := Parse(input)
+		assert.NoError(t, err)
+		assert.Equal(t, "janedoe@gmail.com", addr.Canonical(fold), input)
+	}
+
+	addr, err := Parse("jane.doe+news@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "jane.doe+news@example.com", addr.Canonical(fold))
+
+	addr, err = Parse("jane.doe+news@gmail.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "jane.doe+news@gmail.com", addr.Canonical(CanonicalOptions{}))
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"jane@example.com",
+		`"jane doe"@example.com`,
+		`"a\"b"@example.com`,
+		"josé@bücher.de",
+		"Jane.Doe+news@Gmail.com",
+		"a..b@example.com",
+		"Jane <jane@example.com>",
+		"jane@[127.0.0.1]",
+		"@",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		addr, err := Parse(input)
+		if err != nil {
+			return
+		}
+
+		// Both forms must parse back to the same mailbox.
+		for _, form := range []string{addr.String(), addr.ASCII()} {
+			again, err := Parse(form)
+			if err != nil {
+				t.Fatalf("Parse(%q) = %q, which does not parse: %v", input, form, err)
+			}
+			if again.Canonical(CanonicalOptions{}) != addr.Canonical(CanonicalOptions{}) {
+				t.Fatalf("canonical form of %q changed after round trip through %q", input, form)
+			}
+		}
+
+		canonical := addr.Canonical(CanonicalOptions{FoldGmail: true})
+		if !utf8.ValidString(canonical) || strings.ToLower(canonical) != canonical {
+			t.Fatalf("canonical form %q of %q is not lowercase UTF-8", canonical, input)
+		}
+	})
+}
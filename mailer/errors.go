@@ -0,0 +1,8 @@
+package mailer
+
+import "errors"
+
+var (
+	errHeaderNewline = errors.New("mail headers cannot contain line breaks")
+	errNoRecipient   = errors.New("message has no recipient")
+)
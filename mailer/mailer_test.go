@@ -0,0 +1,53 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"mime/quotedprintable"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter_Send(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewWriter(&buf, "Users <noreply@example.com>")
+
+	err := m.Send(context.Background(), Message{To: "jane@example.com", Subject: "Bestätigen", Body: "Hello Jane,\nyour code is abc.\n"})
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "From: Users <noreply@example.com>\r\n")
+	assert.Contains(t, out, "To: jane@example.com\r\n")
+	assert.Contains(t, out, "Subject: =?utf-8?q?Best=C3=A4tigen?=\r\n")
+
+	_, body, ok := strings.Cut(out, "\r\n\r\n")
+	require.True(t, ok)
+	decoded := new(bytes.Buffer)
+	_, err = decoded.ReadFrom(quotedprintable.NewReader(strings.NewReader(body)))
+	require.NoError(t, err)
+	assert.Contains(t, decoded.String(), "Hello Jane,\r\nyour code is abc.\r\n")
+}
+
+func TestWriter_RejectsHeaderInjection(t *testing.T) {
+	m := NewWriter(&bytes.Buffer{}, "noreply@example.com")
+	err := m.Send(context.Background(), Message{To: "jane@example.com\r\nBcc: eve@example.com", Subject: "Hi"})
+	assert.ErrorIs(t, err, errHeaderNewline)
+}
+
+func TestFile_Appends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.txt")
+	m := NewFile(path, "noreply@example.com")
+
+	require.NoError(t, m.Send(context.Background(), Message{To: "a@example.com", Subject: "One", Body: "1"}))
+	require.NoError(t, m.Send(context.Background(), Message{To: "b@example.com", Subject: "Two", Body: "2"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "MIME-Version: 1.0"))
+	assert.Contains(t, string(data), "To: b@example.com")
+}
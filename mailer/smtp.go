@@ -0,0 +1,106 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+
+	"github.com/sergey4qb/mf1-test/email"
+)
+
+// defaultSMTPTimeout bounds a delivery when ctx has no deadline.
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPConfig locates the mail server. Without Username no authentication is
+// attempted. Connections are upgraded with STARTTLS whenever the server
+// offers it, and credentials are only sent over TLS or to localhost.
+type SMTPConfig struct {
+	Addr     string
+	Username string
+	Password string
+	// From is the sender, e.g. "Users <noreply@example.com>".
+	From string
+}
+
+// SMTP delivers each message over a new connection.
+type SMTP struct {
+	cfg  SMTPConfig
+	from string
+}
+
+// NewSMTP checks cfg and returns a Mailer delivering through it.
+func NewSMTP(cfg SMTPConfig) (*SMTP, error) {
+	if _, _, err := net.SplitHostPort(cfg.Addr); err != nil {
+		return nil, fmt.Errorf("smtp address %q: %w", cfg.Addr, err)
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("smtp sender %q: %w", cfg.From, err)
+	}
+	return &SMTP{cfg: cfg, from: from.Address}, nil
+}
+
+func (m *SMTP) Send(ctx context.Context, msg Message) error {
+	to, err := email.Parse(msg.To)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errNoRecipient, err)
+	}
+	data, err := format(m.cfg.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", m.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultSMTPTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	host, _, _ := net.SplitHostPort(m.cfg.Addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if m.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to.ASCII()); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
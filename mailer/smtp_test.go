@@ -0,0 +1,110 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTP accepts one session and records the envelope and data.
+type fakeSMTP struct {
+	addr string
+	done chan struct{}
+
+	auth string
+	from string
+	rcpt []string
+	data string
+}
+
+func startFakeSMTP(t *testing.T, extensions ...string) *fakeSMTP {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+
+	s := &fakeSMTP{addr: lis.Addr().String(), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.serve(textproto.NewConn(conn), extensions)
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(c *textproto.Conn, extensions []string) {
+	c.PrintfLine("220 fake ESMTP")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			for _, ext := range extensions {
+				c.PrintfLine("250-%s", ext)
+			}
+			c.PrintfLine("250 fake")
+		case "AUTH":
+			s.auth = arg
+			c.PrintfLine("235 ok")
+		case "MAIL":
+			s.from = arg
+			c.PrintfLine("250 ok")
+		case "RCPT":
+			s.rcpt = append(s.rcpt, arg)
+			c.PrintfLine("250 ok")
+		case "DATA":
+			c.PrintfLine("354 go ahead")
+			data, err := c.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.data = string(data)
+			c.PrintfLine("250 queued")
+		case "QUIT":
+			c.PrintfLine("221 bye")
+			return
+		default:
+			c.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func TestSMTP_Send(t *testing.T) {
+	srv := startFakeSMTP(t, "AUTH PLAIN")
+	m, err := NewSMTP(SMTPConfig{Addr: srv.addr, Username: "mailer", Password: "secret", From: "Users <noreply@example.com>"})
+	require.NoError(t, err)
+
+	err = m.Send(context.Background(), Message{To: "info@bücher.de", Subject: "Verify", Body: ".hidden line\nbye"})
+	require.NoError(t, err)
+	<-srv.done
+
+	assert.True(t, strings.HasPrefix(srv.auth, "PLAIN "))
+	assert.Equal(t, "FROM:<noreply@example.com>", srv.from)
+	assert.Equal(t, []string{"TO:<info@xn--bcher-kva.de>"}, srv.rcpt)
+	assert.Contains(t, srv.data, "Subject: Verify\n")
+	assert.Contains(t, srv.data, "\n.hidden line\n")
+}
+
+func TestSMTP_Errors(t *testing.T) {
+	_, err := NewSMTP(SMTPConfig{Addr: "localhost", From: "noreply@example.com"})
+	assert.Error(t, err)
+	_, err = NewSMTP(SMTPConfig{Addr: "localhost:25", From: "not an address"})
+	assert.Error(t, err)
+
+	m, err := NewSMTP(SMTPConfig{Addr: "127.0.0.1:1", From: "noreply@example.com"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, m.Send(context.Background(), Message{To: "Jane <jane@example.com>"}), errNoRecipient)
+}
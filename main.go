@@ -1,8 +1,9 @@
 package main
 
 import (
-	"github.com/sergey4qb/mf1-test/application"
+	"github.com/sergey4qb/mf1-test/cli"
 	"log"
+	"os"
 )
 
 func main() {
@@ -11,13 +12,7 @@ func main() {
 	// 	log.Fatalf("Error loading .env file")
 	// }
 
-	app, err := application.New()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	err = app.Run()
-	if err != nil {
+	if err := cli.Run(os.Args[1:]); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditOutcome tells whether an audited call succeeded.
+type AuditOutcome string
+
+const (
+	AuditSuccess AuditOutcome = "success"
+	AuditFailure AuditOutcome = "failure"
+)
+
+// AuditEntry records one call that viewed or changed users. Entries form a
+// chain: each holds the hash of the one before it, so removing or editing
+// an entry breaks every later hash.
+type AuditEntry struct {
+	// Sequence numbers the entries of a log from 1.
+	Sequence       int64     `json:"seq"`
+	At             time.Time `json:"at"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Actor          string    `json:"actor"`
+	// Action is what was done, e.g. "user.update".
+	Action string `json:"action"`
+	// Target is the ID of the user acted on, empty for listings.
+	Target string `json:"target,omitempty"`
+	// BeforeHash and AfterHash are SHA-256 hashes of the user before and
+	// after the call, empty when it did not exist.
+	BeforeHash string       `json:"before_hash,omitempty"`
+	AfterHash  string       `json:"after_hash,omitempty"`
+	RequestID  string       `json:"request_id,omitempty"`
+	Peer       string       `json:"peer,omitempty"`
+	Outcome    AuditOutcome `json:"outcome"`
+	Error      string       `json:"error,omitempty"`
+
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType names a change to a user published to other systems.
+type EventType string
+
+const (
+	EventUserCreated EventType = "UserCreated"
+	EventUserUpdated EventType = "UserUpdated"
+	EventUserDeleted EventType = "UserDeleted"
+)
+
+// Event is a change to a user as published to other systems. Events may be
+// delivered more than once; consumers tell repeats apart by ID.
+type Event struct {
+	ID             uuid.UUID `json:"id"`
+	Type           EventType `json:"type"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	At             time.Time `json:"at"`
+	// User is the user after the change, without credentials. It is nil
+	// for deletions.
+	User *User `json:"user,omitempty"`
+}
+
+// PendingEvent is an event waiting in the outbox, with how its delivery has
+// gone so far.
+type PendingEvent struct {
+	Event
+	// Delivered names the sinks that accepted the event already.
+	Delivered []string `json:"delivered,omitempty"`
+	// Attempts counts the failed deliveries; NextAttempt is when the next
+	// one is due, nil when it is due now.
+	Attempts    int        `json:"attempts,omitempty"`
+	NextAttempt *time.Time `json:"next_attempt,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// DeadLetter is an event given up on after too many failed deliveries.
+type DeadLetter struct {
+	PendingEvent
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// Clone returns a copy of e that shares no user or slices with it.
+func (e PendingEvent) Clone() PendingEvent {
+	if e.User != nil {
+		u := e.User.Clone()
+		e.User = &u
+	}
+	e.Delivered = slices.Clone(e.Delivered)
+	if e.NextAttempt != nil {
+		next := *e.NextAttempt
+		e.NextAttempt = &next
+	}
+	return e
+}
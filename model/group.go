@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Group is a named set of users, e.g. a team.
+type Group struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Name           string    `json:"name"`
+	// NameKey is Name folded for case- and accent-insensitive comparison;
+	// group names are unique by it.
+	NameKey     string    `json:"name_key"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Membership puts a user in a group.
+type Membership struct {
+	GroupID        uuid.UUID `json:"group_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	AddedAt        time.Time `json:"added_at"`
+}
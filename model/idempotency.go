@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// IdempotencyRecord is the outcome of a mutating call made with an
+// idempotency key, kept so a retried call can be answered without running
+// it again.
+type IdempotencyRecord struct {
+	Key         string `json:"key"`
+	Method      string `json:"method"`
+	RequestHash string `json:"request_hash"`
+	// ResponseType names the stored message so it can be decoded on replay.
+	ResponseType string    `json:"response_type"`
+	Response     []byte    `json:"response"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (r *IdempotencyRecord) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
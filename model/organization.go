@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization is a customer hosted on the deployment. Users belong to
+// exactly one organization and are invisible to the others.
+type Organization struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	// NameKey is Name folded for case- and accent-insensitive comparison;
+	// organization names are unique by it.
+	NameKey   string    `json:"name_key"`
+	CreatedAt time.Time `json:"created_at"`
+}
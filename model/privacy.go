@@ -0,0 +1,39 @@
+package model
+
+import (
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tombstone records that the data about a user was erased, so the erasure
+// stays traceable once the data is gone.
+type Tombstone struct {
+	UserID         uuid.UUID `json:"user_id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	ErasedAt       time.Time `json:"erased_at"`
+	Actor          string    `json:"actor"`
+	RequestID      string    `json:"request_id,omitempty"`
+	// Erased counts the records each store removed or pseudonymized, by
+	// store name.
+	Erased map[string]int `json:"erased"`
+}
+
+// Clone returns a copy of t that shares no map with it.
+func (t Tombstone) Clone() Tombstone {
+	t.Erased = maps.Clone(t.Erased)
+	return t
+}
+
+// DataExport is everything stored about a user.
+type DataExport struct {
+	UserID         uuid.UUID `json:"user_id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	ExportedAt     time.Time `json:"exported_at"`
+	// Data holds what each store keeps about the user, by store name.
+	// Stores keeping nothing are left out.
+	Data map[string]any `json:"data"`
+	// Tombstones are the earlier erasures of the user, oldest first.
+	Tombstones []Tombstone `json:"tombstones,omitempty"`
+}
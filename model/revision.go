@@ -0,0 +1,55 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevisionAction is the kind of change a revision records.
+type RevisionAction string
+
+const (
+	RevisionCreate RevisionAction = "create"
+	RevisionUpdate RevisionAction = "update"
+	RevisionDelete RevisionAction = "delete"
+)
+
+// UserRevision is one version of a user: who changed it, when, and how.
+type UserRevision struct {
+	UserID         uuid.UUID `json:"user_id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	// Number counts the revisions of a user from 1.
+	Number int64          `json:"number"`
+	Action RevisionAction `json:"action"`
+	Actor  string         `json:"actor"`
+	At     time.Time      `json:"at"`
+	// Changes are the fields that differ from the previous revision.
+	Changes []FieldChange `json:"changes,omitempty"`
+	// User is the user as saved by this revision, without credentials. It
+	// is nil for deletions.
+	User *User `json:"user,omitempty"`
+	// RevertedFrom is the revision this one restored, or 0.
+	RevertedFrom int64 `json:"reverted_from,omitempty"`
+}
+
+// FieldChange is a field's value before and after a revision. Field is the
+// JSON name of the user field, with nested values such as attributes
+// joined by dots, e.g. "attributes.department". Old or New is nil when the
+// field was unset.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old,omitempty"`
+	New   any    `json:"new,omitempty"`
+}
+
+// Clone returns a copy of r that shares no user or change list with it.
+func (r UserRevision) Clone() UserRevision {
+	r.Changes = slices.Clone(r.Changes)
+	if r.User != nil {
+		u := r.User.Clone()
+		r.User = &u
+	}
+	return r
+}
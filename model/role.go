@@ -0,0 +1,51 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Role is a named set of permissions. Permissions are strings such as
+// "documents:read"; services/role describes the accepted format.
+type Role struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Name           string    `json:"name"`
+	// NameKey is Name folded for case- and accent-insensitive comparison;
+	// role names are unique by it.
+	NameKey     string    `json:"name_key"`
+	Description string    `json:"description,omitempty"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Clone returns a deep copy of r.
+func (r Role) Clone() Role {
+	r.Permissions = slices.Clone(r.Permissions)
+	return r
+}
+
+// PrincipalKind is what a role is assigned to.
+type PrincipalKind string
+
+const (
+	PrincipalUser  PrincipalKind = "user"
+	PrincipalGroup PrincipalKind = "group"
+)
+
+// Principal is a user or a group roles can be assigned to. Roles assigned
+// to a group apply to each of its members.
+type Principal struct {
+	Kind PrincipalKind `json:"kind"`
+	ID   uuid.UUID     `json:"id"`
+}
+
+// RoleAssignment grants a role to a principal.
+type RoleAssignment struct {
+	RoleID         uuid.UUID `json:"role_id"`
+	Principal      Principal `json:"principal"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	AssignedAt     time.Time `json:"assigned_at"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is an issued refresh token. Only its hash is kept. Every
+// refresh replaces the token with a new one of the same family, so a family
+// is one login session.
+type RefreshToken struct {
+	Hash     string    `json:"hash"`
+	FamilyID uuid.UUID `json:"family_id"`
+	UserID   uuid.UUID `json:"user_id"`
+	// OrganizationID is the organization of the user; refreshes act for it
+	// whatever organization the request names.
+	OrganizationID uuid.UUID `json:"organization_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	// UsedAt is set once the token has been exchanged; presenting it again
+	// means it leaked.
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (t *RefreshToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// SigningKey is a key access tokens are signed with. Retired keys no longer
+// sign but are published until the tokens they signed have expired.
+type SigningKey struct {
+	ID        string `json:"id"`
+	Algorithm string `json:"algorithm"`
+	// PrivateKey is the PKCS #8 DER encoding of the key.
+	PrivateKey []byte     `json:"private_key"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RetiredAt  *time.Time `json:"retired_at,omitempty"`
+}
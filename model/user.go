@@ -1,9 +1,150 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserStatus is where a user is in its lifecycle; services/user defines the
+// allowed transitions.
+type UserStatus string
+
+const (
+	StatusPending   UserStatus = "pending"
+	StatusActive    UserStatus = "active"
+	StatusSuspended UserStatus = "suspended"
+	StatusDisabled  UserStatus = "disabled"
+)
+
+// StatusChange records the latest status transition of a user.
+type StatusChange struct {
+	From   UserStatus `json:"from"`
+	Reason string     `json:"reason,omitempty"`
+	Actor  string     `json:"actor"`
+	At     time.Time  `json:"at"`
+}
 
 type User struct {
 	ID    uuid.UUID `json:"id"`
 	Name  string    `json:"name"`
 	Email string    `json:"email"`
+	// EmailCanonical identifies the mailbox behind Email and is what email
+	// uniqueness is enforced on.
+	EmailCanonical string `json:"email_canonical,omitempty"`
+	// EmailVerified is set once the user proves they receive mail at Email
+	// and cleared when Email changes.
+	EmailVerified bool `json:"email_verified,omitempty"`
+	// NameKey is Name folded for case- and accent-insensitive search and
+	// ordering.
+	NameKey string `json:"name_key,omitempty"`
+
+	// Optional profile fields.
+	GivenName  string `json:"given_name,omitempty"`
+	FamilyName string `json:"family_name,omitempty"`
+	// Phone is in E.164 format, e.g. +14155550100.
+	Phone string `json:"phone,omitempty"`
+	// Locale is a BCP 47 language tag, e.g. en-US.
+	Locale string `json:"locale,omitempty"`
+	// TimeZone is an IANA time zone name, e.g. Europe/Berlin.
+	TimeZone  string `json:"time_zone,omitempty"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+
+	// Attributes are custom values declared in the attribute schema. Values
+	// are JSON types: string, float64, bool or []any of those.
+	Attributes map[string]any `json:"attributes,omitempty"`
+
+	Status UserStatus `json:"status"`
+	// StatusChange is nil until the status first changes.
+	StatusChange *StatusChange `json:"status_change,omitempty"`
+
+	// PasswordHash is the argon2id hash of the user's password, empty when
+	// none is set.
+	PasswordHash string `json:"password_hash,omitempty"`
+	// LoginFailures are the times of recent failed logins, kept to decide
+	// on a lockout; LockedUntil is set while logins are refused.
+	LoginFailures []time.Time `json:"login_failures,omitempty"`
+	LockedUntil   *time.Time  `json:"locked_until,omitempty"`
+
+	// MFA is the user's TOTP enrollment, nil when there is none.
+	MFA *MFA `json:"mfa,omitempty"`
+}
+
+// MFA is a TOTP enrollment. It protects logins once confirmed with a first
+// code.
+type MFA struct {
+	// Secret is the TOTP secret sealed with the server's MFA key.
+	Secret      []byte     `json:"secret"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	// LastCounter is the time step of the last accepted code. Codes of that
+	// step and earlier are refused, so each code works once.
+	LastCounter int64 `json:"last_counter,omitempty"`
+	// RecoveryCodes are keyed hashes of the unused recovery codes.
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// Enabled reports whether logins need a second factor.
+func (m *MFA) Enabled() bool {
+	return m != nil && m.ConfirmedAt != nil
+}
+
+// Clone returns a copy of u that shares no maps or slices with it.
+func (u User) Clone() User {
+	u.Attributes = cloneValue(u.Attributes).(map[string]any)
+	if u.StatusChange != nil {
+		change := *u.StatusChange
+		u.StatusChange = &change
+	}
+	u.LoginFailures = slices.Clone(u.LoginFailures)
+	if u.LockedUntil != nil {
+		until := *u.LockedUntil
+		u.LockedUntil = &until
+	}
+	if u.MFA != nil {
+		mfa := *u.MFA
+		mfa.Secret = slices.Clone(mfa.Secret)
+		if mfa.ConfirmedAt != nil {
+			at := *mfa.ConfirmedAt
+			mfa.ConfirmedAt = &at
+		}
+		mfa.RecoveryCodes = slices.Clone(mfa.RecoveryCodes)
+		u.MFA = &mfa
+	}
+	return u
+}
+
+// WithoutCredentials returns a copy of u without its password hash, login
+// failures and MFA enrollment, for keeping or sending outside the user store.
+func (u User) WithoutCredentials() User {
+	u = u.Clone()
+	u.PasswordHash = ""
+	u.LoginFailures = nil
+	u.LockedUntil = nil
+	u.MFA = nil
+	return u
+}
+
+func cloneValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		if v == nil {
+			return v
+		}
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = cloneValue(e)
+		}
+		return out
+	case []any:
+		if v == nil {
+			return v
+		}
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = cloneValue(e)
+		}
+		return out
+	}
+	return v
 }
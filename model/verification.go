@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VerificationToken is an issued email verification token. Only the hash of
+// the token is kept, so a leaked store cannot be used to verify addresses.
+type VerificationToken struct {
+	Hash   string    `json:"hash"`
+	UserID uuid.UUID `json:"user_id"`
+	// OrganizationID is the organization of the user, so a token works
+	// from a link that names no organization.
+	OrganizationID uuid.UUID `json:"organization_id"`
+	// Email is the canonical address the token was sent to; the token is
+	// void once the user's email changes.
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (t *VerificationToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
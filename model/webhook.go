@@ -0,0 +1,86 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook is a partner endpoint that receives the events of its
+// organization as signed HTTP callbacks.
+type Webhook struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	URL            string    `json:"url"`
+	// EventTypes are the events the webhook receives; empty means all.
+	EventTypes []EventType `json:"event_types,omitempty"`
+	// Secret signs the deliveries; see services/webhook.Sign.
+	Secret string `json:"secret"`
+	// Failures counts the failed attempts since the last successful one.
+	Failures int `json:"failures,omitempty"`
+	// DisabledAt is set when the webhook stopped receiving events, by
+	// request or after too many failures in a row.
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Receives reports whether the webhook wants events of type t.
+func (w Webhook) Receives(t EventType) bool {
+	return w.DisabledAt == nil && (len(w.EventTypes) == 0 || slices.Contains(w.EventTypes, t))
+}
+
+// Clone returns a copy of w that shares no slices or pointers with it.
+func (w Webhook) Clone() Webhook {
+	w.EventTypes = slices.Clone(w.EventTypes)
+	if w.DisabledAt != nil {
+		at := *w.DisabledAt
+		w.DisabledAt = &at
+	}
+	return w
+}
+
+// WebhookDelivery is an event queued for a webhook.
+type WebhookDelivery struct {
+	ID             uuid.UUID `json:"id"`
+	WebhookID      uuid.UUID `json:"webhook_id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Event          Event     `json:"event"`
+	// Attempts counts the failed attempts; NextAttempt is when the next one
+	// is due.
+	Attempts    int       `json:"attempts,omitempty"`
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+// Clone returns a copy of d that shares no user with it.
+func (d WebhookDelivery) Clone() WebhookDelivery {
+	if d.Event.User != nil {
+		u := d.Event.User.Clone()
+		d.Event.User = &u
+	}
+	return d
+}
+
+// WebhookAttempt is one HTTP request made to deliver an event to a webhook.
+type WebhookAttempt struct {
+	// Seq orders the attempts of a repository; it is set when the attempt
+	// is recorded.
+	Seq            int64     `json:"seq"`
+	WebhookID      uuid.UUID `json:"webhook_id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	DeliveryID     uuid.UUID `json:"delivery_id"`
+	EventID        uuid.UUID `json:"event_id"`
+	EventType      EventType `json:"event_type"`
+	// Attempt is 1 for the first request of a delivery.
+	Attempt int       `json:"attempt"`
+	At      time.Time `json:"at"`
+	// StatusCode is the response status, 0 when no response came.
+	StatusCode int           `json:"status_code,omitempty"`
+	Duration   time.Duration `json:"duration"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// Succeeded reports whether the endpoint accepted the delivery.
+func (a WebhookAttempt) Succeeded() bool {
+	return a.Error == ""
+}
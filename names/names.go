@@ -0,0 +1,71 @@
+// Package names normalizes person names and derives the folded keys used to
+// search and sort them.
+package names
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength is the longest accepted name in user-perceived characters
+// (grapheme clusters), so "é" counts once whether or not it is precomposed.
+const MaxLength = 200
+
+var (
+	ErrControlCharacter = errors.New("name contains control characters")
+	ErrTooLong          = errors.New("name is longer than 200 characters")
+)
+
+// bidiControls can reorder surrounding text and are used to spoof names.
+var bidiControls = runes.In(&unicode.RangeTable{R16: []unicode.Range16{
+	{Lo: 0x202a, Hi: 0x202e, Stride: 1},
+	{Lo: 0x2066, Hi: 0x2069, Stride: 1},
+}})
+
+var folder = cases.Fold()
+
+// Normalize returns s in NFC with surrounding whitespace trimmed and inner
+// runs of whitespace collapsed to one space. A name made only of whitespace
+// normalizes to "".
+func Normalize(s string) string {
+	return strings.Join(strings.Fields(norm.NFC.String(s)), " ")
+}
+
+// Validate reports control characters and names longer than MaxLength. It
+// expects a normalized name; tabs and newlines are removed by Normalize.
+// Format characters scripts rely on, such as the zero width joiner, are allowed.
+func Validate(s string) error {
+	for _, r := range s {
+		if unicode.IsControl(r) || bidiControls.Contains(r) {
+			return ErrControlCharacter
+		}
+	}
+	if Length(s) > MaxLength {
+		return ErrTooLong
+	}
+	return nil
+}
+
+// Length returns the number of grapheme clusters in s.
+func Length(s string) int {
+	return uniseg.GraphemeClusterCount(s)
+}
+
+// SearchKey folds case, diacritics and compatibility forms so that "José",
+// "JOSE" and "jose" share a key. Keys are for matching and ordering only and
+// are never shown.
+func SearchKey(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFKC)
+	folded, _, err := transform.String(t, Normalize(s))
+	if err != nil {
+		folded = Normalize(s)
+	}
+	return folder.String(folded)
+}
@@ -0,0 +1,52 @@
+package names
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "decomposed to NFC", input: "José", want: "José"},
+		{name: "trim", input: "  Jane Doe \t", want: "Jane Doe"},
+		{name: "collapse", input: "Jane \n  Doe", want: "Jane Doe"},
+		{name: "whitespace only", input: " \t　 ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.input))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("Jane Doe"))
+	assert.NoError(t, Validate("क्‍ष"), "zero width joiner is allowed")
+	assert.ErrorIs(t, Validate("Jane\x00Doe"), ErrControlCharacter)
+	assert.ErrorIs(t, Validate("Jane‮eoD"), ErrControlCharacter)
+
+	// Combining marks do not count towards the limit.
+	assert.NoError(t, Validate(strings.Repeat("é", MaxLength)))
+	assert.ErrorIs(t, Validate(strings.Repeat("e", MaxLength+1)), ErrTooLong)
+}
+
+func TestLength(t *testing.T) {
+	assert.Equal(t, 4, Length("José"))
+	assert.Equal(t, 1, Length("\U0001F469‍\U0001F4BB"))
+}
+
+func TestSearchKey(t *testing.T) {
+	for _, input := range []string{"José", "José", "JOSE", " jose "} {
+		assert.Equal(t, "jose", SearchKey(input), input)
+	}
+	assert.Equal(t, "strasse", SearchKey("Straße"))
+	assert.Equal(t, "fiona", SearchKey("ﬁona"))
+	assert.Equal(t, "jane doe", SearchKey("Jane\t DOE"))
+}
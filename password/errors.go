@@ -0,0 +1,8 @@
+package password
+
+import "errors"
+
+var (
+	ErrInvalidParams = errors.New("invalid argon2id parameters")
+	ErrMalformedHash = errors.New("malformed password hash")
+)
@@ -0,0 +1,73 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testParams keep tests fast; real deployments use DefaultParams.
+var testParams = Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHashAndVerify(t *testing.T) {
+	encoded, err := Hash("correct horse", testParams)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$"))
+
+	ok, err := Verify("correct horse", encoded)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = Verify("correct horsE", encoded)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	other, err := Hash("correct horse", testParams)
+	require.NoError(t, err)
+	assert.NotEqual(t, encoded, other, "salts must differ")
+}
+
+func TestNeedsRehash(t *testing.T) {
+	encoded, err := Hash("correct horse", testParams)
+	require.NoError(t, err)
+
+	assert.False(t, NeedsRehash(encoded, testParams))
+	stronger := testParams
+	stronger.Iterations = 2
+	assert.True(t, NeedsRehash(encoded, stronger))
+	assert.True(t, NeedsRehash("$2a$10$bcrypt", testParams))
+}
+
+func TestVerify_MalformedHash(t *testing.T) {
+	for _, encoded := range []string{
+		"",
+		"plain",
+		"$argon2i$v=19$m=64,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5a2V5a2V5a2V5a2V5",
+		"$argon2id$v=18$m=64,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=64,t=0,p=1$c2FsdHNhbHQ$a2V5a2V5a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5a2V5a2V5a2V5a2V5a2V5",
+	} {
+		_, err := Verify("x", encoded)
+		assert.ErrorIs(t, err, ErrMalformedHash, encoded)
+	}
+}
+
+func TestHash_InvalidParams(t *testing.T) {
+	_, err := Hash("x", Params{})
+	assert.ErrorIs(t, err, ErrInvalidParams)
+}
+
+func TestPolicy_Violations(t *testing.T) {
+	p := DefaultPolicy
+	assert.Empty(t, p.Violations("a long and unusual passphrase"))
+	assert.Equal(t, []string{"must be at least 12 characters"}, p.Violations("short"))
+	assert.Equal(t, []string{"must be at most 128 characters"}, p.Violations(strings.Repeat("x", 129)))
+	assert.Equal(t, []string{"is too common"}, p.Violations("Password1234"))
+	assert.Equal(t, []string{"must not contain control characters"}, p.Violations("tab\tin the middle"))
+	assert.Equal(t, []string{"must not contain your name or email"}, p.Violations("JaneDoe-rocks-2024", "jane@example.com", "Jane Doe", "janedoe"))
+	assert.Empty(t, p.Violations("mixed up little phrase", "Al"))
+	// Length counts characters, not bytes.
+	assert.Empty(t, p.Violations("ääääääääääää"))
+}
@@ -0,0 +1,78 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Policy decides which passwords are acceptable. It follows NIST SP 800-63B:
+// length matters and composition rules do not, but passwords that are common
+// or derived from the account are refused.
+type Policy struct {
+	MinLength int
+	// MaxLength bounds the work a single hash does.
+	MaxLength int
+}
+
+// DefaultPolicy is used unless the deployment configures another.
+var DefaultPolicy = Policy{MinLength: 12, MaxLength: 128}
+
+// commonPasswords are refused regardless of length. The list is short on
+// purpose: the minimum length already rules out most breached passwords.
+var commonPasswords = map[string]bool{
+	"123456789012":              true,
+	"1234567890123":             true,
+	"12345678901234":            true,
+	"123456123456":              true,
+	"111111111111":              true,
+	"000000000000":              true,
+	"qwertyuiopas":              true,
+	"qwertyuiop123":             true,
+	"qwerty123456":              true,
+	"1q2w3e4r5t6y":              true,
+	"passwordpassword":          true,
+	"password1234":              true,
+	"password12345":             true,
+	"password123456":            true,
+	"iloveyou1234":              true,
+	"letmein12345":              true,
+	"welcome12345":              true,
+	"administrator":             true,
+	"changemenow1":              true,
+	"correcthorsebatterystaple": true,
+}
+
+// Violations lists every way password breaks the policy. related holds
+// account values the password must not contain, e.g. the name or email;
+// values shorter than four characters are ignored.
+func (p Policy) Violations(password string, related ...string) []string {
+	var violations []string
+
+	n := utf8.RuneCountInString(norm.NFC.String(password))
+	if p.MinLength > 0 && n < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && n > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("must be at most %d characters", p.MaxLength))
+	}
+	if !utf8.ValidString(password) || strings.ContainsFunc(password, unicode.IsControl) {
+		violations = append(violations, "must not contain control characters")
+	}
+
+	folded := strings.ToLower(password)
+	if commonPasswords[folded] {
+		violations = append(violations, "is too common")
+	}
+	for _, value := range related {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if utf8.RuneCountInString(value) >= 4 && strings.Contains(folded, value) {
+			violations = append(violations, "must not contain your name or email")
+			break
+		}
+	}
+	return violations
+}
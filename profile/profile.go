@@ -0,0 +1,90 @@
+// Package profile normalizes and validates the optional profile fields of a
+// user: phone number, locale, time zone and avatar URL.
+package profile
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	// Time zones are checked against the embedded IANA database so that
+	// validation does not depend on the zoneinfo installed on the host.
+	_ "time/tzdata"
+
+	"golang.org/x/text/language"
+)
+
+const maxAvatarURLLength = 2048
+
+var (
+	ErrInvalidPhone     = errors.New("phone must be an E.164 number such as +14155550100")
+	ErrInvalidLocale    = errors.New("locale must be a BCP 47 language tag such as en-US")
+	ErrInvalidTimeZone  = errors.New("time zone must be an IANA name such as Europe/Berlin")
+	ErrInvalidAvatarURL = errors.New("avatar URL must be an absolute http or https URL")
+)
+
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// phoneSeparators are the formatting characters people type between digits.
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+
+// NormalizePhone drops spaces, hyphens, dots and parentheses, so
+// "+1 (415) 555-0100" becomes "+14155550100".
+func NormalizePhone(s string) string {
+	return phoneSeparators.Replace(strings.TrimSpace(s))
+}
+
+// ValidatePhone requires a normalized E.164 number.
+func ValidatePhone(s string) error {
+	if !e164.MatchString(s) {
+		return ErrInvalidPhone
+	}
+	return nil
+}
+
+// NormalizeLocale returns the canonical form of a BCP 47 tag, e.g. "en-US"
+// for "EN_us". Values that do not parse are returned trimmed for
+// ValidateLocale to reject.
+func NormalizeLocale(s string) string {
+	s = strings.TrimSpace(s)
+	tag, err := language.Parse(s)
+	if err != nil {
+		return s
+	}
+	return tag.String()
+}
+
+// ValidateLocale requires a well-formed BCP 47 language tag.
+func ValidateLocale(s string) error {
+	tag, err := language.Parse(s)
+	if err != nil || tag == language.Und {
+		return ErrInvalidLocale
+	}
+	return nil
+}
+
+// ValidateTimeZone requires an IANA time zone name. "Local" is rejected
+// because it means the server's zone, not the user's.
+func ValidateTimeZone(s string) error {
+	if s == "" || s == "Local" {
+		return ErrInvalidTimeZone
+	}
+	if _, err := time.LoadLocation(s); err != nil {
+		return ErrInvalidTimeZone
+	}
+	return nil
+}
+
+// ValidateAvatarURL requires an absolute http or https URL without
+// credentials.
+func ValidateAvatarURL(s string) error {
+	if len(s) > maxAvatarURLLength {
+		return ErrInvalidAvatarURL
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return ErrInvalidAvatarURL
+	}
+	return nil
+}
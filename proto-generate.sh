@@ -13,4 +13,64 @@ CreateUserProto() {
     proto/user.proto
 }
 
+CreateGroupProto() {
+  echo "-> Processing: group.proto"
+  protoc -I="./proto" \
+    --go_out="./proto/pb" \
+    --go_opt=Mgroup.proto="." \
+    --go-grpc_out=require_unimplemented_servers=false:"./proto/pb" \
+    --go-grpc_opt=Mgroup.proto="." \
+    --experimental_allow_proto3_optional \
+    proto/group.proto
+}
+
+CreateRoleProto() {
+  echo "-> Processing: role.proto"
+  protoc -I="./proto" \
+    --go_out="./proto/pb" \
+    --go_opt=Mrole.proto="." \
+    --go-grpc_out=require_unimplemented_servers=false:"./proto/pb" \
+    --go-grpc_opt=Mrole.proto="." \
+    --experimental_allow_proto3_optional \
+    proto/role.proto
+}
+
+CreateOrganizationProto() {
+  echo "-> Processing: organization.proto"
+  protoc -I="./proto" \
+    --go_out="./proto/pb" \
+    --go_opt=Morganization.proto="." \
+    --go-grpc_out=require_unimplemented_servers=false:"./proto/pb" \
+    --go-grpc_opt=Morganization.proto="." \
+    --experimental_allow_proto3_optional \
+    proto/organization.proto
+}
+
+CreateAuditProto() {
+  echo "-> Processing: audit.proto"
+  protoc -I="./proto" \
+    --go_out="./proto/pb" \
+    --go_opt=Maudit.proto="." \
+    --go-grpc_out=require_unimplemented_servers=false:"./proto/pb" \
+    --go-grpc_opt=Maudit.proto="." \
+    --experimental_allow_proto3_optional \
+    proto/audit.proto
+}
+
+CreateWebhookProto() {
+  echo "-> Processing: webhook.proto"
+  protoc -I="./proto" \
+    --go_out="./proto/pb" \
+    --go_opt=Mwebhook.proto="." \
+    --go-grpc_out=require_unimplemented_servers=false:"./proto/pb" \
+    --go-grpc_opt=Mwebhook.proto="." \
+    --experimental_allow_proto3_optional \
+    proto/webhook.proto
+}
+
 CreateUserProto
+CreateGroupProto
+CreateRoleProto
+CreateOrganizationProto
+CreateAuditProto
+CreateWebhookProto
@@ -0,0 +1,161 @@
+// Package audit stores the audit log: an append-only, hash-chained record of
+// who viewed or changed users, see Verify.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+// Repository stores audit entries. Entries are appended for the organization
+// in the context and only its entries are listed. Implementations must be
+// safe for concurrent use, never change an appended entry and honour context
+// cancellation.
+type Repository interface {
+	// Append adds entry to the end of the log, setting its Sequence,
+	// OrganizationID and hashes.
+	Append(ctx context.Context, entry *model.AuditEntry) error
+	// List returns the entries matching filter in the order they were
+	// appended.
+	List(ctx context.Context, filter Filter) ([]model.AuditEntry, error)
+}
+
+// fileAuditRepository writes one JSON entry per line and only ever appends
+// to the file.
+type fileAuditRepository struct {
+	filePath string
+	mu       sync.Mutex
+	// last is the newest entry, and size the size of the file once it was
+	// written. A different size means another process appended since.
+	last *model.AuditEntry
+	size int64
+}
+
+func NewFile(filePath string) (Repository, error) {
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errCreateAuditFile
+	}
+	f.Close()
+
+	r := &fileAuditRepository{filePath: filePath}
+	if err := r.loadLastNoLock(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *fileAuditRepository) Append(ctx context.Context, entry *model.AuditEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, err := os.OpenFile(r.filePath, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() != r.size {
+		if err := r.loadLastNoLock(); err != nil {
+			return err
+		}
+	}
+
+	entry.OrganizationID = tenant.FromContext(ctx)
+	if err := seal(entry, r.last); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	last := *entry
+	r.last = &last
+	r.size = info.Size() + int64(len(data)) + 1
+	return nil
+}
+
+func (r *fileAuditRepository) List(ctx context.Context, filter Filter) ([]model.AuditEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org := tenant.FromContext(ctx)
+	var entries []model.AuditEntry
+	err := r.scanNoLock(func(entry *model.AuditEntry) bool {
+		if filter.matches(org, entry) {
+			entries = append(entries, *entry)
+		}
+		return !filter.full(entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// loadLastNoLock reads the newest entry and the size of the file.
+func (r *fileAuditRepository) loadLastNoLock() error {
+	info, err := os.Stat(r.filePath)
+	if err != nil {
+		return err
+	}
+
+	var last *model.AuditEntry
+	err = r.scanNoLock(func(entry *model.AuditEntry) bool {
+		last = entry
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	r.last = last
+	r.size = info.Size()
+	return nil
+}
+
+// scanNoLock calls fn with each entry of the file until fn returns false.
+func (r *fileAuditRepository) scanNoLock(fn func(entry *model.AuditEntry) bool) error {
+	f, err := os.Open(r.filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		entry := &model.AuditEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			return fmt.Errorf("%s line %d: %w", r.filePath, line, err)
+		}
+		if !fn(entry) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,125 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/audit"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+func writeLog(t *testing.T, n int) (string, []model.AuditEntry) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	repo, err := audit.NewFile(path)
+	require.NoError(t, err)
+	var entries []model.AuditEntry
+	for i := 0; i < n; i++ {
+		e := &model.AuditEntry{At: time.Now().UTC(), Actor: "alice", Action: "user.view", Target: "jane", Outcome: model.AuditSuccess}
+		require.NoError(t, repo.Append(context.Background(), e))
+		entries = append(entries, *e)
+	}
+	return path, entries
+}
+
+func TestVerify(t *testing.T) {
+	path, entries := writeLog(t, 3)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	n, head, err := audit.Verify(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, entries[2].Hash, head)
+
+	lines := strings.SplitAfter(string(data), "\n")
+	tests := []struct {
+		name string
+		log  string
+	}{
+		{"edited", strings.Replace(string(data), `"actor":"alice"`, `"actor":"mallory"`, 1)},
+		{"removed", lines[0] + lines[2]},
+		{"reordered", lines[1] + lines[0] + lines[2]},
+		{"garbage", string(data) + "not json\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := audit.Verify(strings.NewReader(tt.log))
+			assert.ErrorIs(t, err, audit.ErrTampered)
+		})
+	}
+}
+
+func TestFileAuditRepository_ContinuesChain(t *testing.T) {
+	path, _ := writeLog(t, 2)
+
+	// A second writer, e.g. a CLI command next to the server, continues the
+	// chain where the file ends.
+	first, err := audit.NewFile(path)
+	require.NoError(t, err)
+	second, err := audit.NewFile(path)
+	require.NoError(t, err)
+	for _, repo := range []audit.Repository{first, second, first} {
+		require.NoError(t, repo.Append(context.Background(), &model.AuditEntry{Actor: "bob", Action: "user.update", Outcome: model.AuditSuccess}))
+	}
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	n, _, err := audit.Verify(f)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+}
+
+func TestFileAuditRepository_Filter(t *testing.T) {
+	repo, err := audit.NewFile(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var entries []model.AuditEntry
+	for _, target := range []string{"jane", "john", "jane", "john"} {
+		e := &model.AuditEntry{Actor: "alice", Action: "user.update", Target: target, Outcome: model.AuditSuccess}
+		require.NoError(t, repo.Append(ctx, e))
+		entries = append(entries, *e)
+	}
+
+	got, err := repo.List(ctx, audit.Filter{})
+	require.NoError(t, err)
+	assert.Equal(t, entries, got, "entries are returned in the order appended")
+
+	got, err = repo.List(ctx, audit.Filter{Target: "john", After: entries[1].Sequence})
+	require.NoError(t, err)
+	assert.Equal(t, []model.AuditEntry{entries[3]}, got)
+
+	got, err = repo.List(ctx, audit.Filter{Actor: "alice", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []model.AuditEntry{entries[0]}, got)
+}
+
+func TestFileAuditRepository_TenantIsolation(t *testing.T) {
+	repo, err := audit.NewFile(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	org := uuid.New()
+	scoped := tenant.NewContext(context.Background(), org)
+
+	require.NoError(t, repo.Append(context.Background(), &model.AuditEntry{Actor: "alice", Outcome: model.AuditSuccess}))
+	e := &model.AuditEntry{Actor: "alice", Outcome: model.AuditSuccess}
+	require.NoError(t, repo.Append(scoped, e))
+	assert.Equal(t, org, e.OrganizationID)
+	assert.Equal(t, int64(2), e.Sequence, "organizations share one chain")
+
+	got, err := repo.List(scoped, audit.Filter{})
+	require.NoError(t, err)
+	assert.Equal(t, []model.AuditEntry{*e}, got)
+}
@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+// maxLineSize bounds one entry of the log file.
+const maxLineSize = 1 << 20
+
+// Hash returns the hash an entry must carry: the hex SHA-256 of its JSON
+// form with Hash left empty. The form includes PrevHash, which chains the
+// entry to the one before it.
+func Hash(entry *model.AuditEntry) (string, error) {
+	unsealed := *entry
+	unsealed.Hash = ""
+	data, err := json.Marshal(&unsealed)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// seal makes entry the next one after last, which is nil for the first
+// entry of a log.
+func seal(entry *model.AuditEntry, last *model.AuditEntry) error {
+	entry.Sequence = 1
+	entry.PrevHash = ""
+	if last != nil {
+		entry.Sequence = last.Sequence + 1
+		entry.PrevHash = last.Hash
+	}
+	hash, err := Hash(entry)
+	if err != nil {
+		return err
+	}
+	entry.Hash = hash
+	return nil
+}
+
+// Verify reads a log written by the file repository and checks that every
+// entry is intact and follows the one before it. It returns the number of
+// entries and the hash of the last one; removing entries from the end of a
+// log goes unnoticed unless that hash is compared with one noted earlier.
+func Verify(r io.Reader) (int, string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var last *model.AuditEntry
+	n := 0
+	for scanner.Scan() {
+		n++
+		var entry model.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return n - 1, "", fmt.Errorf("%w: line %d is not an entry: %v", ErrTampered, n, err)
+		}
+		if err := checkLink(&entry, last); err != nil {
+			return n - 1, "", fmt.Errorf("%w: line %d: %v", ErrTampered, n, err)
+		}
+		last = &entry
+	}
+	if err := scanner.Err(); err != nil {
+		return n, "", err
+	}
+	if last == nil {
+		return 0, "", nil
+	}
+	return n, last.Hash, nil
+}
+
+// checkLink reports why entry is not a valid successor of last.
+func checkLink(entry, last *model.AuditEntry) error {
+	wantSeq, wantPrev := int64(1), ""
+	if last != nil {
+		wantSeq, wantPrev = last.Sequence+1, last.Hash
+	}
+	if entry.Sequence != wantSeq {
+		return fmt.Errorf("sequence %d, want %d", entry.Sequence, wantSeq)
+	}
+	if entry.PrevHash != wantPrev {
+		return fmt.Errorf("entry %d does not follow the entry before it", entry.Sequence)
+	}
+	hash, err := Hash(entry)
+	if err != nil {
+		return err
+	}
+	if hash != entry.Hash {
+		return fmt.Errorf("entry %d was modified", entry.Sequence)
+	}
+	return nil
+}
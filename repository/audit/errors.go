@@ -0,0 +1,8 @@
+package audit
+
+import "errors"
+
+// ErrTampered is returned by Verify for logs whose entries no longer chain.
+var ErrTampered = errors.New("audit log has been tampered with")
+
+var errCreateAuditFile = errors.New("failed to create audit file")
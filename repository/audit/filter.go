@@ -0,0 +1,28 @@
+package audit
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+// Filter selects audit entries. Empty fields match every entry.
+type Filter struct {
+	Target string
+	Actor  string
+	// After skips entries up to and including this sequence number.
+	After int64
+	// Limit caps the number of entries returned; zero means no limit.
+	Limit int
+}
+
+func (f *Filter) matches(org uuid.UUID, entry *model.AuditEntry) bool {
+	return entry.OrganizationID == org &&
+		entry.Sequence > f.After &&
+		(f.Target == "" || entry.Target == f.Target) &&
+		(f.Actor == "" || entry.Actor == f.Actor)
+}
+
+func (f *Filter) full(entries []model.AuditEntry) bool {
+	return f.Limit > 0 && len(entries) >= f.Limit
+}
@@ -0,0 +1,117 @@
+// Package deadletter stores the events the outbox dispatcher gave up on, so
+// they can be inspected and published by hand.
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+// Repository stores dead letters. Like user.Repository it is scoped by the
+// organization in the context: letters are stored for it and only its
+// letters are returned. Implementations must be safe for concurrent use and
+// honour context cancellation.
+type Repository interface {
+	// Add stores letter, setting its OrganizationID.
+	Add(ctx context.Context, letter *model.DeadLetter) error
+	// List returns the dead letters in the order they were added.
+	List(ctx context.Context) ([]model.DeadLetter, error)
+	// Redact drops the user snapshots from the dead letters of a user and
+	// returns how many letters it changed.
+	Redact(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+type fileDeadLetterRepository struct {
+	filePath string
+	mu       sync.Mutex
+}
+
+func NewFile(filePath string) (Repository, error) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if err := os.WriteFile(filePath, []byte("{}"), 0644); err != nil {
+			return nil, errCreateDeadLetterFile
+		}
+	}
+	return &fileDeadLetterRepository{filePath: filePath}, nil
+}
+
+func (r *fileDeadLetterRepository) Add(ctx context.Context, letter *model.DeadLetter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return err
+	}
+	s.add(tenant.FromContext(ctx), letter)
+	return r.writeNoLock(s)
+}
+
+func (r *fileDeadLetterRepository) List(ctx context.Context) ([]model.DeadLetter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return nil, err
+	}
+	return s.list(tenant.FromContext(ctx)), nil
+}
+
+func (r *fileDeadLetterRepository) Redact(ctx context.Context, userID uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return 0, err
+	}
+	n := s.redact(tenant.FromContext(ctx), userID)
+	if n == 0 {
+		return 0, nil
+	}
+	return n, r.writeNoLock(s)
+}
+
+func (r *fileDeadLetterRepository) readNoLock() (*store, error) {
+	data, err := os.ReadFile(r.filePath)
+	if os.IsNotExist(err) {
+		return &store{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s store
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *fileDeadLetterRepository) writeNoLock(s *store) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.filePath, data, 0644)
+}
@@ -0,0 +1,96 @@
+package deadletter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+func newTestRepo(t *testing.T) Repository {
+	repo, err := NewFile(filepath.Join(t.TempDir(), "dead_letters.json"))
+	require.NoError(t, err)
+	return repo
+}
+
+func newLetter(name string) *model.DeadLetter {
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+	return &model.DeadLetter{
+		PendingEvent: model.PendingEvent{
+			Event: model.Event{
+				ID:     uuid.New(),
+				Type:   model.EventUserUpdated,
+				UserID: userID,
+				At:     at,
+				User:   &model.User{ID: userID, Name: name, Email: "user@example.com", Status: model.StatusActive},
+			},
+			Delivered: []string{"file"},
+			Attempts:  10,
+			LastError: "webhook: 503 Service Unavailable",
+		},
+		FailedAt: at.Add(time.Hour),
+	}
+}
+
+func TestFileRepository_AddAndList(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	var want []model.DeadLetter
+	for _, name := range []string{"Jane", "John", "Joan"} {
+		letter := newLetter(name)
+		require.NoError(t, repo.Add(ctx, letter))
+		assert.Equal(t, tenant.Default, letter.OrganizationID)
+		want = append(want, *letter)
+	}
+
+	got, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, want, got, "letters are returned in the order added")
+}
+
+func TestFileRepository_Redact(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	jane, john := newLetter("Jane"), newLetter("John")
+	require.NoError(t, repo.Add(ctx, jane))
+	require.NoError(t, repo.Add(ctx, john))
+
+	n, err := repo.Redact(ctx, jane.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	got, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, got, 2, "redacted letters are kept")
+	assert.Nil(t, got[0].User)
+	assert.Equal(t, jane.UserID, got[0].UserID)
+	assert.Equal(t, *john, got[1])
+
+	n, err = repo.Redact(tenant.NewContext(ctx, uuid.New()), john.UserID)
+	require.NoError(t, err)
+	assert.Zero(t, n, "other organizations are left alone")
+}
+
+func TestFileRepository_TenantIsolation(t *testing.T) {
+	repo := newTestRepo(t)
+	org := uuid.New()
+	scoped := tenant.NewContext(context.Background(), org)
+
+	require.NoError(t, repo.Add(context.Background(), newLetter("Jane")))
+	letter := newLetter("John")
+	require.NoError(t, repo.Add(scoped, letter))
+	assert.Equal(t, org, letter.OrganizationID)
+
+	got, err := repo.List(scoped)
+	require.NoError(t, err)
+	assert.Equal(t, []model.DeadLetter{*letter}, got)
+}
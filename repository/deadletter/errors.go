@@ -0,0 +1,5 @@
+package deadletter
+
+import "errors"
+
+var errCreateDeadLetterFile = errors.New("failed to create dead letter file")
@@ -0,0 +1,44 @@
+package deadletter
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+// store holds the dead letters of a repository; it is also the layout of
+// the dead letter file. Callers serialise access.
+type store struct {
+	DeadLetters []model.DeadLetter `json:"dead_letters"`
+}
+
+func (s *store) add(org uuid.UUID, letter *model.DeadLetter) {
+	letter.OrganizationID = org
+	s.DeadLetters = append(s.DeadLetters, clone(letter))
+}
+
+func (s *store) list(org uuid.UUID) []model.DeadLetter {
+	var letters []model.DeadLetter
+	for i := range s.DeadLetters {
+		if s.DeadLetters[i].OrganizationID == org {
+			letters = append(letters, clone(&s.DeadLetters[i]))
+		}
+	}
+	return letters
+}
+
+func (s *store) redact(org, userID uuid.UUID) int {
+	var n int
+	for i := range s.DeadLetters {
+		letter := &s.DeadLetters[i]
+		if letter.OrganizationID == org && letter.UserID == userID && letter.User != nil {
+			letter.User = nil
+			n++
+		}
+	}
+	return n
+}
+
+func clone(letter *model.DeadLetter) model.DeadLetter {
+	return model.DeadLetter{PendingEvent: letter.PendingEvent.Clone(), FailedAt: letter.FailedAt}
+}
@@ -0,0 +1,18 @@
+package group
+
+import "errors"
+
+// Errors every Repository implementation returns.
+var (
+	ErrGroupNotFound      = errors.New("group not found")
+	ErrGroupAlreadyExists = errors.New("group already exists")
+	ErrNameTaken          = errors.New("group name is already in use")
+	ErrAlreadyMember      = errors.New("user is already a member of the group")
+	ErrNotMember          = errors.New("user is not a member of the group")
+	// ErrGroupFull and ErrTooManyGroups are returned by AddMember when a
+	// membership would go over its Limits.
+	ErrGroupFull     = errors.New("group has reached its member limit")
+	ErrTooManyGroups = errors.New("user has reached the group limit")
+)
+
+var errCreateGroupFile = errors.New("failed to create group file")
@@ -0,0 +1,183 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+// Repository stores groups and their memberships. Like user.Repository it is
+// scoped by the organization in the context: groups and memberships are
+// stored for it and only its own are returned or changed. Implementations
+// must be safe for concurrent use, return groups in creation order and
+// memberships in the order they were added, and honour context cancellation.
+type Repository interface {
+	Create(ctx context.Context, group *model.Group) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Group, error)
+	GetAll(ctx context.Context) ([]model.Group, error)
+	Update(ctx context.Context, group *model.Group) error
+	// Delete removes a group together with its memberships.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// AddMember stores a membership unless it would go over limits.
+	AddMember(ctx context.Context, membership *model.Membership, limits Limits) error
+	RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error
+	// Members returns the memberships of a group.
+	Members(ctx context.Context, groupID uuid.UUID) ([]model.Membership, error)
+	// Memberships returns the memberships of a user, none for unknown users.
+	Memberships(ctx context.Context, userID uuid.UUID) ([]model.Membership, error)
+	// RemoveUser drops every membership of a user, e.g. once it is deleted.
+	RemoveUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// Limits caps membership counts; zero means no limit.
+type Limits struct {
+	// MaxMembers is the most members a group can have.
+	MaxMembers int
+	// MaxGroups is the most groups a user can be a member of.
+	MaxGroups int
+}
+
+type fileGroupRepository struct {
+	filePath string
+	mu       sync.Mutex
+}
+
+func NewFile(filePath string) (Repository, error) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if err := os.WriteFile(filePath, []byte("{}"), 0644); err != nil {
+			return nil, errCreateGroupFile
+		}
+	}
+	return &fileGroupRepository{filePath: filePath}, nil
+}
+
+func (r *fileGroupRepository) Create(ctx context.Context, group *model.Group) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.create(tenant.FromContext(ctx), group)
+	})
+}
+
+func (r *fileGroupRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Group, error) {
+	s, err := r.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.get(tenant.FromContext(ctx), id)
+}
+
+func (r *fileGroupRepository) GetAll(ctx context.Context) ([]model.Group, error) {
+	s, err := r.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.getAll(tenant.FromContext(ctx)), nil
+}
+
+func (r *fileGroupRepository) Update(ctx context.Context, group *model.Group) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.update(tenant.FromContext(ctx), group)
+	})
+}
+
+func (r *fileGroupRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.delete(tenant.FromContext(ctx), id)
+	})
+}
+
+func (r *fileGroupRepository) AddMember(ctx context.Context, membership *model.Membership, limits Limits) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.addMember(tenant.FromContext(ctx), membership, limits)
+	})
+}
+
+func (r *fileGroupRepository) RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.removeMember(tenant.FromContext(ctx), groupID, userID)
+	})
+}
+
+func (r *fileGroupRepository) Members(ctx context.Context, groupID uuid.UUID) ([]model.Membership, error) {
+	s, err := r.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.members(tenant.FromContext(ctx), groupID)
+}
+
+func (r *fileGroupRepository) Memberships(ctx context.Context, userID uuid.UUID) ([]model.Membership, error) {
+	s, err := r.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.memberships(tenant.FromContext(ctx), userID), nil
+}
+
+func (r *fileGroupRepository) RemoveUser(ctx context.Context, userID uuid.UUID) error {
+	return r.modify(ctx, func(s *store) error {
+		s.removeUser(tenant.FromContext(ctx), userID)
+		return nil
+	})
+}
+
+func (r *fileGroupRepository) read(ctx context.Context) (*store, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.readNoLock()
+}
+
+// modify applies change to the stored data and writes the result unless
+// change fails.
+func (r *fileGroupRepository) modify(ctx context.Context, change func(*store) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return err
+	}
+	if err := change(s); err != nil {
+		return err
+	}
+	return r.writeNoLock(s)
+}
+
+func (r *fileGroupRepository) readNoLock() (*store, error) {
+	data, err := os.ReadFile(r.filePath)
+	if os.IsNotExist(err) {
+		return &store{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s store
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *fileGroupRepository) writeNoLock(s *store) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.filePath, data, 0644)
+}
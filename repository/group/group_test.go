@@ -0,0 +1,236 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+func newTestRepo(t *testing.T) Repository {
+	repo, err := NewFile(filepath.Join(t.TempDir(), "groups.json"))
+	require.NoError(t, err)
+	return repo
+}
+
+func createGroups(t *testing.T, repo Repository, n int) []model.Group {
+	t.Helper()
+
+	groups := make([]model.Group, 0, n)
+	for i := 0; i < n; i++ {
+		g := &model.Group{
+			ID:        uuid.New(),
+			Name:      fmt.Sprintf("Group %d", i),
+			NameKey:   fmt.Sprintf("group %d", i),
+			CreatedAt: time.Date(2025, 1, 1, 0, i, 0, 0, time.UTC),
+		}
+		require.NoError(t, repo.Create(context.Background(), g))
+		groups = append(groups, *g)
+	}
+	return groups
+}
+
+func membership(groupID, userID uuid.UUID) *model.Membership {
+	return &model.Membership{
+		GroupID: groupID,
+		UserID:  userID,
+		AddedAt: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func userIDs(memberships []model.Membership) []uuid.UUID {
+	out := make([]uuid.UUID, 0, len(memberships))
+	for _, m := range memberships {
+		out = append(out, m.UserID)
+	}
+	return out
+}
+
+func TestFileGroupRepository_CreateAndUpdate(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	groups := createGroups(t, repo, 3)
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, groups, all, "groups are returned in creation order")
+
+	updated := groups[1]
+	updated.Name = "Renamed"
+	updated.NameKey = "renamed"
+	require.NoError(t, repo.Update(ctx, &updated))
+	got, err := repo.GetByID(ctx, updated.ID)
+	require.NoError(t, err)
+	assert.Equal(t, updated, *got)
+
+	_, err = repo.GetByID(ctx, uuid.New())
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+	assert.ErrorIs(t, repo.Create(ctx, &groups[0]), ErrGroupAlreadyExists)
+}
+
+func TestFileGroupRepository_DuplicateName(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	groups := createGroups(t, repo, 2)
+
+	dup := &model.Group{ID: uuid.New(), Name: "Group 0", NameKey: groups[0].NameKey}
+	assert.ErrorIs(t, repo.Create(ctx, dup), ErrNameTaken)
+
+	renamed := groups[1]
+	renamed.NameKey = groups[0].NameKey
+	assert.ErrorIs(t, repo.Update(ctx, &renamed), ErrNameTaken)
+
+	same := groups[0]
+	same.Description = "Changed"
+	assert.NoError(t, repo.Update(ctx, &same), "a group keeps its own name")
+}
+
+func TestFileGroupRepository_Members(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	groups := createGroups(t, repo, 2)
+	users := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	for _, u := range users {
+		require.NoError(t, repo.AddMember(ctx, membership(groups[0].ID, u), Limits{}))
+	}
+	require.NoError(t, repo.AddMember(ctx, membership(groups[1].ID, users[1]), Limits{}))
+
+	members, err := repo.Members(ctx, groups[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, users, userIDs(members), "members are returned in the order added")
+
+	assert.ErrorIs(t, repo.AddMember(ctx, membership(groups[0].ID, users[0]), Limits{}), ErrAlreadyMember)
+	assert.ErrorIs(t, repo.AddMember(ctx, membership(uuid.New(), users[0]), Limits{}), ErrGroupNotFound)
+	assert.ErrorIs(t, repo.RemoveMember(ctx, groups[0].ID, uuid.New()), ErrNotMember)
+
+	require.NoError(t, repo.RemoveMember(ctx, groups[0].ID, users[1]))
+	members, err = repo.Members(ctx, groups[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{users[0], users[2]}, userIDs(members))
+
+	memberships, err := repo.Memberships(ctx, users[1])
+	require.NoError(t, err)
+	require.Len(t, memberships, 1)
+	assert.Equal(t, groups[1].ID, memberships[0].GroupID)
+}
+
+func TestFileGroupRepository_DeleteRemovesMemberships(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	groups := createGroups(t, repo, 2)
+	userID := uuid.New()
+	for _, g := range groups {
+		require.NoError(t, repo.AddMember(ctx, membership(g.ID, userID), Limits{}))
+	}
+
+	require.NoError(t, repo.Delete(ctx, groups[0].ID))
+	assert.ErrorIs(t, repo.Delete(ctx, groups[0].ID), ErrGroupNotFound)
+
+	memberships, err := repo.Memberships(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, memberships, 1)
+	assert.Equal(t, groups[1].ID, memberships[0].GroupID)
+}
+
+func TestFileGroupRepository_Limits(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	groups := createGroups(t, repo, 3)
+	limits := Limits{MaxMembers: 2, MaxGroups: 2}
+
+	first, second := uuid.New(), uuid.New()
+	require.NoError(t, repo.AddMember(ctx, membership(groups[0].ID, first), limits))
+	require.NoError(t, repo.AddMember(ctx, membership(groups[0].ID, second), limits))
+	assert.ErrorIs(t, repo.AddMember(ctx, membership(groups[0].ID, uuid.New()), limits), ErrGroupFull)
+
+	require.NoError(t, repo.AddMember(ctx, membership(groups[1].ID, first), limits))
+	assert.ErrorIs(t, repo.AddMember(ctx, membership(groups[2].ID, first), limits), ErrTooManyGroups)
+
+	require.NoError(t, repo.RemoveMember(ctx, groups[1].ID, first))
+	assert.NoError(t, repo.AddMember(ctx, membership(groups[2].ID, first), limits), "removing a membership frees its place")
+}
+
+func TestFileGroupRepository_ConcurrentAddMember(t *testing.T) {
+	const n, limit = 30, 10
+	repo := newTestRepo(t)
+	groups := createGroups(t, repo, 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = repo.AddMember(context.Background(), membership(groups[0].ID, uuid.New()), Limits{MaxMembers: limit})
+		}()
+	}
+	wg.Wait()
+
+	members, err := repo.Members(context.Background(), groups[0].ID)
+	require.NoError(t, err)
+	assert.Len(t, members, limit, "the limit holds under concurrent adds")
+}
+
+func TestFileGroupRepository_RemoveUser(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	groups := createGroups(t, repo, 2)
+	gone, kept := uuid.New(), uuid.New()
+	for _, g := range groups {
+		require.NoError(t, repo.AddMember(ctx, membership(g.ID, gone), Limits{}))
+		require.NoError(t, repo.AddMember(ctx, membership(g.ID, kept), Limits{}))
+	}
+
+	require.NoError(t, repo.RemoveUser(ctx, gone))
+	require.NoError(t, repo.RemoveUser(ctx, uuid.New()), "unknown users are not an error")
+
+	for _, g := range groups {
+		members, err := repo.Members(ctx, g.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{kept}, userIDs(members))
+	}
+}
+
+func TestFileGroupRepository_TenantIsolation(t *testing.T) {
+	repo := newTestRepo(t)
+	org := uuid.New()
+	acme := tenant.NewContext(context.Background(), org)
+	globex := tenant.NewContext(context.Background(), uuid.New())
+
+	g := createGroups(t, repo, 1)[0]
+	scoped := &model.Group{ID: uuid.New(), Name: g.Name, NameKey: g.NameKey}
+	require.NoError(t, repo.Create(acme, scoped), "names are unique per organization")
+	assert.Equal(t, org, scoped.OrganizationID)
+	userID := uuid.New()
+	require.NoError(t, repo.AddMember(acme, membership(scoped.ID, userID), Limits{}))
+	require.NoError(t, repo.AddMember(context.Background(), membership(g.ID, userID), Limits{MaxGroups: 1}),
+		"limits count the groups of one organization")
+
+	all, err := repo.GetAll(acme)
+	require.NoError(t, err)
+	assert.Equal(t, []model.Group{*scoped}, all)
+
+	_, err = repo.GetByID(globex, scoped.ID)
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+	assert.ErrorIs(t, repo.Update(globex, scoped), ErrGroupNotFound)
+	assert.ErrorIs(t, repo.Delete(globex, scoped.ID), ErrGroupNotFound)
+	_, err = repo.Members(globex, scoped.ID)
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+	assert.ErrorIs(t, repo.AddMember(globex, membership(scoped.ID, uuid.New()), Limits{}), ErrGroupNotFound)
+	assert.ErrorIs(t, repo.RemoveMember(globex, scoped.ID, userID), ErrGroupNotFound)
+
+	require.NoError(t, repo.RemoveUser(globex, userID))
+	require.NoError(t, repo.RemoveUser(context.Background(), userID))
+	memberships, err := repo.Memberships(acme, userID)
+	require.NoError(t, err)
+	require.Len(t, memberships, 1, "removing a user leaves other organizations alone")
+	assert.Equal(t, org, memberships[0].OrganizationID)
+}
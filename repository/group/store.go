@@ -0,0 +1,168 @@
+package group
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+// store holds the groups and memberships of a repository; it is also the
+// layout of the group file. Callers serialise access.
+type store struct {
+	Groups      []model.Group      `json:"groups"`
+	Memberships []model.Membership `json:"memberships"`
+}
+
+func (s *store) create(org uuid.UUID, group *model.Group) error {
+	if s.indexOf(group.ID) != -1 {
+		return ErrGroupAlreadyExists
+	}
+	group.OrganizationID = org
+	if s.nameTaken(group) {
+		return ErrNameTaken
+	}
+	s.Groups = append(s.Groups, *group)
+	return nil
+}
+
+func (s *store) get(org, id uuid.UUID) (*model.Group, error) {
+	i := s.indexIn(org, id)
+	if i == -1 {
+		return nil, ErrGroupNotFound
+	}
+	group := s.Groups[i]
+	return &group, nil
+}
+
+func (s *store) getAll(org uuid.UUID) []model.Group {
+	var groups []model.Group
+	for _, g := range s.Groups {
+		if g.OrganizationID == org {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+func (s *store) update(org uuid.UUID, group *model.Group) error {
+	i := s.indexIn(org, group.ID)
+	if i == -1 {
+		return ErrGroupNotFound
+	}
+	group.OrganizationID = org
+	if s.nameTaken(group) {
+		return ErrNameTaken
+	}
+	s.Groups[i] = *group
+	return nil
+}
+
+func (s *store) delete(org, id uuid.UUID) error {
+	i := s.indexIn(org, id)
+	if i == -1 {
+		return ErrGroupNotFound
+	}
+	s.Groups = slices.Delete(s.Groups, i, i+1)
+	s.Memberships = slices.DeleteFunc(s.Memberships, func(m model.Membership) bool {
+		return m.GroupID == id
+	})
+	return nil
+}
+
+func (s *store) addMember(org uuid.UUID, membership *model.Membership, limits Limits) error {
+	if s.indexIn(org, membership.GroupID) == -1 {
+		return ErrGroupNotFound
+	}
+	membership.OrganizationID = org
+	var members, groups int
+	for _, m := range s.Memberships {
+		if m.GroupID == membership.GroupID && m.UserID == membership.UserID {
+			return ErrAlreadyMember
+		}
+		if m.GroupID == membership.GroupID {
+			members++
+		}
+		if m.OrganizationID == org && m.UserID == membership.UserID {
+			groups++
+		}
+	}
+	if limits.MaxMembers > 0 && members >= limits.MaxMembers {
+		return ErrGroupFull
+	}
+	if limits.MaxGroups > 0 && groups >= limits.MaxGroups {
+		return ErrTooManyGroups
+	}
+	s.Memberships = append(s.Memberships, *membership)
+	return nil
+}
+
+func (s *store) removeMember(org, groupID, userID uuid.UUID) error {
+	if s.indexIn(org, groupID) == -1 {
+		return ErrGroupNotFound
+	}
+	n := len(s.Memberships)
+	s.Memberships = slices.DeleteFunc(s.Memberships, func(m model.Membership) bool {
+		return m.GroupID == groupID && m.UserID == userID
+	})
+	if len(s.Memberships) == n {
+		return ErrNotMember
+	}
+	return nil
+}
+
+func (s *store) members(org, groupID uuid.UUID) ([]model.Membership, error) {
+	if s.indexIn(org, groupID) == -1 {
+		return nil, ErrGroupNotFound
+	}
+	return s.filter(func(m model.Membership) bool { return m.GroupID == groupID }), nil
+}
+
+func (s *store) memberships(org, userID uuid.UUID) []model.Membership {
+	return s.filter(func(m model.Membership) bool {
+		return m.OrganizationID == org && m.UserID == userID
+	})
+}
+
+func (s *store) removeUser(org, userID uuid.UUID) {
+	s.Memberships = slices.DeleteFunc(s.Memberships, func(m model.Membership) bool {
+		return m.OrganizationID == org && m.UserID == userID
+	})
+}
+
+func (s *store) filter(keep func(model.Membership) bool) []model.Membership {
+	out := []model.Membership{}
+	for _, m := range s.Memberships {
+		if keep(m) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func (s *store) indexOf(id uuid.UUID) int {
+	return slices.IndexFunc(s.Groups, func(g model.Group) bool {
+		return g.ID == id
+	})
+}
+
+// indexIn is indexOf limited to the groups of org.
+func (s *store) indexIn(org, id uuid.UUID) int {
+	i := s.indexOf(id)
+	if i == -1 || s.Groups[i].OrganizationID != org {
+		return -1
+	}
+	return i
+}
+
+// nameTaken reports whether another group of the same organization has the
+// name of group. Groups without a name key never collide.
+func (s *store) nameTaken(group *model.Group) bool {
+	if group.NameKey == "" {
+		return false
+	}
+	return slices.ContainsFunc(s.Groups, func(g model.Group) bool {
+		return g.ID != group.ID && g.OrganizationID == group.OrganizationID && g.NameKey == group.NameKey
+	})
+}
@@ -0,0 +1,5 @@
+package history
+
+import "errors"
+
+var errCreateHistoryFile = errors.New("failed to create history file")
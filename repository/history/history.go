@@ -0,0 +1,117 @@
+// Package history stores the revisions of users, see Record.
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+// Repository stores user revisions. Like user.Repository it is scoped by the
+// organization in the context: revisions are stored for it and only its
+// revisions are returned. Implementations must be safe for concurrent use
+// and honour context cancellation.
+type Repository interface {
+	// Append stores rev as the next revision of its user, setting its
+	// Number and OrganizationID.
+	Append(ctx context.Context, rev *model.UserRevision) error
+	// List returns the revisions of a user, oldest first.
+	List(ctx context.Context, userID uuid.UUID) ([]model.UserRevision, error)
+	// Redact drops the snapshots and changes of a user's revisions, keeping
+	// who made them and when, and returns how many revisions it changed.
+	Redact(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+type fileHistoryRepository struct {
+	filePath string
+	mu       sync.Mutex
+}
+
+func NewFile(filePath string) (Repository, error) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if err := os.WriteFile(filePath, []byte("{}"), 0644); err != nil {
+			return nil, errCreateHistoryFile
+		}
+	}
+	return &fileHistoryRepository{filePath: filePath}, nil
+}
+
+func (r *fileHistoryRepository) Append(ctx context.Context, rev *model.UserRevision) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return err
+	}
+	s.append(tenant.FromContext(ctx), rev)
+	return r.writeNoLock(s)
+}
+
+func (r *fileHistoryRepository) List(ctx context.Context, userID uuid.UUID) ([]model.UserRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return nil, err
+	}
+	return s.list(tenant.FromContext(ctx), userID), nil
+}
+
+func (r *fileHistoryRepository) Redact(ctx context.Context, userID uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return 0, err
+	}
+	n := s.redact(tenant.FromContext(ctx), userID)
+	if n == 0 {
+		return 0, nil
+	}
+	return n, r.writeNoLock(s)
+}
+
+func (r *fileHistoryRepository) readNoLock() (*store, error) {
+	data, err := os.ReadFile(r.filePath)
+	if os.IsNotExist(err) {
+		return &store{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s store
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *fileHistoryRepository) writeNoLock(s *store) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.filePath, data, 0644)
+}
@@ -0,0 +1,108 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+func newTestRepo(t *testing.T) Repository {
+	repo, err := NewFile(filepath.Join(t.TempDir(), "history.json"))
+	require.NoError(t, err)
+	return repo
+}
+
+func newRevision(userID uuid.UUID, name string) *model.UserRevision {
+	return &model.UserRevision{
+		UserID:  userID,
+		Action:  model.RevisionUpdate,
+		Actor:   "tester",
+		At:      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Changes: []model.FieldChange{{Field: "name", New: name}},
+		User:    &model.User{ID: userID, Name: name, Email: "user@example.com", Status: model.StatusActive},
+	}
+}
+
+func TestFileRepository_AppendAndList(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	jane, john := uuid.New(), uuid.New()
+
+	var want []model.UserRevision
+	for _, name := range []string{"Jane", "Janet", "Jane Doe"} {
+		rev := newRevision(jane, name)
+		require.NoError(t, repo.Append(ctx, rev))
+		require.NoError(t, repo.Append(ctx, newRevision(john, name)))
+		want = append(want, *rev)
+	}
+	for i, rev := range want {
+		assert.Equal(t, int64(i+1), rev.Number, "revisions are numbered per user from 1")
+		assert.Equal(t, tenant.Default, rev.OrganizationID)
+	}
+
+	got, err := repo.List(ctx, jane)
+	require.NoError(t, err)
+	assert.Equal(t, want, got, "revisions are returned oldest first")
+
+	got, err = repo.List(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestFileRepository_Redact(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	jane, john := uuid.New(), uuid.New()
+	for _, name := range []string{"Jane", "Janet"} {
+		require.NoError(t, repo.Append(ctx, newRevision(jane, name)))
+	}
+	other := newRevision(john, "John")
+	require.NoError(t, repo.Append(ctx, other))
+	require.NoError(t, repo.Append(tenant.NewContext(ctx, uuid.New()), newRevision(jane, "Jane")))
+
+	n, err := repo.Redact(ctx, jane)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	got, err := repo.List(ctx, jane)
+	require.NoError(t, err)
+	require.Len(t, got, 2, "redacted revisions are kept")
+	for i, rev := range got {
+		assert.Equal(t, int64(i+1), rev.Number)
+		assert.Nil(t, rev.User)
+		assert.Empty(t, rev.Changes)
+	}
+
+	n, err = repo.Redact(ctx, jane)
+	require.NoError(t, err)
+	assert.Zero(t, n, "redacted revisions are not counted again")
+
+	got, err = repo.List(ctx, john)
+	require.NoError(t, err)
+	assert.Equal(t, []model.UserRevision{*other}, got)
+}
+
+func TestFileRepository_TenantIsolation(t *testing.T) {
+	repo := newTestRepo(t)
+	org := uuid.New()
+	scoped := tenant.NewContext(context.Background(), org)
+	userID := uuid.New()
+
+	require.NoError(t, repo.Append(context.Background(), newRevision(userID, "Jane")))
+	rev := newRevision(userID, "Jane")
+	require.NoError(t, repo.Append(scoped, rev))
+	assert.Equal(t, int64(1), rev.Number, "each organization numbers its own revisions")
+	assert.Equal(t, org, rev.OrganizationID)
+
+	got, err := repo.List(scoped, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []model.UserRevision{*rev}, got)
+}
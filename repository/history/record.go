@@ -0,0 +1,168 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/actor"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/user"
+)
+
+// unrecorded are fields left out of the changes of a revision: the ID never
+// changes and the others are derived from name and email.
+var unrecorded = map[string]bool{
+	"id":              true,
+	"email_canonical": true,
+	"name_key":        true,
+}
+
+type revertContextKey struct{}
+
+// WithRevertedFrom marks the writes made with the returned context as
+// restoring revision number, which their revisions record as RevertedFrom.
+func WithRevertedFrom(ctx context.Context, number int64) context.Context {
+	return context.WithValue(ctx, revertContextKey{}, number)
+}
+
+type recordingRepository struct {
+	user.Repository
+	revisions Repository
+	now       func() time.Time
+
+	// mu serialises writes so each revision is compared with the one
+	// before it.
+	mu sync.Mutex
+}
+
+// Record returns users with every successful Create, Update and Delete
+// stored as a revision in revisions, attributed to the actor in the context.
+// Revisions leave out credentials, so updates that change nothing else, e.g.
+// a failed login or a new password, add none.
+func Record(users user.Repository, revisions Repository) user.Repository {
+	return &recordingRepository{Repository: users, revisions: revisions, now: time.Now}
+}
+
+func (r *recordingRepository) Create(ctx context.Context, u *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.Repository.Create(ctx, u); err != nil {
+		return err
+	}
+	saved := snapshot(u)
+	return r.append(ctx, u.ID, model.RevisionCreate, diff(nil, saved), saved)
+}
+
+func (r *recordingRepository) Update(ctx context.Context, u *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, err := r.Repository.GetByID(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	if err := r.Repository.Update(ctx, u); err != nil {
+		return err
+	}
+
+	saved := snapshot(u)
+	changes := diff(snapshot(previous), saved)
+	if len(changes) == 0 {
+		return nil
+	}
+	return r.append(ctx, u.ID, model.RevisionUpdate, changes, saved)
+}
+
+func (r *recordingRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.Repository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.append(ctx, id, model.RevisionDelete, nil, nil)
+}
+
+func (r *recordingRepository) append(ctx context.Context, id uuid.UUID, action model.RevisionAction, changes []model.FieldChange, saved *model.User) error {
+	rev := &model.UserRevision{
+		UserID:  id,
+		Action:  action,
+		Actor:   actor.FromContext(ctx),
+		At:      r.now().UTC(),
+		Changes: changes,
+		User:    saved,
+	}
+	rev.RevertedFrom, _ = ctx.Value(revertContextKey{}).(int64)
+	// The user is already written: a cancelled request must not lose its
+	// revision.
+	return r.revisions.Append(context.WithoutCancel(ctx), rev)
+}
+
+// snapshot returns a copy of u without credentials and login state.
+func snapshot(u *model.User) *model.User {
+	s := u.WithoutCredentials()
+	return &s
+}
+
+// diff lists the fields that differ between two versions of a user, by
+// name. A nil before lists every set field of after.
+func diff(before, after *model.User) []model.FieldChange {
+	old, updated := fields(before), fields(after)
+
+	var changes []model.FieldChange
+	for name, value := range updated {
+		if !reflect.DeepEqual(old[name], value) {
+			changes = append(changes, model.FieldChange{Field: name, Old: old[name], New: value})
+		}
+	}
+	for name, value := range old {
+		if _, ok := updated[name]; !ok {
+			changes = append(changes, model.FieldChange{Field: name, Old: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// fields flattens the JSON form of u into dotted field names and their
+// values, leaving out unrecorded fields.
+func fields(u *model.User) map[string]any {
+	out := map[string]any{}
+	if u == nil {
+		return out
+	}
+
+	data, err := json.Marshal(u)
+	if err != nil {
+		return out
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return out
+	}
+	flatten(out, "", doc)
+	return out
+}
+
+func flatten(out map[string]any, prefix string, doc map[string]any) {
+	for key, value := range doc {
+		name := prefix + key
+		if unrecorded[name] {
+			continue
+		}
+		if nested, ok := value.(map[string]any); ok {
+			flatten(out, name+".", nested)
+			continue
+		}
+		out[name] = value
+	}
+}
@@ -0,0 +1,80 @@
+package history_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/actor"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/history"
+	"github.com/sergey4qb/mf1-test/repository/user"
+)
+
+func TestRecord(t *testing.T) {
+	dir := t.TempDir()
+	revisions, err := history.NewFile(filepath.Join(dir, "history.json"))
+	require.NoError(t, err)
+	store, err := user.NewFile(filepath.Join(dir, "users.json"))
+	require.NoError(t, err)
+	users := history.Record(store, revisions)
+	ctx := actor.NewContext(context.Background(), "admin")
+
+	u := &model.User{
+		ID:           uuid.New(),
+		Name:         "Jane",
+		Email:        "jane@example.com",
+		Status:       model.StatusActive,
+		PasswordHash: "secret-hash",
+		Attributes:   map[string]any{"department": "sales"},
+	}
+	require.NoError(t, users.Create(ctx, u))
+
+	// Credentials are not recorded, so changing only them adds no revision.
+	u.PasswordHash = "other-hash"
+	require.NoError(t, users.Update(ctx, u))
+
+	u.Name = "Jane Doe"
+	u.Attributes = nil
+	require.NoError(t, users.Update(history.WithRevertedFrom(ctx, 1), u))
+	require.NoError(t, users.Delete(ctx, u.ID))
+
+	got, err := revisions.List(ctx, u.ID)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+
+	created := got[0]
+	assert.Equal(t, model.RevisionCreate, created.Action)
+	assert.Equal(t, "admin", created.Actor)
+	assert.False(t, created.At.IsZero())
+	assert.Equal(t, []model.FieldChange{
+		{Field: "attributes.department", New: "sales"},
+		{Field: "email", New: "jane@example.com"},
+		{Field: "name", New: "Jane"},
+		{Field: "status", New: "active"},
+	}, created.Changes)
+	require.NotNil(t, created.User)
+	assert.Empty(t, created.User.PasswordHash)
+
+	updated := got[1]
+	assert.Equal(t, model.RevisionUpdate, updated.Action)
+	assert.Equal(t, int64(1), updated.RevertedFrom)
+	assert.Equal(t, []model.FieldChange{
+		{Field: "attributes.department", Old: "sales"},
+		{Field: "name", Old: "Jane", New: "Jane Doe"},
+	}, updated.Changes)
+	assert.Equal(t, "Jane Doe", updated.User.Name)
+
+	assert.Equal(t, model.RevisionDelete, got[2].Action)
+	assert.Nil(t, got[2].User)
+
+	// Failed writes add no revision.
+	assert.ErrorIs(t, users.Delete(ctx, u.ID), user.ErrUserNotFound)
+	got, err = revisions.List(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Len(t, got, 3)
+}
@@ -0,0 +1,47 @@
+package history
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+// store holds the revisions of a repository; it is also the layout of the
+// history file. Callers serialise access.
+type store struct {
+	Revisions []model.UserRevision `json:"revisions"`
+}
+
+func (s *store) append(org uuid.UUID, rev *model.UserRevision) {
+	rev.OrganizationID = org
+	rev.Number = 1
+	for i := len(s.Revisions) - 1; i >= 0; i-- {
+		if s.Revisions[i].OrganizationID == org && s.Revisions[i].UserID == rev.UserID {
+			rev.Number = s.Revisions[i].Number + 1
+			break
+		}
+	}
+	s.Revisions = append(s.Revisions, rev.Clone())
+}
+
+func (s *store) list(org, userID uuid.UUID) []model.UserRevision {
+	var revisions []model.UserRevision
+	for i := range s.Revisions {
+		if s.Revisions[i].OrganizationID == org && s.Revisions[i].UserID == userID {
+			revisions = append(revisions, s.Revisions[i].Clone())
+		}
+	}
+	return revisions
+}
+
+func (s *store) redact(org, userID uuid.UUID) int {
+	var n int
+	for i := range s.Revisions {
+		rev := &s.Revisions[i]
+		if rev.OrganizationID == org && rev.UserID == userID && (rev.User != nil || rev.Changes != nil) {
+			rev.User, rev.Changes = nil, nil
+			n++
+		}
+	}
+	return n
+}
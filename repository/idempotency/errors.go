@@ -0,0 +1,7 @@
+package idempotency
+
+import "errors"
+
+var ErrRecordNotFound = errors.New("idempotency record not found")
+
+var errCreateFile = errors.New("failed to create idempotency file")
@@ -0,0 +1,133 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+type Repository interface {
+	// Get returns the unexpired record stored under key.
+	Get(ctx context.Context, key string) (*model.IdempotencyRecord, error)
+	// Save stores rec, replacing any record with the same key, and drops
+	// expired records.
+	Save(ctx context.Context, rec *model.IdempotencyRecord) error
+	// DeleteFunc removes the records match reports true for and returns how
+	// many it removed.
+	DeleteFunc(ctx context.Context, match func(*model.IdempotencyRecord) bool) (int, error)
+}
+
+type fileRepository struct {
+	filePath string
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+func New(filePath string) (Repository, error) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if err := os.WriteFile(filePath, []byte("[]"), 0644); err != nil {
+			return nil, errCreateFile
+		}
+	}
+	return &fileRepository{filePath: filePath, now: time.Now}, nil
+}
+
+func (r *fileRepository) Get(ctx context.Context, key string) (*model.IdempotencyRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records, err := r.readNoLock()
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.now()
+	for _, rec := range records {
+		if rec.Key == key && !rec.Expired(now) {
+			return &rec, nil
+		}
+	}
+	return nil, ErrRecordNotFound
+}
+
+func (r *fileRepository) Save(ctx context.Context, rec *model.IdempotencyRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records, err := r.readNoLock()
+	if err != nil {
+		return err
+	}
+
+	now := r.now()
+	kept := records[:0]
+	for _, existing := range records {
+		if existing.Key != rec.Key && !existing.Expired(now) {
+			kept = append(kept, existing)
+		}
+	}
+	kept = append(kept, *rec)
+	return r.writeNoLock(kept)
+}
+
+func (r *fileRepository) DeleteFunc(ctx context.Context, match func(*model.IdempotencyRecord) bool) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records, err := r.readNoLock()
+	if err != nil {
+		return 0, err
+	}
+
+	kept := records[:0]
+	for i := range records {
+		if !match(&records[i]) {
+			kept = append(kept, records[i])
+		}
+	}
+	n := len(records) - len(kept)
+	if n == 0 {
+		return 0, nil
+	}
+	return n, r.writeNoLock(kept)
+}
+
+func (r *fileRepository) readNoLock() ([]model.IdempotencyRecord, error) {
+	data, err := os.ReadFile(r.filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []model.IdempotencyRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (r *fileRepository) writeNoLock(records []model.IdempotencyRecord) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.filePath, data, 0644)
+}
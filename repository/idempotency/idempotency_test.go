@@ -0,0 +1,88 @@
+package idempotency
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+func newTestRepo(t *testing.T, now *time.Time) *fileRepository {
+	repo, err := New(filepath.Join(t.TempDir(), "idempotency.json"))
+	require.NoError(t, err)
+
+	r := repo.(*fileRepository)
+	r.now = func() time.Time { return *now }
+	return r
+}
+
+func record(key string, now time.Time, ttl time.Duration) *model.IdempotencyRecord {
+	return &model.IdempotencyRecord{
+		Key:          key,
+		Method:       "/user.UserService/CreateUser",
+		RequestHash:  "hash-" + key,
+		ResponseType: "user.CreateUserResponse",
+		Response:     []byte{1, 2, 3},
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(ttl),
+	}
+}
+
+func TestFileRepository_SaveAndGet(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newTestRepo(t, &now)
+
+	rec := record("key-1", now, time.Hour)
+	require.NoError(t, repo.Save(context.Background(), rec))
+
+	got, err := repo.Get(context.Background(), "key-1")
+	require.NoError(t, err)
+	assert.Equal(t, rec.RequestHash, got.RequestHash)
+	assert.Equal(t, rec.Response, got.Response)
+
+	_, err = repo.Get(context.Background(), "other")
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestFileRepository_Expiry(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newTestRepo(t, &now)
+
+	require.NoError(t, repo.Save(context.Background(), record("short", now, time.Minute)))
+	require.NoError(t, repo.Save(context.Background(), record("long", now, time.Hour)))
+
+	now = now.Add(2 * time.Minute)
+	_, err := repo.Get(context.Background(), "short")
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+
+	require.NoError(t, repo.Save(context.Background(), record("new", now, time.Hour)))
+	records, err := repo.readNoLock()
+	require.NoError(t, err)
+	assert.Len(t, records, 2, "expired records are dropped on save")
+}
+
+func TestFileRepository_DeleteFunc(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newTestRepo(t, &now)
+	ctx := context.Background()
+
+	for _, key := range []string{"key-1", "key-2", "key-3"} {
+		require.NoError(t, repo.Save(ctx, record(key, now, time.Hour)))
+	}
+
+	n, err := repo.DeleteFunc(ctx, func(rec *model.IdempotencyRecord) bool {
+		return rec.Key != "key-2"
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = repo.Get(ctx, "key-1")
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+	_, err = repo.Get(ctx, "key-2")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,12 @@
+package organization
+
+import "errors"
+
+// Errors every Repository implementation returns.
+var (
+	ErrOrganizationNotFound      = errors.New("organization not found")
+	ErrOrganizationAlreadyExists = errors.New("organization already exists")
+	ErrNameTaken                 = errors.New("organization name is already in use")
+)
+
+var errCreateOrganizationFile = errors.New("failed to create organization file")
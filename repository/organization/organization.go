@@ -0,0 +1,126 @@
+package organization
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+// Repository stores organizations. Implementations must be safe for
+// concurrent use, return organizations in creation order and honour context
+// cancellation.
+type Repository interface {
+	Create(ctx context.Context, org *model.Organization) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Organization, error)
+	GetAll(ctx context.Context) ([]model.Organization, error)
+	Update(ctx context.Context, org *model.Organization) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type fileOrganizationRepository struct {
+	filePath string
+	mu       sync.Mutex
+}
+
+func NewFile(filePath string) (Repository, error) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if err := os.WriteFile(filePath, []byte("{}"), 0644); err != nil {
+			return nil, errCreateOrganizationFile
+		}
+	}
+	return &fileOrganizationRepository{filePath: filePath}, nil
+}
+
+func (r *fileOrganizationRepository) Create(ctx context.Context, org *model.Organization) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.create(org)
+	})
+}
+
+func (r *fileOrganizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Organization, error) {
+	s, err := r.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.get(id)
+}
+
+func (r *fileOrganizationRepository) GetAll(ctx context.Context) ([]model.Organization, error) {
+	s, err := r.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.Organizations, nil
+}
+
+func (r *fileOrganizationRepository) Update(ctx context.Context, org *model.Organization) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.update(org)
+	})
+}
+
+func (r *fileOrganizationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.delete(id)
+	})
+}
+
+func (r *fileOrganizationRepository) read(ctx context.Context) (*store, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.readNoLock()
+}
+
+// modify applies change to the stored data and writes the result unless
+// change fails.
+func (r *fileOrganizationRepository) modify(ctx context.Context, change func(*store) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return err
+	}
+	if err := change(s); err != nil {
+		return err
+	}
+	return r.writeNoLock(s)
+}
+
+func (r *fileOrganizationRepository) readNoLock() (*store, error) {
+	data, err := os.ReadFile(r.filePath)
+	if os.IsNotExist(err) {
+		return &store{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s store
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *fileOrganizationRepository) writeNoLock(s *store) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.filePath, data, 0644)
+}
@@ -0,0 +1,75 @@
+package organization
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+func newTestRepo(t *testing.T) Repository {
+	repo, err := NewFile(filepath.Join(t.TempDir(), "organizations.json"))
+	require.NoError(t, err)
+	return repo
+}
+
+func createOrganizations(t *testing.T, repo Repository, n int) []model.Organization {
+	t.Helper()
+
+	orgs := make([]model.Organization, 0, n)
+	for i := 0; i < n; i++ {
+		o := &model.Organization{
+			ID:        uuid.New(),
+			Name:      fmt.Sprintf("Organization %d", i),
+			NameKey:   fmt.Sprintf("organization %d", i),
+			CreatedAt: time.Date(2025, 1, 1, 0, i, 0, 0, time.UTC),
+		}
+		require.NoError(t, repo.Create(context.Background(), o))
+		orgs = append(orgs, *o)
+	}
+	return orgs
+}
+
+func TestFileOrganizationRepository_CRUD(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	orgs := createOrganizations(t, repo, 3)
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, orgs, all, "organizations are returned in creation order")
+
+	updated := orgs[0]
+	updated.Name, updated.NameKey = "Renamed", "renamed"
+	require.NoError(t, repo.Update(ctx, &updated))
+	got, err := repo.GetByID(ctx, updated.ID)
+	require.NoError(t, err)
+	assert.Equal(t, updated, *got)
+	assert.ErrorIs(t, repo.Create(ctx, &orgs[1]), ErrOrganizationAlreadyExists)
+
+	require.NoError(t, repo.Delete(ctx, orgs[1].ID))
+	assert.ErrorIs(t, repo.Delete(ctx, orgs[1].ID), ErrOrganizationNotFound)
+	_, err = repo.GetByID(ctx, orgs[1].ID)
+	assert.ErrorIs(t, err, ErrOrganizationNotFound)
+}
+
+func TestFileOrganizationRepository_DuplicateName(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	orgs := createOrganizations(t, repo, 2)
+
+	dup := &model.Organization{ID: uuid.New(), Name: "Organization 0", NameKey: orgs[0].NameKey}
+	assert.ErrorIs(t, repo.Create(ctx, dup), ErrNameTaken)
+
+	renamed := orgs[1]
+	renamed.NameKey = orgs[0].NameKey
+	assert.ErrorIs(t, repo.Update(ctx, &renamed), ErrNameTaken)
+	assert.NoError(t, repo.Update(ctx, &orgs[0]), "an organization keeps its own name")
+}
@@ -0,0 +1,68 @@
+package organization
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+// store holds the organizations of a repository; it is also the layout of
+// the organization file. Callers serialise access.
+type store struct {
+	Organizations []model.Organization `json:"organizations"`
+}
+
+func (s *store) create(org *model.Organization) error {
+	if s.indexOf(org.ID) != -1 {
+		return ErrOrganizationAlreadyExists
+	}
+	if s.nameTaken(org) {
+		return ErrNameTaken
+	}
+	s.Organizations = append(s.Organizations, *org)
+	return nil
+}
+
+func (s *store) get(id uuid.UUID) (*model.Organization, error) {
+	i := s.indexOf(id)
+	if i == -1 {
+		return nil, ErrOrganizationNotFound
+	}
+	org := s.Organizations[i]
+	return &org, nil
+}
+
+func (s *store) update(org *model.Organization) error {
+	i := s.indexOf(org.ID)
+	if i == -1 {
+		return ErrOrganizationNotFound
+	}
+	if s.nameTaken(org) {
+		return ErrNameTaken
+	}
+	s.Organizations[i] = *org
+	return nil
+}
+
+func (s *store) delete(id uuid.UUID) error {
+	i := s.indexOf(id)
+	if i == -1 {
+		return ErrOrganizationNotFound
+	}
+	s.Organizations = slices.Delete(s.Organizations, i, i+1)
+	return nil
+}
+
+func (s *store) indexOf(id uuid.UUID) int {
+	return slices.IndexFunc(s.Organizations, func(o model.Organization) bool {
+		return o.ID == id
+	})
+}
+
+func (s *store) nameTaken(org *model.Organization) bool {
+	return slices.ContainsFunc(s.Organizations, func(o model.Organization) bool {
+		return o.ID != org.ID && o.NameKey == org.NameKey
+	})
+}
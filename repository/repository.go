@@ -1,26 +1,179 @@
 package repository
 
-import "github.com/sergey4qb/mf1-test/repository/user"
+import (
+	"github.com/sergey4qb/mf1-test/config"
+	"github.com/sergey4qb/mf1-test/repository/audit"
+	"github.com/sergey4qb/mf1-test/repository/deadletter"
+	"github.com/sergey4qb/mf1-test/repository/group"
+	"github.com/sergey4qb/mf1-test/repository/history"
+	"github.com/sergey4qb/mf1-test/repository/idempotency"
+	"github.com/sergey4qb/mf1-test/repository/organization"
+	"github.com/sergey4qb/mf1-test/repository/role"
+	"github.com/sergey4qb/mf1-test/repository/token"
+	"github.com/sergey4qb/mf1-test/repository/tombstone"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/repository/verification"
+	"github.com/sergey4qb/mf1-test/repository/webhook"
+)
 
 type Repository interface {
 	GetUser() user.Repository
+	GetIdempotency() idempotency.Repository
+	GetVerification() verification.Repository
+	GetToken() token.Repository
+	GetGroup() group.Repository
+	GetRole() role.Repository
+	GetOrganization() organization.Repository
+	GetHistory() history.Repository
+	GetAudit() audit.Repository
+	GetOutbox() user.Outbox
+	GetDeadLetter() deadletter.Repository
+	GetWebhook() webhook.Repository
+	GetTombstone() tombstone.Repository
 }
 
 type repository struct {
-	user user.Repository
+	user         user.Repository
+	idempotency  idempotency.Repository
+	verification verification.Repository
+	token        token.Repository
+	group        group.Repository
+	role         role.Repository
+	organization organization.Repository
+	history      history.Repository
+	audit        audit.Repository
+	outbox       user.Outbox
+	deadLetter   deadletter.Repository
+	webhook      webhook.Repository
+	tombstone    tombstone.Repository
 }
 
-func New() (Repository, error) {
-	user, err := user.New()
+func New(cfg *config.Config) (Repository, error) {
+	users, err := user.NewFile(cfg.UsersFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := history.NewFile(cfg.HistoryFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := audit.NewFile(cfg.AuditFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters, err := deadletter.NewFile(cfg.DeadLettersFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := webhook.NewFile(cfg.WebhooksFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	tombstones, err := tombstone.NewFile(cfg.TombstonesFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	idempotency, err := idempotency.New(cfg.IdempotencyFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	verification, err := verification.New(cfg.VerificationFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := token.New(cfg.TokenFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := group.NewFile(cfg.GroupsFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := role.NewFile(cfg.RolesFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := organization.NewFile(cfg.OrganizationsFilePath())
 	if err != nil {
 		return nil, err
 	}
 
 	return &repository{
-		user: user,
+		user:         history.Record(users, revisions),
+		idempotency:  idempotency,
+		verification: verification,
+		token:        token,
+		group:        group,
+		role:         role,
+		organization: organization,
+		history:      revisions,
+		audit:        entries,
+		outbox:       users,
+		deadLetter:   deadLetters,
+		webhook:      webhooks,
+		tombstone:    tombstones,
 	}, nil
 }
 
 func (r *repository) GetUser() user.Repository {
 	return r.user
 }
+
+func (r *repository) GetIdempotency() idempotency.Repository {
+	return r.idempotency
+}
+
+func (r *repository) GetVerification() verification.Repository {
+	return r.verification
+}
+
+func (r *repository) GetToken() token.Repository {
+	return r.token
+}
+
+func (r *repository) GetGroup() group.Repository {
+	return r.group
+}
+
+func (r *repository) GetRole() role.Repository {
+	return r.role
+}
+
+func (r *repository) GetOrganization() organization.Repository {
+	return r.organization
+}
+
+func (r *repository) GetHistory() history.Repository {
+	return r.history
+}
+
+func (r *repository) GetAudit() audit.Repository {
+	return r.audit
+}
+
+func (r *repository) GetOutbox() user.Outbox {
+	return r.outbox
+}
+
+func (r *repository) GetDeadLetter() deadletter.Repository {
+	return r.deadLetter
+}
+
+func (r *repository) GetWebhook() webhook.Repository {
+	return r.webhook
+}
+
+func (r *repository) GetTombstone() tombstone.Repository {
+	return r.tombstone
+}
@@ -0,0 +1,14 @@
+package role
+
+import "errors"
+
+// Errors every Repository implementation returns.
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleAlreadyExists = errors.New("role already exists")
+	ErrNameTaken         = errors.New("role name is already in use")
+	ErrAlreadyAssigned   = errors.New("role is already assigned")
+	ErrNotAssigned       = errors.New("role is not assigned")
+)
+
+var errCreateRoleFile = errors.New("failed to create role file")
@@ -0,0 +1,165 @@
+package role
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+// Repository stores roles and their assignments. Like user.Repository it is
+// scoped by the organization in the context: roles and assignments are
+// stored for it and only its own are returned or changed. Implementations
+// must be safe for concurrent use, return roles in creation order and
+// assignments in the order they were made, and honour context cancellation.
+type Repository interface {
+	Create(ctx context.Context, role *model.Role) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Role, error)
+	GetAll(ctx context.Context) ([]model.Role, error)
+	Update(ctx context.Context, role *model.Role) error
+	// Delete removes a role together with its assignments.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	Assign(ctx context.Context, assignment *model.RoleAssignment) error
+	Unassign(ctx context.Context, roleID uuid.UUID, principal model.Principal) error
+	// Assignments returns every assignment.
+	Assignments(ctx context.Context) ([]model.RoleAssignment, error)
+	// RemovePrincipal drops every assignment to a principal, e.g. once the
+	// user or group is deleted.
+	RemovePrincipal(ctx context.Context, principal model.Principal) error
+}
+
+type fileRoleRepository struct {
+	filePath string
+	mu       sync.Mutex
+}
+
+func NewFile(filePath string) (Repository, error) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if err := os.WriteFile(filePath, []byte("{}"), 0644); err != nil {
+			return nil, errCreateRoleFile
+		}
+	}
+	return &fileRoleRepository{filePath: filePath}, nil
+}
+
+func (r *fileRoleRepository) Create(ctx context.Context, role *model.Role) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.create(tenant.FromContext(ctx), role)
+	})
+}
+
+func (r *fileRoleRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Role, error) {
+	s, err := r.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.get(tenant.FromContext(ctx), id)
+}
+
+func (r *fileRoleRepository) GetAll(ctx context.Context) ([]model.Role, error) {
+	s, err := r.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.all(tenant.FromContext(ctx)), nil
+}
+
+func (r *fileRoleRepository) Update(ctx context.Context, role *model.Role) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.update(tenant.FromContext(ctx), role)
+	})
+}
+
+func (r *fileRoleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.delete(tenant.FromContext(ctx), id)
+	})
+}
+
+func (r *fileRoleRepository) Assign(ctx context.Context, assignment *model.RoleAssignment) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.assign(tenant.FromContext(ctx), assignment)
+	})
+}
+
+func (r *fileRoleRepository) Unassign(ctx context.Context, roleID uuid.UUID, principal model.Principal) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.unassign(tenant.FromContext(ctx), roleID, principal)
+	})
+}
+
+func (r *fileRoleRepository) Assignments(ctx context.Context) ([]model.RoleAssignment, error) {
+	s, err := r.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.assignments(tenant.FromContext(ctx)), nil
+}
+
+func (r *fileRoleRepository) RemovePrincipal(ctx context.Context, principal model.Principal) error {
+	return r.modify(ctx, func(s *store) error {
+		s.removePrincipal(tenant.FromContext(ctx), principal)
+		return nil
+	})
+}
+
+func (r *fileRoleRepository) read(ctx context.Context) (*store, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.readNoLock()
+}
+
+// modify applies change to the stored data and writes the result unless
+// change fails.
+func (r *fileRoleRepository) modify(ctx context.Context, change func(*store) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return err
+	}
+	if err := change(s); err != nil {
+		return err
+	}
+	return r.writeNoLock(s)
+}
+
+func (r *fileRoleRepository) readNoLock() (*store, error) {
+	data, err := os.ReadFile(r.filePath)
+	if os.IsNotExist(err) {
+		return &store{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s store
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *fileRoleRepository) writeNoLock(s *store) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.filePath, data, 0644)
+}
@@ -0,0 +1,186 @@
+package role
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+func newTestRepo(t *testing.T) Repository {
+	repo, err := NewFile(filepath.Join(t.TempDir(), "roles.json"))
+	require.NoError(t, err)
+	return repo
+}
+
+func createRoles(t *testing.T, repo Repository, n int) []model.Role {
+	t.Helper()
+
+	roles := make([]model.Role, 0, n)
+	for i := 0; i < n; i++ {
+		r := &model.Role{
+			ID:          uuid.New(),
+			Name:        fmt.Sprintf("Role %d", i),
+			NameKey:     fmt.Sprintf("role %d", i),
+			Permissions: []string{fmt.Sprintf("resource%d:read", i)},
+			CreatedAt:   time.Date(2025, 1, 1, 0, i, 0, 0, time.UTC),
+		}
+		require.NoError(t, repo.Create(context.Background(), r))
+		roles = append(roles, *r)
+	}
+	return roles
+}
+
+func assignment(roleID uuid.UUID, kind model.PrincipalKind, id uuid.UUID) *model.RoleAssignment {
+	return &model.RoleAssignment{
+		RoleID:     roleID,
+		Principal:  model.Principal{Kind: kind, ID: id},
+		AssignedAt: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestFileRoleRepository_CreateAndUpdate(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	roles := createRoles(t, repo, 3)
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, roles, all, "roles are returned in creation order")
+
+	updated := roles[0]
+	updated.Permissions = []string{"everything:*"}
+	require.NoError(t, repo.Update(ctx, &updated))
+	got, err := repo.GetByID(ctx, updated.ID)
+	require.NoError(t, err)
+	assert.Equal(t, updated, *got)
+
+	_, err = repo.GetByID(ctx, uuid.New())
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+	assert.ErrorIs(t, repo.Create(ctx, &roles[1]), ErrRoleAlreadyExists)
+}
+
+func TestFileRoleRepository_DuplicateName(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	roles := createRoles(t, repo, 2)
+
+	dup := &model.Role{ID: uuid.New(), Name: "Role 0", NameKey: roles[0].NameKey}
+	assert.ErrorIs(t, repo.Create(ctx, dup), ErrNameTaken)
+
+	renamed := roles[1]
+	renamed.NameKey = roles[0].NameKey
+	assert.ErrorIs(t, repo.Update(ctx, &renamed), ErrNameTaken)
+	assert.NoError(t, repo.Update(ctx, &roles[0]), "a role keeps its own name")
+}
+
+func TestFileRoleRepository_Assignments(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	roles := createRoles(t, repo, 2)
+	userID, groupID := uuid.New(), uuid.New()
+
+	want := []model.RoleAssignment{
+		*assignment(roles[0].ID, model.PrincipalUser, userID),
+		*assignment(roles[1].ID, model.PrincipalGroup, groupID),
+		*assignment(roles[0].ID, model.PrincipalGroup, userID),
+	}
+	for i := range want {
+		require.NoError(t, repo.Assign(ctx, &want[i]))
+	}
+	assert.ErrorIs(t, repo.Assign(ctx, &want[0]), ErrAlreadyAssigned)
+	assert.ErrorIs(t, repo.Assign(ctx, assignment(uuid.New(), model.PrincipalUser, userID)), ErrRoleNotFound)
+
+	got, err := repo.Assignments(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, want, got, "assignments are returned in the order made")
+
+	require.NoError(t, repo.Unassign(ctx, roles[1].ID, want[1].Principal))
+	assert.ErrorIs(t, repo.Unassign(ctx, roles[1].ID, want[1].Principal), ErrNotAssigned)
+	got, err = repo.Assignments(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []model.RoleAssignment{want[0], want[2]}, got)
+}
+
+func TestFileRoleRepository_DeleteRemovesAssignments(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	roles := createRoles(t, repo, 2)
+	userID := uuid.New()
+	for _, r := range roles {
+		require.NoError(t, repo.Assign(ctx, assignment(r.ID, model.PrincipalUser, userID)))
+	}
+
+	require.NoError(t, repo.Delete(ctx, roles[0].ID))
+	assert.ErrorIs(t, repo.Delete(ctx, roles[0].ID), ErrRoleNotFound)
+
+	got, err := repo.Assignments(ctx)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, roles[1].ID, got[0].RoleID)
+}
+
+func TestFileRoleRepository_RemovePrincipal(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	roles := createRoles(t, repo, 2)
+	gone, kept := uuid.New(), uuid.New()
+	for _, r := range roles {
+		require.NoError(t, repo.Assign(ctx, assignment(r.ID, model.PrincipalUser, gone)))
+		require.NoError(t, repo.Assign(ctx, assignment(r.ID, model.PrincipalGroup, gone)))
+		require.NoError(t, repo.Assign(ctx, assignment(r.ID, model.PrincipalUser, kept)))
+	}
+
+	require.NoError(t, repo.RemovePrincipal(ctx, model.Principal{Kind: model.PrincipalUser, ID: gone}))
+
+	got, err := repo.Assignments(ctx)
+	require.NoError(t, err)
+	require.Len(t, got, 4, "the group with the same ID keeps its assignments")
+	for _, a := range got {
+		assert.False(t, a.Principal.Kind == model.PrincipalUser && a.Principal.ID == gone)
+	}
+}
+
+func TestFileRoleRepository_TenantIsolation(t *testing.T) {
+	repo := newTestRepo(t)
+	org := uuid.New()
+	acme := tenant.NewContext(context.Background(), org)
+	globex := tenant.NewContext(context.Background(), uuid.New())
+
+	r := createRoles(t, repo, 1)[0]
+	scoped := &model.Role{ID: uuid.New(), Name: r.Name, NameKey: r.NameKey}
+	require.NoError(t, repo.Create(acme, scoped), "names are unique per organization")
+	assert.Equal(t, org, scoped.OrganizationID)
+	userID := uuid.New()
+	a := assignment(scoped.ID, model.PrincipalUser, userID)
+	require.NoError(t, repo.Assign(acme, a))
+	assert.Equal(t, org, a.OrganizationID)
+	require.NoError(t, repo.Assign(context.Background(), assignment(r.ID, model.PrincipalUser, userID)))
+
+	all, err := repo.GetAll(acme)
+	require.NoError(t, err)
+	assert.Equal(t, []model.Role{*scoped}, all)
+	got, err := repo.Assignments(acme)
+	require.NoError(t, err)
+	assert.Equal(t, []model.RoleAssignment{*a}, got)
+
+	_, err = repo.GetByID(globex, scoped.ID)
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+	assert.ErrorIs(t, repo.Update(globex, scoped), ErrRoleNotFound)
+	assert.ErrorIs(t, repo.Delete(globex, scoped.ID), ErrRoleNotFound)
+	assert.ErrorIs(t, repo.Assign(globex, assignment(scoped.ID, model.PrincipalUser, uuid.New())), ErrRoleNotFound)
+	assert.ErrorIs(t, repo.Unassign(globex, scoped.ID, a.Principal), ErrRoleNotFound)
+
+	require.NoError(t, repo.RemovePrincipal(context.Background(), a.Principal))
+	got, err = repo.Assignments(acme)
+	require.NoError(t, err)
+	assert.Len(t, got, 1, "removing a principal leaves other organizations alone")
+}
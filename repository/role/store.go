@@ -0,0 +1,142 @@
+package role
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+// store holds the roles and assignments of a repository; it is also the
+// layout of the role file. Callers serialise access.
+type store struct {
+	Roles       []model.Role           `json:"roles"`
+	Assignments []model.RoleAssignment `json:"assignments"`
+}
+
+func (s *store) create(org uuid.UUID, role *model.Role) error {
+	if s.indexOf(role.ID) != -1 {
+		return ErrRoleAlreadyExists
+	}
+	role.OrganizationID = org
+	if s.nameTaken(role) {
+		return ErrNameTaken
+	}
+	s.Roles = append(s.Roles, role.Clone())
+	return nil
+}
+
+func (s *store) get(org, id uuid.UUID) (*model.Role, error) {
+	i := s.indexIn(org, id)
+	if i == -1 {
+		return nil, ErrRoleNotFound
+	}
+	role := s.Roles[i].Clone()
+	return &role, nil
+}
+
+func (s *store) all(org uuid.UUID) []model.Role {
+	var roles []model.Role
+	for i := range s.Roles {
+		if s.Roles[i].OrganizationID == org {
+			roles = append(roles, s.Roles[i].Clone())
+		}
+	}
+	return roles
+}
+
+func (s *store) update(org uuid.UUID, role *model.Role) error {
+	i := s.indexIn(org, role.ID)
+	if i == -1 {
+		return ErrRoleNotFound
+	}
+	role.OrganizationID = org
+	if s.nameTaken(role) {
+		return ErrNameTaken
+	}
+	s.Roles[i] = role.Clone()
+	return nil
+}
+
+func (s *store) delete(org, id uuid.UUID) error {
+	i := s.indexIn(org, id)
+	if i == -1 {
+		return ErrRoleNotFound
+	}
+	s.Roles = slices.Delete(s.Roles, i, i+1)
+	s.Assignments = slices.DeleteFunc(s.Assignments, func(a model.RoleAssignment) bool {
+		return a.RoleID == id
+	})
+	return nil
+}
+
+func (s *store) assign(org uuid.UUID, assignment *model.RoleAssignment) error {
+	if s.indexIn(org, assignment.RoleID) == -1 {
+		return ErrRoleNotFound
+	}
+	if slices.ContainsFunc(s.Assignments, func(a model.RoleAssignment) bool {
+		return a.RoleID == assignment.RoleID && a.Principal == assignment.Principal
+	}) {
+		return ErrAlreadyAssigned
+	}
+	assignment.OrganizationID = org
+	s.Assignments = append(s.Assignments, *assignment)
+	return nil
+}
+
+func (s *store) unassign(org, roleID uuid.UUID, principal model.Principal) error {
+	if s.indexIn(org, roleID) == -1 {
+		return ErrRoleNotFound
+	}
+	n := len(s.Assignments)
+	s.Assignments = slices.DeleteFunc(s.Assignments, func(a model.RoleAssignment) bool {
+		return a.RoleID == roleID && a.Principal == principal
+	})
+	if len(s.Assignments) == n {
+		return ErrNotAssigned
+	}
+	return nil
+}
+
+func (s *store) assignments(org uuid.UUID) []model.RoleAssignment {
+	var assignments []model.RoleAssignment
+	for _, a := range s.Assignments {
+		if a.OrganizationID == org {
+			assignments = append(assignments, a)
+		}
+	}
+	return assignments
+}
+
+func (s *store) removePrincipal(org uuid.UUID, principal model.Principal) {
+	s.Assignments = slices.DeleteFunc(s.Assignments, func(a model.RoleAssignment) bool {
+		return a.OrganizationID == org && a.Principal == principal
+	})
+}
+
+func (s *store) indexOf(id uuid.UUID) int {
+	return slices.IndexFunc(s.Roles, func(r model.Role) bool {
+		return r.ID == id
+	})
+}
+
+// indexIn is indexOf limited to the roles of org.
+func (s *store) indexIn(org, id uuid.UUID) int {
+	i := s.indexOf(id)
+	if i == -1 || s.Roles[i].OrganizationID != org {
+		return -1
+	}
+	return i
+}
+
+// nameTaken reports whether another role of the same organization has the
+// name of role. Roles without a name key never collide.
+func (s *store) nameTaken(role *model.Role) bool {
+	if role.NameKey == "" {
+		return false
+	}
+	return slices.ContainsFunc(s.Roles, func(r model.Role) bool {
+		return r.ID != role.ID && r.OrganizationID == role.OrganizationID && r.NameKey == role.NameKey
+	})
+}
@@ -0,0 +1,7 @@
+package token
+
+import "errors"
+
+var ErrTokenNotFound = errors.New("refresh token not found")
+
+var errCreateFile = errors.New("failed to create token file")
@@ -0,0 +1,248 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+type Repository interface {
+	// Save stores token and drops expired tokens.
+	Save(ctx context.Context, token *model.RefreshToken) error
+	// Get returns the unexpired token with the given hash.
+	Get(ctx context.Context, hash string) (*model.RefreshToken, error)
+	// Use marks the unexpired token with the given hash used at the given
+	// time and returns it as it was before, so a token that comes back with
+	// UsedAt set had already been used.
+	Use(ctx context.Context, hash string, at time.Time) (*model.RefreshToken, error)
+	// RevokeFamily revokes every token of a family.
+	RevokeFamily(ctx context.Context, familyID uuid.UUID, at time.Time) error
+	// RevokeUser revokes every token of a user.
+	RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time) error
+	// ForUser returns the unexpired tokens of a user.
+	ForUser(ctx context.Context, userID uuid.UUID) ([]model.RefreshToken, error)
+	// DeleteUser removes every token of a user and returns how many it
+	// removed.
+	DeleteUser(ctx context.Context, userID uuid.UUID) (int, error)
+
+	// Keys returns the stored signing keys, oldest first.
+	Keys(ctx context.Context) ([]model.SigningKey, error)
+	// SaveKeys replaces the stored signing keys.
+	SaveKeys(ctx context.Context, keys []model.SigningKey) error
+}
+
+// store is the layout of the token file. It holds private keys, so it is
+// written readable by the owner only.
+type store struct {
+	Keys          []model.SigningKey   `json:"keys"`
+	RefreshTokens []model.RefreshToken `json:"refresh_tokens"`
+}
+
+type fileRepository struct {
+	filePath string
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+func New(filePath string) (Repository, error) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if err := os.WriteFile(filePath, []byte("{}"), 0600); err != nil {
+			return nil, errCreateFile
+		}
+	}
+	return &fileRepository{filePath: filePath, now: time.Now}, nil
+}
+
+func (r *fileRepository) Save(ctx context.Context, token *model.RefreshToken) error {
+	return r.modify(ctx, func(s *store) error {
+		s.RefreshTokens = append(s.RefreshTokens, *token)
+		return nil
+	})
+}
+
+func (r *fileRepository) Get(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return nil, err
+	}
+	now := r.now()
+	for _, token := range s.RefreshTokens {
+		if token.Hash == hash && !token.Expired(now) {
+			return &token, nil
+		}
+	}
+	return nil, ErrTokenNotFound
+}
+
+func (r *fileRepository) Use(ctx context.Context, hash string, at time.Time) (*model.RefreshToken, error) {
+	var found *model.RefreshToken
+	err := r.modify(ctx, func(s *store) error {
+		for i := range s.RefreshTokens {
+			token := &s.RefreshTokens[i]
+			if token.Hash != hash {
+				continue
+			}
+			before := *token
+			found = &before
+			if token.UsedAt == nil {
+				token.UsedAt = &at
+			}
+			return nil
+		}
+		return ErrTokenNotFound
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+func (r *fileRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID, at time.Time) error {
+	return r.revoke(ctx, at, func(token *model.RefreshToken) bool {
+		return token.FamilyID == familyID
+	})
+}
+
+func (r *fileRepository) RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	return r.revoke(ctx, at, func(token *model.RefreshToken) bool {
+		return token.UserID == userID
+	})
+}
+
+func (r *fileRepository) revoke(ctx context.Context, at time.Time, match func(*model.RefreshToken) bool) error {
+	return r.modify(ctx, func(s *store) error {
+		for i := range s.RefreshTokens {
+			token := &s.RefreshTokens[i]
+			if token.RevokedAt == nil && match(token) {
+				token.RevokedAt = &at
+			}
+		}
+		return nil
+	})
+}
+
+func (r *fileRepository) ForUser(ctx context.Context, userID uuid.UUID) ([]model.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return nil, err
+	}
+	now := r.now()
+	var tokens []model.RefreshToken
+	for _, token := range s.RefreshTokens {
+		if token.UserID == userID && !token.Expired(now) {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *fileRepository) DeleteUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	var n int
+	err := r.modify(ctx, func(s *store) error {
+		kept := s.RefreshTokens[:0]
+		for _, token := range s.RefreshTokens {
+			if token.UserID != userID {
+				kept = append(kept, token)
+			}
+		}
+		n = len(s.RefreshTokens) - len(kept)
+		s.RefreshTokens = kept
+		return nil
+	})
+	return n, err
+}
+
+func (r *fileRepository) Keys(ctx context.Context) ([]model.SigningKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return nil, err
+	}
+	return s.Keys, nil
+}
+
+func (r *fileRepository) SaveKeys(ctx context.Context, keys []model.SigningKey) error {
+	return r.modify(ctx, func(s *store) error {
+		s.Keys = keys
+		return nil
+	})
+}
+
+// modify applies change to the stored data with expired tokens dropped and
+// writes the result unless change fails.
+func (r *fileRepository) modify(ctx context.Context, change func(*store) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return err
+	}
+	now := r.now()
+	kept := s.RefreshTokens[:0]
+	for _, token := range s.RefreshTokens {
+		if !token.Expired(now) {
+			kept = append(kept, token)
+		}
+	}
+	s.RefreshTokens = kept
+
+	if err := change(s); err != nil {
+		return err
+	}
+	return r.writeNoLock(s)
+}
+
+func (r *fileRepository) readNoLock() (*store, error) {
+	data, err := os.ReadFile(r.filePath)
+	if os.IsNotExist(err) {
+		return &store{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s store
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *fileRepository) writeNoLock(s *store) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.filePath, data, 0600)
+}
@@ -0,0 +1,150 @@
+package token
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+func newTestRepo(t *testing.T, now *time.Time) *fileRepository {
+	repo, err := New(filepath.Join(t.TempDir(), "tokens.json"))
+	require.NoError(t, err)
+
+	r := repo.(*fileRepository)
+	r.now = func() time.Time { return *now }
+	return r
+}
+
+func refreshToken(hash string, family uuid.UUID, now time.Time, ttl time.Duration) *model.RefreshToken {
+	return &model.RefreshToken{
+		Hash:      hash,
+		FamilyID:  family,
+		UserID:    uuid.New(),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+}
+
+func TestFileRepository_Use(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newTestRepo(t, &now)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Save(ctx, refreshToken("hash-1", uuid.New(), now, time.Hour)))
+
+	first, err := repo.Use(ctx, "hash-1", now)
+	require.NoError(t, err)
+	assert.Nil(t, first.UsedAt)
+
+	second, err := repo.Use(ctx, "hash-1", now.Add(time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, second.UsedAt)
+	assert.True(t, second.UsedAt.Equal(now), "the first use is kept")
+
+	_, err = repo.Use(ctx, "missing", now)
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+}
+
+func TestFileRepository_Revoke(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newTestRepo(t, &now)
+	ctx := context.Background()
+
+	family := uuid.New()
+	require.NoError(t, repo.Save(ctx, refreshToken("a", family, now, time.Hour)))
+	require.NoError(t, repo.Save(ctx, refreshToken("b", family, now, time.Hour)))
+	other := refreshToken("c", uuid.New(), now, time.Hour)
+	require.NoError(t, repo.Save(ctx, other))
+
+	require.NoError(t, repo.RevokeFamily(ctx, family, now))
+	for _, hash := range []string{"a", "b"} {
+		got, err := repo.Get(ctx, hash)
+		require.NoError(t, err)
+		assert.NotNil(t, got.RevokedAt, hash)
+	}
+	got, err := repo.Get(ctx, "c")
+	require.NoError(t, err)
+	assert.Nil(t, got.RevokedAt)
+
+	require.NoError(t, repo.RevokeUser(ctx, other.UserID, now))
+	got, err = repo.Get(ctx, "c")
+	require.NoError(t, err)
+	assert.NotNil(t, got.RevokedAt)
+}
+
+func TestFileRepository_DeleteUser(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newTestRepo(t, &now)
+	ctx := context.Background()
+
+	first := refreshToken("a", uuid.New(), now, time.Hour)
+	second := refreshToken("b", uuid.New(), now, time.Hour)
+	second.UserID = first.UserID
+	require.NoError(t, repo.Save(ctx, first))
+	require.NoError(t, repo.Save(ctx, second))
+	require.NoError(t, repo.Save(ctx, refreshToken("c", uuid.New(), now, time.Hour)))
+
+	got, err := repo.ForUser(ctx, first.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, []model.RefreshToken{*first, *second}, got)
+
+	n, err := repo.DeleteUser(ctx, first.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	got, err = repo.ForUser(ctx, first.UserID)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	_, err = repo.Get(ctx, "c")
+	assert.NoError(t, err, "DeleteUser must leave other users' tokens alone")
+}
+
+func TestFileRepository_ExpiredTokens(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newTestRepo(t, &now)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Save(ctx, refreshToken("old", uuid.New(), now, time.Minute)))
+	now = now.Add(time.Minute)
+
+	_, err := repo.Get(ctx, "old")
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+	_, err = repo.Use(ctx, "old", now)
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+
+	require.NoError(t, repo.Save(ctx, refreshToken("new", uuid.New(), now, time.Hour)))
+	s, err := repo.readNoLock()
+	require.NoError(t, err)
+	require.Len(t, s.RefreshTokens, 1)
+	assert.Equal(t, "new", s.RefreshTokens[0].Hash)
+}
+
+func TestFileRepository_Keys(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newTestRepo(t, &now)
+	ctx := context.Background()
+
+	keys, err := repo.Keys(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	saved := []model.SigningKey{{ID: "k1", Algorithm: "ES256", PrivateKey: []byte{1, 2, 3}, CreatedAt: now}}
+	require.NoError(t, repo.SaveKeys(ctx, saved))
+	require.NoError(t, repo.Save(ctx, refreshToken("hash", uuid.New(), now, time.Hour)))
+
+	keys, err = repo.Keys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, saved, keys)
+
+	info, err := os.Stat(repo.filePath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
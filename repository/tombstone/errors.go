@@ -0,0 +1,5 @@
+package tombstone
+
+import "errors"
+
+var errCreateTombstoneFile = errors.New("failed to create tombstone file")
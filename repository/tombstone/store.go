@@ -0,0 +1,28 @@
+package tombstone
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+// store holds the tombstones of a repository; it is also the layout of the
+// tombstone file. Callers serialise access.
+type store struct {
+	Tombstones []model.Tombstone `json:"tombstones"`
+}
+
+func (s *store) add(org uuid.UUID, t *model.Tombstone) {
+	t.OrganizationID = org
+	s.Tombstones = append(s.Tombstones, t.Clone())
+}
+
+func (s *store) list(org, userID uuid.UUID) []model.Tombstone {
+	var tombstones []model.Tombstone
+	for _, t := range s.Tombstones {
+		if t.OrganizationID == org && t.UserID == userID {
+			tombstones = append(tombstones, t.Clone())
+		}
+	}
+	return tombstones
+}
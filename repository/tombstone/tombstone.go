@@ -0,0 +1,95 @@
+// Package tombstone stores the records left behind when the data about a
+// user is erased.
+package tombstone
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+// Repository stores tombstones. Like user.Repository it is scoped by the
+// organization in the context: tombstones are stored for it and only its
+// tombstones are returned. Implementations must be safe for concurrent use
+// and honour context cancellation.
+type Repository interface {
+	// Add stores t, setting its OrganizationID.
+	Add(ctx context.Context, t *model.Tombstone) error
+	// List returns the tombstones of a user in the order they were added.
+	List(ctx context.Context, userID uuid.UUID) ([]model.Tombstone, error)
+}
+
+type fileTombstoneRepository struct {
+	filePath string
+	mu       sync.Mutex
+}
+
+func NewFile(filePath string) (Repository, error) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if err := os.WriteFile(filePath, []byte("{}"), 0644); err != nil {
+			return nil, errCreateTombstoneFile
+		}
+	}
+	return &fileTombstoneRepository{filePath: filePath}, nil
+}
+
+func (r *fileTombstoneRepository) Add(ctx context.Context, t *model.Tombstone) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return err
+	}
+	s.add(tenant.FromContext(ctx), t)
+	return r.writeNoLock(s)
+}
+
+func (r *fileTombstoneRepository) List(ctx context.Context, userID uuid.UUID) ([]model.Tombstone, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return nil, err
+	}
+	return s.list(tenant.FromContext(ctx), userID), nil
+}
+
+func (r *fileTombstoneRepository) readNoLock() (*store, error) {
+	data, err := os.ReadFile(r.filePath)
+	if os.IsNotExist(err) {
+		return &store{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s store
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *fileTombstoneRepository) writeNoLock(s *store) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.filePath, data, 0644)
+}
@@ -0,0 +1,70 @@
+package tombstone
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+func newTestRepo(t *testing.T) Repository {
+	repo, err := NewFile(filepath.Join(t.TempDir(), "tombstones.json"))
+	require.NoError(t, err)
+	return repo
+}
+
+func newTombstone(userID uuid.UUID, n int) *model.Tombstone {
+	return &model.Tombstone{
+		UserID:    userID,
+		ErasedAt:  time.Date(2025, 1, 1, n, 0, 0, 0, time.UTC),
+		Actor:     "dpo",
+		RequestID: "req-1",
+		Erased:    map[string]int{"users": 1, "history": n},
+	}
+}
+
+func TestFileRepository_AddAndList(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	var want []model.Tombstone
+	for n := 1; n <= 2; n++ {
+		ts := newTombstone(userID, n)
+		require.NoError(t, repo.Add(ctx, ts))
+		assert.Equal(t, tenant.Default, ts.OrganizationID)
+		want = append(want, *ts)
+		require.NoError(t, repo.Add(ctx, newTombstone(uuid.New(), n)))
+	}
+
+	got, err := repo.List(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, want, got, "tombstones of the user are returned in the order added")
+}
+
+func TestFileRepository_TenantIsolation(t *testing.T) {
+	repo := newTestRepo(t)
+	org := uuid.New()
+	scoped := tenant.NewContext(context.Background(), org)
+	userID := uuid.New()
+
+	require.NoError(t, repo.Add(context.Background(), newTombstone(userID, 1)))
+	ts := newTombstone(userID, 2)
+	require.NoError(t, repo.Add(scoped, ts))
+	assert.Equal(t, org, ts.OrganizationID)
+
+	got, err := repo.List(scoped, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []model.Tombstone{*ts}, got)
+
+	got, err = repo.List(tenant.NewContext(context.Background(), uuid.New()), userID)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
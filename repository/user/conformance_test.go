@@ -0,0 +1,39 @@
+package user_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/repository/user/repositorytest"
+)
+
+func TestFileUserRepository_Conformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) user.Repository {
+		repo, err := user.NewFile(filepath.Join(t.TempDir(), "users.json"))
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestMemoryUserRepository_Conformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) user.Repository {
+		return user.NewMemory()
+	})
+}
+
+func TestFileUserRepository_OutboxConformance(t *testing.T) {
+	repositorytest.RunOutbox(t, func(t *testing.T) user.Store {
+		repo, err := user.NewFile(filepath.Join(t.TempDir(), "users.json"))
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestMemoryUserRepository_OutboxConformance(t *testing.T) {
+	repositorytest.RunOutbox(t, func(t *testing.T) user.Store {
+		return user.NewMemory()
+	})
+}
@@ -2,7 +2,15 @@ package user
 
 import "errors"
 
+// Errors every Repository implementation returns, see repositorytest.
 var (
-	errCreateUserFile = errors.New("failed to create user file")
-	errUserNotFound   = errors.New("user not found")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrUserAlreadyExists = errors.New("user already exists")
+	ErrEmailTaken        = errors.New("email is already in use")
+	ErrEventNotFound     = errors.New("event not found")
+)
+
+var (
+	errCreateUserFile    = errors.New("failed to create user file")
+	errUnsupportedSchema = errors.New("unsupported storage schema version")
 )
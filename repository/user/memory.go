@@ -0,0 +1,169 @@
+package user
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+type memoryUserRepository struct {
+	org    uuid.UUID
+	mu     sync.RWMutex
+	users  []model.User
+	outbox []model.PendingEvent
+}
+
+// NewMemory returns a Store kept in memory, for tests and tools that do
+// not need persistence.
+func NewMemory() Store {
+	return newTenantRepository(func(org uuid.UUID) (Store, error) {
+		return &memoryUserRepository{org: org}, nil
+	})
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *model.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.indexNoLock(user.ID) != -1 {
+		return ErrUserAlreadyExists
+	}
+	if emailTaken(r.users, user) {
+		return ErrEmailTaken
+	}
+	r.users = append(r.users, user.Clone())
+	r.outbox = append(r.outbox, *newEvent(r.org, nil, user))
+	return nil
+}
+
+func (r *memoryUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.indexNoLock(id)
+	if i == -1 {
+		return nil, ErrUserNotFound
+	}
+	u := r.users[i].Clone()
+	return &u, nil
+}
+
+func (r *memoryUserRepository) GetAll(ctx context.Context) ([]model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]model.User, len(r.users))
+	for i := range r.users {
+		users[i] = r.users[i].Clone()
+	}
+	return users, nil
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, user *model.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexNoLock(user.ID)
+	if i == -1 {
+		return ErrUserNotFound
+	}
+	if emailTaken(r.users, user) {
+		return ErrEmailTaken
+	}
+	if event := newEvent(r.org, &r.users[i], user); event != nil {
+		r.outbox = append(r.outbox, *event)
+	}
+	r.users[i] = user.Clone()
+	return nil
+}
+
+func (r *memoryUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexNoLock(id)
+	if i == -1 {
+		return ErrUserNotFound
+	}
+	r.outbox = append(r.outbox, *newEvent(r.org, &r.users[i], nil))
+	r.users = append(r.users[:i], r.users[i+1:]...)
+	return nil
+}
+
+func (r *memoryUserRepository) Pending(ctx context.Context) ([]model.PendingEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return cloneEvents(r.outbox), nil
+}
+
+func (r *memoryUserRepository) Reschedule(ctx context.Context, event *model.PendingEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return reschedule(r.outbox, event)
+}
+
+func (r *memoryUserRepository) Remove(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var err error
+	r.outbox, err = removeEvent(r.outbox, id)
+	return err
+}
+
+func (r *memoryUserRepository) Redact(ctx context.Context, userID uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return redactEvents(r.outbox, userID), nil
+}
+
+func (r *memoryUserRepository) indexNoLock(id uuid.UUID) int {
+	for i := range r.users {
+		if r.users[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,121 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+// Outbox holds the events raised by changes to users until they are
+// published. Stores keep each event in the same write as the change raising
+// it, so neither is kept without the other. Like Repository it is scoped by
+// the organization in the context.
+type Outbox interface {
+	// Pending returns the events waiting to be published, oldest first.
+	Pending(ctx context.Context) ([]model.PendingEvent, error)
+	// Reschedule saves the delivery state of a pending event.
+	Reschedule(ctx context.Context, event *model.PendingEvent) error
+	// Remove drops an event once it is published or given up on.
+	Remove(ctx context.Context, id uuid.UUID) error
+	// Redact drops the user snapshots from the pending events of a user,
+	// which are published without them, and returns how many events it
+	// changed.
+	Redact(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// Store is a Repository that keeps the events of its changes in an Outbox.
+type Store interface {
+	Repository
+	Outbox
+}
+
+// newEvent returns the event raised by changing before into after; before
+// is nil for creations and after for deletions. Changes to credentials and
+// login state alone raise none.
+func newEvent(org uuid.UUID, before, after *model.User) *model.PendingEvent {
+	event := &model.PendingEvent{Event: model.Event{
+		ID:             uuid.New(),
+		OrganizationID: org,
+		At:             time.Now().UTC(),
+	}}
+	switch {
+	case before == nil:
+		event.Type, event.UserID = model.EventUserCreated, after.ID
+	case after == nil:
+		event.Type, event.UserID = model.EventUserDeleted, before.ID
+		return event
+	default:
+		event.Type, event.UserID = model.EventUserUpdated, after.ID
+	}
+
+	saved := after.WithoutCredentials()
+	if before != nil && samePublicFields(before.WithoutCredentials(), saved) {
+		return nil
+	}
+	event.User = &saved
+	return event
+}
+
+// samePublicFields compares users by their JSON form, which ignores the
+// monotonic clock readings of times that reflect.DeepEqual would see.
+func samePublicFields(a, b model.User) bool {
+	x, errX := json.Marshal(a)
+	y, errY := json.Marshal(b)
+	return errX == nil && errY == nil && bytes.Equal(x, y)
+}
+
+func eventIndex(events []model.PendingEvent, id uuid.UUID) int {
+	for i := range events {
+		if events[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// reschedule copies the delivery state of event to its stored copy.
+func reschedule(events []model.PendingEvent, event *model.PendingEvent) error {
+	i := eventIndex(events, event.ID)
+	if i == -1 {
+		return ErrEventNotFound
+	}
+	updated := event.Clone()
+	events[i].Delivered = updated.Delivered
+	events[i].Attempts = updated.Attempts
+	events[i].NextAttempt = updated.NextAttempt
+	events[i].LastError = updated.LastError
+	return nil
+}
+
+// redactEvents drops the user snapshots from the events of userID.
+func redactEvents(events []model.PendingEvent, userID uuid.UUID) int {
+	var n int
+	for i := range events {
+		if events[i].UserID == userID && events[i].User != nil {
+			events[i].User = nil
+			n++
+		}
+	}
+	return n
+}
+
+func removeEvent(events []model.PendingEvent, id uuid.UUID) ([]model.PendingEvent, error) {
+	i := eventIndex(events, id)
+	if i == -1 {
+		return nil, ErrEventNotFound
+	}
+	return append(events[:i], events[i+1:]...), nil
+}
+
+func cloneEvents(events []model.PendingEvent) []model.PendingEvent {
+	out := make([]model.PendingEvent, len(events))
+	for i := range events {
+		out[i] = events[i].Clone()
+	}
+	return out
+}
@@ -0,0 +1,185 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+// StoreFactory returns a new, empty store for each subtest.
+type StoreFactory func(t *testing.T) user.Store
+
+// RunOutbox executes the conformance checks of the user.Outbox of stores
+// from factory.
+func RunOutbox(t *testing.T, factory StoreFactory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store user.Store)
+	}{
+		{"RaisesEvents", testRaisesEvents},
+		{"CredentialsRaiseNone", testCredentialsRaiseNone},
+		{"RescheduleAndRemove", testRescheduleAndRemove},
+		{"Redact", testRedact},
+		{"OutboxTenantIsolation", testOutboxTenantIsolation},
+		{"OutboxCancelledContext", testOutboxCancelledContext},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, factory(t))
+		})
+	}
+}
+
+func testRaisesEvents(t *testing.T, store user.Store) {
+	ctx := context.Background()
+	u := newUser(1)
+	u.PasswordHash = "secret-hash"
+	require.NoError(t, store.Create(ctx, u))
+	updated := *u
+	updated.Name = "Renamed"
+	require.NoError(t, store.Update(ctx, &updated))
+	require.NoError(t, store.Delete(ctx, u.ID))
+
+	events, err := store.Pending(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	var types []model.EventType
+	for _, e := range events {
+		types = append(types, e.Type)
+		assert.NotEqual(t, uuid.Nil, e.ID)
+		assert.Equal(t, u.ID, e.UserID)
+		assert.Equal(t, tenant.Default, e.OrganizationID)
+		assert.False(t, e.At.IsZero())
+		assert.Zero(t, e.Attempts)
+	}
+	assert.Equal(t, []model.EventType{model.EventUserCreated, model.EventUserUpdated, model.EventUserDeleted}, types)
+
+	require.NotNil(t, events[0].User)
+	assert.Equal(t, u.Name, events[0].User.Name)
+	assert.Empty(t, events[0].User.PasswordHash, "events carry no credentials")
+	require.NotNil(t, events[1].User)
+	assert.Equal(t, "Renamed", events[1].User.Name)
+	assert.Nil(t, events[2].User)
+}
+
+func testCredentialsRaiseNone(t *testing.T, store user.Store) {
+	ctx := context.Background()
+	u := newUser(1)
+	require.NoError(t, store.Create(ctx, u))
+
+	locked := *u
+	until := time.Now().Add(time.Hour)
+	locked.PasswordHash, locked.LockedUntil = "new-hash", &until
+	require.NoError(t, store.Update(ctx, &locked))
+
+	events, err := store.Pending(ctx)
+	require.NoError(t, err)
+	assert.Len(t, events, 1, "only the creation raises an event")
+}
+
+func testRescheduleAndRemove(t *testing.T, store user.Store) {
+	ctx := context.Background()
+	createUsers(t, store, 2)
+
+	events, err := store.Pending(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	next := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+	failed := events[0]
+	failed.Attempts, failed.NextAttempt, failed.LastError = 1, &next, "sink down"
+	failed.Delivered = []string{"file"}
+	failed.Type = model.EventUserDeleted
+	require.NoError(t, store.Reschedule(ctx, &failed))
+	failed.Delivered[0] = "changed after saving"
+
+	require.NoError(t, store.Remove(ctx, events[1].ID))
+
+	pending, err := store.Pending(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, events[0].ID, pending[0].ID)
+	assert.Equal(t, 1, pending[0].Attempts)
+	require.NotNil(t, pending[0].NextAttempt)
+	assert.True(t, next.Equal(*pending[0].NextAttempt))
+	assert.Equal(t, "sink down", pending[0].LastError)
+	assert.Equal(t, []string{"file"}, pending[0].Delivered)
+	assert.Equal(t, model.EventUserCreated, pending[0].Type, "only the delivery state is saved")
+
+	assert.ErrorIs(t, store.Remove(ctx, events[1].ID), user.ErrEventNotFound)
+	missing := events[1]
+	assert.ErrorIs(t, store.Reschedule(ctx, &missing), user.ErrEventNotFound)
+}
+
+func testRedact(t *testing.T, store user.Store) {
+	ctx := context.Background()
+	u, other := newUser(1), newUser(2)
+	require.NoError(t, store.Create(ctx, u))
+	require.NoError(t, store.Create(ctx, other))
+	require.NoError(t, store.Delete(ctx, u.ID))
+
+	n, err := store.Redact(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "only events carrying the user are counted")
+
+	events, err := store.Pending(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 3, "Redact must keep the events")
+	assert.Nil(t, events[0].User)
+	assert.Equal(t, u.ID, events[0].UserID)
+	require.NotNil(t, events[1].User, "Redact must leave other users alone")
+	assert.Equal(t, other.Name, events[1].User.Name)
+
+	n, err = store.Redact(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func testOutboxTenantIsolation(t *testing.T, store user.Store) {
+	org := uuid.New()
+	acme := tenant.NewContext(context.Background(), org)
+	globex := tenant.NewContext(context.Background(), uuid.New())
+
+	require.NoError(t, store.Create(acme, newUser(1)))
+
+	events, err := store.Pending(globex)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+	events, err = store.Pending(acme)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, org, events[0].OrganizationID)
+
+	assert.ErrorIs(t, store.Remove(globex, events[0].ID), user.ErrEventNotFound)
+	require.NoError(t, store.Remove(acme, events[0].ID))
+}
+
+func testOutboxCancelledContext(t *testing.T, store user.Store) {
+	createUsers(t, store, 1)
+	events, err := store.Pending(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = store.Pending(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, store.Reschedule(ctx, &events[0]), context.Canceled)
+	assert.ErrorIs(t, store.Remove(ctx, events[0].ID), context.Canceled)
+	_, err = store.Redact(ctx, events[0].UserID)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	pending, err := store.Pending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, events, pending, "cancelled calls must not change the outbox")
+}
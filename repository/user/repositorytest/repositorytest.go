@@ -0,0 +1,356 @@
+// Package repositorytest is a conformance suite for user.Repository
+// implementations. A new backend is expected to pass it unchanged:
+//
+//	func TestConformance(t *testing.T) {
+//		repositorytest.Run(t, func(t *testing.T) user.Repository {
+//			return newBackend(t)
+//		})
+//	}
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+// Factory returns a new, empty repository for each subtest.
+type Factory func(t *testing.T) user.Repository
+
+// Run executes every conformance check against repositories from factory.
+// Run it with -race to catch unsynchronised implementations.
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo user.Repository)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"GetAllEmpty", testGetAllEmpty},
+		{"GetByIDNotFound", testGetByIDNotFound},
+		{"Update", testUpdate},
+		{"UpdateNotFound", testUpdateNotFound},
+		{"Delete", testDelete},
+		{"DeleteNotFound", testDeleteNotFound},
+		{"DuplicateID", testDuplicateID},
+		{"DuplicateEmail", testDuplicateEmail},
+		{"TenantIsolation", testTenantIsolation},
+		{"CreationOrder", testCreationOrder},
+		{"ReturnsCopies", testReturnsCopies},
+		{"CancelledContext", testCancelledContext},
+		{"ConcurrentCreate", testConcurrentCreate},
+		{"ConcurrentUpdateAndDelete", testConcurrentUpdateAndDelete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, factory(t))
+		})
+	}
+}
+
+func newUser(n int) *model.User {
+	return &model.User{
+		ID:    uuid.New(),
+		Name:  fmt.Sprintf("User %d", n),
+		Email: fmt.Sprintf("user%d@example.com", n),
+		Attributes: map[string]any{
+			"seats": float64(n),
+			"tags":  []any{"team", fmt.Sprintf("t%d", n)},
+		},
+	}
+}
+
+func createUsers(t *testing.T, repo user.Repository, n int) []model.User {
+	t.Helper()
+
+	users := make([]model.User, 0, n)
+	for i := 0; i < n; i++ {
+		u := newUser(i)
+		require.NoError(t, repo.Create(context.Background(), u))
+		users = append(users, *u)
+	}
+	return users
+}
+
+func ids(users []model.User) []uuid.UUID {
+	out := make([]uuid.UUID, 0, len(users))
+	for _, u := range users {
+		out = append(out, u.ID)
+	}
+	return out
+}
+
+func testCreateAndGet(t *testing.T, repo user.Repository) {
+	u := newUser(1)
+	require.NoError(t, repo.Create(context.Background(), u))
+
+	got, err := repo.GetByID(context.Background(), u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, *u, *got)
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []model.User{*u}, all)
+}
+
+func testGetAllEmpty(t *testing.T, repo user.Repository) {
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+func testGetByIDNotFound(t *testing.T, repo user.Repository) {
+	createUsers(t, repo, 2)
+
+	got, err := repo.GetByID(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, user.ErrUserNotFound)
+	assert.Nil(t, got)
+}
+
+func testUpdate(t *testing.T, repo user.Repository) {
+	users := createUsers(t, repo, 3)
+
+	updated := users[1]
+	updated.Name = "Updated"
+	updated.Email = "updated@example.com"
+	require.NoError(t, repo.Update(context.Background(), &updated))
+
+	got, err := repo.GetByID(context.Background(), updated.ID)
+	require.NoError(t, err)
+	assert.Equal(t, updated, *got)
+
+	other, err := repo.GetByID(context.Background(), users[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, users[0], *other)
+}
+
+func testUpdateNotFound(t *testing.T, repo user.Repository) {
+	users := createUsers(t, repo, 1)
+
+	err := repo.Update(context.Background(), newUser(2))
+	assert.ErrorIs(t, err, user.ErrUserNotFound)
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, users, all)
+}
+
+func testDelete(t *testing.T, repo user.Repository) {
+	users := createUsers(t, repo, 3)
+
+	require.NoError(t, repo.Delete(context.Background(), users[1].ID))
+
+	_, err := repo.GetByID(context.Background(), users[1].ID)
+	assert.ErrorIs(t, err, user.ErrUserNotFound)
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{users[0].ID, users[2].ID}, ids(all))
+}
+
+func testDeleteNotFound(t *testing.T, repo user.Repository) {
+	users := createUsers(t, repo, 1)
+
+	assert.ErrorIs(t, repo.Delete(context.Background(), uuid.New()), user.ErrUserNotFound)
+
+	require.NoError(t, repo.Delete(context.Background(), users[0].ID))
+	assert.ErrorIs(t, repo.Delete(context.Background(), users[0].ID), user.ErrUserNotFound)
+}
+
+func testDuplicateID(t *testing.T, repo user.Repository) {
+	users := createUsers(t, repo, 1)
+
+	dup := newUser(2)
+	dup.ID = users[0].ID
+	assert.ErrorIs(t, repo.Create(context.Background(), dup), user.ErrUserAlreadyExists)
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, users, all)
+}
+
+func testDuplicateEmail(t *testing.T, repo user.Repository) {
+	first := newUser(1)
+	first.EmailCanonical = "shared@example.com"
+	require.NoError(t, repo.Create(context.Background(), first))
+
+	dup := newUser(2)
+	dup.EmailCanonical = first.EmailCanonical
+	assert.ErrorIs(t, repo.Create(context.Background(), dup), user.ErrEmailTaken)
+
+	other := newUser(3)
+	other.EmailCanonical = "other@example.com"
+	require.NoError(t, repo.Create(context.Background(), other))
+
+	other.EmailCanonical = first.EmailCanonical
+	assert.ErrorIs(t, repo.Update(context.Background(), other), user.ErrEmailTaken)
+
+	// Saving a user with its own canonical email is not a conflict.
+	first.Name = "Renamed"
+	assert.NoError(t, repo.Update(context.Background(), first))
+
+	// Records without a canonical email predate the check and never collide.
+	require.NoError(t, repo.Create(context.Background(), newUser(4)))
+	require.NoError(t, repo.Create(context.Background(), newUser(5)))
+}
+
+func testTenantIsolation(t *testing.T, repo user.Repository) {
+	acme := tenant.NewContext(context.Background(), uuid.New())
+	globex := tenant.NewContext(context.Background(), uuid.New())
+
+	u := newUser(1)
+	u.EmailCanonical = "shared@example.com"
+	require.NoError(t, repo.Create(acme, u))
+
+	// Emails are unique per organization, and so are IDs.
+	other := newUser(2)
+	other.EmailCanonical = u.EmailCanonical
+	require.NoError(t, repo.Create(globex, other))
+	same := *u
+	require.NoError(t, repo.Create(context.Background(), &same))
+
+	_, err := repo.GetByID(globex, u.ID)
+	assert.ErrorIs(t, err, user.ErrUserNotFound)
+	renamed := *u
+	renamed.Name, renamed.EmailCanonical = "Renamed", "renamed@example.com"
+	assert.ErrorIs(t, repo.Update(globex, &renamed), user.ErrUserNotFound)
+	assert.ErrorIs(t, repo.Delete(globex, u.ID), user.ErrUserNotFound)
+
+	all, err := repo.GetAll(acme)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, u.ID, all[0].ID)
+
+	require.NoError(t, repo.Delete(context.Background(), same.ID))
+	got, err := repo.GetByID(acme, u.ID)
+	require.NoError(t, err, "deleting in one organization leaves the others alone")
+	assert.Equal(t, u.Name, got.Name)
+}
+
+func testCreationOrder(t *testing.T, repo user.Repository) {
+	users := createUsers(t, repo, 10)
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ids(users), ids(all), "GetAll must return users in creation order")
+
+	updated := users[4]
+	updated.Name = "Moved?"
+	require.NoError(t, repo.Update(context.Background(), &updated))
+	require.NoError(t, repo.Delete(context.Background(), users[7].ID))
+
+	all, err = repo.GetAll(context.Background())
+	require.NoError(t, err)
+	want := append(ids(users[:7]), ids(users[8:])...)
+	assert.Equal(t, want, ids(all), "Update and Delete must keep the order of other users")
+}
+
+func testReturnsCopies(t *testing.T, repo user.Repository) {
+	u := newUser(1)
+	original := u.Clone()
+	require.NoError(t, repo.Create(context.Background(), u))
+	u.Name = "Mutated after create"
+	u.Attributes["seats"] = float64(99)
+	u.Attributes["tags"].([]any)[0] = "mutated"
+
+	got, err := repo.GetByID(context.Background(), original.ID)
+	require.NoError(t, err)
+	assert.Equal(t, original, *got)
+	got.Name = "Mutated after get"
+	got.Attributes["seats"] = float64(98)
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	all[0].Name = "Mutated after get all"
+	all[0].Attributes["tags"].([]any)[1] = "mutated"
+
+	got, err = repo.GetByID(context.Background(), original.ID)
+	require.NoError(t, err)
+	assert.Equal(t, original, *got)
+}
+
+func testCancelledContext(t *testing.T, repo user.Repository) {
+	users := createUsers(t, repo, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, repo.Create(ctx, newUser(2)), context.Canceled)
+	_, err := repo.GetByID(ctx, users[0].ID)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.GetAll(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	updated := users[0]
+	updated.Name = "Updated"
+	assert.ErrorIs(t, repo.Update(ctx, &updated), context.Canceled)
+	assert.ErrorIs(t, repo.Delete(ctx, users[0].ID), context.Canceled)
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, users, all, "cancelled calls must not change the store")
+}
+
+func testConcurrentCreate(t *testing.T, repo user.Repository) {
+	const n = 50
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- repo.Create(context.Background(), newUser(i))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, all, n)
+}
+
+func testConcurrentUpdateAndDelete(t *testing.T, repo user.Repository) {
+	const n = 40
+	users := createUsers(t, repo, n)
+
+	var wg sync.WaitGroup
+	for i, u := range users {
+		wg.Add(2)
+		go func(i int, u model.User) {
+			defer wg.Done()
+			if i%2 == 0 {
+				assert.NoError(t, repo.Delete(context.Background(), u.ID))
+				return
+			}
+			u.Name = "Updated"
+			assert.NoError(t, repo.Update(context.Background(), &u))
+		}(i, u)
+		go func(u model.User) {
+			defer wg.Done()
+			_, _ = repo.GetByID(context.Background(), u.ID)
+		}(u)
+	}
+	wg.Wait()
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, all, n/2)
+	for i, u := range all {
+		assert.Equal(t, users[2*i+1].ID, u.ID)
+		assert.Equal(t, "Updated", u.Name)
+	}
+}
@@ -0,0 +1,206 @@
+package user
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sergey4qb/mf1-test/email"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/names"
+)
+
+// CurrentSchemaVersion is the storage layout written by this build. Version 1
+// is the legacy bare JSON array of users.
+const CurrentSchemaVersion = 11
+
+type document struct {
+	SchemaVersion int          `json:"schema_version"`
+	Users         []model.User `json:"users"`
+	// Outbox holds the events of the changes to Users that are not
+	// published yet, see Outbox.
+	Outbox []model.PendingEvent `json:"outbox,omitempty"`
+}
+
+// migrations upgrade a decoded document from the version it is keyed by to the
+// next one.
+var migrations = map[int]func(doc *document) error{
+	// 1 -> 2 only introduces the versioned envelope.
+	1: func(doc *document) error { return nil },
+	// 2 -> 3 backfills the canonical email. Addresses that no longer parse
+	// are left without one; the verify command reports them.
+	2: func(doc *document) error {
+		for i := range doc.Users {
+			u := &doc.Users[i]
+			if addr, err := email.Parse(u.Email); err == nil {
+				u.EmailCanonical = addr.Canonical(email.CanonicalOptions{})
+			}
+		}
+		return nil
+	},
+	// 3 -> 4 normalizes names and backfills their search keys.
+	3: func(doc *document) error {
+		for i := range doc.Users {
+			u := &doc.Users[i]
+			u.Name = names.Normalize(u.Name)
+			u.NameKey = names.SearchKey(u.Name)
+		}
+		return nil
+	},
+	// 4 -> 5 adds optional profile fields, which existing records leave
+	// empty. The bump stops older builds, which would drop the fields on
+	// their next write, from opening the file.
+	4: func(doc *document) error { return nil },
+	// 5 -> 6 adds custom attributes, likewise empty for existing records.
+	5: func(doc *document) error { return nil },
+	// 6 -> 7 introduces statuses; every existing user is active.
+	6: func(doc *document) error {
+		for i := range doc.Users {
+			if doc.Users[i].Status == "" {
+				doc.Users[i].Status = model.StatusActive
+			}
+		}
+		return nil
+	},
+	// 7 -> 8 adds email_verified; no existing address has been verified.
+	7: func(doc *document) error { return nil },
+	// 8 -> 9 adds password hashes and login failure tracking.
+	8: func(doc *document) error { return nil },
+	// 9 -> 10 adds TOTP enrollments.
+	9: func(doc *document) error { return nil },
+	// 10 -> 11 adds the outbox; older builds would drop pending events.
+	10: func(doc *document) error { return nil },
+}
+
+type rawDocument struct {
+	SchemaVersion int               `json:"schema_version"`
+	Users         []json.RawMessage `json:"users"`
+}
+
+// ReadRawRecords returns the schema version of the file at path and its user
+// records undecoded, so integrity checks can report malformed entries one by one.
+func ReadRawRecords(path string) (int, []json.RawMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if isLegacyLayout(data) {
+		var records []json.RawMessage
+		if err := json.Unmarshal(data, &records); err != nil {
+			return 0, nil, err
+		}
+		return 1, records, nil
+	}
+
+	var raw rawDocument
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return 0, nil, err
+	}
+	return raw.SchemaVersion, raw.Users, nil
+}
+
+// Migrate rewrites the file at path in the current schema and returns the
+// version it was upgraded from.
+func Migrate(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	doc, from, err := decodeDocument(data)
+	if err != nil {
+		return 0, err
+	}
+	if from == CurrentSchemaVersion {
+		return from, nil
+	}
+
+	if err := writeDocument(path, doc); err != nil {
+		return 0, err
+	}
+	return from, nil
+}
+
+// decodeDocument parses data in any known schema and upgrades it in memory.
+// It also returns the version found on disk.
+func decodeDocument(data []byte) (*document, int, error) {
+	doc := &document{}
+	if isLegacyLayout(data) {
+		doc.SchemaVersion = 1
+		if err := json.Unmarshal(data, &doc.Users); err != nil {
+			return nil, 0, err
+		}
+	} else if err := json.Unmarshal(data, doc); err != nil {
+		return nil, 0, err
+	}
+
+	from := doc.SchemaVersion
+	if from < 1 || from > CurrentSchemaVersion {
+		return nil, 0, fmt.Errorf("%w: %d", errUnsupportedSchema, from)
+	}
+
+	for doc.SchemaVersion < CurrentSchemaVersion {
+		if err := migrations[doc.SchemaVersion](doc); err != nil {
+			return nil, 0, fmt.Errorf("migrate schema %d: %w", doc.SchemaVersion, err)
+		}
+		doc.SchemaVersion++
+	}
+	if doc.Users == nil {
+		doc.Users = []model.User{}
+	}
+
+	return doc, from, nil
+}
+
+// writeDocument replaces the file at path with doc. The document is written
+// to a temporary file in the same directory, synced and renamed over the
+// original, so a crash or a full disk leaves either the old file or the new
+// one, never a truncated mix.
+func writeDocument(path string, doc *document) error {
+	doc.SchemaVersion = CurrentSchemaVersion
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if err := writeSynced(f, data); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// writeSynced writes data to f with the mode users files have always had,
+// flushes it to disk and closes f.
+func writeSynced(f *os.File, data []byte) error {
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func isLegacyLayout(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("["))
+}
@@ -0,0 +1,100 @@
+package user
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+func TestFileUserRepository_ReadsLegacyLayout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	id := uuid.New()
+	legacy := `[{"id":"` + id.String() + `","name":"Legacy","email":"legacy@example.com"}]`
+	assert.NoError(t, os.WriteFile(path, []byte(legacy), 0644))
+
+	repo, err := NewFile(path)
+	assert.NoError(t, err)
+
+	u, err := repo.GetByID(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Legacy", u.Name)
+}
+
+func TestMigrate_UpgradesLegacyLayout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	id := uuid.New()
+	legacy := `[{"id":"` + id.String() + `","name":"Legacy","email":"legacy@example.com"}]`
+	assert.NoError(t, os.WriteFile(path, []byte(legacy), 0644))
+
+	from, err := Migrate(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, from)
+
+	version, records, err := ReadRawRecords(path)
+	assert.NoError(t, err)
+	assert.Equal(t, CurrentSchemaVersion, version)
+	assert.Len(t, records, 1)
+
+	from, err = Migrate(path)
+	assert.NoError(t, err)
+	assert.Equal(t, CurrentSchemaVersion, from)
+}
+
+func TestMigrate_UnsupportedVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"schema_version":99,"users":[]}`), 0644))
+
+	_, err := Migrate(path)
+	assert.ErrorIs(t, err, errUnsupportedSchema)
+}
+
+func TestFileUserRepository_BackfillsCanonicalEmail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	id := uuid.New()
+	v2 := `{"schema_version":2,"users":[{"id":"` + id.String() + `","name":"Jane","email":"Jane@Example.COM"}]}`
+	assert.NoError(t, os.WriteFile(path, []byte(v2), 0644))
+
+	repo, err := NewFile(path)
+	assert.NoError(t, err)
+
+	u, err := repo.GetByID(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane@Example.COM", u.Email)
+	assert.Equal(t, "jane@example.com", u.EmailCanonical)
+}
+
+func TestMigrate_NormalizesNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	id := uuid.New()
+	v3 := `{"schema_version":3,"users":[{"id":"` + id.String() + `","name":"  José  Garcia ","email":"jose@example.com"}]}`
+	assert.NoError(t, os.WriteFile(path, []byte(v3), 0644))
+
+	repo, err := NewFile(path)
+	assert.NoError(t, err)
+
+	u, err := repo.GetByID(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, "José Garcia", u.Name)
+	assert.Equal(t, "jose garcia", u.NameKey)
+}
+
+func TestMigrate_BackfillsActiveStatus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	id := uuid.New()
+	v6 := `{"schema_version":6,"users":[{"id":"` + id.String() + `","name":"Jane","email":"jane@example.com"}]}`
+	assert.NoError(t, os.WriteFile(path, []byte(v6), 0644))
+
+	repo, err := NewFile(path)
+	assert.NoError(t, err)
+
+	u, err := repo.GetByID(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusActive, u.Status)
+	assert.Nil(t, u.StatusChange)
+}
@@ -0,0 +1,154 @@
+package user
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+// organizationsDir holds a directory per organization next to the file of
+// the default organization.
+const organizationsDir = "organizations"
+
+// tenantRepository routes every call to the store of the organization in the
+// context, see package tenant, so no call can reach another organization's
+// users. Stores are opened on first use.
+type tenantRepository struct {
+	open func(org uuid.UUID) (Store, error)
+
+	mu     sync.Mutex
+	stores map[uuid.UUID]Store
+}
+
+func newTenantRepository(open func(org uuid.UUID) (Store, error)) *tenantRepository {
+	return &tenantRepository{open: open, stores: map[uuid.UUID]Store{}}
+}
+
+// TenantFilePath returns where NewFile(filePath) keeps the users of org.
+func TenantFilePath(filePath string, org uuid.UUID) string {
+	if org == tenant.Default {
+		return filePath
+	}
+	return filepath.Join(filepath.Dir(filePath), organizationsDir, org.String(), filepath.Base(filePath))
+}
+
+// TenantFiles returns the files of every organization with users kept by
+// NewFile(filePath), the default organization's first.
+func TenantFiles(filePath string) ([]string, error) {
+	pattern := filepath.Join(filepath.Dir(filePath), organizationsDir, "*", filepath.Base(filePath))
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{filePath}, files...), nil
+}
+
+func (r *tenantRepository) store(ctx context.Context) (Store, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	org := tenant.FromContext(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s, ok := r.stores[org]; ok {
+		return s, nil
+	}
+	s, err := r.open(org)
+	if err != nil {
+		return nil, err
+	}
+	r.stores[org] = s
+	return s, nil
+}
+
+func (r *tenantRepository) Create(ctx context.Context, user *model.User) error {
+	s, err := r.store(ctx)
+	if err != nil {
+		return err
+	}
+	return s.Create(ctx, user)
+}
+
+func (r *tenantRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	s, err := r.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetByID(ctx, id)
+}
+
+func (r *tenantRepository) GetAll(ctx context.Context) ([]model.User, error) {
+	s, err := r.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAll(ctx)
+}
+
+func (r *tenantRepository) Update(ctx context.Context, user *model.User) error {
+	s, err := r.store(ctx)
+	if err != nil {
+		return err
+	}
+	return s.Update(ctx, user)
+}
+
+func (r *tenantRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	s, err := r.store(ctx)
+	if err != nil {
+		return err
+	}
+	return s.Delete(ctx, id)
+}
+
+func (r *tenantRepository) Pending(ctx context.Context) ([]model.PendingEvent, error) {
+	s, err := r.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.Pending(ctx)
+}
+
+func (r *tenantRepository) Reschedule(ctx context.Context, event *model.PendingEvent) error {
+	s, err := r.store(ctx)
+	if err != nil {
+		return err
+	}
+	return s.Reschedule(ctx, event)
+}
+
+func (r *tenantRepository) Remove(ctx context.Context, id uuid.UUID) error {
+	s, err := r.store(ctx)
+	if err != nil {
+		return err
+	}
+	return s.Remove(ctx, id)
+}
+
+func (r *tenantRepository) Redact(ctx context.Context, userID uuid.UUID) (int, error) {
+	s, err := r.store(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return s.Redact(ctx, userID)
+}
+
+// openTenantFile opens the file of org, creating its directory on first use.
+func openTenantFile(filePath string, org uuid.UUID) (Store, error) {
+	path := TenantFilePath(filePath, org)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errCreateUserFile
+	}
+	if err := initUserJsonFile(path); err != nil {
+		return nil, err
+	}
+	return &fileUserRepository{filePath: path, org: org}, nil
+}
@@ -2,7 +2,6 @@ package user
 
 import (
 	"context"
-	"encoding/json"
 	"github.com/google/uuid"
 	"os"
 	"sync"
@@ -14,6 +13,10 @@ var (
 	fileRepoPath = "users.json"
 )
 
+// Repository stores users. Implementations must be safe for concurrent use,
+// keep the users of each organization apart, see package tenant, return
+// users in creation order from GetAll, and honour context cancellation;
+// repositorytest.Run checks these guarantees.
 type Repository interface {
 	Create(ctx context.Context, user *model.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
@@ -24,40 +27,60 @@ type Repository interface {
 
 type fileUserRepository struct {
 	filePath string
+	org      uuid.UUID
 	mu       sync.Mutex
 }
 
-func New() (Repository, error) {
-	if err := initUserJsonFile(fileRepoPath); err != nil {
+func New() (Store, error) {
+	return NewFile(fileRepoPath)
+}
+
+// NewFile opens the file backed repository stored at filePath, creating an
+// empty store if it does not exist yet. filePath holds the users of the
+// default organization; each other organization gets a file of its own, see
+// TenantFilePath. Events raised by changes are kept in the same files.
+func NewFile(filePath string) (Store, error) {
+	if err := initUserJsonFile(filePath); err != nil {
 		return nil, err
 	}
-	return &fileUserRepository{filePath: fileRepoPath}, nil
+	return newTenantRepository(func(org uuid.UUID) (Store, error) {
+		return openTenantFile(filePath, org)
+	}), nil
 }
 
 func (r *fileUserRepository) Create(ctx context.Context, user *model.User) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	users, err := r.getAllNoLock()
-	if err != nil {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	users = append(users, *user)
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	data, err := json.MarshalIndent(users, "", "  ")
+	doc, err := r.readNoLock()
 	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(r.filePath, data, 0644); err != nil {
-		return err
+	for _, u := range doc.Users {
+		if u.ID == user.ID {
+			return ErrUserAlreadyExists
+		}
+	}
+	if emailTaken(doc.Users, user) {
+		return ErrEmailTaken
 	}
 
-	return nil
+	doc.Users = append(doc.Users, *user)
+	doc.Outbox = append(doc.Outbox, *newEvent(r.org, nil, user))
+
+	return writeDocument(r.filePath, doc)
 }
 
 func (r *fileUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -71,10 +94,14 @@ func (r *fileUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.
 			return &u, nil
 		}
 	}
-	return nil, errUserNotFound
+	return nil, ErrUserNotFound
 }
 
 func (r *fileUserRepository) GetAll(ctx context.Context) ([]model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -82,75 +109,152 @@ func (r *fileUserRepository) GetAll(ctx context.Context) ([]model.User, error) {
 }
 
 func (r *fileUserRepository) Update(ctx context.Context, user *model.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	users, err := r.getAllNoLock()
+	doc, err := r.readNoLock()
 	if err != nil {
 		return err
 	}
 
+	if emailTaken(doc.Users, user) {
+		return ErrEmailTaken
+	}
+
 	found := false
-	for i, u := range users {
+	for i, u := range doc.Users {
 		if u.ID == user.ID {
-			users[i] = *user
+			if event := newEvent(r.org, &u, user); event != nil {
+				doc.Outbox = append(doc.Outbox, *event)
+			}
+			doc.Users[i] = *user
 			found = true
 			break
 		}
 	}
 	if !found {
-		return errUserNotFound
+		return ErrUserNotFound
 	}
 
-	data, err := json.MarshalIndent(users, "", "  ")
-	if err != nil {
-		return err
-	}
+	return writeDocument(r.filePath, doc)
+}
 
-	if err := os.WriteFile(r.filePath, data, 0644); err != nil {
+func (r *fileUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	return nil
-}
-
-func (r *fileUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	users, err := r.getAllNoLock()
+	doc, err := r.readNoLock()
 	if err != nil {
 		return err
 	}
 
 	index := -1
-	for i, u := range users {
+	for i, u := range doc.Users {
 		if u.ID == id {
 			index = i
 			break
 		}
 	}
 	if index == -1 {
-		return errUserNotFound
+		return ErrUserNotFound
+	}
+
+	doc.Outbox = append(doc.Outbox, *newEvent(r.org, &doc.Users[index], nil))
+	doc.Users = append(doc.Users[:index], doc.Users[index+1:]...)
+
+	return writeDocument(r.filePath, doc)
+}
+
+func (r *fileUserRepository) Pending(ctx context.Context) ([]model.PendingEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	users = append(users[:index], users[index+1:]...)
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	data, err := json.MarshalIndent(users, "", "  ")
+	doc, err := r.readNoLock()
 	if err != nil {
+		return nil, err
+	}
+	return cloneEvents(doc.Outbox), nil
+}
+
+func (r *fileUserRepository) Reschedule(ctx context.Context, event *model.PendingEvent) error {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(r.filePath, data, 0644); err != nil {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	doc, err := r.readNoLock()
+	if err != nil {
+		return err
+	}
+	if err := reschedule(doc.Outbox, event); err != nil {
+		return err
+	}
+	return writeDocument(r.filePath, doc)
+}
+
+func (r *fileUserRepository) Remove(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	return nil
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	doc, err := r.readNoLock()
+	if err != nil {
+		return err
+	}
+	if doc.Outbox, err = removeEvent(doc.Outbox, id); err != nil {
+		return err
+	}
+	return writeDocument(r.filePath, doc)
+}
+
+func (r *fileUserRepository) Redact(ctx context.Context, userID uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	doc, err := r.readNoLock()
+	if err != nil {
+		return 0, err
+	}
+	n := redactEvents(doc.Outbox, userID)
+	if n == 0 {
+		return 0, nil
+	}
+	return n, writeDocument(r.filePath, doc)
 }
 
 func (r *fileUserRepository) getAllNoLock() ([]model.User, error) {
+	doc, err := r.readNoLock()
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.Users, nil
+}
+
+func (r *fileUserRepository) readNoLock() (*document, error) {
 	if _, err := os.Stat(r.filePath); os.IsNotExist(err) {
-		return []model.User{}, nil
+		return &document{SchemaVersion: CurrentSchemaVersion, Users: []model.User{}}, nil
 	}
 
 	data, err := os.ReadFile(r.filePath)
@@ -158,10 +262,10 @@ func (r *fileUserRepository) getAllNoLock() ([]model.User, error) {
 		return nil, err
 	}
 
-	var users []model.User
-	if err := json.Unmarshal(data, &users); err != nil {
+	doc, _, err := decodeDocument(data)
+	if err != nil {
 		return nil, err
 	}
 
-	return users, nil
+	return doc, nil
 }
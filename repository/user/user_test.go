@@ -80,6 +80,10 @@ func TestFileUserRepository_Create_ErrorOnWrite(t *testing.T) {
 
 	repo, err := New()
 	assert.NoError(t, err)
+	// Writes replace the file through a temporary one next to it, so it is
+	// the directory that has to refuse them.
+	assert.NoError(t, os.Chmod(tempDir, 0555))
+	t.Cleanup(func() { os.Chmod(tempDir, 0755) })
 
 	newUser := &model.User{
 		ID:    uuid.New(),
@@ -109,11 +113,19 @@ func TestFileUserRepository_ContentPersistence(t *testing.T) {
 	data, err := os.ReadFile(fileRepoPath)
 	assert.NoError(t, err)
 
-	var users []model.User
-	err = json.Unmarshal(data, &users)
+	var doc document
+	err = json.Unmarshal(data, &doc)
 	assert.NoError(t, err)
-	assert.Len(t, users, 1)
-	assert.Equal(t, newUser.Name, users[0].Name)
+	assert.Equal(t, CurrentSchemaVersion, doc.SchemaVersion)
+	assert.Len(t, doc.Users, 1)
+	assert.Equal(t, newUser.Name, doc.Users[0].Name)
+
+	entries, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary file is left behind")
+	info, err := os.Stat(fileRepoPath)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
 }
 
 func TestFileUserRepository_GetByID_Success(t *testing.T) {
@@ -231,3 +243,29 @@ func TestFileUserRepository_Delete_NotFound(t *testing.T) {
 	err = repo.Delete(context.Background(), uuid.New())
 	assert.Error(t, err)
 }
+
+func TestFileUserRepository_OutboxSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+
+	repo, err := NewFile(path)
+	assert.NoError(t, err)
+	u := &model.User{ID: uuid.New(), Name: "Jane", Email: "jane@example.com"}
+	assert.NoError(t, repo.Create(context.Background(), u))
+
+	// The event is in the same file as the user it was raised by.
+	var doc document
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &doc))
+	assert.Len(t, doc.Users, 1)
+	assert.Len(t, doc.Outbox, 1)
+
+	reopened, err := NewFile(path)
+	assert.NoError(t, err)
+	events, err := reopened.Pending(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, model.EventUserCreated, events[0].Type)
+		assert.Equal(t, u.ID, events[0].UserID)
+	}
+}
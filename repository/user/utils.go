@@ -2,14 +2,30 @@ package user
 
 import (
 	"os"
+
+	"github.com/sergey4qb/mf1-test/model"
 )
 
 func initUserJsonFile(filePath string) error {
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		initialData := []byte("[]")
-		if err := os.WriteFile(filePath, initialData, 0644); err != nil {
+		doc := &document{Users: []model.User{}}
+		if err := writeDocument(filePath, doc); err != nil {
 			return errCreateUserFile
 		}
 	}
 	return nil
 }
+
+// emailTaken reports whether another user already holds the canonical email
+// of user. Users without a canonical email are never in conflict.
+func emailTaken(users []model.User, user *model.User) bool {
+	if user.EmailCanonical == "" {
+		return false
+	}
+	for _, u := range users {
+		if u.ID != user.ID && u.EmailCanonical == user.EmailCanonical {
+			return true
+		}
+	}
+	return false
+}
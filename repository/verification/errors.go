@@ -0,0 +1,7 @@
+package verification
+
+import "errors"
+
+var ErrTokenNotFound = errors.New("verification token not found")
+
+var errCreateFile = errors.New("failed to create verification token file")
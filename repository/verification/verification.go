@@ -0,0 +1,166 @@
+package verification
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+type Repository interface {
+	// Save stores token and drops expired tokens.
+	Save(ctx context.Context, token *model.VerificationToken) error
+	// Consume removes and returns the unexpired token with the given hash,
+	// so each token can be used once.
+	Consume(ctx context.Context, hash string) (*model.VerificationToken, error)
+	// ForUser returns the unexpired tokens of a user.
+	ForUser(ctx context.Context, userID uuid.UUID) ([]model.VerificationToken, error)
+	// DeleteUser removes every token of a user and returns how many it
+	// removed.
+	DeleteUser(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+type fileRepository struct {
+	filePath string
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+func New(filePath string) (Repository, error) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if err := os.WriteFile(filePath, []byte("[]"), 0600); err != nil {
+			return nil, errCreateFile
+		}
+	}
+	return &fileRepository{filePath: filePath, now: time.Now}, nil
+}
+
+func (r *fileRepository) Save(ctx context.Context, token *model.VerificationToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tokens, err := r.readNoLock()
+	if err != nil {
+		return err
+	}
+	return r.writeNoLock(append(r.unexpired(tokens), *token))
+}
+
+func (r *fileRepository) Consume(ctx context.Context, hash string) (*model.VerificationToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tokens, err := r.readNoLock()
+	if err != nil {
+		return nil, err
+	}
+
+	kept := r.unexpired(tokens)
+	for i, token := range kept {
+		if token.Hash == hash {
+			if err := r.writeNoLock(append(kept[:i], kept[i+1:]...)); err != nil {
+				return nil, err
+			}
+			return &token, nil
+		}
+	}
+	return nil, ErrTokenNotFound
+}
+
+func (r *fileRepository) ForUser(ctx context.Context, userID uuid.UUID) ([]model.VerificationToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tokens, err := r.readNoLock()
+	if err != nil {
+		return nil, err
+	}
+
+	var found []model.VerificationToken
+	for _, token := range r.unexpired(tokens) {
+		if token.UserID == userID {
+			found = append(found, token)
+		}
+	}
+	return found, nil
+}
+
+func (r *fileRepository) DeleteUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tokens, err := r.readNoLock()
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	kept := tokens[:0]
+	for _, token := range tokens {
+		if token.UserID == userID {
+			n++
+			continue
+		}
+		kept = append(kept, token)
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return n, r.writeNoLock(kept)
+}
+
+func (r *fileRepository) unexpired(tokens []model.VerificationToken) []model.VerificationToken {
+	now := r.now()
+	kept := tokens[:0]
+	for _, token := range tokens {
+		if !token.Expired(now) {
+			kept = append(kept, token)
+		}
+	}
+	return kept
+}
+
+func (r *fileRepository) readNoLock() ([]model.VerificationToken, error) {
+	data, err := os.ReadFile(r.filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []model.VerificationToken
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *fileRepository) writeNoLock(tokens []model.VerificationToken) error {
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.filePath, data, 0600)
+}
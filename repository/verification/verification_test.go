@@ -0,0 +1,94 @@
+package verification
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+func newTestRepo(t *testing.T, now *time.Time) *fileRepository {
+	repo, err := New(filepath.Join(t.TempDir(), "verification.json"))
+	require.NoError(t, err)
+
+	r := repo.(*fileRepository)
+	r.now = func() time.Time { return *now }
+	return r
+}
+
+func token(hash string, now time.Time, ttl time.Duration) *model.VerificationToken {
+	return &model.VerificationToken{
+		Hash:      hash,
+		UserID:    uuid.New(),
+		Email:     "jane@example.com",
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+}
+
+func TestFileRepository_ConsumeOnce(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newTestRepo(t, &now)
+	ctx := context.Background()
+
+	saved := token("hash-1", now, time.Hour)
+	require.NoError(t, repo.Save(ctx, saved))
+	require.NoError(t, repo.Save(ctx, token("hash-2", now, time.Hour)))
+
+	got, err := repo.Consume(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, saved.UserID, got.UserID)
+
+	_, err = repo.Consume(ctx, "hash-1")
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+
+	_, err = repo.Consume(ctx, "hash-2")
+	assert.NoError(t, err)
+}
+
+func TestFileRepository_DeleteUser(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newTestRepo(t, &now)
+	ctx := context.Background()
+
+	saved := token("hash-1", now, time.Hour)
+	require.NoError(t, repo.Save(ctx, saved))
+	require.NoError(t, repo.Save(ctx, token("hash-2", now, time.Hour)))
+
+	got, err := repo.ForUser(ctx, saved.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, []model.VerificationToken{*saved}, got)
+
+	n, err := repo.DeleteUser(ctx, saved.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = repo.Consume(ctx, "hash-1")
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+	_, err = repo.Consume(ctx, "hash-2")
+	assert.NoError(t, err, "DeleteUser must leave other users' tokens alone")
+}
+
+func TestFileRepository_ExpiredTokens(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newTestRepo(t, &now)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Save(ctx, token("old", now, time.Minute)))
+	now = now.Add(time.Minute)
+
+	_, err := repo.Consume(ctx, "old")
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+
+	require.NoError(t, repo.Save(ctx, token("new", now, time.Hour)))
+	tokens, err := repo.readNoLock()
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, "new", tokens[0].Hash)
+}
@@ -0,0 +1,11 @@
+package webhook
+
+import "errors"
+
+// Errors every Repository implementation returns.
+var (
+	ErrWebhookNotFound      = errors.New("webhook not found")
+	ErrWebhookAlreadyExists = errors.New("webhook already exists")
+)
+
+var errCreateWebhookFile = errors.New("failed to create webhook file")
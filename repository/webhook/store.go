@@ -0,0 +1,204 @@
+package webhook
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+// attemptsKept bounds the attempt log of each webhook; older attempts are
+// dropped as new ones are recorded.
+const attemptsKept = 500
+
+// store holds the webhooks of a repository with their queued deliveries and
+// attempt logs; it is also the layout of the webhook file. Callers
+// serialise access.
+type store struct {
+	Webhooks   []model.Webhook         `json:"webhooks"`
+	Deliveries []model.WebhookDelivery `json:"deliveries,omitempty"`
+	Attempts   []model.WebhookAttempt  `json:"attempts,omitempty"`
+	LastSeq    int64                   `json:"last_seq,omitempty"`
+}
+
+func (s *store) create(org uuid.UUID, w *model.Webhook) error {
+	if s.indexOf(w.ID) != -1 {
+		return ErrWebhookAlreadyExists
+	}
+	w.OrganizationID = org
+	s.Webhooks = append(s.Webhooks, w.Clone())
+	return nil
+}
+
+func (s *store) get(org, id uuid.UUID) (*model.Webhook, error) {
+	i := s.indexIn(org, id)
+	if i == -1 {
+		return nil, ErrWebhookNotFound
+	}
+	w := s.Webhooks[i].Clone()
+	return &w, nil
+}
+
+func (s *store) getAll(org uuid.UUID) []model.Webhook {
+	var webhooks []model.Webhook
+	for _, w := range s.Webhooks {
+		if w.OrganizationID == org {
+			webhooks = append(webhooks, w.Clone())
+		}
+	}
+	return webhooks
+}
+
+func (s *store) update(org uuid.UUID, w *model.Webhook) error {
+	i := s.indexIn(org, w.ID)
+	if i == -1 {
+		return ErrWebhookNotFound
+	}
+	w.OrganizationID = org
+	s.Webhooks[i] = w.Clone()
+	if w.DisabledAt != nil {
+		s.dropDeliveries(w.ID)
+	}
+	return nil
+}
+
+func (s *store) delete(org, id uuid.UUID) error {
+	i := s.indexIn(org, id)
+	if i == -1 {
+		return ErrWebhookNotFound
+	}
+	s.Webhooks = slices.Delete(s.Webhooks, i, i+1)
+	s.dropDeliveries(id)
+	s.Attempts = slices.DeleteFunc(s.Attempts, func(a model.WebhookAttempt) bool {
+		return a.WebhookID == id
+	})
+	return nil
+}
+
+func (s *store) enqueue(org uuid.UUID, deliveries []model.WebhookDelivery) {
+	for _, d := range deliveries {
+		queued := slices.ContainsFunc(s.Deliveries, func(q model.WebhookDelivery) bool {
+			return q.WebhookID == d.WebhookID && q.Event.ID == d.Event.ID
+		})
+		if queued {
+			continue
+		}
+		d.OrganizationID = org
+		s.Deliveries = append(s.Deliveries, d.Clone())
+	}
+}
+
+func (s *store) pending(org uuid.UUID) []model.WebhookDelivery {
+	var deliveries []model.WebhookDelivery
+	for _, d := range s.Deliveries {
+		if d.OrganizationID == org {
+			deliveries = append(deliveries, d.Clone())
+		}
+	}
+	return deliveries
+}
+
+func (s *store) record(org uuid.UUID, attempt *model.WebhookAttempt, retry *time.Time) (*model.Webhook, error) {
+	i := s.indexIn(org, attempt.WebhookID)
+	if i == -1 {
+		return nil, ErrWebhookNotFound
+	}
+
+	s.LastSeq++
+	attempt.Seq = s.LastSeq
+	attempt.OrganizationID = org
+	s.Attempts = append(s.Attempts, *attempt)
+	s.trimAttempts(attempt.WebhookID)
+
+	if attempt.Succeeded() {
+		s.Webhooks[i].Failures = 0
+	} else {
+		s.Webhooks[i].Failures++
+	}
+
+	j := slices.IndexFunc(s.Deliveries, func(d model.WebhookDelivery) bool {
+		return d.ID == attempt.DeliveryID && d.OrganizationID == org
+	})
+	if j != -1 {
+		if retry == nil {
+			s.Deliveries = slices.Delete(s.Deliveries, j, j+1)
+		} else {
+			s.Deliveries[j].Attempts = attempt.Attempt
+			s.Deliveries[j].NextAttempt = *retry
+		}
+	}
+
+	w := s.Webhooks[i].Clone()
+	return &w, nil
+}
+
+func (s *store) attempts(org, webhookID uuid.UUID, after int64, limit int) ([]model.WebhookAttempt, error) {
+	if s.indexIn(org, webhookID) == -1 {
+		return nil, ErrWebhookNotFound
+	}
+
+	var attempts []model.WebhookAttempt
+	for _, a := range s.Attempts {
+		if a.WebhookID != webhookID || a.Seq <= after {
+			continue
+		}
+		if limit > 0 && len(attempts) == limit {
+			break
+		}
+		attempts = append(attempts, a)
+	}
+	return attempts, nil
+}
+
+func (s *store) redact(org, userID uuid.UUID) int {
+	var n int
+	for i := range s.Deliveries {
+		d := &s.Deliveries[i]
+		if d.OrganizationID == org && d.Event.UserID == userID && d.Event.User != nil {
+			d.Event.User = nil
+			n++
+		}
+	}
+	return n
+}
+
+// trimAttempts drops the oldest attempts of a webhook beyond attemptsKept.
+func (s *store) trimAttempts(webhookID uuid.UUID) {
+	n := 0
+	for _, a := range s.Attempts {
+		if a.WebhookID == webhookID {
+			n++
+		}
+	}
+	for i := 0; n > attemptsKept; {
+		if s.Attempts[i].WebhookID == webhookID {
+			s.Attempts = slices.Delete(s.Attempts, i, i+1)
+			n--
+			continue
+		}
+		i++
+	}
+}
+
+func (s *store) dropDeliveries(webhookID uuid.UUID) {
+	s.Deliveries = slices.DeleteFunc(s.Deliveries, func(d model.WebhookDelivery) bool {
+		return d.WebhookID == webhookID
+	})
+}
+
+func (s *store) indexOf(id uuid.UUID) int {
+	return slices.IndexFunc(s.Webhooks, func(w model.Webhook) bool {
+		return w.ID == id
+	})
+}
+
+// indexIn is indexOf limited to the webhooks of org.
+func (s *store) indexIn(org, id uuid.UUID) int {
+	i := s.indexOf(id)
+	if i == -1 || s.Webhooks[i].OrganizationID != org {
+		return -1
+	}
+	return i
+}
@@ -0,0 +1,200 @@
+// Package webhook stores the webhooks partners subscribe to user events
+// with, the deliveries queued for them and a log of delivery attempts.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+// Repository stores webhooks. Like user.Repository it is scoped by the
+// organization in the context: webhooks, deliveries and attempts are stored
+// for it and only its own are returned. Implementations must be safe for
+// concurrent use, return webhooks in creation order and honour context
+// cancellation.
+type Repository interface {
+	// Create stores w, setting its OrganizationID.
+	Create(ctx context.Context, w *model.Webhook) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error)
+	GetAll(ctx context.Context) ([]model.Webhook, error)
+	// Update replaces a webhook. Disabling it drops its queued deliveries.
+	Update(ctx context.Context, w *model.Webhook) error
+	// Delete removes a webhook with its queued deliveries and attempts.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Enqueue queues deliveries, setting their OrganizationID. A delivery
+	// of an event already queued for the same webhook is skipped, so an
+	// event handed over twice is sent once.
+	Enqueue(ctx context.Context, deliveries []model.WebhookDelivery) error
+	// Pending returns the queued deliveries in the order they were queued.
+	Pending(ctx context.Context) ([]model.WebhookDelivery, error)
+	// Record logs attempt, setting its Seq and OrganizationID, and counts
+	// it against its webhook: a success resets Failures and a failure
+	// increments it. The delivery is rescheduled for retry, or removed from
+	// the queue when retry is nil. Record returns the updated webhook.
+	Record(ctx context.Context, attempt *model.WebhookAttempt, retry *time.Time) (*model.Webhook, error)
+	// Attempts returns up to limit logged attempts of a webhook with a Seq
+	// after after, oldest first; a limit of 0 returns all of them. Only
+	// the latest attempts of each webhook are kept.
+	Attempts(ctx context.Context, webhookID uuid.UUID, after int64, limit int) ([]model.WebhookAttempt, error)
+	// Redact drops the user snapshots from the queued deliveries of a
+	// user's events, which are sent without them, and returns how many
+	// deliveries it changed.
+	Redact(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+type fileWebhookRepository struct {
+	filePath string
+	mu       sync.Mutex
+}
+
+func NewFile(filePath string) (Repository, error) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if err := os.WriteFile(filePath, []byte("{}"), 0644); err != nil {
+			return nil, errCreateWebhookFile
+		}
+	}
+	return &fileWebhookRepository{filePath: filePath}, nil
+}
+
+func (r *fileWebhookRepository) Create(ctx context.Context, w *model.Webhook) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.create(tenant.FromContext(ctx), w)
+	})
+}
+
+func (r *fileWebhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	s, err := r.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.get(tenant.FromContext(ctx), id)
+}
+
+func (r *fileWebhookRepository) GetAll(ctx context.Context) ([]model.Webhook, error) {
+	s, err := r.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.getAll(tenant.FromContext(ctx)), nil
+}
+
+func (r *fileWebhookRepository) Update(ctx context.Context, w *model.Webhook) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.update(tenant.FromContext(ctx), w)
+	})
+}
+
+func (r *fileWebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.modify(ctx, func(s *store) error {
+		return s.delete(tenant.FromContext(ctx), id)
+	})
+}
+
+func (r *fileWebhookRepository) Enqueue(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	return r.modify(ctx, func(s *store) error {
+		s.enqueue(tenant.FromContext(ctx), deliveries)
+		return nil
+	})
+}
+
+func (r *fileWebhookRepository) Pending(ctx context.Context) ([]model.WebhookDelivery, error) {
+	s, err := r.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.pending(tenant.FromContext(ctx)), nil
+}
+
+func (r *fileWebhookRepository) Record(ctx context.Context, attempt *model.WebhookAttempt, retry *time.Time) (*model.Webhook, error) {
+	var w *model.Webhook
+	err := r.modify(ctx, func(s *store) error {
+		var err error
+		w, err = s.record(tenant.FromContext(ctx), attempt, retry)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (r *fileWebhookRepository) Attempts(ctx context.Context, webhookID uuid.UUID, after int64, limit int) ([]model.WebhookAttempt, error) {
+	s, err := r.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.attempts(tenant.FromContext(ctx), webhookID, after, limit)
+}
+
+func (r *fileWebhookRepository) Redact(ctx context.Context, userID uuid.UUID) (int, error) {
+	var n int
+	err := r.modify(ctx, func(s *store) error {
+		n = s.redact(tenant.FromContext(ctx), userID)
+		return nil
+	})
+	return n, err
+}
+
+func (r *fileWebhookRepository) read(ctx context.Context) (*store, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.readNoLock()
+}
+
+// modify applies change to the stored data and writes the result unless
+// change fails.
+func (r *fileWebhookRepository) modify(ctx context.Context, change func(*store) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.readNoLock()
+	if err != nil {
+		return err
+	}
+	if err := change(s); err != nil {
+		return err
+	}
+	return r.writeNoLock(s)
+}
+
+func (r *fileWebhookRepository) readNoLock() (*store, error) {
+	data, err := os.ReadFile(r.filePath)
+	if os.IsNotExist(err) {
+		return &store{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s store
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *fileWebhookRepository) writeNoLock(s *store) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.filePath, data, 0644)
+}
@@ -0,0 +1,222 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+func newTestRepo(t *testing.T) Repository {
+	repo, err := NewFile(filepath.Join(t.TempDir(), "webhooks.json"))
+	require.NoError(t, err)
+	return repo
+}
+
+func createWebhooks(t *testing.T, ctx context.Context, repo Repository, n int) []model.Webhook {
+	t.Helper()
+
+	webhooks := make([]model.Webhook, 0, n)
+	for i := 0; i < n; i++ {
+		w := &model.Webhook{
+			ID:         uuid.New(),
+			URL:        fmt.Sprintf("https://partner%d.example.com/hooks", i),
+			EventTypes: []model.EventType{model.EventUserCreated},
+			Secret:     "0123456789abcdef0123456789abcdef",
+			CreatedAt:  time.Date(2025, 1, 1, 0, i, 0, 0, time.UTC),
+		}
+		require.NoError(t, repo.Create(ctx, w))
+		webhooks = append(webhooks, *w)
+	}
+	return webhooks
+}
+
+func newDelivery(webhookID uuid.UUID) model.WebhookDelivery {
+	userID := uuid.New()
+	return model.WebhookDelivery{
+		ID:        uuid.New(),
+		WebhookID: webhookID,
+		Event: model.Event{
+			ID:     uuid.New(),
+			Type:   model.EventUserCreated,
+			UserID: userID,
+			At:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			User:   &model.User{ID: userID, Name: "Jane", Email: "jane@example.com", Status: model.StatusActive},
+		},
+		NextAttempt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func newAttempt(d model.WebhookDelivery, n int, failed bool) *model.WebhookAttempt {
+	a := &model.WebhookAttempt{
+		WebhookID:  d.WebhookID,
+		DeliveryID: d.ID,
+		EventID:    d.Event.ID,
+		EventType:  d.Event.Type,
+		Attempt:    n,
+		At:         d.NextAttempt,
+		StatusCode: 204,
+	}
+	if failed {
+		a.StatusCode = 503
+		a.Error = "503 Service Unavailable"
+	}
+	return a
+}
+
+func TestFileWebhookRepository_CRUD(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	webhooks := createWebhooks(t, ctx, repo, 2)
+	assert.Equal(t, tenant.Default, webhooks[0].OrganizationID)
+	assert.ErrorIs(t, repo.Create(ctx, &webhooks[0]), ErrWebhookAlreadyExists)
+
+	updated := webhooks[0]
+	updated.URL = "https://renamed.example.com/hooks"
+	require.NoError(t, repo.Update(ctx, &updated))
+	got, err := repo.GetByID(ctx, updated.ID)
+	require.NoError(t, err)
+	assert.Equal(t, updated, *got)
+
+	d := newDelivery(webhooks[0].ID)
+	require.NoError(t, repo.Enqueue(ctx, []model.WebhookDelivery{d, newDelivery(webhooks[1].ID)}))
+	require.NoError(t, repo.Delete(ctx, webhooks[0].ID))
+	assert.ErrorIs(t, repo.Delete(ctx, webhooks[0].ID), ErrWebhookNotFound)
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks[1:], all)
+	pending, err := repo.Pending(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1, "deleting a webhook drops its queued deliveries")
+	assert.Equal(t, webhooks[1].ID, pending[0].WebhookID)
+}
+
+func TestFileWebhookRepository_Enqueue(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	webhooks := createWebhooks(t, ctx, repo, 2)
+
+	first := newDelivery(webhooks[0].ID)
+	second := newDelivery(webhooks[1].ID)
+	second.Event = first.Event
+	require.NoError(t, repo.Enqueue(ctx, []model.WebhookDelivery{first, second}))
+
+	// An event already queued for a webhook is not queued again.
+	again := newDelivery(webhooks[0].ID)
+	again.Event = first.Event
+	require.NoError(t, repo.Enqueue(ctx, []model.WebhookDelivery{again}))
+
+	pending, err := repo.Pending(ctx)
+	require.NoError(t, err)
+	first.OrganizationID, second.OrganizationID = tenant.Default, tenant.Default
+	assert.Equal(t, []model.WebhookDelivery{first, second}, pending)
+
+	disabled := webhooks[0]
+	at := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	disabled.DisabledAt = &at
+	require.NoError(t, repo.Update(ctx, &disabled))
+
+	pending, err = repo.Pending(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1, "disabling a webhook drops its queued deliveries")
+	assert.Equal(t, webhooks[1].ID, pending[0].WebhookID)
+}
+
+func TestFileWebhookRepository_Record(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	w := createWebhooks(t, ctx, repo, 1)[0]
+	d := newDelivery(w.ID)
+	require.NoError(t, repo.Enqueue(ctx, []model.WebhookDelivery{d}))
+
+	retry := d.NextAttempt.Add(time.Minute)
+	for n := 1; n <= 2; n++ {
+		attempt := newAttempt(d, n, true)
+		got, err := repo.Record(ctx, attempt, &retry)
+		require.NoError(t, err)
+		assert.Equal(t, n, got.Failures)
+		assert.Equal(t, int64(n), attempt.Seq)
+	}
+
+	pending, err := repo.Pending(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 2, pending[0].Attempts)
+	assert.Equal(t, retry, pending[0].NextAttempt)
+
+	got, err := repo.Record(ctx, newAttempt(d, 3, false), nil)
+	require.NoError(t, err)
+	assert.Zero(t, got.Failures, "a success resets the failures")
+	pending, err = repo.Pending(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	attempts, err := repo.Attempts(ctx, w.ID, 0, 2)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	attempts, err = repo.Attempts(ctx, w.ID, attempts[1].Seq, 0)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.True(t, attempts[0].Succeeded())
+
+	_, err = repo.Record(ctx, newAttempt(newDelivery(uuid.New()), 1, true), nil)
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+}
+
+func TestFileWebhookRepository_Redact(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	webhooks := createWebhooks(t, ctx, repo, 2)
+
+	first := newDelivery(webhooks[0].ID)
+	second := newDelivery(webhooks[1].ID)
+	second.Event = first.Event
+	other := newDelivery(webhooks[0].ID)
+	require.NoError(t, repo.Enqueue(ctx, []model.WebhookDelivery{first, second, other}))
+
+	n, err := repo.Redact(ctx, first.Event.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	pending, err := repo.Pending(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 3, "redacted deliveries are kept")
+	assert.Nil(t, pending[0].Event.User)
+	assert.Nil(t, pending[1].Event.User)
+	assert.NotNil(t, pending[2].Event.User)
+}
+
+func TestFileWebhookRepository_TenantIsolation(t *testing.T) {
+	repo := newTestRepo(t)
+	org := uuid.New()
+	scoped := tenant.NewContext(context.Background(), org)
+
+	defaults := createWebhooks(t, context.Background(), repo, 1)
+	w := createWebhooks(t, scoped, repo, 1)[0]
+	assert.Equal(t, org, w.OrganizationID)
+	d := newDelivery(w.ID)
+	require.NoError(t, repo.Enqueue(scoped, []model.WebhookDelivery{d}))
+
+	all, err := repo.GetAll(scoped)
+	require.NoError(t, err)
+	assert.Equal(t, []model.Webhook{w}, all)
+
+	_, err = repo.GetByID(scoped, defaults[0].ID)
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+	assert.ErrorIs(t, repo.Delete(scoped, defaults[0].ID), ErrWebhookNotFound)
+	_, err = repo.Record(context.Background(), newAttempt(d, 1, true), nil)
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+
+	pending, err := repo.Pending(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
@@ -0,0 +1,27 @@
+// Package request carries facts about the call being served through a
+// context, so services can record where a change came from.
+package request
+
+import "context"
+
+// Info identifies a call.
+type Info struct {
+	// ID correlates the call with client and server logs.
+	ID string
+	// Peer is the network address of the caller.
+	Peer string
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying info.
+func NewContext(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext returns the Info stored in ctx, which is empty for calls that
+// did not come through the server, e.g. CLI commands.
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(contextKey{}).(Info)
+	return info
+}
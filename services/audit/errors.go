@@ -0,0 +1,27 @@
+package audit
+
+import "errors"
+
+// Error kinds returned by the service.
+var ErrValidation = errors.New("validation failed")
+
+var (
+	errInvalidPageSize  = newValidationError("page size cannot be negative")
+	errInvalidPageToken = newValidationError("invalid page token")
+)
+
+type validationError struct {
+	msg string
+}
+
+func newValidationError(msg string) error {
+	return &validationError{msg: msg}
+}
+
+func (e *validationError) Error() string {
+	return e.msg
+}
+
+func (e *validationError) Is(target error) bool {
+	return target == ErrValidation
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/audit"
+	"github.com/sergey4qb/mf1-test/services/history"
+)
+
+type auditedHistory struct {
+	history.History
+	recorder
+}
+
+// NewHistory returns h with reads of user history recorded in log. Reverts
+// go through the users passed to history.New, which records them as updates
+// when they come from NewUsers.
+func NewHistory(h history.History, log audit.Repository) history.History {
+	return &auditedHistory{History: h, recorder: recorder{log: log, now: time.Now}}
+}
+
+func (a *auditedHistory) List(ctx context.Context, req *dto.ListRevisionsDTO) (*dto.RevisionsPage, error) {
+	page, err := a.History.List(ctx, req)
+	if err := a.record(ctx, ActionViewHistory, req.UserID.String(), nil, nil, err); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (a *auditedHistory) Get(ctx context.Context, userID uuid.UUID, number int64) (*model.UserRevision, error) {
+	revision, err := a.History.Get(ctx, userID, number)
+	return a.view(ctx, userID, revision, err)
+}
+
+func (a *auditedHistory) AsOf(ctx context.Context, userID uuid.UUID, t time.Time) (*model.UserRevision, error) {
+	revision, err := a.History.AsOf(ctx, userID, t)
+	return a.view(ctx, userID, revision, err)
+}
+
+// view records a read of a past version of a user.
+func (a *auditedHistory) view(ctx context.Context, userID uuid.UUID, revision *model.UserRevision, err error) (*model.UserRevision, error) {
+	var u *model.User
+	if revision != nil {
+		u = revision.User
+	}
+	if err := a.record(ctx, ActionViewHistory, userID.String(), nil, u, err); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
@@ -0,0 +1,32 @@
+package audit
+
+import (
+	"encoding/base64"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// Page tokens hold the sequence number of the last entry already returned.
+func encodePageToken(number int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(number, 10)))
+}
+
+func decodePageToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, errInvalidPageToken
+	}
+	number, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || number < 1 {
+		return 0, errInvalidPageToken
+	}
+	return number, nil
+}
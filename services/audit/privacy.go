@@ -0,0 +1,40 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/audit"
+	"github.com/sergey4qb/mf1-test/services/privacy"
+)
+
+type auditedPrivacy struct {
+	privacy.Privacy
+	recorder
+}
+
+// NewPrivacy returns p with data exports and erasures recorded in log. The
+// entries of an erased user are kept: they hold its ID and hashes of its
+// data, not the data itself.
+func NewPrivacy(p privacy.Privacy, log audit.Repository) privacy.Privacy {
+	return &auditedPrivacy{Privacy: p, recorder: recorder{log: log, now: time.Now}}
+}
+
+func (a *auditedPrivacy) Export(ctx context.Context, userID uuid.UUID) (*model.DataExport, error) {
+	export, err := a.Privacy.Export(ctx, userID)
+	if err := a.record(ctx, ActionExport, userID.String(), nil, nil, err); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+func (a *auditedPrivacy) Erase(ctx context.Context, userID uuid.UUID) (*model.Tombstone, error) {
+	tombstone, err := a.Privacy.Erase(ctx, userID)
+	if err := a.record(ctx, ActionErase, userID.String(), nil, nil, err); err != nil {
+		return nil, err
+	}
+	return tombstone, nil
+}
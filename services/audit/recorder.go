@@ -0,0 +1,81 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/sergey4qb/mf1-test/actor"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/audit"
+	"github.com/sergey4qb/mf1-test/request"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionCreate         = "user.create"
+	ActionView           = "user.view"
+	ActionList           = "user.list"
+	ActionViewHistory    = "user.view_history"
+	ActionUpdate         = "user.update"
+	ActionVerifyEmail    = "user.verify_email"
+	ActionDelete         = "user.delete"
+	ActionChangeStatus   = "user.change_status"
+	ActionSetPassword    = "user.set_password"
+	ActionChangePassword = "user.change_password"
+	ActionEnrollMFA      = "user.enroll_mfa"
+	ActionConfirmMFA     = "user.confirm_mfa"
+	ActionDisableMFA     = "user.disable_mfa"
+	ActionExport         = "user.export"
+	ActionErase          = "user.erase"
+)
+
+// recorder appends the entries of the audited services.
+type recorder struct {
+	log audit.Repository
+	now func() time.Time
+}
+
+// record appends an entry for a call that ended with err and returns err.
+// Failing to append is reported too, even when the call succeeded: a
+// change must not go unrecorded silently.
+func (r *recorder) record(ctx context.Context, action, target string, before, after *model.User, err error) error {
+	info := request.FromContext(ctx)
+	entry := &model.AuditEntry{
+		At:         r.now().UTC(),
+		Actor:      actor.FromContext(ctx),
+		Action:     action,
+		Target:     target,
+		BeforeHash: hashUser(before),
+		AfterHash:  hashUser(after),
+		RequestID:  info.ID,
+		Peer:       info.Peer,
+		Outcome:    model.AuditSuccess,
+	}
+	if err != nil {
+		entry.Outcome = model.AuditFailure
+		entry.Error = err.Error()
+	}
+
+	// The call is over: a request cancelled now must still be recorded.
+	if aerr := r.log.Append(context.WithoutCancel(ctx), entry); aerr != nil {
+		return errors.Join(err, aerr)
+	}
+	return err
+}
+
+// hashUser returns the hex SHA-256 of the JSON form of u, or "" for nil.
+func hashUser(u *model.User) string {
+	if u == nil {
+		return ""
+	}
+	data, err := json.Marshal(u)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
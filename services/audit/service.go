@@ -0,0 +1,58 @@
+// Package audit records who viewed or changed users and answers queries on
+// the resulting log. NewUsers and NewHistory wrap the services whose calls
+// are recorded; New queries the log.
+package audit
+
+import (
+	"context"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/repository/audit"
+)
+
+type Audit interface {
+	// List pages through the entries of the organization in the context,
+	// oldest first, optionally only those of one target or actor.
+	List(ctx context.Context, req *dto.ListAuditEntriesDTO) (*dto.AuditPage, error)
+}
+
+type service struct {
+	log audit.Repository
+}
+
+func New(log audit.Repository) Audit {
+	return &service{log: log}
+}
+
+func (s *service) List(ctx context.Context, req *dto.ListAuditEntriesDTO) (*dto.AuditPage, error) {
+	if req.PageSize < 0 {
+		return nil, errInvalidPageSize
+	}
+	after, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	size := defaultPageSize
+	if req.PageSize > 0 {
+		size = min(req.PageSize, maxPageSize)
+	}
+
+	// One entry more than the page tells whether there is a next one.
+	entries, err := s.log.List(ctx, audit.Filter{
+		Target: req.Target,
+		Actor:  req.Actor,
+		After:  after,
+		Limit:  size + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &dto.AuditPage{Entries: entries}
+	if len(entries) > size {
+		page.Entries = entries[:size]
+		page.NextPageToken = encodePageToken(entries[size-1].Sequence)
+	}
+	return page, nil
+}
@@ -0,0 +1,181 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/actor"
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/audit"
+	userstore "github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/request"
+	"github.com/sergey4qb/mf1-test/services/user"
+)
+
+func newTestUsers(t *testing.T) (user.User, audit.Repository) {
+	t.Helper()
+
+	dir := t.TempDir()
+	log, err := audit.NewFile(filepath.Join(dir, "audit.log"))
+	require.NoError(t, err)
+	return NewUsers(user.New(newUserStore(t)), log), log
+}
+
+func newUserStore(t *testing.T) userstore.Store {
+	t.Helper()
+
+	users, err := userstore.NewFile(filepath.Join(t.TempDir(), "users.json"))
+	require.NoError(t, err)
+	return users
+}
+
+func TestNewUsers_RecordsCalls(t *testing.T) {
+	users, log := newTestUsers(t)
+	ctx := actor.NewContext(context.Background(), "alice")
+	ctx = request.NewContext(ctx, request.Info{ID: "req-1", Peer: "10.0.0.1:5000"})
+
+	u := &model.User{Name: "Jane", Email: "jane@example.com"}
+	require.NoError(t, users.Create(ctx, u))
+	_, err := users.GetByID(ctx, u.ID)
+	require.NoError(t, err)
+	name := "Janet"
+	_, err = users.Update(ctx, &dto.UpdateUserDTO{ID: u.ID, Name: &name})
+	require.NoError(t, err)
+	require.NoError(t, users.Delete(ctx, u.ID))
+
+	entries, err := log.List(ctx, audit.Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+
+	var actions []string
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+		assert.Equal(t, "alice", entry.Actor)
+		assert.Equal(t, u.ID.String(), entry.Target)
+		assert.Equal(t, "req-1", entry.RequestID)
+		assert.Equal(t, "10.0.0.1:5000", entry.Peer)
+		assert.Equal(t, model.AuditSuccess, entry.Outcome)
+	}
+	assert.Equal(t, []string{ActionCreate, ActionView, ActionUpdate, ActionDelete}, actions)
+
+	created, viewed, updated, deleted := entries[0], entries[1], entries[2], entries[3]
+	assert.Empty(t, created.BeforeHash)
+	assert.Equal(t, created.AfterHash, viewed.AfterHash)
+	assert.Equal(t, created.AfterHash, updated.BeforeHash)
+	assert.NotEqual(t, updated.BeforeHash, updated.AfterHash)
+	assert.Equal(t, updated.AfterHash, deleted.BeforeHash)
+	assert.Empty(t, deleted.AfterHash)
+}
+
+func TestNewUsers_RecordsFailures(t *testing.T) {
+	users, log := newTestUsers(t)
+	ctx := context.Background()
+	id := uuid.New()
+
+	_, err := users.GetByID(ctx, id)
+	assert.ErrorIs(t, err, user.ErrNotFound)
+	err = users.Create(ctx, &model.User{Name: "Jane"})
+	assert.ErrorIs(t, err, user.ErrValidation)
+
+	entries, err := log.List(ctx, audit.Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, id.String(), entries[0].Target)
+	assert.Equal(t, model.AuditFailure, entries[0].Outcome)
+	assert.NotEmpty(t, entries[0].Error)
+	assert.Equal(t, actor.Unknown, entries[0].Actor)
+	assert.Equal(t, ActionCreate, entries[1].Action)
+	assert.Empty(t, entries[1].Target, "no user was created")
+	assert.Equal(t, model.AuditFailure, entries[1].Outcome)
+}
+
+func TestNewUsers_RecordsEmailVerification(t *testing.T) {
+	users, log := newTestUsers(t)
+	ctx := context.Background()
+
+	u := &model.User{Name: "Jane", Email: "jane@example.com"}
+	require.NoError(t, users.Create(ctx, u))
+	_, err := users.VerifyEmail(ctx, u.ID, "old@example.com")
+	assert.ErrorIs(t, err, user.ErrEmailChanged)
+	verified, err := users.VerifyEmail(ctx, u.ID, u.EmailCanonical)
+	require.NoError(t, err)
+	assert.True(t, verified.EmailVerified)
+
+	entries, err := log.List(ctx, audit.Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	failed, succeeded := entries[1], entries[2]
+	assert.Equal(t, ActionVerifyEmail, failed.Action)
+	assert.Equal(t, model.AuditFailure, failed.Outcome)
+	assert.Equal(t, ActionVerifyEmail, succeeded.Action)
+	assert.Equal(t, u.ID.String(), succeeded.Target)
+	assert.Equal(t, entries[0].AfterHash, succeeded.BeforeHash)
+	assert.NotEqual(t, succeeded.BeforeHash, succeeded.AfterHash)
+}
+
+type failingLog struct {
+	audit.Repository
+}
+
+var errLogDown = errors.New("log down")
+
+func (failingLog) Append(context.Context, *model.AuditEntry) error {
+	return errLogDown
+}
+
+func TestNewUsers_FailsWhenUnrecorded(t *testing.T) {
+	users := NewUsers(user.New(newUserStore(t)), failingLog{})
+
+	err := users.Create(context.Background(), &model.User{Name: "Jane", Email: "jane@example.com"})
+	assert.ErrorIs(t, err, errLogDown)
+}
+
+func TestList_Pages(t *testing.T) {
+	users, log := newTestUsers(t)
+	srv := New(log)
+	ctx := actor.NewContext(context.Background(), "alice")
+
+	u := &model.User{Name: "Jane", Email: "jane@example.com"}
+	require.NoError(t, users.Create(ctx, u))
+	for range 3 {
+		_, err := users.GetByID(ctx, u.ID)
+		require.NoError(t, err)
+	}
+	_, err := users.GetByID(actor.NewContext(ctx, "bob"), u.ID)
+	require.NoError(t, err)
+	_, err = users.List(ctx, &dto.ListUsersDTO{})
+	require.NoError(t, err)
+
+	page, err := srv.List(ctx, &dto.ListAuditEntriesDTO{Target: u.ID.String(), PageSize: 3})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 3)
+	assert.Equal(t, ActionCreate, page.Entries[0].Action)
+	require.NotEmpty(t, page.NextPageToken)
+
+	page, err = srv.List(ctx, &dto.ListAuditEntriesDTO{Target: u.ID.String(), PageSize: 3, PageToken: page.NextPageToken})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+	assert.Equal(t, "bob", page.Entries[1].Actor)
+	assert.Empty(t, page.NextPageToken)
+
+	page, err = srv.List(ctx, &dto.ListAuditEntriesDTO{Actor: "bob"})
+	require.NoError(t, err)
+	assert.Len(t, page.Entries, 1)
+
+	page, err = srv.List(ctx, &dto.ListAuditEntriesDTO{})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 6)
+	assert.Equal(t, ActionList, page.Entries[5].Action)
+
+	_, err = srv.List(ctx, &dto.ListAuditEntriesDTO{PageToken: "bogus"})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = srv.List(ctx, &dto.ListAuditEntriesDTO{PageSize: -1})
+	assert.ErrorIs(t, err, ErrValidation)
+}
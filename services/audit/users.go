@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/audit"
+	"github.com/sergey4qb/mf1-test/services/user"
+)
+
+type auditedUsers struct {
+	user.User
+	recorder
+}
+
+// NewUsers returns users with every call that views or changes users
+// recorded in log, whether it succeeds or not. Logins are not recorded.
+func NewUsers(users user.User, log audit.Repository) user.User {
+	return &auditedUsers{User: users, recorder: recorder{log: log, now: time.Now}}
+}
+
+func (a *auditedUsers) Create(ctx context.Context, u *model.User) error {
+	err := a.User.Create(ctx, u)
+	var target string
+	var after *model.User
+	if err == nil {
+		target, after = u.ID.String(), u
+	}
+	return a.record(ctx, ActionCreate, target, nil, after, err)
+}
+
+func (a *auditedUsers) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	u, err := a.User.GetByID(ctx, id)
+	if err := a.record(ctx, ActionView, id.String(), nil, u, err); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (a *auditedUsers) GetAll(ctx context.Context) ([]model.User, error) {
+	users, err := a.User.GetAll(ctx)
+	if err := a.record(ctx, ActionList, "", nil, nil, err); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (a *auditedUsers) List(ctx context.Context, req *dto.ListUsersDTO) (*dto.UsersPage, error) {
+	page, err := a.User.List(ctx, req)
+	if err := a.record(ctx, ActionList, "", nil, nil, err); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (a *auditedUsers) Update(ctx context.Context, req *dto.UpdateUserDTO) (*model.User, error) {
+	var u *model.User
+	err := a.change(ctx, ActionUpdate, req.ID, func() error {
+		var err error
+		u, err = a.User.Update(ctx, req)
+		return err
+	})
+	return u, err
+}
+
+func (a *auditedUsers) VerifyEmail(ctx context.Context, id uuid.UUID, email string) (*model.User, error) {
+	var u *model.User
+	err := a.change(ctx, ActionVerifyEmail, id, func() error {
+		var err error
+		u, err = a.User.VerifyEmail(ctx, id, email)
+		return err
+	})
+	return u, err
+}
+
+func (a *auditedUsers) Delete(ctx context.Context, id uuid.UUID) error {
+	return a.change(ctx, ActionDelete, id, func() error {
+		return a.User.Delete(ctx, id)
+	})
+}
+
+func (a *auditedUsers) ChangeStatus(ctx context.Context, req *dto.ChangeStatusDTO) (*model.User, error) {
+	var u *model.User
+	err := a.change(ctx, ActionChangeStatus, req.ID, func() error {
+		var err error
+		u, err = a.User.ChangeStatus(ctx, req)
+		return err
+	})
+	return u, err
+}
+
+func (a *auditedUsers) SetPassword(ctx context.Context, id uuid.UUID, password string) error {
+	return a.change(ctx, ActionSetPassword, id, func() error {
+		return a.User.SetPassword(ctx, id, password)
+	})
+}
+
+func (a *auditedUsers) ChangePassword(ctx context.Context, id uuid.UUID, current, password string) error {
+	return a.change(ctx, ActionChangePassword, id, func() error {
+		return a.User.ChangePassword(ctx, id, current, password)
+	})
+}
+
+func (a *auditedUsers) EnrollMFA(ctx context.Context, id uuid.UUID) (*dto.MFAEnrollment, error) {
+	var enrollment *dto.MFAEnrollment
+	err := a.change(ctx, ActionEnrollMFA, id, func() error {
+		var err error
+		enrollment, err = a.User.EnrollMFA(ctx, id)
+		return err
+	})
+	return enrollment, err
+}
+
+func (a *auditedUsers) ConfirmMFA(ctx context.Context, id uuid.UUID, code string) ([]string, error) {
+	var codes []string
+	err := a.change(ctx, ActionConfirmMFA, id, func() error {
+		var err error
+		codes, err = a.User.ConfirmMFA(ctx, id, code)
+		return err
+	})
+	return codes, err
+}
+
+func (a *auditedUsers) DisableMFA(ctx context.Context, id uuid.UUID) error {
+	return a.change(ctx, ActionDisableMFA, id, func() error {
+		return a.User.DisableMFA(ctx, id)
+	})
+}
+
+// change runs call, which changes user id, and records it with the user as
+// stored before and after.
+func (a *auditedUsers) change(ctx context.Context, action string, id uuid.UUID, call func() error) error {
+	before, _ := a.User.GetByID(ctx, id)
+	err := call()
+	var after *model.User
+	if err == nil {
+		after, _ = a.User.GetByID(ctx, id)
+	}
+	return a.record(ctx, action, id.String(), before, after, err)
+}
@@ -0,0 +1,45 @@
+package group
+
+import (
+	"errors"
+
+	"github.com/sergey4qb/mf1-test/repository/group"
+	"github.com/sergey4qb/mf1-test/repository/user"
+)
+
+// Error kinds returned by the service.
+var (
+	ErrNotFound      = group.ErrGroupNotFound
+	ErrAlreadyExists = group.ErrGroupAlreadyExists
+	ErrNameTaken     = group.ErrNameTaken
+	ErrAlreadyMember = group.ErrAlreadyMember
+	ErrNotMember     = group.ErrNotMember
+	ErrGroupFull     = group.ErrGroupFull
+	ErrTooManyGroups = group.ErrTooManyGroups
+	// ErrUserNotFound is returned when a membership names an unknown user.
+	ErrUserNotFound = user.ErrUserNotFound
+	ErrValidation   = errors.New("validation failed")
+)
+
+var (
+	errInvalidName        = newValidationError("name cannot be empty")
+	errInvalidDescription = newValidationError("description is longer than 1000 characters")
+	errInvalidPageSize    = newValidationError("page size cannot be negative")
+	errInvalidPageToken   = newValidationError("invalid page token")
+)
+
+type validationError struct {
+	msg string
+}
+
+func newValidationError(msg string) error {
+	return &validationError{msg: msg}
+}
+
+func (e *validationError) Error() string {
+	return e.msg
+}
+
+func (e *validationError) Is(target error) bool {
+	return target == ErrValidation
+}
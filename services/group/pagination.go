@@ -0,0 +1,78 @@
+package group
+
+import (
+	"encoding/base64"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+const maxPageSize = 1000
+
+// cursor is the last member already returned. Members are ordered by when
+// they were added, so pages stay stable while members come and go.
+type cursor struct {
+	addedAt time.Time
+	userID  uuid.UUID
+}
+
+func encodePageToken(last *model.Membership) string {
+	raw := strconv.FormatInt(last.AddedAt.UnixNano(), 10) + ":" + last.UserID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodePageToken(token string) (*cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errInvalidPageToken
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, errInvalidPageToken
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, errInvalidPageToken
+	}
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errInvalidPageToken
+	}
+	return &cursor{addedAt: time.Unix(0, n), userID: userID}, nil
+}
+
+func sortMembers(members []model.Membership) {
+	slices.SortStableFunc(members, func(a, b model.Membership) int {
+		return compareMembers(a.AddedAt, a.UserID, b.AddedAt, b.UserID)
+	})
+}
+
+// pageStart returns the index of the first member after c in sorted members.
+func pageStart(members []model.Membership, c *cursor) int {
+	if c == nil {
+		return 0
+	}
+	start, found := slices.BinarySearchFunc(members, c, func(m model.Membership, c *cursor) int {
+		return compareMembers(m.AddedAt, m.UserID, c.addedAt, c.userID)
+	})
+	if found {
+		start++
+	}
+	return start
+}
+
+func compareMembers(aAt time.Time, aID uuid.UUID, bAt time.Time, bID uuid.UUID) int {
+	if c := aAt.Compare(bAt); c != 0 {
+		return c
+	}
+	return strings.Compare(aID.String(), bID.String())
+}
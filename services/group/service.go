@@ -0,0 +1,244 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/names"
+	"github.com/sergey4qb/mf1-test/repository/group"
+	"github.com/sergey4qb/mf1-test/repository/user"
+)
+
+// maxDescriptionLength is the longest accepted description in characters.
+const maxDescriptionLength = 1000
+
+type Group interface {
+	Create(ctx context.Context, group *model.Group) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Group, error)
+	GetAll(ctx context.Context) ([]model.Group, error)
+	Update(ctx context.Context, dto *dto.UpdateGroupDTO) (*model.Group, error)
+	// Delete removes a group; its members stay users.
+	Delete(ctx context.Context, id uuid.UUID) error
+	AddMember(ctx context.Context, groupID, userID uuid.UUID) (*model.Membership, error)
+	RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error
+	ListMembers(ctx context.Context, req *dto.ListMembersDTO) (*dto.MembersPage, error)
+	// ListUserGroups returns the groups a user is a member of, in the order
+	// the user joined them.
+	ListUserGroups(ctx context.Context, userID uuid.UUID) ([]model.Group, error)
+	// RemoveUser drops the memberships of a deleted user.
+	RemoveUser(ctx context.Context, userID uuid.UUID) error
+}
+
+type service struct {
+	repo   group.Repository
+	users  user.Repository
+	limits group.Limits
+	now    func() time.Time
+
+	deleteHooks     []func(ctx context.Context, id uuid.UUID) error
+	membershipHooks []func(groupID, userID uuid.UUID)
+}
+
+type Option func(*service)
+
+// WithLimits caps how many members a group and how many groups a user can
+// have; by default there is no cap.
+func WithLimits(limits group.Limits) Option {
+	return func(s *service) {
+		s.limits = limits
+	}
+}
+
+// WithDeleteHook runs hook after a group is deleted, to clean up data that
+// refers to it, e.g. role assignments.
+func WithDeleteHook(hook func(ctx context.Context, id uuid.UUID) error) Option {
+	return func(s *service) {
+		s.deleteHooks = append(s.deleteHooks, hook)
+	}
+}
+
+// WithMembershipHook runs hook after a user is added to or removed from a
+// group, e.g. to drop cached permissions.
+func WithMembershipHook(hook func(groupID, userID uuid.UUID)) Option {
+	return func(s *service) {
+		s.membershipHooks = append(s.membershipHooks, hook)
+	}
+}
+
+func New(repo group.Repository, users user.Repository, opts ...Option) Group {
+	s := &service{repo: repo, users: users, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *service) Create(ctx context.Context, group *model.Group) error {
+	normalize(group)
+	if err := validate(group); err != nil {
+		return err
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+	group.ID = id
+	group.CreatedAt = s.now().UTC()
+	return s.repo.Create(ctx, group)
+}
+
+func (s *service) GetByID(ctx context.Context, id uuid.UUID) (*model.Group, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *service) GetAll(ctx context.Context) ([]model.Group, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *service) Update(ctx context.Context, dto *dto.UpdateGroupDTO) (*model.Group, error) {
+	group, err := s.repo.GetByID(ctx, dto.ID)
+	if err != nil {
+		return nil, err
+	}
+	if dto.Name != nil {
+		group.Name = *dto.Name
+	}
+	if dto.Description != nil {
+		group.Description = *dto.Description
+	}
+	normalize(group)
+	if err := validate(group); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (s *service) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	for _, hook := range s.deleteHooks {
+		if err := hook(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) AddMember(ctx context.Context, groupID, userID uuid.UUID) (*model.Membership, error) {
+	if _, err := s.users.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	membership := &model.Membership{
+		GroupID: groupID,
+		UserID:  userID,
+		AddedAt: s.now().UTC(),
+	}
+	if err := s.repo.AddMember(ctx, membership, s.limits); err != nil {
+		return nil, err
+	}
+	s.membershipChanged(groupID, userID)
+	return membership, nil
+}
+
+func (s *service) RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	if err := s.repo.RemoveMember(ctx, groupID, userID); err != nil {
+		return err
+	}
+	s.membershipChanged(groupID, userID)
+	return nil
+}
+
+func (s *service) membershipChanged(groupID, userID uuid.UUID) {
+	for _, hook := range s.membershipHooks {
+		hook(groupID, userID)
+	}
+}
+
+func (s *service) ListMembers(ctx context.Context, req *dto.ListMembersDTO) (*dto.MembersPage, error) {
+	if req.PageSize < 0 {
+		return nil, errInvalidPageSize
+	}
+	after, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.repo.Members(ctx, req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	sortMembers(members)
+
+	start := pageStart(members, after)
+	end := len(members)
+	if req.PageSize > 0 {
+		end = min(start+min(req.PageSize, maxPageSize), len(members))
+	}
+
+	page := &dto.MembersPage{Members: members[start:end]}
+	if end < len(members) {
+		page.NextPageToken = encodePageToken(&members[end-1])
+	}
+	return page, nil
+}
+
+func (s *service) ListUserGroups(ctx context.Context, userID uuid.UUID) ([]model.Group, error) {
+	if _, err := s.users.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	memberships, err := s.repo.Memberships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]model.Group, 0, len(memberships))
+	for _, m := range memberships {
+		g, err := s.repo.GetByID(ctx, m.GroupID)
+		if errors.Is(err, group.ErrGroupNotFound) {
+			// Deleted since the memberships were read.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, *g)
+	}
+	return groups, nil
+}
+
+func (s *service) RemoveUser(ctx context.Context, userID uuid.UUID) error {
+	return s.repo.RemoveUser(ctx, userID)
+}
+
+// normalize stores the name in NFC with whitespace collapsed, together with
+// the key names are unique by.
+func normalize(group *model.Group) {
+	group.Name = names.Normalize(group.Name)
+	group.NameKey = names.SearchKey(group.Name)
+	group.Description = strings.TrimSpace(group.Description)
+}
+
+func validate(group *model.Group) error {
+	if group.Name == "" {
+		return errInvalidName
+	}
+	if err := names.Validate(group.Name); err != nil {
+		return newValidationError(err.Error())
+	}
+	if utf8.RuneCountInString(group.Description) > maxDescriptionLength {
+		return errInvalidDescription
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package group
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/group"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+func newTestService(t *testing.T, opts ...Option) (*service, user.Repository) {
+	t.Helper()
+
+	dir := t.TempDir()
+	users, err := user.NewFile(filepath.Join(dir, "users.json"))
+	require.NoError(t, err)
+	groups, err := group.NewFile(filepath.Join(dir, "groups.json"))
+	require.NoError(t, err)
+	srv := New(groups, users, opts...).(*service)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	srv.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return srv, users
+}
+
+func createUser(t *testing.T, users user.Repository) uuid.UUID {
+	t.Helper()
+
+	u := &model.User{ID: uuid.New(), Name: "Jane", Email: "jane@example.com"}
+	require.NoError(t, users.Create(context.Background(), u))
+	return u.ID
+}
+
+func TestCreate(t *testing.T) {
+	srv, _ := newTestService(t)
+	ctx := context.Background()
+
+	g := &model.Group{Name: "  Platform   Team ", Description: " Runs the platform "}
+	require.NoError(t, srv.Create(ctx, g))
+	assert.NotEqual(t, uuid.Nil, g.ID)
+	assert.Equal(t, "Platform Team", g.Name)
+	assert.Equal(t, "Runs the platform", g.Description)
+	assert.False(t, g.CreatedAt.IsZero())
+
+	err := srv.Create(ctx, &model.Group{Name: "PLATFORM team"})
+	assert.ErrorIs(t, err, ErrNameTaken, "names are unique ignoring case")
+
+	err = srv.Create(ctx, &model.Group{Name: "  "})
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestUpdate(t *testing.T) {
+	srv, _ := newTestService(t)
+	ctx := context.Background()
+
+	g := &model.Group{Name: "Platform"}
+	require.NoError(t, srv.Create(ctx, g))
+
+	name := "Infrastructure"
+	updated, err := srv.Update(ctx, &dto.UpdateGroupDTO{ID: g.ID, Name: &name})
+	require.NoError(t, err)
+	assert.Equal(t, "Infrastructure", updated.Name)
+	assert.Equal(t, g.CreatedAt, updated.CreatedAt)
+
+	empty := ""
+	_, err = srv.Update(ctx, &dto.UpdateGroupDTO{ID: g.ID, Name: &empty})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = srv.Update(ctx, &dto.UpdateGroupDTO{ID: uuid.New(), Name: &name})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestAddMember(t *testing.T) {
+	srv, users := newTestService(t, WithLimits(group.Limits{MaxMembers: 1}))
+	ctx := context.Background()
+
+	g := &model.Group{Name: "Platform"}
+	require.NoError(t, srv.Create(ctx, g))
+
+	_, err := srv.AddMember(ctx, g.ID, uuid.New())
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	userID := createUser(t, users)
+	m, err := srv.AddMember(ctx, g.ID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, userID, m.UserID)
+
+	_, err = srv.AddMember(ctx, g.ID, userID)
+	assert.ErrorIs(t, err, ErrAlreadyMember)
+
+	other := &model.User{ID: uuid.New(), Name: "John", Email: "john@example.com"}
+	require.NoError(t, users.Create(ctx, other))
+	_, err = srv.AddMember(ctx, g.ID, other.ID)
+	assert.ErrorIs(t, err, ErrGroupFull)
+}
+
+func TestListMembers_Pages(t *testing.T) {
+	srv, _ := newTestService(t)
+	ctx := context.Background()
+
+	g := &model.Group{Name: "Platform"}
+	require.NoError(t, srv.Create(ctx, g))
+	var want []uuid.UUID
+	for i := 0; i < 5; i++ {
+		u := &model.User{ID: uuid.New(), Name: "User", Email: uuid.NewString() + "@example.com"}
+		require.NoError(t, srv.users.Create(ctx, u))
+		_, err := srv.AddMember(ctx, g.ID, u.ID)
+		require.NoError(t, err)
+		want = append(want, u.ID)
+	}
+
+	page, err := srv.ListMembers(ctx, &dto.ListMembersDTO{GroupID: g.ID, PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Members, 2)
+	require.NotEmpty(t, page.NextPageToken)
+
+	// A member removed between pages does not shift the next page.
+	require.NoError(t, srv.RemoveMember(ctx, g.ID, want[1]))
+
+	var got []uuid.UUID
+	for _, m := range page.Members {
+		got = append(got, m.UserID)
+	}
+	for page.NextPageToken != "" {
+		page, err = srv.ListMembers(ctx, &dto.ListMembersDTO{GroupID: g.ID, PageSize: 2, PageToken: page.NextPageToken})
+		require.NoError(t, err)
+		for _, m := range page.Members {
+			got = append(got, m.UserID)
+		}
+	}
+	assert.Equal(t, want, got)
+
+	_, err = srv.ListMembers(ctx, &dto.ListMembersDTO{GroupID: g.ID, PageToken: "bogus"})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = srv.ListMembers(ctx, &dto.ListMembersDTO{GroupID: uuid.New()})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestTenantIsolation(t *testing.T) {
+	srv, users := newTestService(t)
+	acme := tenant.NewContext(context.Background(), uuid.New())
+	globex := tenant.NewContext(context.Background(), uuid.New())
+
+	g := &model.Group{Name: "Platform"}
+	require.NoError(t, srv.Create(acme, g))
+	jane := &model.User{ID: uuid.New(), Name: "Jane", Email: "jane@example.com"}
+	require.NoError(t, users.Create(acme, jane))
+	_, err := srv.AddMember(acme, g.ID, jane.ID)
+	require.NoError(t, err)
+	john := &model.User{ID: uuid.New(), Name: "John", Email: "john@example.com"}
+	require.NoError(t, users.Create(globex, john))
+
+	all, err := srv.GetAll(globex)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+	_, err = srv.ListMembers(globex, &dto.ListMembersDTO{GroupID: g.ID})
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = srv.AddMember(globex, g.ID, john.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = srv.AddMember(acme, g.ID, john.ID)
+	assert.ErrorIs(t, err, ErrUserNotFound, "users of other organizations cannot join")
+	assert.ErrorIs(t, srv.RemoveMember(globex, g.ID, jane.ID), ErrNotFound)
+
+	require.NoError(t, srv.RemoveUser(globex, jane.ID))
+	page, err := srv.ListMembers(acme, &dto.ListMembersDTO{GroupID: g.ID})
+	require.NoError(t, err)
+	require.Len(t, page.Members, 1, "deleting a user elsewhere leaves the membership alone")
+	assert.Equal(t, jane.ID, page.Members[0].UserID)
+}
+
+func TestListUserGroups(t *testing.T) {
+	srv, users := newTestService(t)
+	ctx := context.Background()
+	userID := createUser(t, users)
+
+	var want []string
+	for _, name := range []string{"Platform", "Security", "Design"} {
+		g := &model.Group{Name: name}
+		require.NoError(t, srv.Create(ctx, g))
+		if name != "Security" {
+			_, err := srv.AddMember(ctx, g.ID, userID)
+			require.NoError(t, err)
+			want = append(want, name)
+		}
+	}
+
+	groups, err := srv.ListUserGroups(ctx, userID)
+	require.NoError(t, err)
+	var got []string
+	for _, g := range groups {
+		got = append(got, g.Name)
+	}
+	assert.Equal(t, want, got)
+
+	require.NoError(t, srv.RemoveUser(ctx, userID))
+	groups, err = srv.ListUserGroups(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+
+	_, err = srv.ListUserGroups(ctx, uuid.New())
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestHooks(t *testing.T) {
+	var deleted []uuid.UUID
+	var changed int
+	srv, users := newTestService(t,
+		WithDeleteHook(func(ctx context.Context, id uuid.UUID) error {
+			deleted = append(deleted, id)
+			return nil
+		}),
+		WithMembershipHook(func(groupID, userID uuid.UUID) {
+			changed++
+		}),
+	)
+	ctx := context.Background()
+	userID := createUser(t, users)
+
+	g := &model.Group{Name: "Platform"}
+	require.NoError(t, srv.Create(ctx, g))
+	_, err := srv.AddMember(ctx, g.ID, userID)
+	require.NoError(t, err)
+	_, err = srv.AddMember(ctx, g.ID, userID)
+	assert.ErrorIs(t, err, ErrAlreadyMember)
+	require.NoError(t, srv.RemoveMember(ctx, g.ID, userID))
+	assert.Equal(t, 2, changed, "only actual changes run the hook")
+
+	assert.ErrorIs(t, srv.Delete(ctx, uuid.New()), ErrNotFound)
+	require.NoError(t, srv.Delete(ctx, g.ID))
+	assert.Equal(t, []uuid.UUID{g.ID}, deleted)
+}
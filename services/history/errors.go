@@ -0,0 +1,39 @@
+package history
+
+import (
+	"errors"
+
+	"github.com/sergey4qb/mf1-test/repository/user"
+)
+
+// Error kinds returned by the service.
+var (
+	// ErrNotFound is returned for unknown users, and for users that did
+	// not exist at the requested time or revision.
+	ErrNotFound         = user.ErrUserNotFound
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrValidation       = errors.New("validation failed")
+)
+
+var (
+	errInvalidRevision  = newValidationError("revision must be positive")
+	errInvalidPageSize  = newValidationError("page size cannot be negative")
+	errInvalidPageToken = newValidationError("invalid page token")
+	errRevertDeletion   = newValidationError("cannot revert to a deletion")
+)
+
+type validationError struct {
+	msg string
+}
+
+func newValidationError(msg string) error {
+	return &validationError{msg: msg}
+}
+
+func (e *validationError) Error() string {
+	return e.msg
+}
+
+func (e *validationError) Is(target error) bool {
+	return target == ErrValidation
+}
@@ -0,0 +1,140 @@
+// Package history answers what a user looked like at a point in time and
+// restores earlier versions. Revisions are recorded by history.Record
+// wrapping the user repository.
+package history
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/history"
+	"github.com/sergey4qb/mf1-test/services/user"
+)
+
+type History interface {
+	// List pages through the revisions of a user, oldest first. Deleted
+	// users keep their history.
+	List(ctx context.Context, req *dto.ListRevisionsDTO) (*dto.RevisionsPage, error)
+	// Get returns revision number of a user.
+	Get(ctx context.Context, userID uuid.UUID, number int64) (*model.UserRevision, error)
+	// AsOf returns the revision of a user in effect at t. It fails with
+	// ErrNotFound when the user had not been created yet.
+	AsOf(ctx context.Context, userID uuid.UUID, t time.Time) (*model.UserRevision, error)
+	// Revert restores the profile and attributes of revision number through
+	// an update, which is recorded as a new revision.
+	Revert(ctx context.Context, userID uuid.UUID, number int64) (*model.User, error)
+}
+
+type service struct {
+	revisions history.Repository
+	users     user.User
+}
+
+func New(revisions history.Repository, users user.User) History {
+	return &service{revisions: revisions, users: users}
+}
+
+func (s *service) List(ctx context.Context, req *dto.ListRevisionsDTO) (*dto.RevisionsPage, error) {
+	if req.PageSize < 0 {
+		return nil, errInvalidPageSize
+	}
+	after, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.list(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := 0
+	for start < len(revisions) && revisions[start].Number <= after {
+		start++
+	}
+	end := len(revisions)
+	if req.PageSize > 0 {
+		end = min(start+min(req.PageSize, maxPageSize), len(revisions))
+	}
+
+	page := &dto.RevisionsPage{Revisions: revisions[start:end]}
+	if end < len(revisions) {
+		page.NextPageToken = encodePageToken(revisions[end-1].Number)
+	}
+	return page, nil
+}
+
+func (s *service) Get(ctx context.Context, userID uuid.UUID, number int64) (*model.UserRevision, error) {
+	if number < 1 {
+		return nil, errInvalidRevision
+	}
+	revisions, err := s.list(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range revisions {
+		if revisions[i].Number == number {
+			return &revisions[i], nil
+		}
+	}
+	return nil, ErrRevisionNotFound
+}
+
+func (s *service) AsOf(ctx context.Context, userID uuid.UUID, t time.Time) (*model.UserRevision, error) {
+	revisions, err := s.list(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if !revisions[i].At.After(t) {
+			return &revisions[i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *service) Revert(ctx context.Context, userID uuid.UUID, number int64) (*model.User, error) {
+	rev, err := s.Get(ctx, userID, number)
+	if err != nil {
+		return nil, err
+	}
+	if rev.User == nil {
+		return nil, errRevertDeletion
+	}
+
+	old := rev.User
+	update := &dto.UpdateUserDTO{
+		ID:                userID,
+		Name:              &old.Name,
+		Email:             &old.Email,
+		GivenName:         &old.GivenName,
+		FamilyName:        &old.FamilyName,
+		Phone:             &old.Phone,
+		Locale:            &old.Locale,
+		TimeZone:          &old.TimeZone,
+		AvatarURL:         &old.AvatarURL,
+		Attributes:        old.Attributes,
+		ReplaceAttributes: true,
+	}
+	return s.users.Update(history.WithRevertedFrom(ctx, number), update)
+}
+
+// list returns the revisions of a user, failing with ErrNotFound for users
+// that neither exist nor have a history.
+func (s *service) list(ctx context.Context, userID uuid.UUID) ([]model.UserRevision, error) {
+	revisions, err := s.revisions.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) > 0 {
+		return revisions, nil
+	}
+	if _, err := s.users.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
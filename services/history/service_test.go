@@ -0,0 +1,129 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/history"
+	userstore "github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/services/user"
+)
+
+func newTestService(t *testing.T) (*service, user.User) {
+	t.Helper()
+
+	dir := t.TempDir()
+	revisions, err := history.NewFile(filepath.Join(dir, "history.json"))
+	require.NoError(t, err)
+	store, err := userstore.NewFile(filepath.Join(dir, "users.json"))
+	require.NoError(t, err)
+	users := user.New(history.Record(store, revisions),
+		user.WithAttributeSchema(&user.AttributeSchema{
+			Properties: map[string]*user.AttributeDef{"department": {Type: user.AttributeString}},
+		}),
+	)
+	return New(revisions, users).(*service), users
+}
+
+// createHistory creates a user and renames it twice.
+func createHistory(t *testing.T, users user.User) *model.User {
+	t.Helper()
+	ctx := context.Background()
+
+	u := &model.User{Name: "Jane", Email: "jane@example.com", Attributes: map[string]any{"department": "sales"}}
+	require.NoError(t, users.Create(ctx, u))
+	for _, name := range []string{"Janet", "Jane Doe"} {
+		_, err := users.Update(ctx, &dto.UpdateUserDTO{ID: u.ID, Name: &name})
+		require.NoError(t, err)
+	}
+	return u
+}
+
+func TestList_Pages(t *testing.T) {
+	srv, users := newTestService(t)
+	ctx := context.Background()
+	u := createHistory(t, users)
+
+	page, err := srv.List(ctx, &dto.ListRevisionsDTO{UserID: u.ID, PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Revisions, 2)
+	assert.Equal(t, model.RevisionCreate, page.Revisions[0].Action)
+	require.NotEmpty(t, page.NextPageToken)
+
+	page, err = srv.List(ctx, &dto.ListRevisionsDTO{UserID: u.ID, PageSize: 2, PageToken: page.NextPageToken})
+	require.NoError(t, err)
+	require.Len(t, page.Revisions, 1)
+	assert.Equal(t, int64(3), page.Revisions[0].Number)
+	assert.Equal(t, []model.FieldChange{{Field: "name", Old: "Janet", New: "Jane Doe"}}, page.Revisions[0].Changes)
+	assert.Empty(t, page.NextPageToken)
+
+	_, err = srv.List(ctx, &dto.ListRevisionsDTO{UserID: u.ID, PageToken: "bogus"})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = srv.List(ctx, &dto.ListRevisionsDTO{UserID: uuid.New()})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Deleted users keep their history.
+	require.NoError(t, users.Delete(ctx, u.ID))
+	page, err = srv.List(ctx, &dto.ListRevisionsDTO{UserID: u.ID})
+	require.NoError(t, err)
+	require.Len(t, page.Revisions, 4)
+	assert.Equal(t, model.RevisionDelete, page.Revisions[3].Action)
+}
+
+func TestGetAndAsOf(t *testing.T) {
+	srv, users := newTestService(t)
+	ctx := context.Background()
+	u := createHistory(t, users)
+
+	rev, err := srv.Get(ctx, u.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Janet", rev.User.Name)
+	_, err = srv.Get(ctx, u.ID, 4)
+	assert.ErrorIs(t, err, ErrRevisionNotFound)
+	_, err = srv.Get(ctx, u.ID, 0)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	rev, err = srv.AsOf(ctx, u.ID, rev.At)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), rev.Number)
+	rev, err = srv.AsOf(ctx, u.ID, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", rev.User.Name)
+	_, err = srv.AsOf(ctx, u.ID, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, ErrNotFound, "the user did not exist yet")
+}
+
+func TestRevert(t *testing.T) {
+	srv, users := newTestService(t)
+	ctx := context.Background()
+	u := createHistory(t, users)
+	_, err := users.Update(ctx, &dto.UpdateUserDTO{ID: u.ID, Attributes: map[string]any{"department": nil}})
+	require.NoError(t, err)
+
+	reverted, err := srv.Revert(ctx, u.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", reverted.Name)
+	assert.Equal(t, map[string]any{"department": "sales"}, reverted.Attributes)
+
+	rev, err := srv.Get(ctx, u.ID, 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), rev.RevertedFrom)
+	assert.Equal(t, []model.FieldChange{
+		{Field: "attributes.department", New: "sales"},
+		{Field: "name", Old: "Jane Doe", New: "Jane"},
+	}, rev.Changes)
+
+	require.NoError(t, users.Delete(ctx, u.ID))
+	_, err = srv.Revert(ctx, u.ID, 6)
+	assert.ErrorIs(t, err, ErrValidation, "a deletion cannot be restored")
+	_, err = srv.Revert(ctx, u.ID, 1)
+	assert.ErrorIs(t, err, ErrNotFound, "deleted users cannot be reverted")
+}
@@ -0,0 +1,8 @@
+package idempotency
+
+import "errors"
+
+var (
+	ErrKeyReused  = errors.New("idempotency key was already used with a different request")
+	ErrInvalidKey = errors.New("idempotency key must be 1 to 255 printable ASCII characters")
+)
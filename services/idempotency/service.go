@@ -0,0 +1,137 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/idempotency"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+const maxKeyLength = 255
+
+// Response is the serialized outcome of a call, stored for replay.
+type Response struct {
+	Type string
+	Data []byte
+}
+
+type Idempotency interface {
+	// Do runs call at most once per key. Repeating a key with the same
+	// fingerprint returns the stored response with replayed set; a different
+	// fingerprint fails with ErrKeyReused. Failed calls are not stored, so
+	// they can be retried with the same key.
+	Do(ctx context.Context, key, method, fingerprint string, call func() (*Response, error)) (resp *Response, replayed bool, err error)
+}
+
+type service struct {
+	repo idempotency.Repository
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu       sync.Mutex
+	inFlight map[string]*keyLock
+}
+
+type keyLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func New(repo idempotency.Repository, ttl time.Duration) Idempotency {
+	return &service{
+		repo:     repo,
+		ttl:      ttl,
+		now:      time.Now,
+		inFlight: map[string]*keyLock{},
+	}
+}
+
+func (s *service) Do(ctx context.Context, key, method, fingerprint string, call func() (*Response, error)) (*Response, bool, error) {
+	if !validKey(key) {
+		return nil, false, ErrInvalidKey
+	}
+	// Clients pick keys, so each organization gets a key space of its own
+	// and cannot replay another's responses.
+	if org := tenant.FromContext(ctx); org != tenant.Default {
+		key = org.String() + "/" + key
+	}
+
+	// Concurrent calls with the same key wait for the first one, so a
+	// retry racing the original request cannot run the call twice.
+	unlock := s.lock(key)
+	defer unlock()
+
+	rec, err := s.repo.Get(ctx, key)
+	switch {
+	case err == nil:
+		if rec.Method != method || rec.RequestHash != fingerprint {
+			return nil, false, ErrKeyReused
+		}
+		return &Response{Type: rec.ResponseType, Data: rec.Response}, true, nil
+	case !errors.Is(err, idempotency.ErrRecordNotFound):
+		return nil, false, err
+	}
+
+	resp, err := call()
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := s.now()
+	err = s.repo.Save(ctx, &model.IdempotencyRecord{
+		Key:          key,
+		Method:       method,
+		RequestHash:  fingerprint,
+		ResponseType: resp.Type,
+		Response:     resp.Data,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(s.ttl),
+	})
+	if err != nil {
+		// The call already took effect; failing it now would invite the
+		// very retry this service exists to absorb.
+		log.Printf("idempotency: store response for key %q: %v", key, err)
+	}
+
+	return resp, false, nil
+}
+
+func (s *service) lock(key string) func() {
+	s.mu.Lock()
+	l, ok := s.inFlight[key]
+	if !ok {
+		l = &keyLock{}
+		s.inFlight[key] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+
+		s.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(s.inFlight, key)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func validKey(key string) bool {
+	if key == "" || len(key) > maxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
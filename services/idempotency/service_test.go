@@ -0,0 +1,124 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/repository/idempotency"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+func newService(t *testing.T) Idempotency {
+	repo, err := idempotency.New(filepath.Join(t.TempDir(), "idempotency.json"))
+	require.NoError(t, err)
+	return New(repo, time.Hour)
+}
+
+func counting(calls *int32, data string) func() (*Response, error) {
+	return func() (*Response, error) {
+		atomic.AddInt32(calls, 1)
+		return &Response{Type: "test.Response", Data: []byte(data)}, nil
+	}
+}
+
+func TestDo_ReplaysStoredResponse(t *testing.T) {
+	svc := newService(t)
+	var calls int32
+
+	resp, replayed, err := svc.Do(context.Background(), "key", "/m", "fp", counting(&calls, "first"))
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, "first", string(resp.Data))
+
+	resp, replayed, err = svc.Do(context.Background(), "key", "/m", "fp", counting(&calls, "second"))
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, "first", string(resp.Data))
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestDo_KeyReusedWithDifferentRequest(t *testing.T) {
+	svc := newService(t)
+	var calls int32
+
+	_, _, err := svc.Do(context.Background(), "key", "/m", "fp", counting(&calls, "first"))
+	require.NoError(t, err)
+
+	_, _, err = svc.Do(context.Background(), "key", "/m", "other", counting(&calls, "second"))
+	assert.ErrorIs(t, err, ErrKeyReused)
+
+	_, _, err = svc.Do(context.Background(), "key", "/other", "fp", counting(&calls, "second"))
+	assert.ErrorIs(t, err, ErrKeyReused)
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestDo_KeysArePerOrganization(t *testing.T) {
+	svc := newService(t)
+	var calls int32
+	acme := tenant.NewContext(context.Background(), uuid.New())
+
+	_, _, err := svc.Do(context.Background(), "key", "/m", "fp", counting(&calls, "default"))
+	require.NoError(t, err)
+
+	resp, replayed, err := svc.Do(acme, "key", "/m", "fp", counting(&calls, "acme"))
+	require.NoError(t, err)
+	assert.False(t, replayed, "another organization's key must not replay")
+	assert.Equal(t, "acme", string(resp.Data))
+	assert.Equal(t, int32(2), calls)
+}
+
+func TestDo_FailuresAreNotStored(t *testing.T) {
+	svc := newService(t)
+	var calls int32
+	failure := errors.New("boom")
+
+	_, _, err := svc.Do(context.Background(), "key", "/m", "fp", func() (*Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	_, replayed, err := svc.Do(context.Background(), "key", "/m", "fp", counting(&calls, "retry"))
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, int32(2), calls)
+}
+
+func TestDo_ConcurrentCallsRunOnce(t *testing.T) {
+	svc := newService(t)
+	var calls int32
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, _, err := svc.Do(context.Background(), "key", "/m", "fp", counting(&calls, "only"))
+			assert.NoError(t, err)
+			assert.Equal(t, "only", string(resp.Data))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestDo_InvalidKey(t *testing.T) {
+	svc := newService(t)
+	var calls int32
+
+	for _, key := range []string{"", "bad\nkey", string(make([]byte, 256))} {
+		_, _, err := svc.Do(context.Background(), key, "/m", "fp", counting(&calls, "x"))
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	}
+	assert.Equal(t, int32(0), calls)
+}
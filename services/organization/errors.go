@@ -0,0 +1,39 @@
+package organization
+
+import (
+	"errors"
+
+	"github.com/sergey4qb/mf1-test/repository/organization"
+)
+
+// Error kinds returned by the service.
+var (
+	ErrNotFound      = organization.ErrOrganizationNotFound
+	ErrAlreadyExists = organization.ErrOrganizationAlreadyExists
+	ErrNameTaken     = organization.ErrNameTaken
+	// ErrNotEmpty is returned when deleting an organization that still has
+	// users.
+	ErrNotEmpty = errors.New("organization still has users")
+	// ErrPendingEvents is returned when deleting an organization whose
+	// events are not all published yet; retry once they are.
+	ErrPendingEvents = errors.New("organization still has events to publish")
+	ErrValidation    = errors.New("validation failed")
+)
+
+var errInvalidName = newValidationError("name cannot be empty")
+
+type validationError struct {
+	msg string
+}
+
+func newValidationError(msg string) error {
+	return &validationError{msg: msg}
+}
+
+func (e *validationError) Error() string {
+	return e.msg
+}
+
+func (e *validationError) Is(target error) bool {
+	return target == ErrValidation
+}
@@ -0,0 +1,121 @@
+package organization
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/names"
+	"github.com/sergey4qb/mf1-test/repository/organization"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/repository/webhook"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+type Organization interface {
+	Create(ctx context.Context, org *model.Organization) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Organization, error)
+	GetAll(ctx context.Context) ([]model.Organization, error)
+	Update(ctx context.Context, dto *dto.UpdateOrganizationDTO) (*model.Organization, error)
+	// Delete removes an organization without users; it fails with
+	// ErrNotEmpty otherwise, and with ErrPendingEvents while events of its
+	// users are still waiting to be published or delivered to webhooks.
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type service struct {
+	repo     organization.Repository
+	users    user.Repository
+	events   user.Outbox
+	webhooks webhook.Repository
+	now      func() time.Time
+}
+
+// New returns the organization service. Organizations are deployment-wide,
+// so calls ignore the organization in the context.
+func New(repo organization.Repository, users user.Repository, events user.Outbox, webhooks webhook.Repository) Organization {
+	return &service{repo: repo, users: users, events: events, webhooks: webhooks, now: time.Now}
+}
+
+func (s *service) Create(ctx context.Context, org *model.Organization) error {
+	if err := normalize(org); err != nil {
+		return err
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+	org.ID = id
+	org.CreatedAt = s.now().UTC()
+	return s.repo.Create(ctx, org)
+}
+
+func (s *service) GetByID(ctx context.Context, id uuid.UUID) (*model.Organization, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *service) GetAll(ctx context.Context) ([]model.Organization, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *service) Update(ctx context.Context, dto *dto.UpdateOrganizationDTO) (*model.Organization, error) {
+	org, err := s.repo.GetByID(ctx, dto.ID)
+	if err != nil {
+		return nil, err
+	}
+	if dto.Name != nil {
+		org.Name = *dto.Name
+	}
+	if err := normalize(org); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (s *service) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return err
+	}
+	orgCtx := tenant.NewContext(ctx, id)
+	users, err := s.users.GetAll(orgCtx)
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		return ErrNotEmpty
+	}
+	// The dispatchers only poll existing organizations, so events left
+	// behind, such as the deletion of its last users, would never go out.
+	events, err := s.events.Pending(orgCtx)
+	if err != nil {
+		return err
+	}
+	deliveries, err := s.webhooks.Pending(orgCtx)
+	if err != nil {
+		return err
+	}
+	if len(events) > 0 || len(deliveries) > 0 {
+		return ErrPendingEvents
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// normalize stores the name like user and group names and validates it.
+func normalize(org *model.Organization) error {
+	org.Name = names.Normalize(org.Name)
+	org.NameKey = names.SearchKey(org.Name)
+	if org.Name == "" {
+		return errInvalidName
+	}
+	if err := names.Validate(org.Name); err != nil {
+		return newValidationError(err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package organization
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/organization"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/repository/webhook"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+type repos struct {
+	orgs     organization.Repository
+	users    user.Store
+	webhooks webhook.Repository
+}
+
+func newRepos(t *testing.T) *repos {
+	t.Helper()
+
+	dir := t.TempDir()
+	orgs, err := organization.NewFile(filepath.Join(dir, "organizations.json"))
+	require.NoError(t, err)
+	users, err := user.NewFile(filepath.Join(dir, "users.json"))
+	require.NoError(t, err)
+	webhooks, err := webhook.NewFile(filepath.Join(dir, "webhooks.json"))
+	require.NoError(t, err)
+	return &repos{orgs: orgs, users: users, webhooks: webhooks}
+}
+
+func (r *repos) service() Organization {
+	return New(r.orgs, r.users, r.users, r.webhooks)
+}
+
+// drain removes the pending events of the organization in ctx, as
+// publishing them would.
+func (r *repos) drain(t *testing.T, ctx context.Context) {
+	t.Helper()
+
+	events, err := r.users.Pending(ctx)
+	require.NoError(t, err)
+	for _, e := range events {
+		require.NoError(t, r.users.Remove(ctx, e.ID))
+	}
+}
+
+func TestCreateAndUpdate(t *testing.T) {
+	srv := newRepos(t).service()
+	ctx := context.Background()
+
+	org := &model.Organization{Name: "  Acme   Corp "}
+	require.NoError(t, srv.Create(ctx, org))
+	assert.NotEqual(t, uuid.Nil, org.ID)
+	assert.Equal(t, "Acme Corp", org.Name)
+	assert.False(t, org.CreatedAt.IsZero())
+
+	assert.ErrorIs(t, srv.Create(ctx, &model.Organization{Name: "ACME corp"}), ErrNameTaken)
+	assert.ErrorIs(t, srv.Create(ctx, &model.Organization{Name: " "}), ErrValidation)
+
+	name := "Acme Inc"
+	updated, err := srv.Update(ctx, &dto.UpdateOrganizationDTO{ID: org.ID, Name: &name})
+	require.NoError(t, err)
+	assert.Equal(t, "Acme Inc", updated.Name)
+	_, err = srv.Update(ctx, &dto.UpdateOrganizationDTO{ID: uuid.New(), Name: &name})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestDelete_RefusesOrganizationsWithUsers(t *testing.T) {
+	r := newRepos(t)
+	srv := r.service()
+	ctx := context.Background()
+
+	org := &model.Organization{Name: "Acme"}
+	require.NoError(t, srv.Create(ctx, org))
+	u := &model.User{ID: uuid.New(), Name: "Jane", Email: "jane@example.com"}
+	orgCtx := tenant.NewContext(ctx, org.ID)
+	require.NoError(t, r.users.Create(orgCtx, u))
+
+	assert.ErrorIs(t, srv.Delete(ctx, org.ID), ErrNotEmpty)
+
+	require.NoError(t, r.users.Delete(orgCtx, u.ID))
+	r.drain(t, orgCtx)
+	require.NoError(t, srv.Delete(ctx, org.ID))
+	assert.ErrorIs(t, srv.Delete(ctx, org.ID), ErrNotFound)
+}
+
+func TestDelete_RefusesOrganizationsWithPendingEvents(t *testing.T) {
+	r := newRepos(t)
+	srv := r.service()
+	ctx := context.Background()
+
+	org := &model.Organization{Name: "Acme"}
+	require.NoError(t, srv.Create(ctx, org))
+	orgCtx := tenant.NewContext(ctx, org.ID)
+	u := &model.User{ID: uuid.New(), Name: "Jane", Email: "jane@example.com"}
+	require.NoError(t, r.users.Create(orgCtx, u))
+	require.NoError(t, r.users.Delete(orgCtx, u.ID))
+
+	assert.ErrorIs(t, srv.Delete(ctx, org.ID), ErrPendingEvents, "the deletion of the user is not published yet")
+	events, err := r.users.Pending(orgCtx)
+	require.NoError(t, err)
+	r.drain(t, orgCtx)
+
+	w := &model.Webhook{ID: uuid.New(), URL: "https://partner.example.com/hooks", EventTypes: []model.EventType{model.EventUserDeleted}}
+	require.NoError(t, r.webhooks.Create(orgCtx, w))
+	d := model.WebhookDelivery{ID: uuid.New(), WebhookID: w.ID, Event: events[0].Event}
+	require.NoError(t, r.webhooks.Enqueue(orgCtx, []model.WebhookDelivery{d}))
+	assert.ErrorIs(t, srv.Delete(ctx, org.ID), ErrPendingEvents, "the event is not delivered to the webhook yet")
+
+	require.NoError(t, r.webhooks.Delete(orgCtx, w.ID))
+	require.NoError(t, srv.Delete(ctx, org.ID))
+}
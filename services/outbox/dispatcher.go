@@ -0,0 +1,236 @@
+// Package outbox publishes the events kept in the user outbox, see
+// user.Outbox, to other systems. Events are delivered at least once: an
+// event leaves the outbox only after every sink accepted it, so a crash or a
+// failing sink causes repeats, never losses.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/deadletter"
+	"github.com/sergey4qb/mf1-test/repository/organization"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+const (
+	defaultInterval    = time.Second
+	defaultBackoff     = time.Second
+	defaultMaxBackoff  = 5 * time.Minute
+	defaultMaxAttempts = 10
+)
+
+// Sink publishes events to another system. Deliver returns nil only once
+// the event is accepted; Name tells sinks apart in the delivery state kept
+// for each event, so it must not change between runs.
+type Sink interface {
+	Name() string
+	Deliver(ctx context.Context, event *model.Event) error
+}
+
+type Dispatcher interface {
+	// Run delivers pending events until ctx is done.
+	Run(ctx context.Context)
+	// Flush makes one delivery attempt for every due event of every
+	// organization.
+	Flush(ctx context.Context) error
+}
+
+type dispatcher struct {
+	outbox      user.Outbox
+	deadLetters deadletter.Repository
+	orgs        organization.Repository
+	sinks       []Sink
+
+	interval    time.Duration
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	maxAttempts int
+	now         func() time.Time
+
+	// mu keeps flushes from delivering the same event twice at once.
+	mu sync.Mutex
+}
+
+type Option func(*dispatcher)
+
+// WithInterval sets how often Run looks for pending events; zero keeps the
+// default of a second.
+func WithInterval(interval time.Duration) Option {
+	return func(d *dispatcher) {
+		if interval > 0 {
+			d.interval = interval
+		}
+	}
+}
+
+// WithBackoff sets the delay after the first failed delivery of an event,
+// doubled after each further failure up to max. Zero values keep the
+// defaults of a second and five minutes.
+func WithBackoff(initial, max time.Duration) Option {
+	return func(d *dispatcher) {
+		if initial > 0 {
+			d.backoff = initial
+		}
+		if max > 0 {
+			d.maxBackoff = max
+		}
+	}
+}
+
+// WithMaxAttempts sets after how many failed deliveries an event is moved to
+// the dead letters; zero keeps the default of 10.
+func WithMaxAttempts(n int) Option {
+	return func(d *dispatcher) {
+		if n > 0 {
+			d.maxAttempts = n
+		}
+	}
+}
+
+// New returns a Dispatcher delivering the events of outbox to sinks, for
+// the default organization and every organization in orgs. Without sinks
+// events are dropped as they come.
+func New(outbox user.Outbox, deadLetters deadletter.Repository, orgs organization.Repository, sinks []Sink, opts ...Option) Dispatcher {
+	d := &dispatcher{
+		outbox:      outbox,
+		deadLetters: deadLetters,
+		orgs:        orgs,
+		sinks:       sinks,
+		interval:    defaultInterval,
+		backoff:     defaultBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		maxAttempts: defaultMaxAttempts,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+func (d *dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Flush(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("outbox: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *dispatcher) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	orgs, err := d.orgs.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	ids := []uuid.UUID{tenant.Default}
+	for _, org := range orgs {
+		ids = append(ids, org.ID)
+	}
+
+	var errs []error
+	for _, id := range ids {
+		if err := d.flush(tenant.NewContext(ctx, id)); err != nil {
+			errs = append(errs, fmt.Errorf("organization %s: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// flush delivers the due events of the organization in ctx. Events of one
+// user are delivered in order: while one waits for a retry, the later ones
+// wait with it.
+func (d *dispatcher) flush(ctx context.Context) error {
+	events, err := d.outbox.Pending(ctx)
+	if err != nil {
+		return err
+	}
+
+	waiting := map[uuid.UUID]bool{}
+	for i := range events {
+		event := &events[i]
+		if waiting[event.UserID] {
+			continue
+		}
+		if event.NextAttempt != nil && d.now().Before(*event.NextAttempt) {
+			waiting[event.UserID] = true
+			continue
+		}
+
+		delivered, err := d.deliver(ctx, event)
+		if err != nil {
+			return err
+		}
+		if !delivered {
+			waiting[event.UserID] = true
+		}
+	}
+	return nil
+}
+
+// deliver hands event to the sinks that have not accepted it yet and
+// records the outcome. It reports whether the event left the outbox.
+func (d *dispatcher) deliver(ctx context.Context, event *model.PendingEvent) (bool, error) {
+	var failures []string
+	for _, sink := range d.sinks {
+		if slices.Contains(event.Delivered, sink.Name()) {
+			continue
+		}
+		if err := sink.Deliver(ctx, &event.Event); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", sink.Name(), err))
+			continue
+		}
+		event.Delivered = append(event.Delivered, sink.Name())
+	}
+	if len(failures) == 0 {
+		return true, d.outbox.Remove(ctx, event.ID)
+	}
+	// A delivery cut short by shutdown is not the sink's failure.
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	event.Attempts++
+	event.LastError = strings.Join(failures, "; ")
+	if event.Attempts >= d.maxAttempts {
+		letter := &model.DeadLetter{PendingEvent: *event, FailedAt: d.now().UTC()}
+		if err := d.deadLetters.Add(ctx, letter); err != nil {
+			return false, err
+		}
+		return true, d.outbox.Remove(ctx, event.ID)
+	}
+
+	next := d.now().Add(d.delay(event.Attempts)).UTC()
+	event.NextAttempt = &next
+	return false, d.outbox.Reschedule(ctx, event)
+}
+
+// delay returns how long to wait after the given number of failed
+// deliveries.
+func (d *dispatcher) delay(attempts int) time.Duration {
+	delay := d.backoff
+	for i := 1; i < attempts && delay < d.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.maxBackoff)
+}
@@ -0,0 +1,237 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/deadletter"
+	"github.com/sergey4qb/mf1-test/repository/organization"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+var errSinkDown = errors.New("sink down")
+
+// fakeSink fails its first fail deliveries and records the others.
+type fakeSink struct {
+	name string
+	fail int
+
+	mu     sync.Mutex
+	calls  int
+	events []model.Event
+}
+
+func (s *fakeSink) Name() string {
+	return s.name
+}
+
+func (s *fakeSink) Deliver(ctx context.Context, event *model.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.calls <= s.fail {
+		return errSinkDown
+	}
+	s.events = append(s.events, *event)
+	return nil
+}
+
+type fixture struct {
+	users       user.Store
+	deadLetters deadletter.Repository
+	orgs        organization.Repository
+	now         time.Time
+}
+
+func newFixture(t *testing.T) *fixture {
+	dir := t.TempDir()
+	users, err := user.NewFile(filepath.Join(dir, "users.json"))
+	require.NoError(t, err)
+	deadLetters, err := deadletter.NewFile(filepath.Join(dir, "dead_letters.json"))
+	require.NoError(t, err)
+	orgs, err := organization.NewFile(filepath.Join(dir, "organizations.json"))
+	require.NoError(t, err)
+	return &fixture{
+		users:       users,
+		deadLetters: deadLetters,
+		orgs:        orgs,
+		now:         time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (f *fixture) dispatcher(sinks []Sink, opts ...Option) *dispatcher {
+	d := New(f.users, f.deadLetters, f.orgs, sinks, opts...).(*dispatcher)
+	d.now = func() time.Time { return f.now }
+	return d
+}
+
+func (f *fixture) createUser(t *testing.T, ctx context.Context) *model.User {
+	t.Helper()
+
+	u := &model.User{ID: uuid.New(), Name: "Jane", Email: "jane@example.com"}
+	require.NoError(t, f.users.Create(ctx, u))
+	return u
+}
+
+func (f *fixture) pending(t *testing.T) []model.PendingEvent {
+	t.Helper()
+
+	events, err := f.users.Pending(context.Background())
+	require.NoError(t, err)
+	return events
+}
+
+func TestFlush_Delivers(t *testing.T) {
+	f := newFixture(t)
+	sink := &fakeSink{name: "fake"}
+	d := f.dispatcher([]Sink{sink})
+	ctx := context.Background()
+
+	u := f.createUser(t, ctx)
+	require.NoError(t, f.users.Delete(ctx, u.ID))
+	require.NoError(t, d.Flush(ctx))
+
+	require.Len(t, sink.events, 2)
+	assert.Equal(t, model.EventUserCreated, sink.events[0].Type)
+	assert.Equal(t, model.EventUserDeleted, sink.events[1].Type)
+	assert.Empty(t, f.pending(t))
+}
+
+func TestFlush_EveryOrganization(t *testing.T) {
+	f := newFixture(t)
+	sink := &fakeSink{name: "fake"}
+	d := f.dispatcher([]Sink{sink})
+	org := &model.Organization{ID: uuid.New(), Name: "Acme"}
+	require.NoError(t, f.orgs.Create(context.Background(), org))
+
+	u := f.createUser(t, tenant.NewContext(context.Background(), org.ID))
+	require.NoError(t, d.Flush(context.Background()))
+
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, u.ID, sink.events[0].UserID)
+	assert.Equal(t, org.ID, sink.events[0].OrganizationID)
+}
+
+func TestFlush_RetriesWithBackoff(t *testing.T) {
+	f := newFixture(t)
+	sink := &fakeSink{name: "fake", fail: 2}
+	d := f.dispatcher([]Sink{sink}, WithBackoff(time.Second, time.Minute))
+	ctx := context.Background()
+	f.createUser(t, ctx)
+
+	require.NoError(t, d.Flush(ctx))
+	events := f.pending(t)
+	require.Len(t, events, 1)
+	assert.Equal(t, 1, events[0].Attempts)
+	assert.Equal(t, "fake: sink down", events[0].LastError)
+	require.NotNil(t, events[0].NextAttempt)
+	assert.Equal(t, f.now.Add(time.Second), *events[0].NextAttempt)
+
+	require.NoError(t, d.Flush(ctx))
+	assert.Equal(t, 1, sink.calls, "the event is not due yet")
+
+	f.now = f.now.Add(time.Second)
+	require.NoError(t, d.Flush(ctx))
+	events = f.pending(t)
+	require.Len(t, events, 1)
+	assert.Equal(t, f.now.Add(2*time.Second), *events[0].NextAttempt, "the delay doubles")
+
+	f.now = f.now.Add(2 * time.Second)
+	require.NoError(t, d.Flush(ctx))
+	assert.Len(t, sink.events, 1)
+	assert.Empty(t, f.pending(t))
+}
+
+func TestFlush_KeepsUserOrder(t *testing.T) {
+	f := newFixture(t)
+	sink := &fakeSink{name: "fake", fail: 1}
+	d := f.dispatcher([]Sink{sink})
+	ctx := context.Background()
+
+	u := f.createUser(t, ctx)
+	other := &model.User{ID: uuid.New(), Name: "John", Email: "john@example.com"}
+	require.NoError(t, f.users.Create(ctx, other))
+	u.Name = "Janet"
+	require.NoError(t, f.users.Update(ctx, u))
+
+	require.NoError(t, d.Flush(ctx))
+	require.Len(t, sink.events, 1, "the update waits for the creation")
+	assert.Equal(t, other.ID, sink.events[0].UserID)
+
+	f.now = f.now.Add(time.Hour)
+	require.NoError(t, d.Flush(ctx))
+	require.Len(t, sink.events, 3)
+	assert.Equal(t, model.EventUserCreated, sink.events[1].Type)
+	assert.Equal(t, model.EventUserUpdated, sink.events[2].Type)
+}
+
+func TestFlush_RetriesOnlyFailedSinks(t *testing.T) {
+	f := newFixture(t)
+	good := &fakeSink{name: "good"}
+	flaky := &fakeSink{name: "flaky", fail: 1}
+	d := f.dispatcher([]Sink{good, flaky})
+	ctx := context.Background()
+	f.createUser(t, ctx)
+
+	require.NoError(t, d.Flush(ctx))
+	events := f.pending(t)
+	require.Len(t, events, 1)
+	assert.Equal(t, []string{"good"}, events[0].Delivered)
+
+	f.now = f.now.Add(time.Hour)
+	require.NoError(t, d.Flush(ctx))
+	assert.Len(t, good.events, 1)
+	assert.Len(t, flaky.events, 1)
+	assert.Empty(t, f.pending(t))
+}
+
+func TestFlush_DeadLetters(t *testing.T) {
+	f := newFixture(t)
+	sink := &fakeSink{name: "fake", fail: 100}
+	d := f.dispatcher([]Sink{sink}, WithMaxAttempts(2))
+	ctx := context.Background()
+	u := f.createUser(t, ctx)
+
+	require.NoError(t, d.Flush(ctx))
+	f.now = f.now.Add(time.Hour)
+	require.NoError(t, d.Flush(ctx))
+
+	assert.Empty(t, f.pending(t))
+	letters, err := f.deadLetters.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.Equal(t, u.ID, letters[0].UserID)
+	assert.Equal(t, 2, letters[0].Attempts)
+	assert.Equal(t, "fake: sink down", letters[0].LastError)
+	assert.Equal(t, f.now, letters[0].FailedAt)
+}
+
+func TestFlush_WithoutSinks(t *testing.T) {
+	f := newFixture(t)
+	d := f.dispatcher(nil)
+	f.createUser(t, context.Background())
+
+	require.NoError(t, d.Flush(context.Background()))
+	assert.Empty(t, f.pending(t))
+}
+
+func TestDelay(t *testing.T) {
+	d := New(nil, nil, nil, nil, WithBackoff(time.Second, 5*time.Second)).(*dispatcher)
+
+	var delays []time.Duration
+	for attempts := 1; attempts <= 5; attempts++ {
+		delays = append(delays, d.delay(attempts))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, delays)
+}
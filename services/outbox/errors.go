@@ -0,0 +1,9 @@
+package outbox
+
+import "errors"
+
+var (
+	errUnexpectedStatus = errors.New("unexpected response status")
+	errNATSRejected     = errors.New("nats server rejected the message")
+	errNATSProtocol     = errors.New("unexpected nats server reply")
+)
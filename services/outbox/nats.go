@@ -0,0 +1,146 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+const natsTimeout = 10 * time.Second
+
+// natsSink speaks the NATS client protocol in verbose mode, in which the
+// server acknowledges every message, so a publish only succeeds once the
+// server has the message.
+type natsSink struct {
+	addr   string
+	prefix string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewNATSSink returns a Sink publishing each event as JSON to the NATS
+// server at url, e.g. "nats://localhost:4222", on the subject prefix
+// followed by the event type, e.g. "users.UserCreated".
+func NewNATSSink(url, prefix string) Sink {
+	return &natsSink{addr: strings.TrimPrefix(url, "nats://"), prefix: prefix}
+}
+
+func (s *natsSink) Name() string {
+	return "nats"
+}
+
+func (s *natsSink) Deliver(ctx context.Context, event *model.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connectNoLock(ctx); err != nil {
+			return err
+		}
+	}
+	if err := s.publishNoLock(ctx, s.prefix+"."+string(event.Type), payload); err != nil {
+		// The connection may be half way through a message; start over.
+		s.closeNoLock()
+		return err
+	}
+	return nil
+}
+
+func (s *natsSink) connectNoLock(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.conn, s.reader = conn, bufio.NewReader(conn)
+	if err := s.handshakeNoLock(ctx); err != nil {
+		s.closeNoLock()
+		return err
+	}
+	return nil
+}
+
+// handshakeNoLock waits for the server's INFO greeting and has CONNECT
+// acknowledged.
+func (s *natsSink) handshakeNoLock(ctx context.Context) error {
+	s.setDeadlineNoLock(ctx)
+	line, err := s.readNoLock()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("%w: %q", errNATSProtocol, line)
+	}
+	if _, err := fmt.Fprint(s.conn, "CONNECT {\"verbose\":true,\"pedantic\":false,\"name\":\"mf1-test\"}\r\n"); err != nil {
+		return err
+	}
+	return s.awaitOKNoLock()
+}
+
+func (s *natsSink) closeNoLock() {
+	s.conn.Close()
+	s.conn, s.reader = nil, nil
+}
+
+func (s *natsSink) publishNoLock(ctx context.Context, subject string, payload []byte) error {
+	s.setDeadlineNoLock(ctx)
+	if _, err := fmt.Fprintf(s.conn, "PUB %s %d\r\n%s\r\n", subject, len(payload), payload); err != nil {
+		return err
+	}
+	return s.awaitOKNoLock()
+}
+
+// awaitOKNoLock reads replies until the server acknowledges the last
+// command, answering its keepalive pings on the way.
+func (s *natsSink) awaitOKNoLock() error {
+	for {
+		line, err := s.readNoLock()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "+OK":
+			return nil
+		case line == "PING":
+			if _, err := fmt.Fprint(s.conn, "PONG\r\n"); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("%w: %s", errNATSRejected, strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		case strings.HasPrefix(line, "INFO "), line == "PONG":
+			// Cluster updates and late pongs need no answer.
+		default:
+			return fmt.Errorf("%w: %q", errNATSProtocol, line)
+		}
+	}
+}
+
+func (s *natsSink) readNoLock() (string, error) {
+	line, err := s.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (s *natsSink) setDeadlineNoLock(ctx context.Context) {
+	deadline := time.Now().Add(natsTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = s.conn.SetDeadline(deadline)
+}
@@ -0,0 +1,154 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+func newEvent() *model.Event {
+	id := uuid.New()
+	return &model.Event{
+		ID:     uuid.New(),
+		Type:   model.EventUserCreated,
+		UserID: id,
+		At:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		User:   &model.User{ID: id, Name: "Jane", Email: "jane@example.com", Status: model.StatusActive},
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received []model.Event
+	var keys []string
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var event model.Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received = append(received, event)
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(srv.URL)
+	event := newEvent()
+	require.NoError(t, sink.Deliver(context.Background(), event))
+	require.Len(t, received, 1)
+	assert.Equal(t, *event, received[0])
+	assert.Equal(t, []string{event.ID.String()}, keys)
+
+	status = http.StatusServiceUnavailable
+	assert.ErrorIs(t, sink.Deliver(context.Background(), event), errUnexpectedStatus)
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink := NewFileSink(path)
+
+	first, second := newEvent(), newEvent()
+	require.NoError(t, sink.Deliver(context.Background(), first))
+	require.NoError(t, sink.Deliver(context.Background(), second))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	var got model.Event
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &got))
+	assert.Equal(t, *second, got)
+}
+
+// fakeNATS accepts one connection and acknowledges what it receives the
+// way a server in verbose mode does, rejecting subjects starting with
+// "denied". It sends the subjects and payloads of accepted messages on
+// published.
+func fakeNATS(t *testing.T) (addr string, published chan [2]string) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+
+	published = make(chan [2]string, 10)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+
+		fmt.Fprint(conn, "INFO {\"server_id\":\"fake\"}\r\n")
+		line, _ := r.ReadString('\n')
+		if !strings.HasPrefix(line, "CONNECT ") {
+			return
+		}
+		// A ping before the ack must be answered.
+		fmt.Fprint(conn, "PING\r\n")
+		if pong, _ := r.ReadString('\n'); pong != "PONG\r\n" {
+			return
+		}
+		fmt.Fprint(conn, "+OK\r\n")
+
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			var subject string
+			var size int
+			if _, err := fmt.Sscanf(line, "PUB %s %d\r\n", &subject, &size); err != nil {
+				return
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			if strings.HasPrefix(subject, "denied") {
+				fmt.Fprint(conn, "-ERR 'Permissions Violation'\r\n")
+				continue
+			}
+			published <- [2]string{subject, string(payload[:size])}
+			fmt.Fprint(conn, "+OK\r\n")
+		}
+	}()
+	return lis.Addr().String(), published
+}
+
+func TestNATSSink(t *testing.T) {
+	addr, published := fakeNATS(t)
+	sink := NewNATSSink("nats://"+addr, "users")
+
+	event := newEvent()
+	require.NoError(t, sink.Deliver(context.Background(), event))
+	msg := <-published
+	assert.Equal(t, "users.UserCreated", msg[0])
+	var got model.Event
+	require.NoError(t, json.Unmarshal([]byte(msg[1]), &got))
+	assert.Equal(t, *event, got)
+}
+
+func TestNATSSink_Rejected(t *testing.T) {
+	addr, _ := fakeNATS(t)
+	sink := NewNATSSink(addr, "denied")
+
+	assert.ErrorIs(t, sink.Deliver(context.Background(), newEvent()), errNATSRejected)
+}
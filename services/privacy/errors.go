@@ -0,0 +1,6 @@
+package privacy
+
+import "github.com/sergey4qb/mf1-test/repository/user"
+
+// ErrNotFound is returned for users without data that were never erased.
+var ErrNotFound = user.ErrUserNotFound
@@ -0,0 +1,129 @@
+// Package privacy answers subject access requests and erases users: Export
+// collects what every registered Store holds about a user and Erase removes
+// or pseudonymizes it, leaving a tombstone behind so the erasure stays
+// traceable.
+package privacy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/actor"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/tombstone"
+	"github.com/sergey4qb/mf1-test/request"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+type Privacy interface {
+	// Export returns what the stores hold about a user, with the
+	// tombstones of earlier erasures.
+	Export(ctx context.Context, userID uuid.UUID) (*model.DataExport, error)
+	// Erase removes or pseudonymizes what the stores hold about a user and
+	// returns the tombstone it stored. Erasing a user again only erases what
+	// was stored since; when nothing was, the latest tombstone is returned.
+	// A failing store stops the erasure; what was erased until then is
+	// still tombstoned, and a retry resumes.
+	Erase(ctx context.Context, userID uuid.UUID) (*model.Tombstone, error)
+}
+
+// Store is where the data about users is kept, as seen by Export and Erase.
+type Store interface {
+	// Name identifies the store in exports and tombstones.
+	Name() string
+	// Export returns what the store holds about a user as a value encoding
+	// to JSON, or nil when it holds nothing.
+	Export(ctx context.Context, userID uuid.UUID) (any, error)
+	// Erase removes or pseudonymizes what the store holds about a user and
+	// returns how many records it changed.
+	Erase(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+type service struct {
+	tombstones tombstone.Repository
+	stores     []Store
+	now        func() time.Time
+}
+
+// New returns a Privacy over stores, which Erase visits in order.
+func New(tombstones tombstone.Repository, stores []Store) Privacy {
+	return &service{tombstones: tombstones, stores: stores, now: time.Now}
+}
+
+func (s *service) Export(ctx context.Context, userID uuid.UUID) (*model.DataExport, error) {
+	export := &model.DataExport{
+		UserID:         userID,
+		OrganizationID: tenant.FromContext(ctx),
+		ExportedAt:     s.now().UTC(),
+		Data:           map[string]any{},
+	}
+	for _, store := range s.stores {
+		data, err := store.Export(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", store.Name(), err)
+		}
+		if data != nil {
+			export.Data[store.Name()] = data
+		}
+	}
+
+	tombstones, err := s.tombstones.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(export.Data) == 0 && len(tombstones) == 0 {
+		return nil, ErrNotFound
+	}
+	export.Tombstones = tombstones
+	return export, nil
+}
+
+func (s *service) Erase(ctx context.Context, userID uuid.UUID) (*model.Tombstone, error) {
+	erased := map[string]int{}
+	for _, store := range s.stores {
+		n, err := store.Erase(ctx, userID)
+		if err != nil {
+			err = fmt.Errorf("erase %s: %w", store.Name(), err)
+			if len(erased) > 0 {
+				_, terr := s.bury(ctx, userID, erased)
+				err = errors.Join(err, terr)
+			}
+			return nil, err
+		}
+		if n > 0 {
+			erased[store.Name()] = n
+		}
+	}
+
+	if len(erased) == 0 {
+		tombstones, err := s.tombstones.List(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(tombstones) == 0 {
+			return nil, ErrNotFound
+		}
+		return &tombstones[len(tombstones)-1], nil
+	}
+	return s.bury(ctx, userID, erased)
+}
+
+// bury stores the tombstone of an erasure.
+func (s *service) bury(ctx context.Context, userID uuid.UUID, erased map[string]int) (*model.Tombstone, error) {
+	t := &model.Tombstone{
+		UserID:    userID,
+		ErasedAt:  s.now().UTC(),
+		Actor:     actor.FromContext(ctx),
+		RequestID: request.FromContext(ctx).ID,
+		Erased:    erased,
+	}
+	// The data is gone: a request cancelled now must still leave a trace.
+	if err := s.tombstones.Add(context.WithoutCancel(ctx), t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
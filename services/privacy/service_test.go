@@ -0,0 +1,229 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/actor"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/audit"
+	"github.com/sergey4qb/mf1-test/repository/deadletter"
+	groupstore "github.com/sergey4qb/mf1-test/repository/group"
+	"github.com/sergey4qb/mf1-test/repository/history"
+	rolestore "github.com/sergey4qb/mf1-test/repository/role"
+	"github.com/sergey4qb/mf1-test/repository/token"
+	"github.com/sergey4qb/mf1-test/repository/tombstone"
+	userstore "github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/repository/verification"
+	"github.com/sergey4qb/mf1-test/repository/webhook"
+	"github.com/sergey4qb/mf1-test/request"
+	"github.com/sergey4qb/mf1-test/services/group"
+	"github.com/sergey4qb/mf1-test/services/role"
+	"github.com/sergey4qb/mf1-test/services/user"
+)
+
+type testEnv struct {
+	svc       *service
+	users     user.User
+	groups    group.Group
+	roles     role.Role
+	outbox    userstore.Outbox
+	revisions history.Repository
+	tokens    token.Repository
+	verify    verification.Repository
+}
+
+// open opens the file repository name in dir with newFile.
+func open[R any](t *testing.T, dir, name string, newFile func(string) (R, error)) R {
+	t.Helper()
+
+	repo, err := newFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	return repo
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	dir := t.TempDir()
+	users := open(t, dir, "users.json", userstore.NewFile)
+	revisions := open(t, dir, "history.json", history.NewFile)
+	repo := history.Record(users, revisions)
+	groupRepo := open(t, dir, "groups.json", groupstore.NewFile)
+	roleRepo := open(t, dir, "roles.json", rolestore.NewFile)
+	roles := role.New(roleRepo, groupRepo, repo)
+	groups := group.New(groupRepo, repo, group.WithMembershipHook(roles.MembershipChanged))
+	userService := user.New(repo, user.WithDeleteHook(groups.RemoveUser), user.WithDeleteHook(roles.RemoveUser))
+
+	tokens := open(t, dir, "tokens.json", token.New)
+	verify := open(t, dir, "verification.json", verification.New)
+
+	stores := []Store{
+		NewGroupStore(groupRepo),
+		NewRoleStore(roleRepo, roles),
+		NewUserStore(repo, userService),
+		NewHistoryStore(revisions),
+		NewOutboxStore(users),
+		NewWebhookStore(open(t, dir, "webhooks.json", webhook.NewFile)),
+		NewDeadLetterStore(open(t, dir, "dead_letters.json", deadletter.NewFile)),
+		NewTokenStore(tokens),
+		NewVerificationStore(verify),
+		NewAuditStore(open(t, dir, "audit.log", audit.NewFile)),
+	}
+	return &testEnv{
+		svc:       New(open(t, dir, "tombstones.json", tombstone.NewFile), stores).(*service),
+		users:     userService,
+		groups:    groups,
+		roles:     roles,
+		outbox:    users,
+		revisions: revisions,
+		tokens:    tokens,
+		verify:    verify,
+	}
+}
+
+// createUser creates a user with a group, a role and tokens.
+func (e *testEnv) createUser(t *testing.T, name string) *model.User {
+	t.Helper()
+	ctx := context.Background()
+
+	u := &model.User{Name: name, Email: strings.ToLower(name) + "@example.com", PasswordHash: "secret-hash"}
+	require.NoError(t, e.users.Create(ctx, u))
+
+	g := &model.Group{Name: name + " team"}
+	require.NoError(t, e.groups.Create(ctx, g))
+	_, err := e.groups.AddMember(ctx, g.ID, u.ID)
+	require.NoError(t, err)
+
+	r := &model.Role{Name: name + " editor", Permissions: []string{"users.read"}}
+	require.NoError(t, e.roles.Create(ctx, r))
+	_, err = e.roles.Assign(ctx, r.ID, model.Principal{Kind: model.PrincipalUser, ID: u.ID})
+	require.NoError(t, err)
+
+	now := time.Now()
+	require.NoError(t, e.tokens.Save(ctx, &model.RefreshToken{
+		Hash: "refresh-hash", FamilyID: uuid.New(), UserID: u.ID, CreatedAt: now, ExpiresAt: now.Add(time.Hour),
+	}))
+	require.NoError(t, e.verify.Save(ctx, &model.VerificationToken{
+		Hash: "verify-hash", UserID: u.ID, Email: u.Email, CreatedAt: now, ExpiresAt: now.Add(time.Hour),
+	}))
+	return u
+}
+
+func TestExport(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.createUser(t, "Jane")
+
+	export, err := env.svc.Export(context.Background(), u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, export.UserID)
+	assert.ElementsMatch(t, []string{"users", "groups", "roles", "history", "outbox", "refresh_tokens", "verification_tokens"},
+		keys(export.Data), "stores holding nothing are left out")
+	assert.Empty(t, export.Tombstones)
+
+	exported := export.Data["users"].(model.User)
+	assert.Equal(t, "jane@example.com", exported.Email)
+	assert.Empty(t, exported.PasswordHash, "exports carry no credentials")
+	assert.Empty(t, export.Data["refresh_tokens"].([]model.RefreshToken)[0].Hash)
+	assert.Equal(t, "jane@example.com", export.Data["verification_tokens"].([]model.VerificationToken)[0].Email)
+	assert.Empty(t, export.Data["verification_tokens"].([]model.VerificationToken)[0].Hash)
+
+	_, err = env.svc.Export(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestErase(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.createUser(t, "Jane")
+	other := env.createUser(t, "John")
+	ctx := request.NewContext(actor.NewContext(context.Background(), "dpo"), request.Info{ID: "req-1"})
+
+	ts, err := env.svc.Erase(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, ts.UserID)
+	assert.Equal(t, "dpo", ts.Actor)
+	assert.Equal(t, "req-1", ts.RequestID)
+	assert.Equal(t, map[string]int{
+		"groups": 1, "roles": 1, "users": 1, "history": 1, "outbox": 1,
+		"refresh_tokens": 1, "verification_tokens": 1,
+	}, ts.Erased)
+
+	_, err = env.users.GetByID(ctx, u.ID)
+	assert.ErrorIs(t, err, user.ErrNotFound)
+
+	revisions, err := env.revisions.List(ctx, u.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2, "revisions are kept to trace the user's lifecycle")
+	for _, rev := range revisions {
+		assert.Nil(t, rev.User)
+		assert.Empty(t, rev.Changes)
+	}
+	events, err := env.outbox.Pending(ctx)
+	require.NoError(t, err)
+	for _, e := range events {
+		if e.UserID == u.ID {
+			assert.Nil(t, e.User, "pending events are published without the user")
+		}
+	}
+
+	export, err := env.svc.Export(ctx, u.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"history", "outbox"}, keys(export.Data))
+	assert.Equal(t, []model.Tombstone{*ts}, export.Tombstones)
+
+	again, err := env.svc.Erase(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ts, again, "erasing again returns the tombstone of the first erasure")
+
+	export, err = env.svc.Export(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Contains(t, export.Data, "users", "other users are left alone")
+
+	_, err = env.svc.Erase(ctx, uuid.New())
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestErase_StoreFailure(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.createUser(t, "Jane")
+	ctx := context.Background()
+	down := errors.New("store down")
+	stores := env.svc.stores
+	env.svc.stores = append(slices.Clone(stores[:3]), &store{
+		name:   "broken",
+		export: func(context.Context, uuid.UUID) (any, error) { return nil, nil },
+		erase:  func(context.Context, uuid.UUID) (int, error) { return 0, down },
+	})
+	env.svc.stores = append(env.svc.stores, stores[3:]...)
+
+	_, err := env.svc.Erase(ctx, u.ID)
+	assert.ErrorIs(t, err, down)
+
+	tombstones, err := env.svc.tombstones.List(ctx, u.ID)
+	require.NoError(t, err)
+	require.Len(t, tombstones, 1, "what was erased before the failure is tombstoned")
+	assert.Equal(t, map[string]int{"groups": 1, "roles": 1, "users": 1}, tombstones[0].Erased)
+
+	env.svc.stores = stores
+	ts, err := env.svc.Erase(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"history": 1, "outbox": 1, "refresh_tokens": 1, "verification_tokens": 1}, ts.Erased,
+		"a retry erases what the failed erasure left")
+}
+
+func keys(m map[string]any) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,267 @@
+package privacy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/audit"
+	"github.com/sergey4qb/mf1-test/repository/deadletter"
+	groupstore "github.com/sergey4qb/mf1-test/repository/group"
+	"github.com/sergey4qb/mf1-test/repository/history"
+	"github.com/sergey4qb/mf1-test/repository/idempotency"
+	rolestore "github.com/sergey4qb/mf1-test/repository/role"
+	"github.com/sergey4qb/mf1-test/repository/token"
+	userstore "github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/repository/verification"
+	"github.com/sergey4qb/mf1-test/repository/webhook"
+	"github.com/sergey4qb/mf1-test/services/role"
+	"github.com/sergey4qb/mf1-test/services/user"
+)
+
+// store is a Store made of functions.
+type store struct {
+	name   string
+	export func(ctx context.Context, userID uuid.UUID) (any, error)
+	erase  func(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+func (s *store) Name() string {
+	return s.name
+}
+
+func (s *store) Export(ctx context.Context, userID uuid.UUID) (any, error) {
+	return s.export(ctx, userID)
+}
+
+func (s *store) Erase(ctx context.Context, userID uuid.UUID) (int, error) {
+	return s.erase(ctx, userID)
+}
+
+// NewUserStore exports a user without credentials and erases it by deleting
+// it through users, so its delete hooks run and the deletion is published.
+func NewUserStore(repo userstore.Repository, users user.User) Store {
+	get := func(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+		u, err := repo.GetByID(ctx, userID)
+		if errors.Is(err, userstore.ErrUserNotFound) {
+			return nil, nil
+		}
+		return u, err
+	}
+	return &store{
+		name: "users",
+		export: func(ctx context.Context, userID uuid.UUID) (any, error) {
+			u, err := get(ctx, userID)
+			if u == nil {
+				return nil, err
+			}
+			return u.WithoutCredentials(), nil
+		},
+		erase: func(ctx context.Context, userID uuid.UUID) (int, error) {
+			u, err := get(ctx, userID)
+			if u == nil {
+				return 0, err
+			}
+			if err := users.Delete(ctx, userID); err != nil {
+				return 0, err
+			}
+			return 1, nil
+		},
+	}
+}
+
+// NewGroupStore exports and removes the group memberships of a user.
+func NewGroupStore(repo groupstore.Repository) Store {
+	return &store{
+		name: "groups",
+		export: func(ctx context.Context, userID uuid.UUID) (any, error) {
+			memberships, err := repo.Memberships(ctx, userID)
+			return nonEmpty(memberships), err
+		},
+		erase: func(ctx context.Context, userID uuid.UUID) (int, error) {
+			memberships, err := repo.Memberships(ctx, userID)
+			if err != nil || len(memberships) == 0 {
+				return 0, err
+			}
+			return len(memberships), repo.RemoveUser(ctx, userID)
+		},
+	}
+}
+
+// NewRoleStore exports and removes the roles assigned to a user directly;
+// removals go through roles so its permission cache forgets the user.
+func NewRoleStore(repo rolestore.Repository, roles role.Role) Store {
+	assignments := func(ctx context.Context, userID uuid.UUID) ([]model.RoleAssignment, error) {
+		all, err := repo.Assignments(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var assigned []model.RoleAssignment
+		for _, a := range all {
+			if a.Principal == (model.Principal{Kind: model.PrincipalUser, ID: userID}) {
+				assigned = append(assigned, a)
+			}
+		}
+		return assigned, nil
+	}
+	return &store{
+		name: "roles",
+		export: func(ctx context.Context, userID uuid.UUID) (any, error) {
+			assigned, err := assignments(ctx, userID)
+			return nonEmpty(assigned), err
+		},
+		erase: func(ctx context.Context, userID uuid.UUID) (int, error) {
+			assigned, err := assignments(ctx, userID)
+			if err != nil || len(assigned) == 0 {
+				return 0, err
+			}
+			return len(assigned), roles.RemoveUser(ctx, userID)
+		},
+	}
+}
+
+// NewHistoryStore exports the revisions of a user and erases their
+// snapshots and changes, keeping who made each revision and when.
+func NewHistoryStore(repo history.Repository) Store {
+	return &store{
+		name: "history",
+		export: func(ctx context.Context, userID uuid.UUID) (any, error) {
+			revisions, err := repo.List(ctx, userID)
+			return nonEmpty(revisions), err
+		},
+		erase: repo.Redact,
+	}
+}
+
+// NewOutboxStore exports the unpublished events of a user and erases their
+// snapshots; the events are still published, without them.
+func NewOutboxStore(outbox userstore.Outbox) Store {
+	return &store{
+		name: "outbox",
+		export: func(ctx context.Context, userID uuid.UUID) (any, error) {
+			events, err := outbox.Pending(ctx)
+			return nonEmpty(filter(events, func(e model.PendingEvent) bool {
+				return e.UserID == userID
+			})), err
+		},
+		erase: outbox.Redact,
+	}
+}
+
+// NewDeadLetterStore exports the dead letters of a user and erases their
+// snapshots.
+func NewDeadLetterStore(repo deadletter.Repository) Store {
+	return &store{
+		name: "dead_letters",
+		export: func(ctx context.Context, userID uuid.UUID) (any, error) {
+			letters, err := repo.List(ctx)
+			return nonEmpty(filter(letters, func(l model.DeadLetter) bool {
+				return l.UserID == userID
+			})), err
+		},
+		erase: repo.Redact,
+	}
+}
+
+// NewWebhookStore exports the queued webhook deliveries of a user's events
+// and erases their snapshots. Attempt logs hold no personal data.
+func NewWebhookStore(repo webhook.Repository) Store {
+	return &store{
+		name: "webhook_deliveries",
+		export: func(ctx context.Context, userID uuid.UUID) (any, error) {
+			deliveries, err := repo.Pending(ctx)
+			return nonEmpty(filter(deliveries, func(d model.WebhookDelivery) bool {
+				return d.Event.UserID == userID
+			})), err
+		},
+		erase: repo.Redact,
+	}
+}
+
+// NewTokenStore exports the refresh tokens of a user without their hashes
+// and deletes them, signing the user out.
+func NewTokenStore(repo token.Repository) Store {
+	return &store{
+		name: "refresh_tokens",
+		export: func(ctx context.Context, userID uuid.UUID) (any, error) {
+			tokens, err := repo.ForUser(ctx, userID)
+			for i := range tokens {
+				tokens[i].Hash = ""
+			}
+			return nonEmpty(tokens), err
+		},
+		erase: repo.DeleteUser,
+	}
+}
+
+// NewVerificationStore exports the email verification tokens of a user
+// without their hashes and deletes them.
+func NewVerificationStore(repo verification.Repository) Store {
+	return &store{
+		name: "verification_tokens",
+		export: func(ctx context.Context, userID uuid.UUID) (any, error) {
+			tokens, err := repo.ForUser(ctx, userID)
+			for i := range tokens {
+				tokens[i].Hash = ""
+			}
+			return nonEmpty(tokens), err
+		},
+		erase: repo.DeleteUser,
+	}
+}
+
+// NewIdempotencyStore erases the stored responses mentioning a user's ID,
+// which may hold its data until they expire. It exports nothing: the
+// responses are copies of what other stores hold.
+func NewIdempotencyStore(repo idempotency.Repository) Store {
+	return &store{
+		name: "idempotency",
+		export: func(context.Context, uuid.UUID) (any, error) {
+			return nil, nil
+		},
+		erase: func(ctx context.Context, userID uuid.UUID) (int, error) {
+			id := []byte(userID.String())
+			return repo.DeleteFunc(ctx, func(rec *model.IdempotencyRecord) bool {
+				return bytes.Contains(rec.Response, id)
+			})
+		},
+	}
+}
+
+// NewAuditStore exports the audit entries about a user. It erases nothing:
+// the log is append-only and hash-chained, and its entries hold the user's
+// ID and hashes of its data, not the data itself.
+func NewAuditStore(repo audit.Repository) Store {
+	return &store{
+		name: "audit",
+		export: func(ctx context.Context, userID uuid.UUID) (any, error) {
+			entries, err := repo.List(ctx, audit.Filter{Target: userID.String()})
+			return nonEmpty(entries), err
+		},
+		erase: func(context.Context, uuid.UUID) (int, error) {
+			return 0, nil
+		},
+	}
+}
+
+// nonEmpty returns s, or nil when it is empty, so stores holding nothing
+// are left out of exports.
+func nonEmpty[T any](s []T) any {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
+
+func filter[T any](s []T, keep func(T) bool) []T {
+	var kept []T
+	for _, v := range s {
+		if keep(v) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,78 @@
+package role
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/dto"
+)
+
+// DefaultCacheSize is how many users' effective permissions are kept unless
+// WithCacheSize says otherwise.
+const DefaultCacheSize = 10000
+
+// cacheKey identifies a user within the organization its permissions were
+// computed for, since roles and groups are scoped by organization.
+type cacheKey struct {
+	org  uuid.UUID
+	user uuid.UUID
+}
+
+// cache keeps effective permissions per user. Every invalidation bumps the
+// generation, so a result computed while roles or assignments changed is
+// not stored.
+type cache struct {
+	mu         sync.Mutex
+	size       int
+	generation uint64
+	users      map[cacheKey]*dto.EffectivePermissions
+}
+
+func newCache(size int) *cache {
+	return &cache{size: size, users: map[cacheKey]*dto.EffectivePermissions{}}
+}
+
+func (c *cache) get(key cacheKey) (*dto.EffectivePermissions, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.users[key], c.generation
+}
+
+// put stores e unless the cache was invalidated since generation was read.
+// A full cache is emptied rather than tracking recency.
+func (c *cache) put(key cacheKey, generation uint64, e *dto.EffectivePermissions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation || c.size <= 0 {
+		return
+	}
+	if len(c.users) >= c.size {
+		clear(c.users)
+	}
+	c.users[key] = e
+}
+
+// invalidate drops the permissions of the user in every organization, as
+// the membership hook does not know which one changed.
+func (c *cache) invalidate(userID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for key := range c.users {
+		if key.user == userID {
+			delete(c.users, key)
+		}
+	}
+}
+
+func (c *cache) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	clear(c.users)
+}
@@ -0,0 +1,46 @@
+package role
+
+import (
+	"errors"
+
+	"github.com/sergey4qb/mf1-test/repository/group"
+	"github.com/sergey4qb/mf1-test/repository/role"
+	"github.com/sergey4qb/mf1-test/repository/user"
+)
+
+// Error kinds returned by the service.
+var (
+	ErrNotFound        = role.ErrRoleNotFound
+	ErrAlreadyExists   = role.ErrRoleAlreadyExists
+	ErrNameTaken       = role.ErrNameTaken
+	ErrAlreadyAssigned = role.ErrAlreadyAssigned
+	ErrNotAssigned     = role.ErrNotAssigned
+	// ErrUserNotFound and ErrGroupNotFound are returned when an assignment
+	// or a permission check names an unknown principal.
+	ErrUserNotFound  = user.ErrUserNotFound
+	ErrGroupNotFound = group.ErrGroupNotFound
+	ErrValidation    = errors.New("validation failed")
+)
+
+var (
+	errInvalidName        = newValidationError("name cannot be empty")
+	errInvalidDescription = newValidationError("description is longer than 1000 characters")
+	errInvalidPermission  = newValidationError(`invalid permission, expected e.g. "documents:read", "documents:*" or "*"`)
+	errInvalidPrincipal   = newValidationError("a role is assigned to either a user or a group")
+)
+
+type validationError struct {
+	msg string
+}
+
+func newValidationError(msg string) error {
+	return &validationError{msg: msg}
+}
+
+func (e *validationError) Error() string {
+	return e.msg
+}
+
+func (e *validationError) Is(target error) bool {
+	return target == ErrValidation
+}
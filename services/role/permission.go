@@ -0,0 +1,44 @@
+package role
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Wildcard grants every permission.
+const Wildcard = "*"
+
+// permissionPattern accepts colon-separated lowercase segments, e.g.
+// "documents:read". A last segment of "*" grants every permission below the
+// prefix, so "documents:*" covers "documents:read" and
+// "documents:comments:write".
+var permissionPattern = regexp.MustCompile(`^(\*|[a-z0-9_.-]+(:[a-z0-9_.-]+)*(:\*)?)$`)
+
+func validPermission(p string) bool {
+	return permissionPattern.MatchString(p)
+}
+
+// normalizePermissions trims, sorts and deduplicates permissions so equal
+// sets are stored the same way.
+func normalizePermissions(permissions []string) ([]string, error) {
+	out := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		p = strings.TrimSpace(p)
+		if !validPermission(p) {
+			return nil, errInvalidPermission
+		}
+		out = append(out, p)
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+// grants reports whether the granted permission covers the requested one.
+func grants(granted, requested string) bool {
+	if granted == requested || granted == Wildcard {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(granted, "*")
+	return ok && strings.HasPrefix(requested, prefix)
+}
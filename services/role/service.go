@@ -0,0 +1,332 @@
+package role
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/names"
+	"github.com/sergey4qb/mf1-test/repository/group"
+	"github.com/sergey4qb/mf1-test/repository/role"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+// maxDescriptionLength is the longest accepted description in characters.
+const maxDescriptionLength = 1000
+
+type Role interface {
+	Create(ctx context.Context, role *model.Role) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Role, error)
+	GetAll(ctx context.Context) ([]model.Role, error)
+	Update(ctx context.Context, dto *dto.UpdateRoleDTO) (*model.Role, error)
+	// Delete removes a role and its assignments.
+	Delete(ctx context.Context, id uuid.UUID) error
+	// Assign grants a role to an existing user or group.
+	Assign(ctx context.Context, roleID uuid.UUID, principal model.Principal) (*model.RoleAssignment, error)
+	Unassign(ctx context.Context, roleID uuid.UUID, principal model.Principal) error
+	// ListAssignments returns the assignments of a role in the order they
+	// were made.
+	ListAssignments(ctx context.Context, roleID uuid.UUID) ([]model.RoleAssignment, error)
+
+	// CheckPermission reports whether any role of the user grants
+	// permission. Only roles count: a blocked user is still allowed
+	// whatever its roles grant, so callers check the status themselves.
+	CheckPermission(ctx context.Context, userID uuid.UUID, permission string) (bool, error)
+	// EffectivePermissions returns the roles assigned to a user directly
+	// or through its groups and the permissions they grant.
+	EffectivePermissions(ctx context.Context, userID uuid.UUID) (*dto.EffectivePermissions, error)
+
+	// RemoveUser and RemoveGroup drop the assignments of a deleted user or
+	// group; MembershipChanged drops the cached permissions of a user that
+	// joined or left a group. They are meant as hooks of the user and
+	// group services.
+	RemoveUser(ctx context.Context, userID uuid.UUID) error
+	RemoveGroup(ctx context.Context, groupID uuid.UUID) error
+	MembershipChanged(groupID, userID uuid.UUID)
+}
+
+type service struct {
+	repo   role.Repository
+	groups group.Repository
+	users  user.Repository
+	cache  *cache
+	now    func() time.Time
+}
+
+type Option func(*service)
+
+// WithCacheSize sets how many users' effective permissions are kept in
+// memory; zero turns the cache off. See DefaultCacheSize.
+func WithCacheSize(size int) Option {
+	return func(s *service) {
+		s.cache = newCache(size)
+	}
+}
+
+func New(repo role.Repository, groups group.Repository, users user.Repository, opts ...Option) Role {
+	s := &service{
+		repo:   repo,
+		groups: groups,
+		users:  users,
+		cache:  newCache(DefaultCacheSize),
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *service) Create(ctx context.Context, role *model.Role) error {
+	if err := normalize(role); err != nil {
+		return err
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+	role.ID = id
+	role.CreatedAt = s.now().UTC()
+	return s.repo.Create(ctx, role)
+}
+
+func (s *service) GetByID(ctx context.Context, id uuid.UUID) (*model.Role, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *service) GetAll(ctx context.Context) ([]model.Role, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *service) Update(ctx context.Context, dto *dto.UpdateRoleDTO) (*model.Role, error) {
+	role, err := s.repo.GetByID(ctx, dto.ID)
+	if err != nil {
+		return nil, err
+	}
+	if dto.Name != nil {
+		role.Name = *dto.Name
+	}
+	if dto.Description != nil {
+		role.Description = *dto.Description
+	}
+	if dto.Permissions != nil {
+		role.Permissions = *dto.Permissions
+	}
+	if err := normalize(role); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, role); err != nil {
+		return nil, err
+	}
+	s.cache.invalidateAll()
+	return role, nil
+}
+
+func (s *service) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.cache.invalidateAll()
+	return nil
+}
+
+func (s *service) Assign(ctx context.Context, roleID uuid.UUID, principal model.Principal) (*model.RoleAssignment, error) {
+	if err := s.principalExists(ctx, principal); err != nil {
+		return nil, err
+	}
+	assignment := &model.RoleAssignment{
+		RoleID:     roleID,
+		Principal:  principal,
+		AssignedAt: s.now().UTC(),
+	}
+	if err := s.repo.Assign(ctx, assignment); err != nil {
+		return nil, err
+	}
+	s.invalidate(principal)
+	return assignment, nil
+}
+
+func (s *service) Unassign(ctx context.Context, roleID uuid.UUID, principal model.Principal) error {
+	if err := validatePrincipal(principal); err != nil {
+		return err
+	}
+	if err := s.repo.Unassign(ctx, roleID, principal); err != nil {
+		return err
+	}
+	s.invalidate(principal)
+	return nil
+}
+
+func (s *service) ListAssignments(ctx context.Context, roleID uuid.UUID) ([]model.RoleAssignment, error) {
+	if _, err := s.repo.GetByID(ctx, roleID); err != nil {
+		return nil, err
+	}
+	assignments, err := s.repo.Assignments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(assignments, func(a model.RoleAssignment) bool {
+		return a.RoleID != roleID
+	}), nil
+}
+
+func (s *service) CheckPermission(ctx context.Context, userID uuid.UUID, permission string) (bool, error) {
+	permission = strings.TrimSpace(permission)
+	if permission == Wildcard || !validPermission(permission) {
+		return false, errInvalidPermission
+	}
+	effective, err := s.effective(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(effective.Permissions, func(granted string) bool {
+		return grants(granted, permission)
+	}), nil
+}
+
+func (s *service) EffectivePermissions(ctx context.Context, userID uuid.UUID) (*dto.EffectivePermissions, error) {
+	effective, err := s.effective(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// Cached results are shared; hand out a copy.
+	roles := make([]model.Role, len(effective.Roles))
+	for i := range effective.Roles {
+		roles[i] = effective.Roles[i].Clone()
+	}
+	return &dto.EffectivePermissions{
+		Permissions: slices.Clone(effective.Permissions),
+		Roles:       roles,
+	}, nil
+}
+
+func (s *service) RemoveUser(ctx context.Context, userID uuid.UUID) error {
+	defer s.cache.invalidate(userID)
+	return s.repo.RemovePrincipal(ctx, model.Principal{Kind: model.PrincipalUser, ID: userID})
+}
+
+func (s *service) RemoveGroup(ctx context.Context, groupID uuid.UUID) error {
+	// The group's memberships are gone too, so any member may have lost
+	// permissions.
+	defer s.cache.invalidateAll()
+	return s.repo.RemovePrincipal(ctx, model.Principal{Kind: model.PrincipalGroup, ID: groupID})
+}
+
+func (s *service) MembershipChanged(_, userID uuid.UUID) {
+	s.cache.invalidate(userID)
+}
+
+// effective returns the cached permissions of a user, computing them on a
+// miss. The user is looked up first so that one outside the caller's
+// organization is not found even when cached for another.
+func (s *service) effective(ctx context.Context, userID uuid.UUID) (*dto.EffectivePermissions, error) {
+	if _, err := s.users.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	key := cacheKey{org: tenant.FromContext(ctx), user: userID}
+	cached, generation := s.cache.get(key)
+	if cached != nil {
+		return cached, nil
+	}
+
+	memberships, err := s.groups.Memberships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := s.repo.Assignments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	principals := map[model.Principal]bool{{Kind: model.PrincipalUser, ID: userID}: true}
+	for _, m := range memberships {
+		principals[model.Principal{Kind: model.PrincipalGroup, ID: m.GroupID}] = true
+	}
+
+	effective := &dto.EffectivePermissions{Roles: []model.Role{}, Permissions: []string{}}
+	seen := map[uuid.UUID]bool{}
+	for _, a := range assignments {
+		if !principals[a.Principal] || seen[a.RoleID] {
+			continue
+		}
+		seen[a.RoleID] = true
+		r, err := s.repo.GetByID(ctx, a.RoleID)
+		if errors.Is(err, role.ErrRoleNotFound) {
+			// Deleted since the assignments were read.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		effective.Roles = append(effective.Roles, *r)
+		effective.Permissions = append(effective.Permissions, r.Permissions...)
+	}
+	slices.Sort(effective.Permissions)
+	effective.Permissions = slices.Compact(effective.Permissions)
+
+	s.cache.put(key, generation, effective)
+	return effective, nil
+}
+
+func (s *service) principalExists(ctx context.Context, principal model.Principal) error {
+	if err := validatePrincipal(principal); err != nil {
+		return err
+	}
+	if principal.Kind == model.PrincipalUser {
+		_, err := s.users.GetByID(ctx, principal.ID)
+		return err
+	}
+	_, err := s.groups.GetByID(ctx, principal.ID)
+	return err
+}
+
+// invalidate drops the cached permissions a changed assignment affects:
+// those of the user, or of everyone for a group since finding its members
+// costs more than recomputing.
+func (s *service) invalidate(principal model.Principal) {
+	if principal.Kind == model.PrincipalUser {
+		s.cache.invalidate(principal.ID)
+		return
+	}
+	s.cache.invalidateAll()
+}
+
+func validatePrincipal(principal model.Principal) error {
+	if principal.Kind != model.PrincipalUser && principal.Kind != model.PrincipalGroup {
+		return errInvalidPrincipal
+	}
+	return nil
+}
+
+// normalize cleans up a role like the group service does a group and
+// validates it. Permissions are stored sorted and without duplicates.
+func normalize(role *model.Role) error {
+	role.Name = names.Normalize(role.Name)
+	role.NameKey = names.SearchKey(role.Name)
+	role.Description = strings.TrimSpace(role.Description)
+
+	if role.Name == "" {
+		return errInvalidName
+	}
+	if err := names.Validate(role.Name); err != nil {
+		return newValidationError(err.Error())
+	}
+	if utf8.RuneCountInString(role.Description) > maxDescriptionLength {
+		return errInvalidDescription
+	}
+	permissions, err := normalizePermissions(role.Permissions)
+	if err != nil {
+		return err
+	}
+	role.Permissions = permissions
+	return nil
+}
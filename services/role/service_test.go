@@ -0,0 +1,286 @@
+package role
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/group"
+	"github.com/sergey4qb/mf1-test/repository/role"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+type fixture struct {
+	srv    *service
+	users  user.Repository
+	groups group.Repository
+}
+
+func newTestService(t *testing.T, opts ...Option) *fixture {
+	t.Helper()
+
+	dir := t.TempDir()
+	users, err := user.NewFile(filepath.Join(dir, "users.json"))
+	require.NoError(t, err)
+	groups, err := group.NewFile(filepath.Join(dir, "groups.json"))
+	require.NoError(t, err)
+	roles, err := role.NewFile(filepath.Join(dir, "roles.json"))
+	require.NoError(t, err)
+
+	f := &fixture{users: users, groups: groups}
+	f.srv = New(roles, f.groups, f.users, opts...).(*service)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	f.srv.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return f
+}
+
+func (f *fixture) createUser(t *testing.T) uuid.UUID {
+	t.Helper()
+
+	u := &model.User{ID: uuid.New(), Name: "Jane", Email: uuid.NewString() + "@example.com"}
+	require.NoError(t, f.users.Create(context.Background(), u))
+	return u.ID
+}
+
+func (f *fixture) createGroup(t *testing.T, members ...uuid.UUID) uuid.UUID {
+	t.Helper()
+
+	g := &model.Group{ID: uuid.New(), Name: "Platform", NameKey: uuid.NewString()}
+	require.NoError(t, f.groups.Create(context.Background(), g))
+	for _, userID := range members {
+		m := &model.Membership{GroupID: g.ID, UserID: userID}
+		require.NoError(t, f.groups.AddMember(context.Background(), m, group.Limits{}))
+	}
+	return g.ID
+}
+
+func (f *fixture) createRole(t *testing.T, name string, permissions ...string) *model.Role {
+	t.Helper()
+
+	r := &model.Role{Name: name, Permissions: permissions}
+	require.NoError(t, f.srv.Create(context.Background(), r))
+	return r
+}
+
+func userPrincipal(id uuid.UUID) model.Principal {
+	return model.Principal{Kind: model.PrincipalUser, ID: id}
+}
+
+func groupPrincipal(id uuid.UUID) model.Principal {
+	return model.Principal{Kind: model.PrincipalGroup, ID: id}
+}
+
+func TestCreate(t *testing.T) {
+	f := newTestService(t)
+	ctx := context.Background()
+
+	r := &model.Role{Name: "  Editor ", Permissions: []string{"documents:write", " documents:read", "documents:write"}}
+	require.NoError(t, f.srv.Create(ctx, r))
+	assert.NotEqual(t, uuid.Nil, r.ID)
+	assert.Equal(t, "Editor", r.Name)
+	assert.Equal(t, []string{"documents:read", "documents:write"}, r.Permissions)
+
+	assert.ErrorIs(t, f.srv.Create(ctx, &model.Role{Name: "EDITOR"}), ErrNameTaken)
+
+	for _, p := range []string{"", "Documents:read", "documents::read", "documents:*:read", "*:read", "documents read"} {
+		err := f.srv.Create(ctx, &model.Role{Name: "Viewer", Permissions: []string{p}})
+		assert.ErrorIs(t, err, ErrValidation, "permission %q", p)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	f := newTestService(t)
+	ctx := context.Background()
+	r := f.createRole(t, "Editor", "documents:read")
+
+	permissions := []string{"documents:*"}
+	updated, err := f.srv.Update(ctx, &dto.UpdateRoleDTO{ID: r.ID, Permissions: &permissions})
+	require.NoError(t, err)
+	assert.Equal(t, "Editor", updated.Name)
+	assert.Equal(t, []string{"documents:*"}, updated.Permissions)
+
+	bad := []string{"Documents"}
+	_, err = f.srv.Update(ctx, &dto.UpdateRoleDTO{ID: r.ID, Permissions: &bad})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = f.srv.Update(ctx, &dto.UpdateRoleDTO{ID: uuid.New(), Permissions: &permissions})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestAssign(t *testing.T) {
+	f := newTestService(t)
+	ctx := context.Background()
+	r := f.createRole(t, "Editor", "documents:read")
+	userID := f.createUser(t)
+	groupID := f.createGroup(t)
+
+	_, err := f.srv.Assign(ctx, r.ID, userPrincipal(uuid.New()))
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	_, err = f.srv.Assign(ctx, r.ID, groupPrincipal(uuid.New()))
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+	_, err = f.srv.Assign(ctx, r.ID, model.Principal{Kind: "robot", ID: userID})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = f.srv.Assign(ctx, uuid.New(), userPrincipal(userID))
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	a, err := f.srv.Assign(ctx, r.ID, userPrincipal(userID))
+	require.NoError(t, err)
+	assert.False(t, a.AssignedAt.IsZero())
+	_, err = f.srv.Assign(ctx, r.ID, userPrincipal(userID))
+	assert.ErrorIs(t, err, ErrAlreadyAssigned)
+	_, err = f.srv.Assign(ctx, r.ID, groupPrincipal(groupID))
+	require.NoError(t, err)
+
+	assignments, err := f.srv.ListAssignments(ctx, r.ID)
+	require.NoError(t, err)
+	require.Len(t, assignments, 2)
+	assert.Equal(t, groupPrincipal(groupID), assignments[1].Principal)
+
+	require.NoError(t, f.srv.Unassign(ctx, r.ID, userPrincipal(userID)))
+	assert.ErrorIs(t, f.srv.Unassign(ctx, r.ID, userPrincipal(userID)), ErrNotAssigned)
+}
+
+func TestCheckPermission(t *testing.T) {
+	f := newTestService(t)
+	ctx := context.Background()
+	userID := f.createUser(t)
+	groupID := f.createGroup(t, userID)
+
+	viewer := f.createRole(t, "Viewer", "documents:read")
+	admin := f.createRole(t, "Billing admin", "billing:*")
+	_, err := f.srv.Assign(ctx, viewer.ID, userPrincipal(userID))
+	require.NoError(t, err)
+	_, err = f.srv.Assign(ctx, admin.ID, groupPrincipal(groupID))
+	require.NoError(t, err)
+
+	tests := []struct {
+		permission string
+		want       bool
+	}{
+		{"documents:read", true},
+		{"documents:write", false},
+		{"billing:invoices:refund", true},
+		{"billing", false},
+		{"billingx:read", false},
+	}
+	for _, tt := range tests {
+		got, err := f.srv.CheckPermission(ctx, userID, tt.permission)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, tt.permission)
+	}
+
+	_, err = f.srv.CheckPermission(ctx, userID, "*")
+	assert.ErrorIs(t, err, ErrValidation, "a check names one permission")
+	_, err = f.srv.CheckPermission(ctx, uuid.New(), "documents:read")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	effective, err := f.srv.EffectivePermissions(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"billing:*", "documents:read"}, effective.Permissions)
+	assert.Len(t, effective.Roles, 2)
+}
+
+func TestCache_Invalidation(t *testing.T) {
+	f := newTestService(t)
+	ctx := context.Background()
+	userID := f.createUser(t)
+	groupID := f.createGroup(t)
+	r := f.createRole(t, "Viewer", "documents:read")
+
+	allowed := func() bool {
+		t.Helper()
+		ok, err := f.srv.CheckPermission(ctx, userID, "documents:read")
+		require.NoError(t, err)
+		return ok
+	}
+
+	assert.False(t, allowed())
+	_, err := f.srv.Assign(ctx, r.ID, groupPrincipal(groupID))
+	require.NoError(t, err)
+	assert.False(t, allowed(), "the user is not a member yet")
+
+	// Memberships change behind the role service's back; the group service
+	// reports them through the hook.
+	require.NoError(t, f.groups.AddMember(ctx, &model.Membership{GroupID: groupID, UserID: userID}, group.Limits{}))
+	assert.False(t, allowed(), "served from the cache")
+	f.srv.MembershipChanged(groupID, userID)
+	assert.True(t, allowed())
+
+	permissions := []string{"documents:write"}
+	_, err = f.srv.Update(ctx, &dto.UpdateRoleDTO{ID: r.ID, Permissions: &permissions})
+	require.NoError(t, err)
+	assert.False(t, allowed())
+
+	_, err = f.srv.Assign(ctx, f.createRole(t, "Reader", "documents:*").ID, userPrincipal(userID))
+	require.NoError(t, err)
+	assert.True(t, allowed())
+
+	require.NoError(t, f.srv.RemoveUser(ctx, userID))
+	assert.False(t, allowed())
+}
+
+func TestCache_Size(t *testing.T) {
+	f := newTestService(t, WithCacheSize(1))
+	ctx := context.Background()
+	first, second := f.createUser(t), f.createUser(t)
+
+	_, err := f.srv.EffectivePermissions(ctx, first)
+	require.NoError(t, err)
+	_, err = f.srv.EffectivePermissions(ctx, second)
+	require.NoError(t, err)
+
+	assert.Len(t, f.srv.cache.users, 1)
+	assert.Contains(t, f.srv.cache.users, cacheKey{org: tenant.Default, user: second})
+}
+
+func TestCache_TenantIsolation(t *testing.T) {
+	f := newTestService(t)
+	acme := tenant.NewContext(context.Background(), uuid.New())
+	globex := tenant.NewContext(context.Background(), uuid.New())
+
+	u := &model.User{ID: uuid.New(), Name: "Jane", Email: "jane@example.com"}
+	require.NoError(t, f.users.Create(acme, u))
+	r := &model.Role{Name: "Viewer", Permissions: []string{"documents:read"}}
+	require.NoError(t, f.srv.Create(acme, r))
+	_, err := f.srv.Assign(acme, r.ID, userPrincipal(u.ID))
+	require.NoError(t, err)
+
+	ok, err := f.srv.CheckPermission(acme, u.ID, "documents:read")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = f.srv.CheckPermission(globex, u.ID, "documents:read")
+	assert.ErrorIs(t, err, user.ErrUserNotFound, "a cached user stays hidden from other organizations")
+	_, err = f.srv.EffectivePermissions(globex, u.ID)
+	assert.ErrorIs(t, err, user.ErrUserNotFound)
+	_, err = f.srv.Assign(globex, r.ID, userPrincipal(u.ID))
+	assert.ErrorIs(t, err, user.ErrUserNotFound)
+}
+
+func TestGrants(t *testing.T) {
+	tests := []struct {
+		granted, requested string
+		want               bool
+	}{
+		{"documents:read", "documents:read", true},
+		{"documents:read", "documents:write", false},
+		{"documents:*", "documents:read", true},
+		{"documents:*", "documents:comments:write", true},
+		{"documents:*", "documentsx:read", false},
+		{"*", "anything:at:all", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, grants(tt.granted, tt.requested), "%s grants %s", tt.granted, tt.requested)
+	}
+}
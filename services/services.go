@@ -1,24 +1,333 @@
 package services
 
 import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math"
+	"os"
+
+	"github.com/sergey4qb/mf1-test/config"
+	"github.com/sergey4qb/mf1-test/email"
+	"github.com/sergey4qb/mf1-test/mailer"
+	"github.com/sergey4qb/mf1-test/password"
 	"github.com/sergey4qb/mf1-test/repository"
+	groupstore "github.com/sergey4qb/mf1-test/repository/group"
+	"github.com/sergey4qb/mf1-test/services/audit"
+	"github.com/sergey4qb/mf1-test/services/group"
+	"github.com/sergey4qb/mf1-test/services/history"
+	"github.com/sergey4qb/mf1-test/services/idempotency"
+	"github.com/sergey4qb/mf1-test/services/organization"
+	"github.com/sergey4qb/mf1-test/services/outbox"
+	"github.com/sergey4qb/mf1-test/services/privacy"
+	"github.com/sergey4qb/mf1-test/services/role"
+	"github.com/sergey4qb/mf1-test/services/token"
 	"github.com/sergey4qb/mf1-test/services/user"
+	"github.com/sergey4qb/mf1-test/services/verification"
+	"github.com/sergey4qb/mf1-test/services/webhook"
 )
 
 type Services interface {
 	GetUser() user.User
+	GetIdempotency() idempotency.Idempotency
+	GetVerification() verification.Verification
+	GetToken() token.Tokens
+	GetGroup() group.Group
+	GetRole() role.Role
+	GetOrganization() organization.Organization
+	GetHistory() history.History
+	GetAudit() audit.Audit
+	GetOutbox() outbox.Dispatcher
+	GetWebhook() webhook.Webhook
+	GetWebhookDispatcher() webhook.Dispatcher
+	GetPrivacy() privacy.Privacy
 }
 
 type services struct {
-	user user.User
+	user         user.User
+	idempotency  idempotency.Idempotency
+	verification verification.Verification
+	token        token.Tokens
+	group        group.Group
+	role         role.Role
+	organization organization.Organization
+	history      history.History
+	audit        audit.Audit
+	outbox       outbox.Dispatcher
+	webhook      webhook.Webhook
+	webhooks     webhook.Dispatcher
+	privacy      privacy.Privacy
 }
 
-func New(repository repository.Repository) (Services, error) {
+func New(cfg *config.Config, repository repository.Repository) (Services, error) {
+	ids, err := user.ParseIDStrategy(cfg.UserIDStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := user.LoadRules(cfg.ValidationRulesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, err := user.LoadAttributeSchema(cfg.AttributeSchemaFile)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := newMailer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := verificationSecret(cfg)
+	if err != nil {
+		return nil, err
+	}
+	params, err := passwordParams(cfg)
+	if err != nil {
+		return nil, err
+	}
+	mfaKey, err := mfaKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	mfaIssuer := cfg.MFAIssuer
+	if mfaIssuer == "" {
+		mfaIssuer = cfg.TokenIssuer
+	}
+	roles := role.New(repository.GetRole(), repository.GetGroup(), repository.GetUser(),
+		role.WithCacheSize(cfg.PermissionCacheSize),
+	)
+	groups := group.New(repository.GetGroup(), repository.GetUser(),
+		group.WithLimits(groupstore.Limits{
+			MaxMembers: cfg.GroupMaxMembers,
+			MaxGroups:  cfg.UserMaxGroups,
+		}),
+		group.WithDeleteHook(roles.RemoveGroup),
+		group.WithMembershipHook(roles.MembershipChanged),
+	)
+	policy := password.DefaultPolicy
+	if cfg.PasswordMinLength > 0 {
+		policy.MinLength = cfg.PasswordMinLength
+	}
+
+	// Views and changes through the services are audited, including those
+	// made by other services on their behalf, such as reverts.
+	users := audit.NewUsers(user.New(repository.GetUser(),
+		user.WithIDStrategy(ids),
+		user.WithValidator(rules),
+		user.WithAttributeSchema(attributes),
+		user.WithEmailCanonicalization(email.CanonicalOptions{FoldGmail: cfg.EmailFoldGmail}),
+		user.WithPasswordParams(params),
+		user.WithPasswordPolicy(policy),
+		user.WithLockout(lockout(cfg)),
+		user.WithMFA(mfaKey, mfaIssuer),
+		user.WithDeleteHook(groups.RemoveUser),
+		user.WithDeleteHook(roles.RemoveUser),
+	), repository.GetAudit())
+
 	return &services{
-		user: user.New(repository.GetUser()),
+		user:        users,
+		idempotency: idempotency.New(repository.GetIdempotency(), cfg.IdempotencyTTL),
+		verification: verification.New(repository.GetUser(), users, repository.GetVerification(), m, secret,
+			verification.WithTokenTTL(cfg.VerificationTokenTTL),
+			verification.WithLink(cfg.VerificationURL),
+		),
+		token: token.New(repository.GetUser(), repository.GetToken(),
+			token.WithIssuer(cfg.TokenIssuer),
+			token.WithAudience(cfg.TokenAudience),
+			token.WithTTLs(cfg.AccessTokenTTL, cfg.RefreshTokenTTL),
+			token.WithKeyRotation(cfg.SigningKeyRotation),
+		),
+		group:        groups,
+		role:         roles,
+		organization: organization.New(repository.GetOrganization(), repository.GetUser(), repository.GetOutbox(), repository.GetWebhook()),
+		history:      audit.NewHistory(history.New(repository.GetHistory(), users), repository.GetAudit()),
+		audit:        audit.New(repository.GetAudit()),
+		outbox: outbox.New(repository.GetOutbox(), repository.GetDeadLetter(), repository.GetOrganization(), newSinks(cfg, repository),
+			outbox.WithInterval(cfg.EventsPollInterval),
+			outbox.WithMaxAttempts(cfg.EventsMaxAttempts),
+		),
+		webhook: webhook.New(repository.GetWebhook()),
+		webhooks: webhook.NewDispatcher(repository.GetWebhook(), repository.GetOrganization(),
+			webhook.WithInterval(cfg.EventsPollInterval),
+			webhook.WithMaxAttempts(cfg.WebhookMaxAttempts),
+			webhook.WithDisableAfter(cfg.WebhookDisableAfter),
+		),
+		privacy: audit.NewPrivacy(privacy.New(repository.GetTombstone(), privacyStores(repository, users, roles)), repository.GetAudit()),
 	}, nil
 }
 
+// privacyStores returns every store holding data about users, in the order
+// they are erased: memberships and assignments before the user, so they are
+// counted, and the user before the stores its deletion writes to.
+func privacyStores(repository repository.Repository, users user.User, roles role.Role) []privacy.Store {
+	return []privacy.Store{
+		privacy.NewGroupStore(repository.GetGroup()),
+		privacy.NewRoleStore(repository.GetRole(), roles),
+		privacy.NewUserStore(repository.GetUser(), users),
+		privacy.NewHistoryStore(repository.GetHistory()),
+		privacy.NewOutboxStore(repository.GetOutbox()),
+		privacy.NewWebhookStore(repository.GetWebhook()),
+		privacy.NewDeadLetterStore(repository.GetDeadLetter()),
+		privacy.NewTokenStore(repository.GetToken()),
+		privacy.NewVerificationStore(repository.GetVerification()),
+		privacy.NewIdempotencyStore(repository.GetIdempotency()),
+		privacy.NewAuditStore(repository.GetAudit()),
+	}
+}
+
+// passwordParams overrides password.DefaultParams with the configured costs.
+func passwordParams(cfg *config.Config) (password.Params, error) {
+	params := password.DefaultParams
+	if cfg.PasswordArgon2Memory > 0 {
+		params.Memory = cfg.PasswordArgon2Memory
+	}
+	if cfg.PasswordArgon2Iterations > 0 {
+		params.Iterations = cfg.PasswordArgon2Iterations
+	}
+	if cfg.PasswordArgon2Parallelism > 0 {
+		if cfg.PasswordArgon2Parallelism > math.MaxUint8 {
+			return password.Params{}, fmt.Errorf("PASSWORD_ARGON2_PARALLELISM must be at most %d", math.MaxUint8)
+		}
+		params.Parallelism = uint8(cfg.PasswordArgon2Parallelism)
+	}
+	if err := params.Validate(); err != nil {
+		return password.Params{}, fmt.Errorf("password hashing: %w", err)
+	}
+	return params, nil
+}
+
+func lockout(cfg *config.Config) user.Lockout {
+	l := user.DefaultLockout
+	if cfg.LoginMaxFailures > 0 {
+		l.MaxFailures = cfg.LoginMaxFailures
+	}
+	if cfg.LoginFailureWindow > 0 {
+		l.Window = cfg.LoginFailureWindow
+	}
+	if cfg.LoginLockoutDuration > 0 {
+		l.Duration = cfg.LoginLockoutDuration
+	}
+	return l
+}
+
+// newMailer builds the mailer selected by cfg.Mailer; an empty value prints
+// messages to stdout.
+func newMailer(cfg *config.Config) (mailer.Mailer, error) {
+	switch cfg.Mailer {
+	case "", "stdout":
+		return mailer.NewWriter(os.Stdout, cfg.MailFrom), nil
+	case "file":
+		if cfg.MailFile == "" {
+			return nil, fmt.Errorf("MAILER=file needs MAIL_FILE")
+		}
+		return mailer.NewFile(cfg.MailFile, cfg.MailFrom), nil
+	case "smtp":
+		return mailer.NewSMTP(mailer.SMTPConfig{
+			Addr:     cfg.SMTPAddr,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		})
+	}
+	return nil, fmt.Errorf("unknown mailer %q, expected stdout, file or smtp", cfg.Mailer)
+}
+
+// newSinks returns the sinks user events are published to: the subscribed
+// webhooks and those configured.
+func newSinks(cfg *config.Config, repository repository.Repository) []outbox.Sink {
+	sinks := []outbox.Sink{webhook.NewSink(repository.GetWebhook())}
+	if cfg.EventsWebhookURL != "" {
+		sinks = append(sinks, outbox.NewWebhookSink(cfg.EventsWebhookURL))
+	}
+	if cfg.EventsNATSURL != "" {
+		sinks = append(sinks, outbox.NewNATSSink(cfg.EventsNATSURL, cfg.EventsNATSSubject))
+	}
+	if cfg.EventsFile != "" {
+		sinks = append(sinks, outbox.NewFileSink(cfg.EventsFile))
+	}
+	return sinks
+}
+
+// verificationSecret returns the configured signing secret, or a random one
+// that lives as long as the process.
+func verificationSecret(cfg *config.Config) ([]byte, error) {
+	return secretOrRandom("VERIFICATION_SECRET", cfg.VerificationSecret, "verification tokens")
+}
+
+// mfaKey returns the configured MFA key, or a random one that lives as long
+// as the process.
+func mfaKey(cfg *config.Config) ([]byte, error) {
+	return secretOrRandom("MFA_ENCRYPTION_KEY", cfg.MFAEncryptionKey, "MFA enrollments")
+}
+
+// secretOrRandom checks a configured secret of at least 32 bytes, or
+// returns a random one with a warning that what it protects is lost on
+// restart.
+func secretOrRandom(name, value, protects string) ([]byte, error) {
+	if value != "" {
+		if len(value) < 32 {
+			return nil, fmt.Errorf("%s must be at least 32 bytes", name)
+		}
+		return []byte(value), nil
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	log.Printf("WARNING: %s not set, %s will not survive a restart", name, protects)
+	return secret, nil
+}
+
 func (r *services) GetUser() user.User {
 	return r.user
 }
+
+func (r *services) GetIdempotency() idempotency.Idempotency {
+	return r.idempotency
+}
+
+func (r *services) GetVerification() verification.Verification {
+	return r.verification
+}
+
+func (r *services) GetToken() token.Tokens {
+	return r.token
+}
+
+func (r *services) GetGroup() group.Group {
+	return r.group
+}
+
+func (r *services) GetRole() role.Role {
+	return r.role
+}
+
+func (r *services) GetOrganization() organization.Organization {
+	return r.organization
+}
+
+func (r *services) GetHistory() history.History {
+	return r.history
+}
+
+func (r *services) GetAudit() audit.Audit {
+	return r.audit
+}
+
+func (r *services) GetOutbox() outbox.Dispatcher {
+	return r.outbox
+}
+
+func (r *services) GetWebhook() webhook.Webhook {
+	return r.webhook
+}
+
+func (r *services) GetWebhookDispatcher() webhook.Dispatcher {
+	return r.webhooks
+}
+
+func (r *services) GetPrivacy() privacy.Privacy {
+	return r.privacy
+}
@@ -0,0 +1,13 @@
+package token
+
+import "errors"
+
+// Error kinds returned by the service. Refresh tokens that are unknown,
+// expired or revoked all fail with ErrInvalidToken.
+var (
+	ErrInvalidToken = errors.New("invalid refresh token")
+	// ErrTokenReused means a refresh token was presented after it had been
+	// exchanged; the whole session is revoked since the token has leaked.
+	ErrTokenReused        = errors.New("refresh token was already used, the session has been revoked")
+	ErrInvalidAccessToken = errors.New("invalid access token")
+)
@@ -0,0 +1,203 @@
+package token
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+// algorithm is the JWS algorithm of every signing key: ECDSA on P-256 with
+// SHA-256.
+const algorithm = "ES256"
+
+// JWK is the public half of a signing key as described in RFC 7517.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// JWKS is the document resource servers fetch to verify access tokens.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// keyring caches the stored signing keys. Access goes through the service's
+// mutex.
+type keyring struct {
+	loaded  bool
+	keys    []model.SigningKey
+	private map[string]*ecdsa.PrivateKey
+}
+
+// signingKey returns the key new tokens are signed with, rotating it when it
+// is due.
+func (s *service) signingKey(ctx context.Context) (*model.SigningKey, *ecdsa.PrivateKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refreshKeysNoLock(ctx); err != nil {
+		return nil, nil, err
+	}
+	key := &s.keys.keys[len(s.keys.keys)-1]
+	return key, s.keys.private[key.ID], nil
+}
+
+// publicKeys returns the keys tokens that have not yet expired may be
+// signed with, current key last.
+func (s *service) publicKeys(ctx context.Context) (map[string]*ecdsa.PublicKey, []model.SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refreshKeysNoLock(ctx); err != nil {
+		return nil, nil, err
+	}
+	public := make(map[string]*ecdsa.PublicKey, len(s.keys.keys))
+	for _, key := range s.keys.keys {
+		public[key.ID] = &s.keys.private[key.ID].PublicKey
+	}
+	return public, s.keys.keys, nil
+}
+
+// refreshKeysNoLock loads the stored keys on first use, starts a new key when
+// there is none or the current one is older than the rotation period, and
+// drops retired keys no unexpired token can be signed with.
+func (s *service) refreshKeysNoLock(ctx context.Context) error {
+	if !s.keys.loaded {
+		keys, err := s.tokens.Keys(ctx)
+		if err != nil {
+			return err
+		}
+		private := make(map[string]*ecdsa.PrivateKey, len(keys))
+		for _, key := range keys {
+			pk, err := parsePrivateKey(&key)
+			if err != nil {
+				return err
+			}
+			private[key.ID] = pk
+		}
+		s.keys = keyring{loaded: true, keys: keys, private: private}
+	}
+
+	now := s.now()
+	keys := s.keys.keys
+	n := len(keys)
+	rotate := n == 0 || keys[n-1].RetiredAt != nil || now.Sub(keys[n-1].CreatedAt) >= s.rotation
+
+	var kept []model.SigningKey
+	for _, key := range keys {
+		if rotate && key.RetiredAt == nil {
+			retired := now
+			key.RetiredAt = &retired
+		}
+		if key.RetiredAt == nil || now.Sub(*key.RetiredAt) < s.accessTTL {
+			kept = append(kept, key)
+		}
+	}
+	private := make(map[string]*ecdsa.PrivateKey, len(kept)+1)
+	for _, key := range kept {
+		private[key.ID] = s.keys.private[key.ID]
+	}
+	if rotate {
+		key, pk, err := generateKey(now)
+		if err != nil {
+			return err
+		}
+		kept = append(kept, *key)
+		private[key.ID] = pk
+	} else if len(kept) == n {
+		return nil
+	}
+
+	if err := s.tokens.SaveKeys(ctx, kept); err != nil {
+		return err
+	}
+	s.keys.keys, s.keys.private = kept, private
+	return nil
+}
+
+func generateKey(now time.Time) (*model.SigningKey, *ecdsa.PrivateKey, error) {
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(pk)
+	if err != nil {
+		return nil, nil, err
+	}
+	jwk, err := publicJWK("", &pk.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &model.SigningKey{
+		ID:         thumbprint(jwk),
+		Algorithm:  algorithm,
+		PrivateKey: der,
+		CreatedAt:  now,
+	}, pk, nil
+}
+
+func parsePrivateKey(key *model.SigningKey) (*ecdsa.PrivateKey, error) {
+	if key.Algorithm != algorithm {
+		return nil, fmt.Errorf("signing key %s: unsupported algorithm %q", key.ID, key.Algorithm)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("signing key %s: %w", key.ID, err)
+	}
+	pk, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok || pk.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("signing key %s: not a P-256 key", key.ID)
+	}
+	return pk, nil
+}
+
+func publicJWK(id string, pub *ecdsa.PublicKey) (JWK, error) {
+	ecdh, err := pub.ECDH()
+	if err != nil {
+		return JWK{}, err
+	}
+	// The uncompressed point is 0x04 || X || Y.
+	point := ecdh.Bytes()
+	if len(point) != 65 {
+		return JWK{}, errors.New("unexpected P-256 point size")
+	}
+	return JWK{
+		KeyType:   "EC",
+		Curve:     "P-256",
+		X:         base64.RawURLEncoding.EncodeToString(point[1:33]),
+		Y:         base64.RawURLEncoding.EncodeToString(point[33:]),
+		KeyID:     id,
+		Use:       "sig",
+		Algorithm: algorithm,
+	}, nil
+}
+
+// thumbprint is the RFC 7638 thumbprint of jwk, used as the key ID so it is
+// derived from the key itself.
+func thumbprint(jwk JWK) string {
+	// The members must be in lexicographic order without whitespace, which
+	// is what marshalling this struct produces.
+	canonical, _ := json.Marshal(struct {
+		Crv string `json:"crv"`
+		Kty string `json:"kty"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}{jwk.Curve, jwk.KeyType, jwk.X, jwk.Y})
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,324 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/token"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	userservice "github.com/sergey4qb/mf1-test/services/user"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+// Defaults used unless an Option says otherwise.
+const (
+	DefaultIssuer      = "mf1-test"
+	DefaultAccessTTL   = 15 * time.Minute
+	DefaultRefreshTTL  = 30 * 24 * time.Hour
+	DefaultKeyRotation = 30 * 24 * time.Hour
+)
+
+// refreshTokenSize is the number of random bytes in a refresh token.
+const refreshTokenSize = 32
+
+type Tokens interface {
+	// Issue starts a session for a user who has just authenticated.
+	Issue(ctx context.Context, u *model.User) (*Pair, error)
+	// Refresh exchanges a refresh token for a new pair of the same session.
+	// Each refresh token works once; presenting a used one revokes the
+	// session and fails with ErrTokenReused.
+	Refresh(ctx context.Context, refreshToken string) (*Pair, error)
+	// Revoke ends the session a refresh token belongs to. Unknown and
+	// expired tokens are ignored, as RFC 7009 asks.
+	Revoke(ctx context.Context, refreshToken string) error
+	// RevokeUser ends every session of a user.
+	RevokeUser(ctx context.Context, userID uuid.UUID) error
+	// VerifyAccessToken checks an access token's signature and claims.
+	VerifyAccessToken(ctx context.Context, accessToken string) (*Claims, error)
+	// JWKS returns the public keys access tokens are verified with.
+	JWKS(ctx context.Context) (*JWKS, error)
+}
+
+// Pair is what a client gets on login and on every refresh.
+type Pair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// Claims are the claims of an access token. The subject is the user ID.
+type Claims struct {
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified"`
+	// OrganizationID is the organization of the user, empty for the
+	// default one.
+	OrganizationID string `json:"org_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Organization returns the organization named by the claims.
+func (c *Claims) Organization() (uuid.UUID, error) {
+	if c.OrganizationID == "" {
+		return tenant.Default, nil
+	}
+	return uuid.Parse(c.OrganizationID)
+}
+
+type service struct {
+	users  user.Repository
+	tokens token.Repository
+
+	issuer     string
+	audience   string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	rotation   time.Duration
+	now        func() time.Time
+
+	mu   sync.Mutex
+	keys keyring
+}
+
+type Option func(*service)
+
+// WithIssuer sets the iss claim of access tokens.
+func WithIssuer(issuer string) Option {
+	return func(s *service) {
+		if issuer != "" {
+			s.issuer = issuer
+		}
+	}
+}
+
+// WithAudience sets the aud claim of access tokens; by default there is
+// none.
+func WithAudience(audience string) Option {
+	return func(s *service) {
+		s.audience = audience
+	}
+}
+
+// WithTTLs sets how long access and refresh tokens stay valid; zero keeps
+// the default.
+func WithTTLs(access, refresh time.Duration) Option {
+	return func(s *service) {
+		if access > 0 {
+			s.accessTTL = access
+		}
+		if refresh > 0 {
+			s.refreshTTL = refresh
+		}
+	}
+}
+
+// WithKeyRotation sets how long a key signs tokens before it is replaced.
+func WithKeyRotation(rotation time.Duration) Option {
+	return func(s *service) {
+		if rotation > 0 {
+			s.rotation = rotation
+		}
+	}
+}
+
+func New(users user.Repository, tokens token.Repository, opts ...Option) Tokens {
+	s := &service{
+		users:      users,
+		tokens:     tokens,
+		issuer:     DefaultIssuer,
+		accessTTL:  DefaultAccessTTL,
+		refreshTTL: DefaultRefreshTTL,
+		rotation:   DefaultKeyRotation,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *service) Issue(ctx context.Context, u *model.User) (*Pair, error) {
+	return s.issue(ctx, u, uuid.New())
+}
+
+func (s *service) issue(ctx context.Context, u *model.User, family uuid.UUID) (*Pair, error) {
+	now := s.now()
+	access, accessExpiresAt, err := s.signAccessToken(ctx, u, now)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, refreshTokenSize)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(raw)
+	stored := &model.RefreshToken{
+		Hash:           tokenHash(refresh),
+		FamilyID:       family,
+		UserID:         u.ID,
+		OrganizationID: tenant.FromContext(ctx),
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(s.refreshTTL),
+	}
+	if err := s.tokens.Save(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	return &Pair{
+		AccessToken:      access,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: stored.ExpiresAt,
+	}, nil
+}
+
+func (s *service) signAccessToken(ctx context.Context, u *model.User, now time.Time) (string, time.Time, error) {
+	key, pk, err := s.signingKey(ctx)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	// NumericDate keeps whole seconds, so the reported expiry is truncated
+	// to match the claim.
+	expiresAt := now.Add(s.accessTTL).Truncate(time.Second)
+	claims := &Claims{
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    s.issuer,
+			Subject:   u.ID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	if org := tenant.FromContext(ctx); org != tenant.Default {
+		claims.OrganizationID = org.String()
+	}
+	if s.audience != "" {
+		claims.Audience = jwt.ClaimStrings{s.audience}
+	}
+
+	t := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	t.Header["kid"] = key.ID
+	signed, err := t.SignedString(pk)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+func (s *service) Refresh(ctx context.Context, refreshToken string) (*Pair, error) {
+	stored, err := s.tokens.Use(ctx, tokenHash(refreshToken), s.now())
+	if errors.Is(err, token.ErrTokenNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stored.RevokedAt != nil || stored.Expired(s.now()) {
+		return nil, ErrInvalidToken
+	}
+	if stored.UsedAt != nil {
+		if err := s.tokens.RevokeFamily(ctx, stored.FamilyID, s.now()); err != nil {
+			return nil, err
+		}
+		return nil, ErrTokenReused
+	}
+
+	ctx = tenant.NewContext(ctx, stored.OrganizationID)
+	u, err := s.users.GetByID(ctx, stored.UserID)
+	if errors.Is(err, user.ErrUserNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if u.Status != "" && u.Status != model.StatusActive {
+		if err := s.tokens.RevokeFamily(ctx, stored.FamilyID, s.now()); err != nil {
+			return nil, err
+		}
+		return nil, userservice.ErrAccountInactive
+	}
+	return s.issue(ctx, u, stored.FamilyID)
+}
+
+func (s *service) Revoke(ctx context.Context, refreshToken string) error {
+	stored, err := s.tokens.Get(ctx, tokenHash(refreshToken))
+	if errors.Is(err, token.ErrTokenNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.tokens.RevokeFamily(ctx, stored.FamilyID, s.now())
+}
+
+func (s *service) RevokeUser(ctx context.Context, userID uuid.UUID) error {
+	return s.tokens.RevokeUser(ctx, userID, s.now())
+}
+
+func (s *service) VerifyAccessToken(ctx context.Context, accessToken string) (*Claims, error) {
+	public, _, err := s.publicKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{algorithm}),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	}
+	if s.audience != "" {
+		opts = append(opts, jwt.WithAudience(s.audience))
+	}
+
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(accessToken, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		if key, ok := public[kid]; ok {
+			return key, nil
+		}
+		return nil, ErrInvalidAccessToken
+	}, opts...)
+	if err != nil {
+		return nil, ErrInvalidAccessToken
+	}
+	return claims, nil
+}
+
+func (s *service) JWKS(ctx context.Context) (*JWKS, error) {
+	public, keys, err := s.publicKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The current key goes first, where most clients look.
+	set := &JWKS{Keys: []JWK{}}
+	for i := len(keys) - 1; i >= 0; i-- {
+		jwk, err := publicJWK(keys[i].ID, public[keys[i].ID])
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// tokenHash is how a refresh token is stored.
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,239 @@
+package token
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/token"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	userservice "github.com/sergey4qb/mf1-test/services/user"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+type fixture struct {
+	svc    *service
+	users  user.Repository
+	tokens token.Repository
+	user   model.User
+	now    time.Time
+}
+
+func newFixture(t *testing.T, opts ...Option) *fixture {
+	t.Helper()
+
+	tokens, err := token.New(filepath.Join(t.TempDir(), "tokens.json"))
+	require.NoError(t, err)
+
+	f := &fixture{
+		users:  user.NewMemory(),
+		tokens: tokens,
+		user: model.User{
+			ID:             uuid.New(),
+			Name:           "Jane",
+			Email:          "jane@example.com",
+			EmailCanonical: "jane@example.com",
+			Status:         model.StatusActive,
+		},
+		now: time.Now(),
+	}
+	require.NoError(t, f.users.Create(context.Background(), &f.user))
+
+	f.svc = New(f.users, tokens, opts...).(*service)
+	f.svc.now = func() time.Time { return f.now }
+	return f
+}
+
+func TestIssue_AccessTokenVerifies(t *testing.T) {
+	f := newFixture(t, WithAudience("api"))
+	ctx := context.Background()
+
+	pair, err := f.svc.Issue(ctx, &f.user)
+	require.NoError(t, err)
+	assert.WithinDuration(t, f.now.Add(DefaultAccessTTL), pair.AccessExpiresAt, time.Second)
+	assert.WithinDuration(t, f.now.Add(DefaultRefreshTTL), pair.RefreshExpiresAt, time.Second)
+	assert.NotEmpty(t, pair.RefreshToken)
+
+	claims, err := f.svc.VerifyAccessToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, f.user.ID.String(), claims.Subject)
+	assert.Equal(t, DefaultIssuer, claims.Issuer)
+	assert.Equal(t, "jane@example.com", claims.Email)
+	assert.Contains(t, claims.Audience, "api")
+
+	f.now = pair.AccessExpiresAt
+	_, err = f.svc.VerifyAccessToken(ctx, pair.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+
+	_, err = f.svc.VerifyAccessToken(ctx, pair.AccessToken[:len(pair.AccessToken)-4]+"AAAA")
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+}
+
+func TestRefresh_RotatesToken(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	first, err := f.svc.Issue(ctx, &f.user)
+	require.NoError(t, err)
+
+	second, err := f.svc.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	_, err = f.svc.VerifyAccessToken(ctx, second.AccessToken)
+	assert.NoError(t, err)
+
+	third, err := f.svc.Refresh(ctx, second.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEmpty(t, third.RefreshToken)
+
+	_, err = f.svc.Refresh(ctx, "not-a-token")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestRefresh_KeepsOrganization(t *testing.T) {
+	f := newFixture(t)
+	org := uuid.New()
+	ctx := tenant.NewContext(context.Background(), org)
+	u := f.user
+	require.NoError(t, f.users.Create(ctx, &u))
+
+	first, err := f.svc.Issue(ctx, &u)
+	require.NoError(t, err)
+	claims, err := f.svc.VerifyAccessToken(ctx, first.AccessToken)
+	require.NoError(t, err)
+	got, err := claims.Organization()
+	require.NoError(t, err)
+	assert.Equal(t, org, got)
+
+	// The refresh names no organization; the session stays in the user's.
+	second, err := f.svc.Refresh(context.Background(), first.RefreshToken)
+	require.NoError(t, err)
+	claims, err = f.svc.VerifyAccessToken(ctx, second.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, org.String(), claims.OrganizationID)
+}
+
+func TestRefresh_ReuseRevokesFamily(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	first, err := f.svc.Issue(ctx, &f.user)
+	require.NoError(t, err)
+	other, err := f.svc.Issue(ctx, &f.user)
+	require.NoError(t, err)
+
+	second, err := f.svc.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+
+	_, err = f.svc.Refresh(ctx, first.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenReused)
+
+	_, err = f.svc.Refresh(ctx, second.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken, "the whole session is revoked")
+
+	_, err = f.svc.Refresh(ctx, other.RefreshToken)
+	assert.NoError(t, err, "other sessions are untouched")
+}
+
+func TestRefresh_Expired(t *testing.T) {
+	f := newFixture(t, WithTTLs(time.Minute, time.Hour))
+	ctx := context.Background()
+
+	pair, err := f.svc.Issue(ctx, &f.user)
+	require.NoError(t, err)
+
+	f.now = f.now.Add(time.Hour)
+	_, err = f.svc.Refresh(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestRefresh_InactiveUser(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	pair, err := f.svc.Issue(ctx, &f.user)
+	require.NoError(t, err)
+
+	f.user.Status = model.StatusSuspended
+	require.NoError(t, f.users.Update(ctx, &f.user))
+
+	_, err = f.svc.Refresh(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, userservice.ErrAccountInactive)
+
+	f.user.Status = model.StatusActive
+	require.NoError(t, f.users.Update(ctx, &f.user))
+	_, err = f.svc.Refresh(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken, "suspension ends the session")
+}
+
+func TestRevoke(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	first, err := f.svc.Issue(ctx, &f.user)
+	require.NoError(t, err)
+	second, err := f.svc.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+
+	require.NoError(t, f.svc.Revoke(ctx, first.RefreshToken), "any token of the session revokes it")
+	_, err = f.svc.Refresh(ctx, second.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	assert.NoError(t, f.svc.Revoke(ctx, "unknown"))
+
+	third, err := f.svc.Issue(ctx, &f.user)
+	require.NoError(t, err)
+	require.NoError(t, f.svc.RevokeUser(ctx, f.user.ID))
+	_, err = f.svc.Refresh(ctx, third.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestKeyRotation(t *testing.T) {
+	f := newFixture(t, WithKeyRotation(time.Hour), WithTTLs(10*time.Minute, 0))
+	ctx := context.Background()
+
+	old, err := f.svc.Issue(ctx, &f.user)
+	require.NoError(t, err)
+	set, err := f.svc.JWKS(ctx)
+	require.NoError(t, err)
+	require.Len(t, set.Keys, 1)
+	firstKey := set.Keys[0]
+	assert.Equal(t, "EC", firstKey.KeyType)
+	assert.Equal(t, "ES256", firstKey.Algorithm)
+
+	f.now = f.now.Add(time.Hour - time.Minute)
+	old, err = f.svc.Issue(ctx, &f.user)
+	require.NoError(t, err)
+
+	f.now = f.now.Add(time.Minute)
+	current, err := f.svc.Issue(ctx, &f.user)
+	require.NoError(t, err)
+	set, err = f.svc.JWKS(ctx)
+	require.NoError(t, err)
+	require.Len(t, set.Keys, 2, "the retired key is published while its tokens are valid")
+	assert.NotEqual(t, firstKey.KeyID, set.Keys[0].KeyID, "the current key comes first")
+	assert.Equal(t, firstKey.KeyID, set.Keys[1].KeyID)
+
+	_, err = f.svc.VerifyAccessToken(ctx, old.AccessToken)
+	assert.NoError(t, err)
+	_, err = f.svc.VerifyAccessToken(ctx, current.AccessToken)
+	assert.NoError(t, err)
+
+	// A new service instance signs with the stored key instead of
+	// generating one.
+	reloaded := New(f.users, f.tokens, WithKeyRotation(time.Hour), WithTTLs(10*time.Minute, 0)).(*service)
+	reloaded.now = f.svc.now
+	_, err = reloaded.VerifyAccessToken(ctx, current.AccessToken)
+	assert.NoError(t, err)
+
+	f.now = f.now.Add(10 * time.Minute)
+	set, err = f.svc.JWKS(ctx)
+	require.NoError(t, err)
+	assert.Len(t, set.Keys, 1, "the retired key is dropped once its tokens expired")
+}
@@ -0,0 +1,287 @@
+package user
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+// FieldAttributes is the field path of the whole attribute map; a single
+// attribute is addressed as "attributes.<key>".
+const FieldAttributes = "attributes"
+
+// Attribute value types.
+const (
+	AttributeString  = "string"
+	AttributeInteger = "integer"
+	AttributeNumber  = "number"
+	AttributeBoolean = "boolean"
+	AttributeArray   = "array"
+)
+
+// maxSafeInteger is the largest integer a JSON number (float64) holds exactly.
+const maxSafeInteger = 1 << 53
+
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// AttributeSchema declares the custom attributes users may carry. It is
+// loaded from a JSON-Schema-like file, e.g.
+//
+//	{
+//	  "properties": {
+//	    "plan": {"type": "string", "enum": ["free", "pro", "enterprise"], "indexed": true},
+//	    "department": {"type": "string", "maxLength": 64, "indexed": true},
+//	    "seats": {"type": "integer", "minimum": 1},
+//	    "tags": {"type": "array", "items": {"type": "string"}}
+//	  },
+//	  "required": ["plan"]
+//	}
+//
+// Keys not listed in properties are rejected. Indexed attributes are the
+// ones ListUsers can filter on.
+type AttributeSchema struct {
+	Properties map[string]*AttributeDef `json:"properties"`
+	Required   []string                 `json:"required"`
+}
+
+// AttributeDef constrains the values of one attribute.
+type AttributeDef struct {
+	Type      string        `json:"type"`
+	Enum      []any         `json:"enum"`
+	Minimum   *float64      `json:"minimum"`
+	Maximum   *float64      `json:"maximum"`
+	MaxLength int           `json:"maxLength"`
+	Pattern   string        `json:"pattern"`
+	Items     *AttributeDef `json:"items"`
+	Indexed   bool          `json:"indexed"`
+
+	pattern *regexp.Regexp
+}
+
+// LoadAttributeSchema reads the schema file at path. An empty path yields a
+// schema that accepts no attributes.
+func LoadAttributeSchema(path string) (*AttributeSchema, error) {
+	schema := &AttributeSchema{}
+	if path == "" {
+		return schema, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(schema); err != nil {
+		return nil, fmt.Errorf("parse attribute schema %s: %w", path, err)
+	}
+	if err := schema.compile(); err != nil {
+		return nil, fmt.Errorf("attribute schema %s: %w", path, err)
+	}
+	return schema, nil
+}
+
+func (s *AttributeSchema) compile() error {
+	for key, def := range s.Properties {
+		if !attributeKeyPattern.MatchString(key) {
+			return fmt.Errorf("attribute %q: keys must be lowercase letters, digits and underscores", key)
+		}
+		if def == nil {
+			return fmt.Errorf("attribute %q: missing definition", key)
+		}
+		if err := def.compile(false); err != nil {
+			return fmt.Errorf("attribute %q: %w", key, err)
+		}
+	}
+	for _, key := range s.Required {
+		if s.Properties[key] == nil {
+			return fmt.Errorf("required attribute %q is not declared", key)
+		}
+	}
+	return nil
+}
+
+func (d *AttributeDef) compile(item bool) error {
+	switch d.Type {
+	case AttributeString, AttributeInteger, AttributeNumber, AttributeBoolean:
+	case AttributeArray:
+		if item {
+			return fmt.Errorf("arrays cannot be nested")
+		}
+		if d.Items == nil {
+			return fmt.Errorf("array attributes need items")
+		}
+		if err := d.Items.compile(true); err != nil {
+			return fmt.Errorf("items: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown type %q", d.Type)
+	}
+
+	if d.Pattern != "" {
+		re, err := regexp.Compile(d.Pattern)
+		if err != nil {
+			return err
+		}
+		d.pattern = re
+	}
+	for _, v := range d.Enum {
+		if err := d.check(v); err != nil {
+			return fmt.Errorf("enum value %v: %w", v, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks user.Attributes. fields selects what changed: the whole
+// map ("attributes") or single keys ("attributes.plan"); other fields are
+// ignored and an empty list checks everything.
+func (s *AttributeSchema) Validate(user *model.User, fields ...string) error {
+	keys, all := s.selectKeys(user, fields)
+	if !all && len(keys) == 0 {
+		return nil
+	}
+
+	var violations []Violation
+	for _, key := range keys {
+		field := FieldAttributes + "." + key
+		value, ok := user.Attributes[key]
+		def := s.Properties[key]
+
+		switch {
+		case def == nil:
+			violations = append(violations, attributeViolation(field, "is not a declared attribute"))
+		case !ok && slices.Contains(s.Required, key):
+			violations = append(violations, attributeViolation(field, "is required"))
+		case ok:
+			if err := def.check(value); err != nil {
+				violations = append(violations, attributeViolation(field, err.Error()))
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: violations}
+}
+
+// selectKeys returns the attribute keys to check, sorted, and whether the
+// whole map is being checked.
+func (s *AttributeSchema) selectKeys(user *model.User, fields []string) ([]string, bool) {
+	set := map[string]bool{}
+	all := len(fields) == 0 || slices.Contains(fields, FieldAttributes)
+	if all {
+		for key := range user.Attributes {
+			set[key] = true
+		}
+		for _, key := range s.Required {
+			set[key] = true
+		}
+	} else {
+		for _, f := range fields {
+			if key, ok := strings.CutPrefix(f, FieldAttributes+"."); ok {
+				set[key] = true
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, all
+}
+
+// Indexed reports whether ListUsers may filter on key.
+func (s *AttributeSchema) Indexed(key string) bool {
+	def := s.Properties[key]
+	return def != nil && def.Indexed
+}
+
+func (d *AttributeDef) check(value any) error {
+	switch d.Type {
+	case AttributeString:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a string")
+		}
+		if d.MaxLength > 0 && len([]rune(v)) > d.MaxLength {
+			return fmt.Errorf("must be at most %d characters", d.MaxLength)
+		}
+		if d.pattern != nil && !d.pattern.MatchString(v) {
+			return fmt.Errorf("must match %s", d.Pattern)
+		}
+	case AttributeInteger, AttributeNumber:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("must be a number")
+		}
+		if d.Type == AttributeInteger && (v != math.Trunc(v) || math.Abs(v) > maxSafeInteger) {
+			return fmt.Errorf("must be an integer")
+		}
+		if d.Minimum != nil && v < *d.Minimum {
+			return fmt.Errorf("must be at least %v", *d.Minimum)
+		}
+		if d.Maximum != nil && v > *d.Maximum {
+			return fmt.Errorf("must be at most %v", *d.Maximum)
+		}
+	case AttributeBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("must be a boolean")
+		}
+	case AttributeArray:
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("must be an array")
+		}
+		for i, item := range items {
+			if err := d.Items.check(item); err != nil {
+				return fmt.Errorf("item %d %w", i, err)
+			}
+		}
+		return nil
+	}
+
+	if len(d.Enum) > 0 && !slices.Contains(d.Enum, value) {
+		return fmt.Errorf("must be one of %v", d.Enum)
+	}
+	return nil
+}
+
+// checkFilter accepts filters on indexed attributes with scalar values.
+func (s *AttributeSchema) checkFilter(filter map[string]any) error {
+	for key, want := range filter {
+		if !s.Indexed(key) {
+			return newValidationError(fmt.Sprintf("attribute %q is not indexed", key))
+		}
+		switch want.(type) {
+		case string, float64, bool:
+		default:
+			return newValidationError(fmt.Sprintf("filter on attribute %q needs a string, number or boolean", key))
+		}
+	}
+	return nil
+}
+
+// matchesAttribute reports whether the attribute value equals want, or for
+// arrays contains it.
+func matchesAttribute(value, want any) bool {
+	if items, ok := value.([]any); ok {
+		return slices.Contains(items, want)
+	}
+	return value == want
+}
+
+func attributeViolation(field, msg string) Violation {
+	return Violation{Field: field, Message: msg, err: errInvalidAttribute}
+}
@@ -0,0 +1,130 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+const testAttributeSchema = `{
+	"properties": {
+		"plan": {"type": "string", "enum": ["free", "pro"], "indexed": true},
+		"department": {"type": "string", "maxLength": 8, "indexed": true},
+		"seats": {"type": "integer", "minimum": 1},
+		"beta": {"type": "boolean"},
+		"tags": {"type": "array", "items": {"type": "string"}, "indexed": true}
+	},
+	"required": ["plan"]
+}`
+
+func loadTestSchema(t *testing.T) *AttributeSchema {
+	t.Helper()
+	schema, err := LoadAttributeSchema(writeRules(t, testAttributeSchema))
+	require.NoError(t, err)
+	return schema
+}
+
+func TestAttributeSchema_Validate(t *testing.T) {
+	schema := loadTestSchema(t)
+
+	tests := []struct {
+		name       string
+		attributes map[string]any
+		fields     []string
+	}{
+		{name: "valid", attributes: map[string]any{"plan": "pro", "seats": float64(3), "tags": []any{"a"}}},
+		{name: "missing required", attributes: map[string]any{"seats": float64(3)}, fields: []string{"attributes.plan"}},
+		{name: "not in enum", attributes: map[string]any{"plan": "gold"}, fields: []string{"attributes.plan"}},
+		{name: "undeclared", attributes: map[string]any{"plan": "pro", "color": "red"}, fields: []string{"attributes.color"}},
+		{name: "wrong type", attributes: map[string]any{"plan": "pro", "beta": "yes"}, fields: []string{"attributes.beta"}},
+		{name: "fractional integer", attributes: map[string]any{"plan": "pro", "seats": 1.5}, fields: []string{"attributes.seats"}},
+		{name: "below minimum", attributes: map[string]any{"plan": "pro", "seats": float64(0)}, fields: []string{"attributes.seats"}},
+		{name: "too long", attributes: map[string]any{"plan": "pro", "department": "engineering"}, fields: []string{"attributes.department"}},
+		{name: "array item type", attributes: map[string]any{"plan": "pro", "tags": []any{"a", float64(1)}}, fields: []string{"attributes.tags"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate(&model.User{Attributes: tt.attributes})
+			if tt.fields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var verr *ValidationError
+			require.ErrorAs(t, err, &verr)
+			var fields []string
+			for _, v := range verr.Violations {
+				fields = append(fields, v.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
+
+func TestAttributeSchema_ValidateOnlyChangedKeys(t *testing.T) {
+	schema := loadTestSchema(t)
+	u := &model.User{Attributes: map[string]any{"seats": float64(2)}}
+
+	assert.NoError(t, schema.Validate(u, FieldName))
+	assert.NoError(t, schema.Validate(u, "attributes.seats"))
+	assert.Error(t, schema.Validate(u, FieldAttributes), "replacing all attributes checks required ones")
+}
+
+func TestLoadAttributeSchema_Errors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown keyword": `{"properties": {"plan": {"type": "string", "format": "email"}}}`,
+		"unknown type":    `{"properties": {"plan": {"type": "date"}}}`,
+		"bad key":         `{"properties": {"Plan": {"type": "string"}}}`,
+		"undeclared req":  `{"required": ["plan"]}`,
+		"array no items":  `{"properties": {"tags": {"type": "array"}}}`,
+		"enum type":       `{"properties": {"seats": {"type": "integer", "enum": ["one"]}}}`,
+		"invalid pattern": `{"properties": {"code": {"type": "string", "pattern": "("}}}`,
+		"nested arrays":   `{"properties": {"m": {"type": "array", "items": {"type": "array", "items": {"type": "string"}}}}}`,
+	} {
+		_, err := LoadAttributeSchema(writeRules(t, content))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestAttributes_UpdateAndFilter(t *testing.T) {
+	srv := New(newRepo(t), WithAttributeSchema(loadTestSchema(t)))
+	ctx := context.Background()
+
+	alice := &model.User{Name: "Alice", Email: "alice@example.com", Attributes: map[string]any{"plan": "pro", "tags": []any{"eu", "beta"}}}
+	bob := &model.User{Name: "Bob", Email: "bob@example.com", Attributes: map[string]any{"plan": "free", "seats": float64(2)}}
+	require.NoError(t, srv.Create(ctx, alice))
+	require.NoError(t, srv.Create(ctx, bob))
+
+	err := srv.Create(ctx, &model.User{Name: "Carol", Email: "carol@example.com"})
+	assert.ErrorIs(t, err, errInvalidAttribute)
+
+	// Merge: set one key, remove another, keep the rest.
+	updated, err := srv.Update(ctx, &dto.UpdateUserDTO{ID: bob.ID, Attributes: map[string]any{"beta": true, "seats": nil}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"plan": "free", "beta": true}, updated.Attributes)
+
+	// Removing a required attribute fails.
+	_, err = srv.Update(ctx, &dto.UpdateUserDTO{ID: bob.ID, Attributes: map[string]any{"plan": nil}})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	updated, err = srv.Update(ctx, &dto.UpdateUserDTO{ID: bob.ID, Attributes: map[string]any{"plan": "pro"}, ReplaceAttributes: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"plan": "pro"}, updated.Attributes)
+
+	page, err := srv.List(ctx, &dto.ListUsersDTO{Attributes: map[string]any{"plan": "pro"}})
+	require.NoError(t, err)
+	assert.Len(t, page.Users, 2)
+
+	page, err = srv.List(ctx, &dto.ListUsersDTO{Attributes: map[string]any{"plan": "pro", "tags": "beta"}})
+	require.NoError(t, err)
+	require.Len(t, page.Users, 1)
+	assert.Equal(t, alice.ID, page.Users[0].ID)
+
+	_, err = srv.List(ctx, &dto.ListUsersDTO{Attributes: map[string]any{"seats": float64(2)}})
+	assert.ErrorIs(t, err, ErrValidation, "seats is not indexed")
+}
@@ -1,9 +1,67 @@
 package user
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/sergey4qb/mf1-test/repository/user"
+)
+
+// Error kinds returned by the service.
+var (
+	ErrNotFound      = user.ErrUserNotFound
+	ErrAlreadyExists = user.ErrUserAlreadyExists
+	ErrEmailTaken    = user.ErrEmailTaken
+	ErrValidation    = errors.New("validation failed")
+	// ErrInvalidTransition is returned when a user's current status does
+	// not allow the requested change.
+	ErrInvalidTransition = errors.New("invalid status transition")
+	ErrEmailChanged      = errors.New("email changed since the verification token was sent")
+	// ErrInvalidCredentials covers unknown emails, wrong passwords and
+	// locked out accounts alike, so callers cannot probe which emails are
+	// registered.
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrAccountLocked      = errors.New("too many failed logins, try again later")
+	ErrAccountInactive    = errors.New("account is not active")
+	// ErrMFAUnavailable is returned by MFA calls when the service has no
+	// MFA key, see WithMFA.
+	ErrMFAUnavailable = errors.New("multi-factor authentication is not configured")
+	ErrMFAEnabled     = errors.New("multi-factor authentication is already enabled")
+	ErrMFANotEnrolled = errors.New("no multi-factor enrollment to confirm")
+	// ErrInvalidMFAToken covers forged and expired tokens from the first
+	// step of a login; the user has to authenticate again.
+	ErrInvalidMFAToken = errors.New("invalid or expired MFA token")
+)
+
+var errSealedSecret = errors.New("cannot decrypt the stored MFA secret")
 
 var (
-	errInvalidName        = errors.New("name cannot be empty")
-	errInvalidEmail       = errors.New("email cannot be empty")
-	errInvalidFormatEmail = errors.New("invalid format email")
+	errInvalidName        = newValidationError("name cannot be empty")
+	errInvalidEmail       = newValidationError("email cannot be empty")
+	errInvalidFormatEmail = newValidationError("invalid format email")
+	errInvalidPageSize    = newValidationError("page size cannot be negative")
+	errInvalidPageToken   = newValidationError("invalid page token")
+	errInvalidOrderBy     = newValidationError(`order by must be empty or "name"`)
+	errIDNotAllowed       = newValidationError("user id is assigned by the server")
+	errInvalidID          = newValidationError("invalid user id")
+	errInvalidAttribute   = newValidationError("invalid attribute")
+	errInvalidStatus      = newValidationError("invalid status")
+	errReasonRequired     = newValidationError("a reason is required to suspend a user")
+	errWeakPassword       = newValidationError("password does not meet the policy")
+	errInvalidCode        = newValidationError("invalid one-time code")
 )
+
+type validationError struct {
+	msg string
+}
+
+func newValidationError(msg string) error {
+	return &validationError{msg: msg}
+}
+
+func (e *validationError) Error() string {
+	return e.msg
+}
+
+func (e *validationError) Is(target error) bool {
+	return target == ErrValidation
+}
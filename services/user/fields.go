@@ -0,0 +1,106 @@
+package user
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/names"
+	"github.com/sergey4qb/mf1-test/profile"
+)
+
+// Fields lists every user field that can be set, validated and updated, in
+// the order violations are reported.
+var Fields = []string{
+	FieldName,
+	FieldEmail,
+	FieldGivenName,
+	FieldFamilyName,
+	FieldPhone,
+	FieldLocale,
+	FieldTimeZone,
+	FieldAvatarURL,
+}
+
+// fieldRef points at field in user, or is nil for unknown fields.
+func fieldRef(user *model.User, field string) *string {
+	switch field {
+	case FieldName:
+		return &user.Name
+	case FieldEmail:
+		return &user.Email
+	case FieldGivenName:
+		return &user.GivenName
+	case FieldFamilyName:
+		return &user.FamilyName
+	case FieldPhone:
+		return &user.Phone
+	case FieldLocale:
+		return &user.Locale
+	case FieldTimeZone:
+		return &user.TimeZone
+	case FieldAvatarURL:
+		return &user.AvatarURL
+	}
+	return nil
+}
+
+// updateValue returns the new value update carries for field, or nil when the
+// field is left unchanged.
+func updateValue(update *dto.UpdateUserDTO, field string) *string {
+	switch field {
+	case FieldName:
+		return update.Name
+	case FieldEmail:
+		return update.Email
+	case FieldGivenName:
+		return update.GivenName
+	case FieldFamilyName:
+		return update.FamilyName
+	case FieldPhone:
+		return update.Phone
+	case FieldLocale:
+		return update.Locale
+	case FieldTimeZone:
+		return update.TimeZone
+	case FieldAvatarURL:
+		return update.AvatarURL
+	}
+	return nil
+}
+
+// normalize brings fields of user, or all of them when fields is empty, into
+// their stored form before validation. Null attributes are dropped.
+func (s *service) normalize(user *model.User, fields ...string) {
+	if len(fields) == 0 {
+		fields = append(slices.Clone(Fields), FieldAttributes)
+	}
+
+	for _, field := range fields {
+		switch field {
+		case FieldName:
+			normalizeName(user)
+		case FieldEmail:
+			s.normalizeEmail(user)
+		case FieldGivenName:
+			user.GivenName = names.Normalize(user.GivenName)
+		case FieldFamilyName:
+			user.FamilyName = names.Normalize(user.FamilyName)
+		case FieldPhone:
+			user.Phone = profile.NormalizePhone(user.Phone)
+		case FieldLocale:
+			user.Locale = profile.NormalizeLocale(user.Locale)
+		case FieldTimeZone:
+			user.TimeZone = strings.TrimSpace(user.TimeZone)
+		case FieldAvatarURL:
+			user.AvatarURL = strings.TrimSpace(user.AvatarURL)
+		case FieldAttributes:
+			for key, value := range user.Attributes {
+				if value == nil {
+					delete(user.Attributes, key)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package user
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+// IDStrategy decides how Create assigns user IDs.
+type IDStrategy string
+
+const (
+	// IDStrategyV4 assigns random IDs and rejects caller supplied ones.
+	IDStrategyV4 IDStrategy = "v4"
+	// IDStrategyV7 assigns time ordered IDs, so ID order is creation order
+	// and listing can page by ID instead of by offset.
+	IDStrategyV7 IDStrategy = "v7"
+	// IDStrategyProvided keeps IDs supplied by the caller, e.g. when migrating
+	// users from another system, and assigns v7 IDs otherwise.
+	IDStrategyProvided IDStrategy = "provided"
+)
+
+func ParseIDStrategy(s string) (IDStrategy, error) {
+	switch strategy := IDStrategy(s); strategy {
+	case "":
+		return IDStrategyV4, nil
+	case IDStrategyV4, IDStrategyV7, IDStrategyProvided:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("unknown user id strategy %q, expected v4, v7 or provided", s)
+}
+
+// assignID sets user.ID according to the strategy, validating an ID the
+// caller supplied. Collisions are detected by the repository on create.
+func (s IDStrategy) assignID(user *model.User) error {
+	if user.ID != uuid.Nil {
+		if s != IDStrategyProvided {
+			return errIDNotAllowed
+		}
+		return validateID(user.ID)
+	}
+
+	var err error
+	switch s {
+	case IDStrategyV7, IDStrategyProvided:
+		user.ID, err = uuid.NewV7()
+	default:
+		user.ID, err = uuid.NewRandom()
+	}
+	return err
+}
+
+// ordersByID reports whether every generated ID sorts after the previous
+// one, which lets listing use ID cursors.
+func (s IDStrategy) ordersByID() bool {
+	return s == IDStrategyV7
+}
+
+func validateID(id uuid.UUID) error {
+	if id == uuid.Max {
+		return errInvalidID
+	}
+	if id.Variant() != uuid.RFC4122 {
+		return errInvalidID
+	}
+	return nil
+}
+
+func compareIDs(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
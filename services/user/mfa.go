@@ -0,0 +1,360 @@
+package user
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/totp"
+)
+
+const (
+	// MFAChallengeTTL is how long the second step of a login may take.
+	MFAChallengeTTL = 5 * time.Minute
+	// mfaSkew is how many periods of clock drift codes may have.
+	mfaSkew = 1
+
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+	recoveryAlphabet   = "abcdefghijklmnopqrstuvwxyz234567"
+)
+
+// mfa holds the keys derived from the MFA master key; keys is nil when MFA
+// is not configured.
+type mfa struct {
+	keys   *mfaKeys
+	issuer string
+}
+
+type mfaKeys struct {
+	// secrets seals TOTP secrets at rest.
+	secrets cipher.AEAD
+	// recovery keys the hashes of recovery codes, so a leaked store alone
+	// does not allow guessing them offline.
+	recovery []byte
+	// challenge signs the tokens that carry a login from the password step
+	// to the code step.
+	challenge []byte
+}
+
+// WithMFA enables TOTP enrollment. key must be at least 32 random bytes and
+// stay the same across restarts, since it seals the stored secrets; issuer
+// labels the entry in authenticator apps.
+func WithMFA(key []byte, issuer string) Option {
+	return func(s *service) {
+		// Neither call fails for a 32 byte key.
+		block, _ := aes.NewCipher(deriveKey(key, "mfa secret encryption"))
+		aead, _ := cipher.NewGCM(block)
+		s.mfa = mfa{
+			keys: &mfaKeys{
+				secrets:   aead,
+				recovery:  deriveKey(key, "mfa recovery codes"),
+				challenge: deriveKey(key, "mfa challenge"),
+			},
+			issuer: issuer,
+		}
+	}
+}
+
+// WithClock replaces time.Now for lockouts and one-time codes.
+func WithClock(now func() time.Time) Option {
+	return func(s *service) {
+		s.credentials.now = now
+	}
+}
+
+func deriveKey(key []byte, purpose string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(purpose))
+	return h.Sum(nil)
+}
+
+// EnrollMFA starts a TOTP enrollment with a new secret. It takes effect once
+// ConfirmMFA sees a code generated from it; until then a new enrollment
+// replaces it.
+func (s *service) EnrollMFA(ctx context.Context, id uuid.UUID) (*dto.MFAEnrollment, error) {
+	keys := s.mfa.keys
+	if keys == nil {
+		return nil, ErrMFAUnavailable
+	}
+	u, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.MFA.Enabled() {
+		return nil, ErrMFAEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := keys.seal(id, secret)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.updateLogin(ctx, id, func(u *model.User) {
+		u.MFA = &model.MFA{Secret: sealed}
+	}); err != nil {
+		return nil, err
+	}
+	return &dto.MFAEnrollment{
+		Secret: totp.Encode(secret),
+		URI:    totp.URI(s.mfa.issuer, u.Email, secret),
+	}, nil
+}
+
+// ConfirmMFA enables the pending enrollment once code matches its secret
+// and returns the recovery codes, which are not shown again.
+func (s *service) ConfirmMFA(ctx context.Context, id uuid.UUID, code string) ([]string, error) {
+	keys := s.mfa.keys
+	if keys == nil {
+		return nil, ErrMFAUnavailable
+	}
+	u, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.MFA == nil {
+		return nil, ErrMFANotEnrolled
+	}
+	if u.MFA.Enabled() {
+		return nil, ErrMFAEnabled
+	}
+	secret, err := keys.open(id, u.MFA.Secret)
+	if err != nil {
+		return nil, err
+	}
+	now := s.credentials.now()
+	counter, ok := totp.Validate(secret, totp.Normalize(code), now, mfaSkew)
+	if !ok {
+		return nil, errInvalidCode
+	}
+
+	codes, hashes, err := keys.recoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.updateLogin(ctx, id, func(u *model.User) {
+		u.MFA = &model.MFA{
+			Secret:        u.MFA.Secret,
+			ConfirmedAt:   &now,
+			LastCounter:   counter,
+			RecoveryCodes: hashes,
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableMFA removes the user's enrollment, e.g. after a lost device.
+func (s *service) DisableMFA(ctx context.Context, id uuid.UUID) error {
+	_, err := s.updateLogin(ctx, id, func(u *model.User) {
+		u.MFA = nil
+	})
+	return err
+}
+
+// IssueMFAChallenge returns a token for the second step of a login, for a
+// user with MFA enabled who passed Authenticate, and when it expires.
+func (s *service) IssueMFAChallenge(ctx context.Context, id uuid.UUID) (string, time.Time, error) {
+	keys := s.mfa.keys
+	if keys == nil {
+		return "", time.Time{}, ErrMFAUnavailable
+	}
+	// The token carries whole seconds.
+	expiresAt := s.credentials.now().Add(MFAChallengeTTL).Truncate(time.Second)
+	token, err := keys.signChallenge(id, expiresAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// VerifyMFA completes a login with a TOTP code or an unused recovery code.
+// Each code works once, and wrong codes count as failed logins.
+func (s *service) VerifyMFA(ctx context.Context, challenge, code string) (*model.User, error) {
+	keys := s.mfa.keys
+	if keys == nil {
+		return nil, ErrMFAUnavailable
+	}
+	now := s.credentials.now()
+	id, ok := keys.parseChallenge(challenge, now)
+	if !ok {
+		return nil, ErrInvalidMFAToken
+	}
+	u, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	if !u.MFA.Enabled() {
+		return nil, ErrInvalidMFAToken
+	}
+	if s.locked(u) {
+		return nil, ErrAccountLocked
+	}
+
+	code = strings.ToLower(totp.Normalize(code))
+	var counter int64
+	var recovery string
+	if len(code) == totp.Digits {
+		secret, err := keys.open(id, u.MFA.Secret)
+		if err != nil {
+			return nil, err
+		}
+		counter, ok = totp.Validate(secret, code, now, mfaSkew)
+	} else {
+		recovery = keys.recoveryHash(code)
+		ok = hasRecoveryHash(u.MFA.RecoveryCodes, recovery)
+	}
+	if !ok {
+		return nil, s.loginFailed(ctx, id)
+	}
+	if userStatus(u) != model.StatusActive {
+		return nil, ErrAccountInactive
+	}
+
+	// The code is consumed under the lock, so a code raced in twice is
+	// accepted once.
+	var accepted bool
+	u, err = s.updateLogin(ctx, id, func(u *model.User) {
+		if !u.MFA.Enabled() {
+			return
+		}
+		switch {
+		case recovery != "":
+			before := len(u.MFA.RecoveryCodes)
+			u.MFA.RecoveryCodes = slices.DeleteFunc(u.MFA.RecoveryCodes, func(h string) bool { return h == recovery })
+			accepted = len(u.MFA.RecoveryCodes) < before
+		case counter > u.MFA.LastCounter:
+			u.MFA.LastCounter = counter
+			accepted = true
+		}
+		if accepted {
+			u.LoginFailures = nil
+			u.LockedUntil = nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, s.loginFailed(ctx, id)
+	}
+	return u, nil
+}
+
+// seal encrypts a TOTP secret, binding it to the user so it cannot be moved
+// to another record.
+func (k *mfaKeys) seal(id uuid.UUID, secret []byte) ([]byte, error) {
+	nonce := make([]byte, k.secrets.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return k.secrets.Seal(nonce, nonce, secret, id[:]), nil
+}
+
+func (k *mfaKeys) open(id uuid.UUID, sealed []byte) ([]byte, error) {
+	n := k.secrets.NonceSize()
+	if len(sealed) < n {
+		return nil, errSealedSecret
+	}
+	secret, err := k.secrets.Open(nil, sealed[:n], sealed[n:], id[:])
+	if err != nil {
+		return nil, errSealedSecret
+	}
+	return secret, nil
+}
+
+// recoveryCodes returns new recovery codes, formatted "xxxxx-xxxxx", and
+// their hashes.
+func (k *mfaKeys) recoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	raw := make([]byte, recoveryCodeLength)
+	for i := range codes {
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := make([]byte, recoveryCodeLength)
+		for j, b := range raw {
+			// The alphabet has 32 letters, so this is uniform.
+			code[j] = recoveryAlphabet[b%32]
+		}
+		codes[i] = string(code[:5]) + "-" + string(code[5:])
+		hashes[i] = k.recoveryHash(string(code))
+	}
+	return codes, hashes, nil
+}
+
+// recoveryHash hashes a normalized recovery code.
+func (k *mfaKeys) recoveryHash(code string) string {
+	h := hmac.New(sha256.New, k.recovery)
+	h.Write([]byte(code))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func hasRecoveryHash(hashes []string, hash string) bool {
+	found := false
+	for _, h := range hashes {
+		if hmac.Equal([]byte(h), []byte(hash)) {
+			found = true
+		}
+	}
+	return found
+}
+
+// A challenge is base64url(user ID || expiry || nonce || HMAC-SHA256) like
+// an email verification token; it is not stored.
+const (
+	challengeNonceSize   = 16
+	challengePayloadSize = 16 + 8 + challengeNonceSize
+	challengeSize        = challengePayloadSize + sha256.Size
+)
+
+func (k *mfaKeys) signChallenge(id uuid.UUID, expiresAt time.Time) (string, error) {
+	payload := make([]byte, challengePayloadSize, challengeSize)
+	copy(payload, id[:])
+	binary.BigEndian.PutUint64(payload[16:], uint64(expiresAt.Unix()))
+	if _, err := rand.Read(payload[24:]); err != nil {
+		return "", err
+	}
+	h := hmac.New(sha256.New, k.challenge)
+	h.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(h.Sum(payload)), nil
+}
+
+func (k *mfaKeys) parseChallenge(token string, now time.Time) (uuid.UUID, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != challengeSize {
+		return uuid.Nil, false
+	}
+	payload, sig := raw[:challengePayloadSize], raw[challengePayloadSize:]
+	h := hmac.New(sha256.New, k.challenge)
+	h.Write(payload)
+	if !hmac.Equal(sig, h.Sum(nil)) {
+		return uuid.Nil, false
+	}
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(payload[16:24])), 0)
+	if !now.Before(expiresAt) {
+		return uuid.Nil, false
+	}
+	var id uuid.UUID
+	copy(id[:], payload[:16])
+	return id, true
+}
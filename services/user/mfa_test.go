@@ -0,0 +1,159 @@
+package user
+
+import (
+	"context"
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/totp"
+)
+
+var testMFAKey = []byte("test-mfa-key-0123456789abcdefghij")
+
+// enrollMFA enrolls and confirms the user and returns the TOTP secret and
+// the recovery codes.
+func enrollMFA(t *testing.T, srv *service, u *model.User) ([]byte, []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	enrollment, err := srv.EnrollMFA(ctx, u.ID)
+	require.NoError(t, err)
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(enrollment.Secret)
+	require.NoError(t, err)
+
+	codes, err := srv.ConfirmMFA(ctx, u.ID, totp.Code(secret, totp.Counter(srv.credentials.now())))
+	require.NoError(t, err)
+	return secret, codes
+}
+
+func TestEnrollMFA(t *testing.T) {
+	srv, u, now := newPasswordService(t, WithMFA(testMFAKey, "Example"))
+	ctx := context.Background()
+
+	enrollment, err := srv.EnrollMFA(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/Example:jane@example.com?")
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+	stored, err := srv.GetByID(ctx, u.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.MFA)
+	assert.False(t, stored.MFA.Enabled(), "not enabled before the first code")
+	assert.NotContains(t, string(stored.MFA.Secret), enrollment.Secret, "the secret is sealed")
+
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(enrollment.Secret)
+	require.NoError(t, err)
+	_, err = srv.ConfirmMFA(ctx, u.ID, "000000")
+	assert.ErrorIs(t, err, ErrValidation)
+
+	codes, err := srv.ConfirmMFA(ctx, u.ID, totp.Code(secret, totp.Counter(*now)))
+	require.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, codes[0])
+
+	stored, err = srv.GetByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.MFA.Enabled())
+	assert.NotContains(t, stored.MFA.RecoveryCodes, codes[0], "recovery codes are hashed")
+
+	_, err = srv.EnrollMFA(ctx, u.ID)
+	assert.ErrorIs(t, err, ErrMFAEnabled)
+
+	require.NoError(t, srv.DisableMFA(ctx, u.ID))
+	_, err = srv.ConfirmMFA(ctx, u.ID, "123456")
+	assert.ErrorIs(t, err, ErrMFANotEnrolled)
+}
+
+func TestEnrollMFA_NotConfigured(t *testing.T) {
+	srv, u, _ := newPasswordService(t)
+
+	_, err := srv.EnrollMFA(context.Background(), u.ID)
+	assert.ErrorIs(t, err, ErrMFAUnavailable)
+}
+
+func TestVerifyMFA_TOTP(t *testing.T) {
+	srv, u, now := newPasswordService(t, WithMFA(testMFAKey, "Example"))
+	ctx := context.Background()
+	secret, _ := enrollMFA(t, srv, u)
+
+	challenge, expiresAt, err := srv.IssueMFAChallenge(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(MFAChallengeTTL), expiresAt)
+
+	_, err = srv.VerifyMFA(ctx, challenge, totp.Code(secret, totp.Counter(*now)))
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "the confirmation code was used up")
+
+	*now = now.Add(totp.Period)
+	code := totp.Code(secret, totp.Counter(*now))
+	got, err := srv.VerifyMFA(ctx, challenge, code)
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, got.ID)
+
+	_, err = srv.VerifyMFA(ctx, challenge, code)
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "a code works once")
+
+	*now = expiresAt
+	_, err = srv.VerifyMFA(ctx, challenge, totp.Code(secret, totp.Counter(*now)))
+	assert.ErrorIs(t, err, ErrInvalidMFAToken)
+
+	_, err = srv.VerifyMFA(ctx, "forged", "123456")
+	assert.ErrorIs(t, err, ErrInvalidMFAToken)
+}
+
+func TestVerifyMFA_RecoveryCode(t *testing.T) {
+	srv, u, _ := newPasswordService(t, WithMFA(testMFAKey, "Example"))
+	ctx := context.Background()
+	_, codes := enrollMFA(t, srv, u)
+
+	challenge, _, err := srv.IssueMFAChallenge(ctx, u.ID)
+	require.NoError(t, err)
+
+	_, err = srv.VerifyMFA(ctx, challenge, " "+codes[0][:5]+codes[0][6:]+" ")
+	require.NoError(t, err, "dashes and spaces are optional")
+
+	_, err = srv.VerifyMFA(ctx, challenge, codes[0])
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "a recovery code works once")
+
+	stored, err := srv.GetByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored.MFA.RecoveryCodes, recoveryCodeCount-1)
+}
+
+func TestVerifyMFA_Lockout(t *testing.T) {
+	srv, u, now := newPasswordService(t, WithMFA(testMFAKey, "Example"), WithLockout(Lockout{MaxFailures: 2, Window: time.Minute, Duration: time.Minute}))
+	ctx := context.Background()
+	secret, _ := enrollMFA(t, srv, u)
+
+	challenge, _, err := srv.IssueMFAChallenge(ctx, u.ID)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = srv.VerifyMFA(ctx, challenge, "000000")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	*now = now.Add(totp.Period)
+	_, err = srv.VerifyMFA(ctx, challenge, totp.Code(secret, totp.Counter(*now)))
+	assert.ErrorIs(t, err, ErrAccountLocked)
+}
+
+func TestVerifyMFA_OtherKey(t *testing.T) {
+	srv, u, _ := newPasswordService(t, WithMFA(testMFAKey, "Example"))
+	ctx := context.Background()
+	enrollMFA(t, srv, u)
+
+	other := New(srv.repo, WithMFA([]byte("another-mfa-key-0123456789abcdefgh"), "Example"), WithClock(srv.credentials.now)).(*service)
+	challenge, _, err := srv.IssueMFAChallenge(ctx, u.ID)
+	require.NoError(t, err)
+	_, err = other.VerifyMFA(ctx, challenge, "123456")
+	assert.ErrorIs(t, err, ErrInvalidMFAToken, "challenges are signed with the key")
+
+	challenge, _, err = other.IssueMFAChallenge(ctx, u.ID)
+	require.NoError(t, err)
+	_, err = other.VerifyMFA(ctx, challenge, "123456")
+	assert.ErrorIs(t, err, errSealedSecret, "secrets are sealed with the key")
+}
@@ -0,0 +1,61 @@
+package user
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// OrderByName sorts listed users by their folded name instead of creation
+// order.
+const OrderByName = "name"
+
+const (
+	maxPageSize = 1000
+
+	cursorPrefix = "after:"
+)
+
+// pageToken is where the next page starts: either an offset into the user
+// list or, when IDs are time ordered, the last ID already returned. The ID
+// cursor keeps pages stable while users are created or deleted in between.
+type pageToken struct {
+	offset int
+	after  uuid.UUID
+}
+
+// Page tokens are opaque to clients.
+func encodePageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func encodeCursorToken(after uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + after.String()))
+}
+
+func decodePageToken(token string) (pageToken, error) {
+	if token == "" {
+		return pageToken{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return pageToken{}, errInvalidPageToken
+	}
+
+	if cursor, ok := strings.CutPrefix(string(raw), cursorPrefix); ok {
+		after, err := uuid.Parse(cursor)
+		if err != nil {
+			return pageToken{}, errInvalidPageToken
+		}
+		return pageToken{after: after}, nil
+	}
+
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return pageToken{}, errInvalidPageToken
+	}
+	return pageToken{offset: offset}, nil
+}
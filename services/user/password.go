@@ -0,0 +1,242 @@
+package user
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/email"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/password"
+)
+
+// FieldPassword is the field path of password policy violations.
+const FieldPassword = "password"
+
+// Lockout refuses logins for Duration once MaxFailures logins failed within
+// Window. A zero MaxFailures disables lockouts.
+type Lockout struct {
+	MaxFailures int
+	Window      time.Duration
+	Duration    time.Duration
+}
+
+var DefaultLockout = Lockout{MaxFailures: 5, Window: 15 * time.Minute, Duration: 15 * time.Minute}
+
+// credentials holds the password settings of the service.
+type credentials struct {
+	params  password.Params
+	policy  password.Policy
+	lockout Lockout
+	now     func() time.Time
+
+	dummyOnce sync.Once
+	dummy     string
+}
+
+// WithPasswordParams sets the argon2id parameters for new hashes. Hashes made
+// with other parameters are replaced on the next successful login.
+func WithPasswordParams(params password.Params) Option {
+	return func(s *service) {
+		s.credentials.params = params
+	}
+}
+
+// WithPasswordPolicy replaces password.DefaultPolicy.
+func WithPasswordPolicy(policy password.Policy) Option {
+	return func(s *service) {
+		s.credentials.policy = policy
+	}
+}
+
+// WithLockout replaces DefaultLockout.
+func WithLockout(lockout Lockout) Option {
+	return func(s *service) {
+		s.credentials.lockout = lockout
+	}
+}
+
+// SetPassword sets a user's password without asking for the current one,
+// e.g. for a first password or an administrator's reset, and lifts any
+// lockout.
+func (s *service) SetPassword(ctx context.Context, id uuid.UUID, newPassword string) error {
+	u, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.storePassword(ctx, u, newPassword)
+}
+
+// ChangePassword replaces the password after checking the current one. A
+// wrong current password counts as a failed login.
+func (s *service) ChangePassword(ctx context.Context, id uuid.UUID, current, newPassword string) error {
+	u, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if s.locked(u) {
+		return ErrAccountLocked
+	}
+	ok, err := s.verifyPassword(u, current)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return s.loginFailed(ctx, u.ID)
+	}
+	if newPassword == current {
+		return &ValidationError{Violations: []Violation{{Field: FieldPassword, Message: "must differ from the current password", err: errWeakPassword}}}
+	}
+	return s.storePassword(ctx, u, newPassword)
+}
+
+// Authenticate returns the user with the given email if password matches.
+// Unknown emails, users without a password, locked out users and wrong
+// passwords all fail with ErrInvalidCredentials after the same amount of
+// hashing work, so neither errors nor response times tell which emails are
+// registered.
+func (s *service) Authenticate(ctx context.Context, address, pw string) (*model.User, error) {
+	u, err := s.findByEmail(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		s.verifyDummy(pw)
+		return nil, ErrInvalidCredentials
+	}
+
+	ok, err := s.verifyPassword(u, pw)
+	if err != nil {
+		return nil, err
+	}
+	if s.locked(u) {
+		return nil, ErrInvalidCredentials
+	}
+	if !ok {
+		return nil, s.loginFailed(ctx, u.ID)
+	}
+	if userStatus(u) != model.StatusActive {
+		return nil, ErrAccountInactive
+	}
+
+	var rehashed string
+	if password.NeedsRehash(u.PasswordHash, s.credentials.params) {
+		if rehashed, err = password.Hash(pw, s.credentials.params); err != nil {
+			return nil, err
+		}
+	}
+	return s.updateLogin(ctx, u.ID, func(u *model.User) {
+		u.LoginFailures = nil
+		u.LockedUntil = nil
+		if rehashed != "" {
+			u.PasswordHash = rehashed
+		}
+	})
+}
+
+func (s *service) findByEmail(ctx context.Context, address string) (*model.User, error) {
+	addr, err := email.Parse(address)
+	if err != nil {
+		return nil, nil
+	}
+	canonical := addr.Canonical(s.emails)
+
+	users, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		if users[i].EmailCanonical == canonical {
+			return &users[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *service) storePassword(ctx context.Context, u *model.User, newPassword string) error {
+	related := []string{u.Name, u.GivenName, u.FamilyName, u.Email}
+	if addr, err := email.Parse(u.Email); err == nil {
+		related = append(related, addr.Local)
+	}
+	if msgs := s.credentials.policy.Violations(newPassword, related...); len(msgs) > 0 {
+		violations := make([]Violation, 0, len(msgs))
+		for _, msg := range msgs {
+			violations = append(violations, Violation{Field: FieldPassword, Message: msg, err: errWeakPassword})
+		}
+		return &ValidationError{Violations: violations}
+	}
+
+	hash, err := password.Hash(newPassword, s.credentials.params)
+	if err != nil {
+		return err
+	}
+	_, err = s.updateLogin(ctx, u.ID, func(u *model.User) {
+		u.PasswordHash = hash
+		u.LoginFailures = nil
+		u.LockedUntil = nil
+	})
+	return err
+}
+
+// verifyPassword checks pw against the user's hash, hashing a dummy when the
+// user has no password so the work done is the same.
+func (s *service) verifyPassword(u *model.User, pw string) (bool, error) {
+	if u.PasswordHash == "" {
+		s.verifyDummy(pw)
+		return false, nil
+	}
+	return password.Verify(pw, u.PasswordHash)
+}
+
+func (s *service) verifyDummy(pw string) {
+	c := &s.credentials
+	c.dummyOnce.Do(func() {
+		c.dummy, _ = password.Hash("dummy password", c.params)
+	})
+	_, _ = password.Verify(pw, c.dummy)
+}
+
+func (s *service) locked(u *model.User) bool {
+	return u.LockedUntil != nil && s.credentials.now().Before(*u.LockedUntil)
+}
+
+// loginFailed records a failed login, locking the user out once too many
+// failures fall within the window, and returns ErrInvalidCredentials.
+func (s *service) loginFailed(ctx context.Context, id uuid.UUID) error {
+	lockout := s.credentials.lockout
+	if lockout.MaxFailures <= 0 {
+		return ErrInvalidCredentials
+	}
+
+	now := s.credentials.now()
+	_, err := s.updateLogin(ctx, id, func(u *model.User) {
+		cutoff := now.Add(-lockout.Window)
+		recent := u.LoginFailures[:0]
+		for _, at := range u.LoginFailures {
+			if at.After(cutoff) {
+				recent = append(recent, at)
+			}
+		}
+		u.LoginFailures = append(recent, now)
+		if len(u.LoginFailures) >= lockout.MaxFailures {
+			until := now.Add(lockout.Duration)
+			u.LockedUntil = &until
+			u.LoginFailures = nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return ErrInvalidCredentials
+}
+
+// updateLogin is updateUser for changes to credentials and login state,
+// which cannot fail. Serializing them keeps concurrent failures all counted.
+func (s *service) updateLogin(ctx context.Context, id uuid.UUID, change func(u *model.User)) (*model.User, error) {
+	return s.updateUser(ctx, id, func(u *model.User) error {
+		change(u)
+		return nil
+	})
+}
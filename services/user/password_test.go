@@ -0,0 +1,224 @@
+package user
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/password"
+	"github.com/sergey4qb/mf1-test/repository/user"
+)
+
+var testPasswordParams = password.Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+// newPasswordService returns a service with cheap hashing, a settable clock
+// and one user, jane@example.com.
+func newPasswordService(t *testing.T, opts ...Option) (*service, *model.User, *time.Time) {
+	t.Helper()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	opts = append([]Option{WithPasswordParams(testPasswordParams), WithClock(func() time.Time { return now })}, opts...)
+	srv := New(newRepo(t), opts...).(*service)
+
+	u := &model.User{Name: "Jane Doe", Email: "jane@example.com"}
+	require.NoError(t, srv.Create(context.Background(), u))
+	return srv, u, &now
+}
+
+func TestAuthenticate(t *testing.T) {
+	srv, u, _ := newPasswordService(t)
+	ctx := context.Background()
+
+	_, err := srv.Authenticate(ctx, "jane@example.com", "anything at all")
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "no password set yet")
+
+	require.NoError(t, srv.SetPassword(ctx, u.ID, "a long and unusual passphrase"))
+
+	got, err := srv.Authenticate(ctx, "Jane@Example.com", "a long and unusual passphrase")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, got.ID)
+
+	_, err = srv.Authenticate(ctx, "jane@example.com", "a long and unusual passphrasE")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = srv.Authenticate(ctx, "nobody@example.com", "a long and unusual passphrase")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = srv.Authenticate(ctx, "not an email", "a long and unusual passphrase")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestSetPassword_Policy(t *testing.T) {
+	srv, u, _ := newPasswordService(t)
+
+	err := srv.SetPassword(context.Background(), u.ID, "janedoe")
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.ErrorIs(t, err, errWeakPassword)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []Violation{
+		{Field: FieldPassword, Message: "must be at least 12 characters", err: errWeakPassword},
+		{Field: FieldPassword, Message: "must not contain your name or email", err: errWeakPassword},
+	}, verr.Violations)
+
+	assert.ErrorIs(t, srv.SetPassword(context.Background(), uuid.New(), "a long and unusual passphrase"), ErrNotFound)
+}
+
+func TestChangePassword(t *testing.T) {
+	srv, u, _ := newPasswordService(t)
+	ctx := context.Background()
+	require.NoError(t, srv.SetPassword(ctx, u.ID, "a long and unusual passphrase"))
+
+	err := srv.ChangePassword(ctx, u.ID, "wrong current password", "another fine passphrase")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	err = srv.ChangePassword(ctx, u.ID, "a long and unusual passphrase", "a long and unusual passphrase")
+	assert.ErrorIs(t, err, ErrValidation)
+
+	require.NoError(t, srv.ChangePassword(ctx, u.ID, "a long and unusual passphrase", "another fine passphrase"))
+	_, err = srv.Authenticate(ctx, "jane@example.com", "another fine passphrase")
+	assert.NoError(t, err)
+}
+
+func TestAuthenticate_Lockout(t *testing.T) {
+	srv, u, now := newPasswordService(t, WithLockout(Lockout{MaxFailures: 3, Window: time.Minute, Duration: 10 * time.Minute}))
+	ctx := context.Background()
+	require.NoError(t, srv.SetPassword(ctx, u.ID, "a long and unusual passphrase"))
+
+	fail := func() error {
+		_, err := srv.Authenticate(ctx, "jane@example.com", "wrong password here")
+		return err
+	}
+
+	// Failures outside the window do not add up.
+	assert.ErrorIs(t, fail(), ErrInvalidCredentials)
+	assert.ErrorIs(t, fail(), ErrInvalidCredentials)
+	*now = now.Add(2 * time.Minute)
+	assert.ErrorIs(t, fail(), ErrInvalidCredentials)
+	assert.ErrorIs(t, fail(), ErrInvalidCredentials)
+	assert.ErrorIs(t, fail(), ErrInvalidCredentials)
+
+	_, err := srv.Authenticate(ctx, "jane@example.com", "a long and unusual passphrase")
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "even the right password is refused while locked")
+	assert.NotErrorIs(t, err, ErrAccountLocked, "a lockout must not reveal that the email is registered")
+
+	*now = now.Add(10 * time.Minute)
+	_, err = srv.Authenticate(ctx, "jane@example.com", "a long and unusual passphrase")
+	require.NoError(t, err)
+
+	stored, err := srv.GetByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.LoginFailures)
+	assert.Nil(t, stored.LockedUntil)
+}
+
+func TestAuthenticate_RehashesOnParamChange(t *testing.T) {
+	srv, u, _ := newPasswordService(t)
+	ctx := context.Background()
+	require.NoError(t, srv.SetPassword(ctx, u.ID, "a long and unusual passphrase"))
+	before, err := srv.GetByID(ctx, u.ID)
+	require.NoError(t, err)
+
+	stronger := testPasswordParams
+	stronger.Iterations = 2
+	srv.credentials.params = stronger
+
+	_, err = srv.Authenticate(ctx, "jane@example.com", "a long and unusual passphrase")
+	require.NoError(t, err)
+	after, err := srv.GetByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, before.PasswordHash, after.PasswordHash)
+	assert.False(t, password.NeedsRehash(after.PasswordHash, stronger))
+}
+
+func TestAuthenticate_InactiveUser(t *testing.T) {
+	srv, u, _ := newPasswordService(t)
+	ctx := context.Background()
+	require.NoError(t, srv.SetPassword(ctx, u.ID, "a long and unusual passphrase"))
+	_, err := srv.ChangeStatus(ctx, &dto.ChangeStatusDTO{ID: u.ID, To: model.StatusSuspended, Reason: "abuse"})
+	require.NoError(t, err)
+
+	_, err = srv.Authenticate(ctx, "jane@example.com", "a long and unusual passphrase")
+	assert.ErrorIs(t, err, ErrAccountInactive)
+	_, err = srv.Authenticate(ctx, "jane@example.com", "wrong password here")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+// pausingRepo holds the first GetByID after armed until resume is closed,
+// leaving its caller between reading and writing the user.
+type pausingRepo struct {
+	user.Repository
+	armed  atomic.Bool
+	read   chan struct{}
+	resume chan struct{}
+}
+
+func (r *pausingRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	u, err := r.Repository.GetByID(ctx, id)
+	if r.armed.CompareAndSwap(true, false) {
+		close(r.read)
+		<-r.resume
+	}
+	return u, err
+}
+
+func TestChanges_KeepConcurrentCredentialChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(srv *service, id uuid.UUID) error
+	}{
+		{"Update", func(srv *service, id uuid.UUID) error {
+			name := "Janet Doe"
+			_, err := srv.Update(context.Background(), &dto.UpdateUserDTO{ID: id, Name: &name})
+			return err
+		}},
+		{"ChangeStatus", func(srv *service, id uuid.UUID) error {
+			_, err := srv.ChangeStatus(context.Background(), &dto.ChangeStatusDTO{ID: id, To: model.StatusSuspended, Reason: "review"})
+			return err
+		}},
+		{"VerifyEmail", func(srv *service, id uuid.UUID) error {
+			_, err := srv.VerifyEmail(context.Background(), id, "jane@example.com")
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, u, _ := newPasswordService(t)
+			ctx := context.Background()
+			repo := &pausingRepo{Repository: srv.repo, read: make(chan struct{}), resume: make(chan struct{})}
+			srv.repo = repo
+
+			repo.armed.Store(true)
+			changed := make(chan error)
+			go func() { changed <- tt.change(srv, u.ID) }()
+			<-repo.read
+
+			// The password changes while the other call holds its copy of
+			// the user.
+			set := make(chan error)
+			go func() { set <- srv.SetPassword(ctx, u.ID, "a long and unusual passphrase") }()
+			select {
+			case err := <-set:
+				close(repo.resume)
+				require.NoError(t, err)
+				require.NoError(t, <-changed)
+			case <-time.After(50 * time.Millisecond):
+				close(repo.resume)
+				require.NoError(t, <-changed)
+				require.NoError(t, <-set)
+			}
+
+			stored, err := srv.repo.GetByID(ctx, u.ID)
+			require.NoError(t, err)
+			ok, err := srv.verifyPassword(stored, "a long and unusual passphrase")
+			require.NoError(t, err)
+			assert.True(t, ok, "the old password hash must not be written back")
+		})
+	}
+}
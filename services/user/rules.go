@@ -0,0 +1,140 @@
+package user
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// rulesFile is the per-deployment validation config keyed by field, e.g.
+//
+//	{
+//	  "name": {"max_length": 100, "pattern": "^[\\p{L}\\p{M} .'-]+$", "pattern_message": "contains invalid characters"},
+//	  "phone": {"required": true},
+//	  "email": {
+//	    "max_length": 254,
+//	    "blocked_domains": ["competitor.com"],
+//	    "allowed_domains": ["corp.example.com"],
+//	    "disposable_domains_file": "disposable_domains.txt"
+//	  }
+//	}
+//
+// Relative file paths are resolved against the rules file's directory.
+type rulesFile map[string]fieldRules
+
+type fieldRules struct {
+	Required       bool   `json:"required"`
+	MaxLength      int    `json:"max_length"`
+	Pattern        string `json:"pattern"`
+	PatternMessage string `json:"pattern_message"`
+
+	BlockedDomains        []string `json:"blocked_domains"`
+	AllowedDomains        []string `json:"allowed_domains"`
+	DisposableDomainsFile string   `json:"disposable_domains_file"`
+}
+
+// LoadRules returns DefaultRules extended with the rules configured in the
+// file at path. An empty path yields DefaultRules.
+func LoadRules(path string) (Rules, error) {
+	rules := DefaultRules()
+	if path == "" {
+		return rules, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f rulesFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse validation rules %s: %w", path, err)
+	}
+
+	for field := range f {
+		if !slices.Contains(Fields, field) {
+			return nil, fmt.Errorf("validation rules %s: unknown field %q", path, field)
+		}
+	}
+
+	dir := filepath.Dir(path)
+	for _, field := range Fields {
+		fr, ok := f[field]
+		if !ok {
+			continue
+		}
+		extra, err := fr.build(field, dir)
+		if err != nil {
+			return nil, fmt.Errorf("validation rules for %s: %w", field, err)
+		}
+		rules = append(rules, extra...)
+	}
+
+	return rules, nil
+}
+
+func (fr fieldRules) build(field, dir string) (Rules, error) {
+	var rules Rules
+
+	if fr.Required {
+		rules = append(rules, Required(field, newValidationError(field+" is required")))
+	}
+	if fr.MaxLength > 0 {
+		rules = append(rules, MaxLength(field, fr.MaxLength))
+	}
+	if fr.Pattern != "" {
+		re, err := regexp.Compile(fr.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		msg := fr.PatternMessage
+		if msg == "" {
+			msg = "contains characters that are not allowed"
+		}
+		rules = append(rules, Pattern(field, re, msg))
+	}
+	if len(fr.BlockedDomains) > 0 {
+		rules = append(rules, BlockedDomains(field, fr.BlockedDomains))
+	}
+	if len(fr.AllowedDomains) > 0 {
+		rules = append(rules, AllowedDomains(field, fr.AllowedDomains))
+	}
+	if fr.DisposableDomainsFile != "" {
+		path := fr.DisposableDomainsFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		domains, err := readDomainList(path)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, DisposableDomains(field, domains))
+	}
+
+	return rules, nil
+}
+
+// readDomainList reads one domain per line, ignoring blank lines and
+// "#" comments.
+func readDomainList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var domains []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	return domains, scanner.Err()
+}
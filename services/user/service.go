@@ -2,9 +2,17 @@ package user
 
 import (
 	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/email"
 	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/names"
+	"github.com/sergey4qb/mf1-test/password"
 	"github.com/sergey4qb/mf1-test/repository/user"
 )
 
@@ -12,32 +20,109 @@ type User interface {
 	Create(ctx context.Context, user *model.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
 	GetAll(ctx context.Context) ([]model.User, error)
+	List(ctx context.Context, req *dto.ListUsersDTO) (*dto.UsersPage, error)
 	Update(ctx context.Context, dto *dto.UpdateUserDTO) (*model.User, error)
+	// VerifyEmail marks the email of a user verified as long as it is still
+	// the mailbox email, in canonical form, that was proven; it fails with
+	// ErrEmailChanged otherwise.
+	VerifyEmail(ctx context.Context, id uuid.UUID, email string) (*model.User, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+	ChangeStatus(ctx context.Context, req *dto.ChangeStatusDTO) (*model.User, error)
+	SetPassword(ctx context.Context, id uuid.UUID, password string) error
+	ChangePassword(ctx context.Context, id uuid.UUID, current, password string) error
+	Authenticate(ctx context.Context, email, password string) (*model.User, error)
+	EnrollMFA(ctx context.Context, id uuid.UUID) (*dto.MFAEnrollment, error)
+	ConfirmMFA(ctx context.Context, id uuid.UUID, code string) ([]string, error)
+	DisableMFA(ctx context.Context, id uuid.UUID) error
+	IssueMFAChallenge(ctx context.Context, id uuid.UUID) (string, time.Time, error)
+	VerifyMFA(ctx context.Context, challenge, code string) (*model.User, error)
 }
 
 type service struct {
-	repo user.Repository
+	repo       user.Repository
+	ids        IDStrategy
+	validator  Validator
+	attributes *AttributeSchema
+	emails     email.CanonicalOptions
+
+	credentials credentials
+	mfa         mfa
+
+	// mu serializes read-modify-write cycles of users, see updateUser.
+	mu sync.Mutex
+
+	deleteHooks []func(ctx context.Context, id uuid.UUID) error
 }
 
-func New(repo user.Repository) User {
-	return &service{repo: repo}
+type Option func(*service)
+
+// WithIDStrategy selects how new users get their IDs; the default is
+// IDStrategyV4.
+func WithIDStrategy(strategy IDStrategy) Option {
+	return func(s *service) {
+		s.ids = strategy
+	}
 }
 
-func (s *service) Create(ctx context.Context, user *model.User) error {
-	if user.Name == "" {
-		return errInvalidName
+// WithValidator replaces DefaultRules for create and update.
+func WithValidator(validator Validator) Option {
+	return func(s *service) {
+		s.validator = validator
 	}
+}
 
-	if user.Email == "" {
-		return errInvalidEmail
+// WithAttributeSchema declares the custom attributes users may carry. Without
+// it no attributes are accepted.
+func WithAttributeSchema(schema *AttributeSchema) Option {
+	return func(s *service) {
+		s.attributes = schema
 	}
+}
 
-	if !emailRegex.MatchString(user.Email) {
-		return errInvalidFormatEmail
+// WithEmailCanonicalization sets how the canonical email used for uniqueness
+// is derived.
+func WithEmailCanonicalization(opts email.CanonicalOptions) Option {
+	return func(s *service) {
+		s.emails = opts
 	}
+}
 
-	user.ID = uuid.New()
+// WithDeleteHook runs hook after a user is deleted, to clean up data that
+// refers to it, e.g. group memberships.
+func WithDeleteHook(hook func(ctx context.Context, id uuid.UUID) error) Option {
+	return func(s *service) {
+		s.deleteHooks = append(s.deleteHooks, hook)
+	}
+}
+
+func New(repo user.Repository, opts ...Option) User {
+	s := &service{repo: repo, ids: IDStrategyV4, validator: DefaultRules(), attributes: &AttributeSchema{}}
+	s.credentials.params = password.DefaultParams
+	s.credentials.policy = password.DefaultPolicy
+	s.credentials.lockout = DefaultLockout
+	s.credentials.now = time.Now
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *service) Create(ctx context.Context, user *model.User) error {
+	s.normalize(user)
+	if err := s.validate(user); err != nil {
+		return err
+	}
+	if user.Status == "" {
+		user.Status = model.StatusActive
+	}
+	if !ValidStatus(user.Status) {
+		return errInvalidStatus
+	}
+	user.StatusChange = nil
+
+	if err := s.ids.assignID(user); err != nil {
+		return err
+	}
 
 	return s.repo.Create(ctx, user)
 }
@@ -50,32 +135,236 @@ func (s *service) GetAll(ctx context.Context) ([]model.User, error) {
 	return s.repo.GetAll(ctx)
 }
 
-func (s *service) Update(ctx context.Context, dto *dto.UpdateUserDTO) (*model.User, error) {
-	existingUser, err := s.GetByID(ctx, dto.ID)
+func (s *service) List(ctx context.Context, req *dto.ListUsersDTO) (*dto.UsersPage, error) {
+	if req.PageSize < 0 {
+		return nil, errInvalidPageSize
+	}
+	if req.OrderBy != "" && req.OrderBy != OrderByName {
+		return nil, errInvalidOrderBy
+	}
+	if err := s.attributes.checkFilter(req.Attributes); err != nil {
+		return nil, err
+	}
+	for _, status := range req.Statuses {
+		if !ValidStatus(status) {
+			return nil, errInvalidStatus
+		}
+	}
+	token, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	byID := s.ids.ordersByID() && req.OrderBy == ""
+
+	users, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.NameQuery != "" {
+		query := names.SearchKey(req.NameQuery)
+		users = slices.DeleteFunc(users, func(u model.User) bool {
+			return !strings.Contains(nameKey(&u), query)
+		})
+	}
+	if len(req.Statuses) > 0 {
+		users = slices.DeleteFunc(users, func(u model.User) bool {
+			return !slices.Contains(req.Statuses, userStatus(&u))
+		})
+	}
+	if len(req.Attributes) > 0 {
+		users = slices.DeleteFunc(users, func(u model.User) bool {
+			for key, want := range req.Attributes {
+				if !matchesAttribute(u.Attributes[key], want) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+	switch {
+	case req.OrderBy == OrderByName:
+		slices.SortStableFunc(users, func(a, b model.User) int {
+			if c := strings.Compare(nameKey(&a), nameKey(&b)); c != 0 {
+				return c
+			}
+			return compareIDs(a.ID, b.ID)
+		})
+	case byID:
+		slices.SortStableFunc(users, func(a, b model.User) int {
+			return compareIDs(a.ID, b.ID)
+		})
+	}
+
+	start, err := pageStart(users, token, byID)
 	if err != nil {
 		return nil, err
 	}
-	if dto.Name != nil {
-		if *dto.Name == "" {
-			return nil, errInvalidName
+
+	end := len(users)
+	if req.PageSize > 0 {
+		end = min(start+min(req.PageSize, maxPageSize), len(users))
+	}
+
+	page := &dto.UsersPage{Users: users[start:end]}
+	if end < len(users) {
+		if byID {
+			page.NextPageToken = encodeCursorToken(users[end-1].ID)
+		} else {
+			page.NextPageToken = encodePageToken(end)
 		}
-		existingUser.Name = *dto.Name
 	}
-	if dto.Email != nil {
-		if *dto.Email == "" {
-			return nil, errInvalidEmail
+
+	return page, nil
+}
+
+// pageStart resolves token against users, which are sorted by ID when byID
+// is set.
+func pageStart(users []model.User, token pageToken, byID bool) (int, error) {
+	if token.after == uuid.Nil {
+		if token.offset > len(users) {
+			return 0, errInvalidPageToken
+		}
+		return token.offset, nil
+	}
+
+	if byID {
+		start, _ := slices.BinarySearchFunc(users, token.after, func(u model.User, id uuid.UUID) int {
+			return compareIDs(u.ID, id)
+		})
+		for start < len(users) && users[start].ID == token.after {
+			start++
+		}
+		return start, nil
+	}
+
+	for i, u := range users {
+		if u.ID == token.after {
+			return i + 1, nil
+		}
+	}
+	return 0, errInvalidPageToken
+}
+
+func (s *service) Update(ctx context.Context, dto *dto.UpdateUserDTO) (*model.User, error) {
+	return s.updateUser(ctx, dto.ID, func(existingUser *model.User) error {
+		previousEmail := existingUser.EmailCanonical
+		var changed []string
+		for _, field := range Fields {
+			if value := updateValue(dto, field); value != nil {
+				*fieldRef(existingUser, field) = *value
+				changed = append(changed, field)
+			}
+		}
+		changed = append(changed, applyAttributes(existingUser, dto)...)
+		if len(changed) > 0 {
+			s.normalize(existingUser, changed...)
+			if err := s.validate(existingUser, changed...); err != nil {
+				return err
+			}
 		}
-		if !emailRegex.MatchString(*dto.Email) {
-			return nil, errInvalidFormatEmail
+		// A new mailbox has to be verified again; a change in case or
+		// display form of the same address keeps the flag.
+		if existingUser.EmailCanonical != previousEmail {
+			existingUser.EmailVerified = false
+		}
+		return nil
+	})
+}
+
+func (s *service) VerifyEmail(ctx context.Context, id uuid.UUID, email string) (*model.User, error) {
+	return s.updateUser(ctx, id, func(u *model.User) error {
+		if u.EmailCanonical != email {
+			return ErrEmailChanged
 		}
-		existingUser.Email = *dto.Email
+		u.EmailVerified = true
+		return nil
+	})
+}
+
+// updateUser applies change to the stored user and saves it, unless change
+// fails. Every read-modify-write of a user goes through it: the cycles are
+// serialized, so none writes back a stale copy of fields, such as the
+// password hash or the lockout, that another one has just changed.
+func (s *service) updateUser(ctx context.Context, id uuid.UUID, change func(u *model.User) error) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	if err := s.repo.Update(ctx, existingUser); err != nil {
+	if err := change(u); err != nil {
 		return nil, err
 	}
-	return existingUser, nil
+	if err := s.repo.Update(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *service) validate(user *model.User, fields ...string) error {
+	return Chain(s.validator, s.attributes).Validate(user, fields...)
+}
+
+// applyAttributes merges or replaces the attributes of user as update asks
+// and returns the changed field paths.
+func applyAttributes(user *model.User, update *dto.UpdateUserDTO) []string {
+	if update.ReplaceAttributes {
+		user.Attributes = update.Attributes
+		return []string{FieldAttributes}
+	}
+
+	var changed []string
+	for key, value := range update.Attributes {
+		if value == nil {
+			delete(user.Attributes, key)
+		} else {
+			if user.Attributes == nil {
+				user.Attributes = map[string]any{}
+			}
+			user.Attributes[key] = value
+		}
+		changed = append(changed, FieldAttributes+"."+key)
+	}
+	return changed
+}
+
+// normalizeName stores the name in NFC with whitespace collapsed, together
+// with its search key.
+func normalizeName(user *model.User) {
+	user.Name = names.Normalize(user.Name)
+	user.NameKey = names.SearchKey(user.Name)
+}
+
+// nameKey returns the stored search key, deriving it for records written
+// before keys were stored.
+func nameKey(user *model.User) string {
+	if user.NameKey != "" {
+		return user.NameKey
+	}
+	return names.SearchKey(user.Name)
+}
+
+// normalizeEmail stores the display and canonical forms of the user's email.
+// Addresses that do not parse are left for the validator to report.
+func (s *service) normalizeEmail(user *model.User) {
+	addr, err := email.Parse(user.Email)
+	if err != nil {
+		user.EmailCanonical = ""
+		return
+	}
+	user.Email = addr.String()
+	user.EmailCanonical = addr.Canonical(s.emails)
 }
 
 func (s *service) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	for _, hook := range s.deleteHooks {
+		if err := hook(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
 }
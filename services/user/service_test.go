@@ -2,95 +2,63 @@ package user
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/email"
 	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/user"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
 
-type mockRepo struct {
-	users     []model.User
-	createErr error
-}
-
-func (r *mockRepo) Create(ctx context.Context, user *model.User) error {
-	if r.createErr != nil {
-		return r.createErr
-	}
-	r.users = append(r.users, *user)
-	return nil
-}
+// newRepo returns the in-memory repository, which passes the same
+// conformance suite as the file backend.
+func newRepo(t *testing.T, users ...model.User) user.Repository {
+	t.Helper()
 
-func (r *mockRepo) GetAll(ctx context.Context) ([]model.User, error) {
-	return r.users, nil
-}
-
-func (r *mockRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
-	for i := range r.users {
-		if r.users[i].ID == id {
-			return &r.users[i], nil
-		}
+	repo := user.NewMemory()
+	for i := range users {
+		assert.NoError(t, repo.Create(context.Background(), &users[i]))
 	}
-	return nil, errors.New("user not found")
-}
-
-func (r *mockRepo) Update(ctx context.Context, user *model.User) error {
-	for i, u := range r.users {
-		if u.ID == user.ID {
-			r.users[i] = *user
-			return nil
-		}
-	}
-	return errors.New("user not found")
-}
-
-func (r *mockRepo) Delete(ctx context.Context, id uuid.UUID) error {
-	for i, u := range r.users {
-		if u.ID == id {
-			r.users = append(r.users[:i], r.users[i+1:]...)
-			return nil
-		}
-	}
-	return errors.New("user not found")
+	return repo
 }
 
 func TestCreate_InvalidName(t *testing.T) {
-	repo := &mockRepo{}
+	repo := newRepo(t)
 	srv := New(repo)
 	u := &model.User{
 		Name:  "",
 		Email: "test@example.com",
 	}
 	err := srv.Create(context.Background(), u)
-	assert.Equal(t, errInvalidName, err, "empty name should return error")
+	assert.ErrorIs(t, err, errInvalidName, "empty name should return error")
 }
 
 func TestCreate_InvalidEmail_Empty(t *testing.T) {
-	repo := &mockRepo{}
+	repo := newRepo(t)
 	srv := New(repo)
 	u := &model.User{
 		Name:  "Test",
 		Email: "",
 	}
 	err := srv.Create(context.Background(), u)
-	assert.Equal(t, errInvalidEmail, err, "empty email should return error")
+	assert.ErrorIs(t, err, errInvalidEmail, "empty email should return error")
 }
 
 func TestCreate_InvalidEmail_Format(t *testing.T) {
-	repo := &mockRepo{}
+	repo := newRepo(t)
 	srv := New(repo)
 	u := &model.User{
 		Name:  "Test",
 		Email: "invalid-email",
 	}
 	err := srv.Create(context.Background(), u)
-	assert.Equal(t, errInvalidFormatEmail, err, "invalid email should return error")
+	assert.ErrorIs(t, err, errInvalidFormatEmail, "invalid email should return error")
 }
 
 func TestCreate_Success(t *testing.T) {
-	repo := &mockRepo{}
+	repo := newRepo(t)
 	srv := New(repo)
 	u := &model.User{
 		Name:  "Test",
@@ -107,7 +75,6 @@ func TestCreate_Success(t *testing.T) {
 }
 
 func TestGetAll(t *testing.T) {
-	repo := &mockRepo{}
 	user1 := model.User{
 		ID:    uuid.New(),
 		Name:  "User1",
@@ -118,7 +85,7 @@ func TestGetAll(t *testing.T) {
 		Name:  "User2",
 		Email: "user2@example.com",
 	}
-	repo.users = []model.User{user1, user2}
+	repo := newRepo(t, user1, user2)
 
 	srv := New(repo)
 	users, err := srv.GetAll(context.Background())
@@ -129,13 +96,12 @@ func TestGetAll(t *testing.T) {
 }
 
 func TestGetByID_Success(t *testing.T) {
-	repo := &mockRepo{}
 	u := model.User{
 		ID:    uuid.New(),
 		Name:  "Test User",
 		Email: "test@example.com",
 	}
-	repo.users = append(repo.users, u)
+	repo := newRepo(t, u)
 
 	srv := New(repo)
 	userFromService, err := srv.GetByID(context.Background(), u.ID)
@@ -146,13 +112,12 @@ func TestGetByID_Success(t *testing.T) {
 }
 
 func TestUpdate_Success(t *testing.T) {
-	repo := &mockRepo{}
 	u := model.User{
 		ID:    uuid.New(),
 		Name:  "Old Name",
 		Email: "old@example.com",
 	}
-	repo.users = append(repo.users, u)
+	repo := newRepo(t, u)
 
 	srv := New(repo)
 	newName := "New Name"
@@ -175,13 +140,12 @@ func TestUpdate_Success(t *testing.T) {
 }
 
 func TestDelete_Success(t *testing.T) {
-	repo := &mockRepo{}
 	u := model.User{
 		ID:    uuid.New(),
 		Name:  "Test User",
 		Email: "test@example.com",
 	}
-	repo.users = append(repo.users, u)
+	repo := newRepo(t, u)
 
 	srv := New(repo)
 	err := srv.Delete(context.Background(), u.ID)
@@ -190,3 +154,256 @@ func TestDelete_Success(t *testing.T) {
 	_, err = srv.GetByID(context.Background(), u.ID)
 	assert.Error(t, err)
 }
+
+func TestDelete_RunsHooks(t *testing.T) {
+	u := model.User{
+		ID:    uuid.New(),
+		Name:  "Test User",
+		Email: "test@example.com",
+	}
+	var deleted []uuid.UUID
+	srv := New(newRepo(t, u), WithDeleteHook(func(ctx context.Context, id uuid.UUID) error {
+		deleted = append(deleted, id)
+		return nil
+	}))
+
+	assert.ErrorIs(t, srv.Delete(context.Background(), uuid.New()), ErrNotFound)
+	assert.Empty(t, deleted, "hooks only run for deleted users")
+
+	assert.NoError(t, srv.Delete(context.Background(), u.ID))
+	assert.Equal(t, []uuid.UUID{u.ID}, deleted)
+}
+
+func TestList_Pagination(t *testing.T) {
+	var users []model.User
+	for i := 0; i < 5; i++ {
+		users = append(users, model.User{ID: uuid.New(), Name: "User", Email: fmt.Sprintf("user%d@example.com", i)})
+	}
+	srv := New(newRepo(t, users...))
+
+	var seen []uuid.UUID
+	token := ""
+	for {
+		page, err := srv.List(context.Background(), &dto.ListUsersDTO{PageSize: 2, PageToken: token})
+		assert.NoError(t, err)
+		for _, u := range page.Users {
+			seen = append(seen, u.ID)
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		token = page.NextPageToken
+	}
+
+	assert.Len(t, seen, 5)
+	assert.Equal(t, users[4].ID, seen[4])
+}
+
+func TestList_InvalidPageToken(t *testing.T) {
+	srv := New(newRepo(t))
+	_, err := srv.List(context.Background(), &dto.ListUsersDTO{PageToken: "!!"})
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestCreate_IDStrategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy IDStrategy
+		version  uuid.Version
+	}{
+		{name: "v4", strategy: IDStrategyV4, version: 4},
+		{name: "v7", strategy: IDStrategyV7, version: 7},
+		{name: "provided without id", strategy: IDStrategyProvided, version: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := New(newRepo(t), WithIDStrategy(tt.strategy))
+			u := &model.User{Name: "Test", Email: "test@example.com"}
+			assert.NoError(t, srv.Create(context.Background(), u))
+			assert.Equal(t, tt.version, u.ID.Version())
+		})
+	}
+}
+
+func TestCreate_ProvidedID(t *testing.T) {
+	legacyID := uuid.MustParse("3f1c2a9e-5b7d-4c1e-9a2b-6d8e0f1a2b3c")
+
+	srv := New(newRepo(t), WithIDStrategy(IDStrategyV4))
+	err := srv.Create(context.Background(), &model.User{ID: legacyID, Name: "Test", Email: "test@example.com"})
+	assert.ErrorIs(t, err, errIDNotAllowed)
+
+	srv = New(newRepo(t), WithIDStrategy(IDStrategyProvided))
+	u := &model.User{ID: legacyID, Name: "Test", Email: "test@example.com"}
+	assert.NoError(t, srv.Create(context.Background(), u))
+	assert.Equal(t, legacyID, u.ID)
+
+	err = srv.Create(context.Background(), &model.User{ID: legacyID, Name: "Dup", Email: "dup@example.com"})
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+
+	err = srv.Create(context.Background(), &model.User{ID: uuid.Max, Name: "Max", Email: "max@example.com"})
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestList_CursorPaginationWithV7(t *testing.T) {
+	srv := New(newRepo(t), WithIDStrategy(IDStrategyV7))
+	var created []uuid.UUID
+	for i := 0; i < 6; i++ {
+		u := &model.User{Name: "User", Email: fmt.Sprintf("user%d@example.com", i)}
+		assert.NoError(t, srv.Create(context.Background(), u))
+		created = append(created, u.ID)
+	}
+
+	page, err := srv.List(context.Background(), &dto.ListUsersDTO{PageSize: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, created[:2], []uuid.UUID{page.Users[0].ID, page.Users[1].ID})
+
+	// Deleting an already returned user must not shift the next page.
+	assert.NoError(t, srv.Delete(context.Background(), created[1]))
+
+	page, err = srv.List(context.Background(), &dto.ListUsersDTO{PageSize: 2, PageToken: page.NextPageToken})
+	assert.NoError(t, err)
+	assert.Equal(t, created[2:4], []uuid.UUID{page.Users[0].ID, page.Users[1].ID})
+
+	page, err = srv.List(context.Background(), &dto.ListUsersDTO{PageSize: 2, PageToken: page.NextPageToken})
+	assert.NoError(t, err)
+	assert.Equal(t, created[4:6], []uuid.UUID{page.Users[0].ID, page.Users[1].ID})
+	assert.Empty(t, page.NextPageToken)
+}
+
+func TestCreate_NormalizesEmail(t *testing.T) {
+	srv := New(newRepo(t))
+	u := &model.User{Name: "Test", Email: " Jane.Doe@Bücher.DE "}
+	assert.NoError(t, srv.Create(context.Background(), u))
+	assert.Equal(t, "Jane.Doe@bücher.de", u.Email)
+	assert.Equal(t, "jane.doe@xn--bcher-kva.de", u.EmailCanonical)
+
+	err := srv.Create(context.Background(), &model.User{Name: "Dup", Email: "JANE.DOE@xn--bcher-kva.de"})
+	assert.ErrorIs(t, err, ErrEmailTaken)
+}
+
+func TestEmailUniqueness_FoldGmail(t *testing.T) {
+	srv := New(newRepo(t), WithEmailCanonicalization(email.CanonicalOptions{FoldGmail: true}))
+	assert.NoError(t, srv.Create(context.Background(), &model.User{Name: "Jane", Email: "jane.doe@gmail.com"}))
+
+	err := srv.Create(context.Background(), &model.User{Name: "Jane", Email: "janedoe+news@googlemail.com"})
+	assert.ErrorIs(t, err, ErrEmailTaken)
+
+	other := &model.User{Name: "John", Email: "john@gmail.com"}
+	assert.NoError(t, srv.Create(context.Background(), other))
+	taken := "Jane.Doe@gmail.com"
+	_, err = srv.Update(context.Background(), &dto.UpdateUserDTO{ID: other.ID, Email: &taken})
+	assert.ErrorIs(t, err, ErrEmailTaken)
+}
+
+func TestCreate_NormalizesName(t *testing.T) {
+	srv := New(newRepo(t))
+	u := &model.User{Name: "  José \t García ", Email: "jose@example.com"}
+	assert.NoError(t, srv.Create(context.Background(), u))
+	assert.Equal(t, "José García", u.Name)
+	assert.Equal(t, "jose garcia", u.NameKey)
+
+	err := srv.Create(context.Background(), &model.User{Name: " \t ", Email: "blank@example.com"})
+	assert.ErrorIs(t, err, errInvalidName)
+
+	err = srv.Create(context.Background(), &model.User{Name: "Bad\x07Name", Email: "bell@example.com"})
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestList_NameQueryAndOrder(t *testing.T) {
+	srv := New(newRepo(t))
+	for i, name := range []string{"Zoë Adams", "Ángel Ruiz", "zoe baker", "Bob"} {
+		u := &model.User{Name: name, Email: fmt.Sprintf("user%d@example.com", i)}
+		assert.NoError(t, srv.Create(context.Background(), u))
+	}
+
+	page, err := srv.List(context.Background(), &dto.ListUsersDTO{NameQuery: "ZOE"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Zoë Adams", "zoe baker"}, userNames(page.Users))
+
+	page, err = srv.List(context.Background(), &dto.ListUsersDTO{OrderBy: OrderByName, PageSize: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Ángel Ruiz", "Bob", "Zoë Adams"}, userNames(page.Users))
+
+	page, err = srv.List(context.Background(), &dto.ListUsersDTO{OrderBy: OrderByName, PageSize: 3, PageToken: page.NextPageToken})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"zoe baker"}, userNames(page.Users))
+
+	_, err = srv.List(context.Background(), &dto.ListUsersDTO{OrderBy: "email"})
+	assert.ErrorIs(t, err, errInvalidOrderBy)
+}
+
+func userNames(users []model.User) []string {
+	var out []string
+	for _, u := range users {
+		out = append(out, u.Name)
+	}
+	return out
+}
+
+func TestCreate_ProfileFields(t *testing.T) {
+	srv := New(newRepo(t))
+	u := &model.User{
+		Name:       "Jane Doe",
+		Email:      "jane@example.com",
+		GivenName:  " Jane ",
+		FamilyName: "Doe",
+		Phone:      "+1 (415) 555-0100",
+		Locale:     "en_us",
+		TimeZone:   "America/Los_Angeles",
+		AvatarURL:  "https://cdn.example.com/jane.png",
+	}
+	assert.NoError(t, srv.Create(context.Background(), u))
+	assert.Equal(t, "Jane", u.GivenName)
+	assert.Equal(t, "+14155550100", u.Phone)
+	assert.Equal(t, "en-US", u.Locale)
+
+	err := srv.Create(context.Background(), &model.User{
+		Name:      "Bad",
+		Email:     "bad@example.com",
+		Phone:     "555-0100",
+		Locale:    "not a locale",
+		TimeZone:  "Mars/Olympus",
+		AvatarURL: "javascript:alert(1)",
+	})
+	var verr *ValidationError
+	assert.ErrorAs(t, err, &verr)
+	var fields []string
+	for _, v := range verr.Violations {
+		fields = append(fields, v.Field)
+	}
+	assert.ElementsMatch(t, []string{FieldPhone, FieldLocale, FieldTimeZone, FieldAvatarURL}, fields)
+}
+
+func TestUpdate_ClearsProfileFields(t *testing.T) {
+	srv := New(newRepo(t))
+	u := &model.User{Name: "Jane", Email: "jane@example.com", Phone: "+14155550100", TimeZone: "UTC"}
+	assert.NoError(t, srv.Create(context.Background(), u))
+
+	empty, tz := "", "Europe/Kyiv"
+	updated, err := srv.Update(context.Background(), &dto.UpdateUserDTO{ID: u.ID, Phone: &empty, TimeZone: &tz})
+	assert.NoError(t, err)
+	assert.Empty(t, updated.Phone)
+	assert.Equal(t, "Europe/Kyiv", updated.TimeZone)
+	assert.Equal(t, "Jane", updated.Name)
+}
+
+func TestUpdate_EmailChangeResetsVerification(t *testing.T) {
+	u := model.User{ID: uuid.New(), Name: "Jane", Email: "jane@example.com", EmailCanonical: "jane@example.com", EmailVerified: true}
+	srv := New(newRepo(t, u))
+
+	sameMailbox := "Jane@Example.com"
+	updated, err := srv.Update(context.Background(), &dto.UpdateUserDTO{ID: u.ID, Email: &sameMailbox})
+	assert.NoError(t, err)
+	assert.True(t, updated.EmailVerified)
+
+	name := "Jane Doe"
+	updated, err = srv.Update(context.Background(), &dto.UpdateUserDTO{ID: u.ID, Name: &name})
+	assert.NoError(t, err)
+	assert.True(t, updated.EmailVerified)
+
+	newEmail := "jane.doe@example.com"
+	updated, err = srv.Update(context.Background(), &dto.UpdateUserDTO{ID: u.ID, Email: &newEmail})
+	assert.NoError(t, err)
+	assert.False(t, updated.EmailVerified)
+}
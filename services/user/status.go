@@ -0,0 +1,74 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sergey4qb/mf1-test/actor"
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+// transitions lists the statuses each status may move to. Disabled is final:
+// a disabled account is kept for its history but never comes back.
+var transitions = map[model.UserStatus][]model.UserStatus{
+	model.StatusPending:   {model.StatusActive, model.StatusDisabled},
+	model.StatusActive:    {model.StatusSuspended, model.StatusDisabled},
+	model.StatusSuspended: {model.StatusActive, model.StatusDisabled},
+	model.StatusDisabled:  nil,
+}
+
+// ValidStatus reports whether status is one of the known statuses.
+func ValidStatus(status model.UserStatus) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// CanTransition reports whether a user may move from one status to another.
+func CanTransition(from, to model.UserStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// userStatus returns the status of user, treating records written before
+// statuses existed as active.
+func userStatus(user *model.User) model.UserStatus {
+	if user.Status == "" {
+		return model.StatusActive
+	}
+	return user.Status
+}
+
+// ChangeStatus moves a user to req.To, recording the reason and the actor
+// from ctx. Suspending requires a reason.
+func (s *service) ChangeStatus(ctx context.Context, req *dto.ChangeStatusDTO) (*model.User, error) {
+	if !ValidStatus(req.To) {
+		return nil, errInvalidStatus
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if req.To == model.StatusSuspended && reason == "" {
+		return nil, errReasonRequired
+	}
+
+	return s.updateUser(ctx, req.ID, func(existingUser *model.User) error {
+		from := userStatus(existingUser)
+		if !CanTransition(from, req.To) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, from, req.To)
+		}
+
+		existingUser.Status = req.To
+		existingUser.StatusChange = &model.StatusChange{
+			From:   from,
+			Reason: reason,
+			Actor:  actor.FromContext(ctx),
+			At:     time.Now().UTC(),
+		}
+		return nil
+	})
+}
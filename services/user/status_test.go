@@ -0,0 +1,114 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/actor"
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to model.UserStatus
+		want     bool
+	}{
+		{model.StatusPending, model.StatusActive, true},
+		{model.StatusPending, model.StatusSuspended, false},
+		{model.StatusActive, model.StatusSuspended, true},
+		{model.StatusActive, model.StatusActive, false},
+		{model.StatusSuspended, model.StatusActive, true},
+		{model.StatusSuspended, model.StatusDisabled, true},
+		{model.StatusDisabled, model.StatusActive, false},
+		{"unknown", model.StatusActive, false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s to %s", tt.from, tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.want, CanTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestCreate_DefaultsToActive(t *testing.T) {
+	srv := New(newRepo(t))
+	u := &model.User{Name: "Jane", Email: "jane@example.com"}
+	require.NoError(t, srv.Create(context.Background(), u))
+	assert.Equal(t, model.StatusActive, u.Status)
+
+	bad := &model.User{Name: "Joe", Email: "joe@example.com", Status: "banned"}
+	assert.ErrorIs(t, srv.Create(context.Background(), bad), errInvalidStatus)
+}
+
+func TestChangeStatus_RecordsReasonAndActor(t *testing.T) {
+	srv := New(newRepo(t))
+	u := &model.User{Name: "Jane", Email: "jane@example.com"}
+	require.NoError(t, srv.Create(context.Background(), u))
+
+	ctx := actor.NewContext(context.Background(), "alice")
+	suspended, err := srv.ChangeStatus(ctx, &dto.ChangeStatusDTO{ID: u.ID, To: model.StatusSuspended, Reason: " spam "})
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusSuspended, suspended.Status)
+	require.NotNil(t, suspended.StatusChange)
+	assert.Equal(t, model.StatusActive, suspended.StatusChange.From)
+	assert.Equal(t, "spam", suspended.StatusChange.Reason)
+	assert.Equal(t, "alice", suspended.StatusChange.Actor)
+	assert.False(t, suspended.StatusChange.At.IsZero())
+
+	stored, err := srv.GetByID(context.Background(), u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, suspended, stored)
+
+	reactivated, err := srv.ChangeStatus(context.Background(), &dto.ChangeStatusDTO{ID: u.ID, To: model.StatusActive})
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusActive, reactivated.Status)
+	assert.Equal(t, actor.Unknown, reactivated.StatusChange.Actor)
+}
+
+func TestChangeStatus_Rejected(t *testing.T) {
+	srv := New(newRepo(t))
+	u := &model.User{Name: "Jane", Email: "jane@example.com"}
+	require.NoError(t, srv.Create(context.Background(), u))
+	ctx := context.Background()
+
+	_, err := srv.ChangeStatus(ctx, &dto.ChangeStatusDTO{ID: u.ID, To: model.StatusSuspended})
+	assert.ErrorIs(t, err, errReasonRequired)
+
+	_, err = srv.ChangeStatus(ctx, &dto.ChangeStatusDTO{ID: u.ID, To: "banned"})
+	assert.ErrorIs(t, err, errInvalidStatus)
+
+	_, err = srv.ChangeStatus(ctx, &dto.ChangeStatusDTO{ID: u.ID, To: model.StatusActive})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	_, err = srv.ChangeStatus(ctx, &dto.ChangeStatusDTO{ID: u.ID, To: model.StatusDisabled})
+	require.NoError(t, err)
+	_, err = srv.ChangeStatus(ctx, &dto.ChangeStatusDTO{ID: u.ID, To: model.StatusActive})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+}
+
+func TestList_StatusFilter(t *testing.T) {
+	srv := New(newRepo(t,
+		model.User{ID: uuid.New(), Name: "Legacy", Email: "legacy@example.com"},
+		model.User{ID: uuid.New(), Name: "Held", Email: "held@example.com", Status: model.StatusSuspended},
+		model.User{ID: uuid.New(), Name: "Gone", Email: "gone@example.com", Status: model.StatusDisabled},
+	))
+
+	page, err := srv.List(context.Background(), &dto.ListUsersDTO{Statuses: []model.UserStatus{model.StatusActive}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Legacy"}, userNames(page.Users))
+
+	page, err = srv.List(context.Background(), &dto.ListUsersDTO{
+		Statuses: []model.UserStatus{model.StatusSuspended, model.StatusDisabled},
+		OrderBy:  OrderByName,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Gone", "Held"}, userNames(page.Users))
+
+	_, err = srv.List(context.Background(), &dto.ListUsersDTO{Statuses: []model.UserStatus{"banned"}})
+	assert.ErrorIs(t, err, errInvalidStatus)
+}
@@ -1,5 +1,301 @@
 package user
 
-import "regexp"
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
 
-var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
+	"github.com/sergey4qb/mf1-test/email"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/names"
+	"github.com/sergey4qb/mf1-test/profile"
+)
+
+// Field paths used in violations.
+const (
+	FieldName       = "name"
+	FieldEmail      = "email"
+	FieldGivenName  = "given_name"
+	FieldFamilyName = "family_name"
+	FieldPhone      = "phone"
+	FieldLocale     = "locale"
+	FieldTimeZone   = "time_zone"
+	FieldAvatarURL  = "avatar_url"
+)
+
+// Validator checks users before they are stored. Implementations return a
+// *ValidationError listing every violation, or nil.
+type Validator interface {
+	// Validate checks user. When fields is not empty only those fields are
+	// checked, which lets updates leave untouched legacy values alone.
+	Validate(user *model.User, fields ...string) error
+}
+
+// Violation is one failed rule on one field.
+type Violation struct {
+	Field   string
+	Message string
+
+	// err is the sentinel callers may match with errors.Is.
+	err error
+}
+
+// ValidationError carries all violations found in one validation pass.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.Field+": "+v.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	if target == ErrValidation {
+		return true
+	}
+	for _, v := range e.Violations {
+		if v.err == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Rule checks a single field value and returns an error describing the
+// violation, or nil.
+type Rule struct {
+	Field string
+	Check func(value string) error
+}
+
+// Rules is a Validator running every rule in order. After the first failing
+// rule for a field the remaining rules for that field are skipped, so an empty
+// email is not also reported as malformed.
+type Rules []Rule
+
+func (rs Rules) Validate(user *model.User, fields ...string) error {
+	var violations []Violation
+	failed := map[string]bool{}
+
+	for _, r := range rs {
+		if failed[r.Field] || (len(fields) > 0 && !slices.Contains(fields, r.Field)) {
+			continue
+		}
+		if err := r.Check(fieldValue(user, r.Field)); err != nil {
+			failed[r.Field] = true
+			violations = append(violations, Violation{Field: r.Field, Message: err.Error(), err: err})
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: violations}
+}
+
+// Chain runs several validators and merges their violations.
+func Chain(validators ...Validator) Validator {
+	return chain(validators)
+}
+
+type chain []Validator
+
+func (c chain) Validate(user *model.User, fields ...string) error {
+	merged := &ValidationError{}
+	for _, v := range c {
+		err := v.Validate(user, fields...)
+		if err == nil {
+			continue
+		}
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			return err
+		}
+		merged.Violations = append(merged.Violations, verr.Violations...)
+	}
+
+	if len(merged.Violations) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// DefaultRules are the checks applied when no rules file is configured.
+func DefaultRules() Rules {
+	return Rules{
+		Required(FieldName, errInvalidName),
+		NameFormat(FieldName),
+		Required(FieldEmail, errInvalidEmail),
+		EmailFormat(FieldEmail),
+		NameFormat(FieldGivenName),
+		NameFormat(FieldFamilyName),
+		E164(FieldPhone),
+		LanguageTag(FieldLocale),
+		TimeZoneName(FieldTimeZone),
+		HTTPURL(FieldAvatarURL),
+	}
+}
+
+// Required rejects empty and whitespace-only values with err.
+func Required(field string, err error) Rule {
+	return Rule{Field: field, Check: func(value string) error {
+		if strings.TrimSpace(value) == "" {
+			return err
+		}
+		return nil
+	}}
+}
+
+// MaxLength limits value to n user-perceived characters (grapheme clusters).
+func MaxLength(field string, n int) Rule {
+	return Rule{Field: field, Check: func(value string) error {
+		if names.Length(value) > n {
+			return newValidationError(fmt.Sprintf("must be at most %d characters", n))
+		}
+		return nil
+	}}
+}
+
+// Pattern requires the whole value to match re; message explains the rule
+// to the caller.
+func Pattern(field string, re *regexp.Regexp, message string) Rule {
+	return Rule{Field: field, Check: func(value string) error {
+		if !re.MatchString(value) {
+			return newValidationError(message)
+		}
+		return nil
+	}}
+}
+
+// NameFormat rejects control characters and names longer than
+// names.MaxLength.
+func NameFormat(field string) Rule {
+	return Rule{Field: field, Check: func(value string) error {
+		if err := names.Validate(value); err != nil {
+			return newValidationError(err.Error())
+		}
+		return nil
+	}}
+}
+
+// E164 requires an E.164 phone number when the field is set.
+func E164(field string) Rule {
+	return optional(field, profile.ValidatePhone)
+}
+
+// LanguageTag requires a BCP 47 language tag when the field is set.
+func LanguageTag(field string) Rule {
+	return optional(field, profile.ValidateLocale)
+}
+
+// TimeZoneName requires an IANA time zone name when the field is set.
+func TimeZoneName(field string) Rule {
+	return optional(field, profile.ValidateTimeZone)
+}
+
+// HTTPURL requires an absolute http or https URL when the field is set.
+func HTTPURL(field string) Rule {
+	return optional(field, profile.ValidateAvatarURL)
+}
+
+// optional runs check on non-empty values and reports its error as a
+// violation.
+func optional(field string, check func(string) error) Rule {
+	return Rule{Field: field, Check: func(value string) error {
+		if value == "" {
+			return nil
+		}
+		if err := check(value); err != nil {
+			return newValidationError(err.Error())
+		}
+		return nil
+	}}
+}
+
+// EmailFormat requires an RFC 5322 addr-spec; see email.Parse.
+func EmailFormat(field string) Rule {
+	return Rule{Field: field, Check: func(value string) error {
+		_, err := email.Parse(value)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, email.ErrInvalid):
+			return errInvalidFormatEmail
+		default:
+			return newValidationError(err.Error())
+		}
+	}}
+}
+
+// BlockedDomains rejects emails at any of domains or their subdomains.
+func BlockedDomains(field string, domains []string) Rule {
+	return domainRule(field, domains, false, "email domain is not allowed")
+}
+
+// AllowedDomains only accepts emails at one of domains or their subdomains.
+func AllowedDomains(field string, domains []string) Rule {
+	return domainRule(field, domains, true, "email domain is not on the allow list")
+}
+
+// DisposableDomains rejects throwaway email providers.
+func DisposableDomains(field string, domains []string) Rule {
+	return domainRule(field, domains, false, "disposable email addresses are not allowed")
+}
+
+func domainRule(field string, domains []string, allow bool, message string) Rule {
+	set := make(map[string]bool, len(domains))
+	for _, d := range domains {
+		if ascii, err := email.NormalizeDomain(d); err == nil {
+			set[ascii] = true
+		} else {
+			set[strings.ToLower(strings.TrimSpace(d))] = true
+		}
+	}
+
+	return Rule{Field: field, Check: func(value string) error {
+		if matchesDomain(set, emailDomain(value)) != allow {
+			return newValidationError(message)
+		}
+		return nil
+	}}
+}
+
+func matchesDomain(set map[string]bool, domain string) bool {
+	for domain != "" {
+		if set[domain] {
+			return true
+		}
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok {
+			break
+		}
+		domain = parent
+	}
+	return false
+}
+
+// emailDomain returns the ASCII domain of value, falling back to the raw text
+// after the last "@" for addresses that do not parse.
+func emailDomain(value string) string {
+	if addr, err := email.Parse(value); err == nil {
+		return addr.ASCIIDomain
+	}
+	at := strings.LastIndex(value, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(value[at+1:])
+}
+
+func fieldValue(user *model.User, field string) string {
+	if ref := fieldRef(user, field); ref != nil {
+		return *ref
+	}
+	return ""
+}
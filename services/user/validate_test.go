@@ -0,0 +1,147 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+)
+
+func violationFields(t *testing.T, err error) []string {
+	t.Helper()
+
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr), "expected *ValidationError, got %v", err)
+
+	var fields []string
+	for _, v := range verr.Violations {
+		fields = append(fields, v.Field)
+	}
+	return fields
+}
+
+func writeRules(t *testing.T, rules string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "disposable.txt"), []byte("# throwaway\nmailinator.com\n\n"), 0644))
+	path := filepath.Join(dir, "rules.json")
+	require.NoError(t, os.WriteFile(path, []byte(rules), 0644))
+	return path
+}
+
+func TestRules_ReportsAllViolations(t *testing.T) {
+	err := DefaultRules().Validate(&model.User{Name: "", Email: "not-an-email"})
+
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.ErrorIs(t, err, errInvalidName)
+	assert.ErrorIs(t, err, errInvalidFormatEmail)
+	assert.Equal(t, []string{FieldName, FieldEmail}, violationFields(t, err))
+}
+
+func TestRules_OneViolationPerField(t *testing.T) {
+	err := DefaultRules().Validate(&model.User{Name: "Test", Email: ""})
+
+	assert.ErrorIs(t, err, errInvalidEmail)
+	assert.NotErrorIs(t, err, errInvalidFormatEmail)
+	assert.Equal(t, []string{FieldEmail}, violationFields(t, err))
+}
+
+func TestLoadRules(t *testing.T) {
+	path := writeRules(t, `{
+		"name": {"max_length": 10, "pattern": "^[A-Za-z ]+$", "pattern_message": "letters only"},
+		"email": {
+			"blocked_domains": ["blocked.com"],
+			"allowed_domains": ["corp.com", "blocked.com", "mailinator.com"],
+			"disposable_domains_file": "disposable.txt"
+		}
+	}`)
+
+	rules, err := LoadRules(path)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		user   model.User
+		fields []string
+	}{
+		{name: "valid", user: model.User{Name: "Jane Doe", Email: "jane@corp.com"}},
+		{name: "subdomain of allowed", user: model.User{Name: "Jane", Email: "jane@eu.corp.com"}},
+		{name: "name too long", user: model.User{Name: "Jane Maria Doe", Email: "jane@corp.com"}, fields: []string{FieldName}},
+		{name: "name characters", user: model.User{Name: "Jane_1", Email: "jane@corp.com"}, fields: []string{FieldName}},
+		{name: "blocked domain", user: model.User{Name: "Jane", Email: "jane@Blocked.com"}, fields: []string{FieldEmail}},
+		{name: "not allow-listed", user: model.User{Name: "Jane", Email: "jane@gmail.com"}, fields: []string{FieldEmail}},
+		{name: "disposable", user: model.User{Name: "Jane", Email: "jane@mailinator.com"}, fields: []string{FieldEmail}},
+		{name: "both fields", user: model.User{Name: "Jane_Maria_Doe", Email: "jane@gmail.com"}, fields: []string{FieldName, FieldEmail}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rules.Validate(&tt.user)
+			if tt.fields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tt.fields, violationFields(t, err))
+		})
+	}
+}
+
+func TestLoadRules_RequiredProfileField(t *testing.T) {
+	rules, err := LoadRules(writeRules(t, `{"phone": {"required": true}}`))
+	require.NoError(t, err)
+
+	err = rules.Validate(&model.User{Name: "Jane", Email: "jane@example.com"})
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, FieldPhone, verr.Violations[0].Field)
+
+	assert.NoError(t, rules.Validate(&model.User{Name: "Jane", Email: "jane@example.com", Phone: "+14155550100"}))
+}
+
+func TestLoadRules_Errors(t *testing.T) {
+	_, err := LoadRules(writeRules(t, `{"name": {"pattern": "("}}`))
+	assert.Error(t, err)
+
+	_, err = LoadRules(writeRules(t, `{"email": {"disposable_domains_file": "missing.txt"}}`))
+	assert.Error(t, err)
+
+	_, err = LoadRules(writeRules(t, `{"nickname": {"max_length": 10}}`))
+	assert.ErrorContains(t, err, `unknown field "nickname"`)
+
+	rules, err := LoadRules("")
+	assert.NoError(t, err)
+	assert.Len(t, rules, len(DefaultRules()))
+}
+
+func TestChain(t *testing.T) {
+	nameLength := Rules{MaxLength(FieldName, 3)}
+	v := Chain(DefaultRules(), nameLength)
+
+	err := v.Validate(&model.User{Name: "Long name", Email: "bad"})
+	assert.Equal(t, []string{FieldEmail, FieldName}, violationFields(t, err))
+	assert.NoError(t, v.Validate(&model.User{Name: "Ann", Email: "ann@example.com"}))
+}
+
+func TestUpdate_ValidatesOnlyChangedFields(t *testing.T) {
+	legacy := model.User{ID: uuid.New(), Name: "Name that predates the length rule", Email: "legacy@example.com"}
+	srv := New(newRepo(t, legacy), WithValidator(Chain(DefaultRules(), Rules{MaxLength(FieldName, 10)})))
+
+	email := "new@example.com"
+	updated, err := srv.Update(context.Background(), &dto.UpdateUserDTO{ID: legacy.ID, Email: &email})
+	require.NoError(t, err)
+	assert.Equal(t, email, updated.Email)
+
+	name := "Still far too long"
+	badEmail := "bad"
+	_, err = srv.Update(context.Background(), &dto.UpdateUserDTO{ID: legacy.ID, Name: &name, Email: &badEmail})
+	assert.ElementsMatch(t, []string{FieldName, FieldEmail}, violationFields(t, err))
+}
@@ -0,0 +1,18 @@
+package verification
+
+import (
+	"errors"
+
+	"github.com/sergey4qb/mf1-test/services/user"
+)
+
+// Error kinds returned by the service. A token that is well formed and
+// correctly signed fails with one of the precondition errors; anything else
+// is ErrInvalidToken.
+var (
+	ErrInvalidToken    = errors.New("invalid verification token")
+	ErrTokenExpired    = errors.New("verification token expired")
+	ErrTokenUsed       = errors.New("verification token was already used")
+	ErrEmailChanged    = user.ErrEmailChanged
+	ErrAlreadyVerified = errors.New("email is already verified")
+)
@@ -0,0 +1,147 @@
+package verification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/mailer"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/repository/verification"
+	userservice "github.com/sergey4qb/mf1-test/services/user"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+// DefaultTokenTTL is how long tokens stay valid unless WithTokenTTL says
+// otherwise.
+const DefaultTokenTTL = 24 * time.Hour
+
+type Verification interface {
+	// Send mails a new token for the user's current email and returns when
+	// it expires. Earlier tokens stay valid until they expire.
+	Send(ctx context.Context, userID uuid.UUID) (time.Time, error)
+	// Verify consumes token and marks the email it was sent to verified.
+	Verify(ctx context.Context, token string) (*model.User, error)
+}
+
+type service struct {
+	store  user.Repository
+	users  userservice.User
+	tokens verification.Repository
+	mailer mailer.Mailer
+	secret []byte
+	ttl    time.Duration
+	link   string
+	now    func() time.Time
+}
+
+type Option func(*service)
+
+// WithTokenTTL sets how long tokens stay valid.
+func WithTokenTTL(ttl time.Duration) Option {
+	return func(s *service) {
+		if ttl > 0 {
+			s.ttl = ttl
+		}
+	}
+}
+
+// WithLink makes the email contain a link to base with the token added as
+// the "token" query parameter, e.g. https://app.example.com/verify.
+func WithLink(base string) Option {
+	return func(s *service) {
+		s.link = base
+	}
+}
+
+// New returns a service that reads users from store and marks them verified
+// through users, so that the change is made, and audited, like any other.
+func New(store user.Repository, users userservice.User, tokens verification.Repository, m mailer.Mailer, secret []byte, opts ...Option) Verification {
+	s := &service{
+		store:  store,
+		users:  users,
+		tokens: tokens,
+		mailer: m,
+		secret: secret,
+		ttl:    DefaultTokenTTL,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *service) Send(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	u, err := s.store.GetByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if u.EmailVerified {
+		return time.Time{}, ErrAlreadyVerified
+	}
+
+	now := s.now()
+	// Tokens carry whole seconds, so the stored expiry is truncated to match.
+	expiresAt := now.Add(s.ttl).Truncate(time.Second)
+	token, err := signToken(s.secret, u.ID, expiresAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+	err = s.tokens.Save(ctx, &model.VerificationToken{
+		Hash:           tokenHash(token),
+		UserID:         u.ID,
+		OrganizationID: tenant.FromContext(ctx),
+		Email:          u.EmailCanonical,
+		CreatedAt:      now,
+		ExpiresAt:      expiresAt,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if err := s.mailer.Send(ctx, s.message(u, token, expiresAt)); err != nil {
+		return time.Time{}, fmt.Errorf("send verification email: %w", err)
+	}
+	return expiresAt, nil
+}
+
+func (s *service) message(u *model.User, token string, expiresAt time.Time) mailer.Message {
+	body := fmt.Sprintf("Hello %s,\n\nconfirm your email address with this code:\n\n%s\n", u.Name, token)
+	if s.link != "" {
+		if link, err := url.Parse(s.link); err == nil {
+			q := link.Query()
+			q.Set("token", token)
+			link.RawQuery = q.Encode()
+			body = fmt.Sprintf("Hello %s,\n\nconfirm your email address by opening this link:\n\n%s\n", u.Name, link)
+		}
+	}
+	body += fmt.Sprintf("\nThe code expires at %s.\n", expiresAt.UTC().Format(time.RFC1123))
+	return mailer.Message{To: u.Email, Subject: "Confirm your email address", Body: body}
+}
+
+func (s *service) Verify(ctx context.Context, token string) (*model.User, error) {
+	claims, err := parseToken(s.secret, token)
+	if err != nil {
+		return nil, err
+	}
+	if !s.now().Before(claims.expiresAt) {
+		return nil, ErrTokenExpired
+	}
+
+	stored, err := s.tokens.Consume(ctx, tokenHash(token))
+	if errors.Is(err, verification.ErrTokenNotFound) {
+		return nil, ErrTokenUsed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = tenant.NewContext(ctx, stored.OrganizationID)
+	return s.users.VerifyEmail(ctx, stored.UserID, stored.Email)
+}
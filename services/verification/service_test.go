@@ -0,0 +1,170 @@
+package verification
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/mailer"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/user"
+	"github.com/sergey4qb/mf1-test/repository/verification"
+	userservice "github.com/sergey4qb/mf1-test/services/user"
+)
+
+type fakeMailer struct {
+	sent []mailer.Message
+	err  error
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg mailer.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var tokenPattern = regexp.MustCompile(`[A-Za-z0-9_-]{80,}`)
+
+// lastToken extracts the token from the most recent message.
+func (m *fakeMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	require.NotEmpty(t, m.sent)
+	token := tokenPattern.FindString(m.sent[len(m.sent)-1].Body)
+	require.NotEmpty(t, token)
+	return token
+}
+
+type fixture struct {
+	svc    *service
+	users  user.Repository
+	mailer *fakeMailer
+	user   model.User
+	now    time.Time
+}
+
+func newFixture(t *testing.T, opts ...Option) *fixture {
+	t.Helper()
+
+	tokens, err := verification.New(filepath.Join(t.TempDir(), "verification.json"))
+	require.NoError(t, err)
+
+	f := &fixture{
+		users:  user.NewMemory(),
+		mailer: &fakeMailer{},
+		user:   model.User{ID: uuid.New(), Name: "Jane", Email: "jane@example.com", EmailCanonical: "jane@example.com"},
+		now:    time.Now(),
+	}
+	require.NoError(t, f.users.Create(context.Background(), &f.user))
+
+	f.svc = New(f.users, userservice.New(f.users), tokens, f.mailer, []byte("test-secret"), opts...).(*service)
+	f.svc.now = func() time.Time { return f.now }
+	return f
+}
+
+func TestVerify_MarksEmailVerified(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	expiresAt, err := f.svc.Send(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, f.now.Add(DefaultTokenTTL), expiresAt, time.Second)
+	require.Len(t, f.mailer.sent, 1)
+	assert.Equal(t, "jane@example.com", f.mailer.sent[0].To)
+
+	u, err := f.svc.Verify(ctx, f.mailer.lastToken(t))
+	require.NoError(t, err)
+	assert.True(t, u.EmailVerified)
+
+	stored, err := f.users.GetByID(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.EmailVerified)
+
+	_, err = f.svc.Send(ctx, f.user.ID)
+	assert.ErrorIs(t, err, ErrAlreadyVerified)
+}
+
+func TestVerify_SingleUse(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	_, err := f.svc.Send(ctx, f.user.ID)
+	require.NoError(t, err)
+	token := f.mailer.lastToken(t)
+
+	_, err = f.svc.Verify(ctx, token)
+	require.NoError(t, err)
+	_, err = f.svc.Verify(ctx, token)
+	assert.ErrorIs(t, err, ErrTokenUsed)
+}
+
+func TestVerify_RejectsBadTokens(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	_, err := f.svc.Send(ctx, f.user.ID)
+	require.NoError(t, err)
+	token := f.mailer.lastToken(t)
+
+	tampered := []byte(token)
+	tampered[5] ^= 1
+	for _, bad := range []string{"", "not a token", token[:len(token)-2], string(tampered)} {
+		_, err := f.svc.Verify(ctx, bad)
+		assert.ErrorIs(t, err, ErrInvalidToken, bad)
+	}
+
+	other := New(f.users, f.svc.users, f.svc.tokens, f.mailer, []byte("other-secret"))
+	_, err = other.Verify(ctx, token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerify_Expired(t *testing.T) {
+	f := newFixture(t, WithTokenTTL(time.Hour))
+	ctx := context.Background()
+
+	_, err := f.svc.Send(ctx, f.user.ID)
+	require.NoError(t, err)
+
+	f.now = f.now.Add(time.Hour + time.Second)
+	_, err = f.svc.Verify(ctx, f.mailer.lastToken(t))
+	assert.ErrorIs(t, err, ErrTokenExpired)
+}
+
+func TestVerify_EmailChanged(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	_, err := f.svc.Send(ctx, f.user.ID)
+	require.NoError(t, err)
+
+	f.user.Email, f.user.EmailCanonical = "jane.doe@example.com", "jane.doe@example.com"
+	require.NoError(t, f.users.Update(ctx, &f.user))
+
+	_, err = f.svc.Verify(ctx, f.mailer.lastToken(t))
+	assert.ErrorIs(t, err, ErrEmailChanged)
+}
+
+func TestSend_LinkAndErrors(t *testing.T) {
+	f := newFixture(t, WithLink("https://app.example.com/verify?source=mail"))
+	ctx := context.Background()
+
+	_, err := f.svc.Send(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.True(t, strings.Contains(f.mailer.sent[0].Body, "https://app.example.com/verify?source=mail&token="+f.mailer.lastToken(t)))
+
+	_, err = f.svc.Send(ctx, uuid.New())
+	assert.ErrorIs(t, err, user.ErrUserNotFound)
+
+	f.mailer.err = errors.New("connection refused")
+	_, err = f.svc.Send(ctx, f.user.ID)
+	assert.ErrorContains(t, err, "send verification email")
+}
@@ -0,0 +1,66 @@
+package verification
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A token is base64url(payload || HMAC-SHA256(secret, payload)), where the
+// payload is the user ID, the expiry in Unix seconds and a random nonce. The
+// signature lets forged or mistyped tokens be rejected without a lookup; the
+// store only keeps tokenHash, which makes each token single-use.
+const (
+	nonceSize   = 16
+	payloadSize = 16 + 8 + nonceSize
+	tokenSize   = payloadSize + sha256.Size
+)
+
+type tokenClaims struct {
+	userID    uuid.UUID
+	expiresAt time.Time
+}
+
+func signToken(secret []byte, userID uuid.UUID, expiresAt time.Time) (string, error) {
+	payload := make([]byte, payloadSize, tokenSize)
+	copy(payload, userID[:])
+	binary.BigEndian.PutUint64(payload[16:], uint64(expiresAt.Unix()))
+	if _, err := rand.Read(payload[24:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(append(payload, mac(secret, payload)...)), nil
+}
+
+func parseToken(secret []byte, token string) (tokenClaims, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != tokenSize {
+		return tokenClaims{}, ErrInvalidToken
+	}
+	payload, sig := raw[:payloadSize], raw[payloadSize:]
+	if !hmac.Equal(sig, mac(secret, payload)) {
+		return tokenClaims{}, ErrInvalidToken
+	}
+
+	var claims tokenClaims
+	copy(claims.userID[:], payload[:16])
+	claims.expiresAt = time.Unix(int64(binary.BigEndian.Uint64(payload[16:24])), 0)
+	return claims, nil
+}
+
+func mac(secret, payload []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// tokenHash is how a token is stored.
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,297 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/organization"
+	"github.com/sergey4qb/mf1-test/repository/webhook"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+const (
+	defaultInterval     = time.Second
+	defaultBackoff      = 10 * time.Second
+	defaultMaxBackoff   = time.Hour
+	defaultMaxAttempts  = 8
+	defaultDisableAfter = 20
+	requestTimeout      = 10 * time.Second
+)
+
+type Dispatcher interface {
+	// Run delivers queued events until ctx is done.
+	Run(ctx context.Context)
+	// Flush makes one attempt for every due delivery of every
+	// organization.
+	Flush(ctx context.Context) error
+}
+
+type dispatcher struct {
+	repo   webhook.Repository
+	orgs   organization.Repository
+	client *http.Client
+
+	interval     time.Duration
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	maxAttempts  int
+	disableAfter int
+	now          func() time.Time
+	// jitter spreads a retry delay so endpoints coming back up are not hit
+	// by every retry at once.
+	jitter func(time.Duration) time.Duration
+
+	// mu keeps flushes from sending the same delivery twice at once.
+	mu sync.Mutex
+}
+
+type Option func(*dispatcher)
+
+// WithInterval sets how often Run looks for due deliveries; zero keeps the
+// default of a second.
+func WithInterval(interval time.Duration) Option {
+	return func(d *dispatcher) {
+		if interval > 0 {
+			d.interval = interval
+		}
+	}
+}
+
+// WithBackoff sets the delay after the first failed attempt of a delivery,
+// doubled after each further failure up to max; the actual delay is picked
+// at random between half and all of it. Zero values keep the defaults of
+// ten seconds and an hour.
+func WithBackoff(initial, max time.Duration) Option {
+	return func(d *dispatcher) {
+		if initial > 0 {
+			d.backoff = initial
+		}
+		if max > 0 {
+			d.maxBackoff = max
+		}
+	}
+}
+
+// WithMaxAttempts sets after how many failed attempts a delivery is given
+// up; zero keeps the default of 8.
+func WithMaxAttempts(n int) Option {
+	return func(d *dispatcher) {
+		if n > 0 {
+			d.maxAttempts = n
+		}
+	}
+}
+
+// WithDisableAfter sets after how many failed attempts in a row, across
+// deliveries, a webhook is disabled; zero keeps the default of 20.
+func WithDisableAfter(n int) Option {
+	return func(d *dispatcher) {
+		if n > 0 {
+			d.disableAfter = n
+		}
+	}
+}
+
+// NewDispatcher returns a Dispatcher sending the deliveries queued in repo,
+// for the default organization and every organization in orgs. Each event
+// is posted as JSON and any 2xx response accepts it; every attempt is
+// logged in repo.
+func NewDispatcher(repo webhook.Repository, orgs organization.Repository, opts ...Option) Dispatcher {
+	d := &dispatcher{
+		repo:         repo,
+		orgs:         orgs,
+		client:       &http.Client{Timeout: requestTimeout},
+		interval:     defaultInterval,
+		backoff:      defaultBackoff,
+		maxBackoff:   defaultMaxBackoff,
+		maxAttempts:  defaultMaxAttempts,
+		disableAfter: defaultDisableAfter,
+		now:          time.Now,
+		jitter: func(delay time.Duration) time.Duration {
+			return delay/2 + rand.N(delay/2+1)
+		},
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+func (d *dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Flush(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("webhooks: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *dispatcher) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	orgs, err := d.orgs.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	ids := []uuid.UUID{tenant.Default}
+	for _, org := range orgs {
+		ids = append(ids, org.ID)
+	}
+
+	var errs []error
+	for _, id := range ids {
+		if err := d.flush(tenant.NewContext(ctx, id)); err != nil {
+			errs = append(errs, fmt.Errorf("organization %s: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// flush sends the due deliveries of the organization in ctx.
+func (d *dispatcher) flush(ctx context.Context) error {
+	deliveries, err := d.repo.Pending(ctx)
+	if err != nil || len(deliveries) == 0 {
+		return err
+	}
+
+	all, err := d.repo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	webhooks := map[uuid.UUID]*model.Webhook{}
+	for i := range all {
+		webhooks[all[i].ID] = &all[i]
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		w := webhooks[delivery.WebhookID]
+		// Deliveries of a webhook disabled during this flush were dropped.
+		if w == nil || w.DisabledAt != nil || d.now().Before(delivery.NextAttempt) {
+			continue
+		}
+
+		updated, err := d.deliver(ctx, w, delivery)
+		if errors.Is(err, webhook.ErrWebhookNotFound) {
+			// Deleted while the request was in flight.
+			delete(webhooks, w.ID)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		webhooks[w.ID] = updated
+	}
+	return nil
+}
+
+// deliver makes one attempt of delivery, records it and disables w if it
+// failed too often. It returns w as updated.
+func (d *dispatcher) deliver(ctx context.Context, w *model.Webhook, delivery *model.WebhookDelivery) (*model.Webhook, error) {
+	start := d.now()
+	status, err := d.post(ctx, w, delivery)
+	// An attempt cut short by shutdown is not the endpoint's failure.
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	attempt := &model.WebhookAttempt{
+		WebhookID:  w.ID,
+		DeliveryID: delivery.ID,
+		EventID:    delivery.Event.ID,
+		EventType:  delivery.Event.Type,
+		Attempt:    delivery.Attempts + 1,
+		At:         start.UTC(),
+		StatusCode: status,
+		Duration:   d.now().Sub(start),
+	}
+	var retry *time.Time
+	if err != nil {
+		attempt.Error = err.Error()
+		if attempt.Attempt < d.maxAttempts {
+			next := d.now().Add(d.jitter(d.delay(attempt.Attempt))).UTC()
+			retry = &next
+		} else {
+			log.Printf("webhooks: giving up delivery %s of event %s to %s after %d attempts", delivery.ID, delivery.Event.ID, w.URL, attempt.Attempt)
+		}
+	}
+
+	updated, err := d.repo.Record(ctx, attempt, retry)
+	if err != nil {
+		return nil, err
+	}
+	if updated.Failures >= d.disableAfter && updated.DisabledAt == nil {
+		now := d.now().UTC()
+		updated.DisabledAt = &now
+		if err := d.repo.Update(ctx, updated); err != nil {
+			return nil, err
+		}
+		log.Printf("webhooks: disabled %s after %d failed attempts in a row", w.URL, updated.Failures)
+	}
+	return updated, nil
+}
+
+// post sends delivery to w and returns the response status, 0 when none
+// came.
+func (d *dispatcher) post(ctx context.Context, w *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	now := d.now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", delivery.Event.ID.String())
+	req.Header.Set(EventHeader, string(delivery.Event.Type))
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+	req.Header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(w.Secret, now, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Draining lets the connection be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("%w: %s", errUnexpectedStatus, resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// delay returns how long to wait after the given number of failed
+// attempts, before jitter.
+func (d *dispatcher) delay(attempts int) time.Duration {
+	delay := d.backoff
+	for i := 1; i < attempts && delay < d.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.maxBackoff)
+}
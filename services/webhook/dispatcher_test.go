@@ -0,0 +1,292 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/organization"
+	"github.com/sergey4qb/mf1-test/repository/webhook"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// receiver is a partner endpoint that answers status and records the
+// deliveries whose signature checks out.
+type receiver struct {
+	srv    *httptest.Server
+	status int
+
+	mu       sync.Mutex
+	calls    int
+	events   []model.Event
+	headers  []http.Header
+	rejected int
+}
+
+func newReceiver(t *testing.T, now func() time.Time) *receiver {
+	r := &receiver{status: http.StatusNoContent}
+	r.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.calls++
+		body, err := io.ReadAll(req.Body)
+		assert.NoError(t, err)
+		if err := Verify(testSecret, req.Header, body, now(), 5*time.Minute); err != nil {
+			r.rejected++
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var event model.Event
+		assert.NoError(t, json.Unmarshal(body, &event))
+		r.events = append(r.events, event)
+		r.headers = append(r.headers, req.Header.Clone())
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(r.srv.Close)
+	return r
+}
+
+type fixture struct {
+	repo webhook.Repository
+	orgs organization.Repository
+	now  time.Time
+}
+
+func newFixture(t *testing.T) *fixture {
+	dir := t.TempDir()
+	repo, err := webhook.NewFile(filepath.Join(dir, "webhooks.json"))
+	require.NoError(t, err)
+	orgs, err := organization.NewFile(filepath.Join(dir, "organizations.json"))
+	require.NoError(t, err)
+	return &fixture{
+		repo: repo,
+		orgs: orgs,
+		now:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (f *fixture) dispatcher(opts ...Option) *dispatcher {
+	d := NewDispatcher(f.repo, f.orgs, opts...).(*dispatcher)
+	d.now = func() time.Time { return f.now }
+	// Without jitter delays are predictable.
+	d.jitter = func(delay time.Duration) time.Duration { return delay }
+	return d
+}
+
+func (f *fixture) createWebhook(t *testing.T, ctx context.Context, url string, types ...model.EventType) *model.Webhook {
+	t.Helper()
+
+	w := &model.Webhook{ID: uuid.New(), URL: url, EventTypes: types, Secret: testSecret}
+	require.NoError(t, f.repo.Create(ctx, w))
+	return w
+}
+
+func (f *fixture) publish(t *testing.T, org uuid.UUID, eventType model.EventType) *model.Event {
+	t.Helper()
+
+	userID := uuid.New()
+	event := &model.Event{
+		ID:             uuid.New(),
+		Type:           eventType,
+		OrganizationID: org,
+		UserID:         userID,
+		At:             f.now,
+		User:           &model.User{ID: userID, Name: "Jane", Email: "jane@example.com", Status: model.StatusActive},
+	}
+	require.NoError(t, NewSink(f.repo).Deliver(context.Background(), event))
+	return event
+}
+
+func (f *fixture) pending(t *testing.T, ctx context.Context) []model.WebhookDelivery {
+	t.Helper()
+
+	deliveries, err := f.repo.Pending(ctx)
+	require.NoError(t, err)
+	return deliveries
+}
+
+func TestFlush_DeliversSigned(t *testing.T) {
+	f := newFixture(t)
+	r := newReceiver(t, func() time.Time { return f.now })
+	d := f.dispatcher()
+	ctx := context.Background()
+	w := f.createWebhook(t, ctx, r.srv.URL)
+
+	event := f.publish(t, tenant.Default, model.EventUserCreated)
+	require.NoError(t, d.Flush(ctx))
+
+	require.Len(t, r.events, 1)
+	assert.Equal(t, *event, r.events[0])
+	assert.Equal(t, string(model.EventUserCreated), r.headers[0].Get(EventHeader))
+	assert.Equal(t, strconv.FormatInt(f.now.Unix(), 10), r.headers[0].Get(TimestampHeader))
+	assert.Equal(t, event.ID.String(), r.headers[0].Get("Idempotency-Key"))
+	assert.Empty(t, f.pending(t, ctx))
+
+	attempts, err := f.repo.Attempts(ctx, w.ID, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.True(t, attempts[0].Succeeded())
+	assert.Equal(t, http.StatusNoContent, attempts[0].StatusCode)
+	assert.Equal(t, event.ID, attempts[0].EventID)
+	assert.Equal(t, r.headers[0].Get(DeliveryHeader), attempts[0].DeliveryID.String())
+}
+
+func TestSink_QueuesForMatchingWebhooks(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	org := uuid.New()
+	all := f.createWebhook(t, ctx, "https://all.example.com")
+	deletions := f.createWebhook(t, ctx, "https://deletions.example.com", model.EventUserDeleted)
+	disabled := f.createWebhook(t, ctx, "https://disabled.example.com")
+	disabled.DisabledAt = &f.now
+	require.NoError(t, f.repo.Update(ctx, disabled))
+	f.createWebhook(t, tenant.NewContext(ctx, org), "https://other.example.com")
+
+	f.publish(t, tenant.Default, model.EventUserCreated)
+	f.publish(t, tenant.Default, model.EventUserDeleted)
+
+	var queued []uuid.UUID
+	for _, d := range f.pending(t, ctx) {
+		queued = append(queued, d.WebhookID)
+	}
+	assert.Equal(t, []uuid.UUID{all.ID, all.ID, deletions.ID}, queued)
+	assert.Empty(t, f.pending(t, tenant.NewContext(ctx, org)))
+}
+
+func TestFlush_RetriesWithBackoff(t *testing.T) {
+	f := newFixture(t)
+	r := newReceiver(t, func() time.Time { return f.now })
+	r.status = http.StatusServiceUnavailable
+	d := f.dispatcher(WithBackoff(time.Second, time.Minute))
+	ctx := context.Background()
+	w := f.createWebhook(t, ctx, r.srv.URL)
+	f.publish(t, tenant.Default, model.EventUserCreated)
+
+	require.NoError(t, d.Flush(ctx))
+	deliveries := f.pending(t, ctx)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, f.now.Add(time.Second), deliveries[0].NextAttempt)
+
+	require.NoError(t, d.Flush(ctx))
+	assert.Equal(t, 1, r.calls, "the delivery is not due yet")
+
+	f.now = f.now.Add(time.Second)
+	require.NoError(t, d.Flush(ctx))
+	deliveries = f.pending(t, ctx)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, f.now.Add(2*time.Second), deliveries[0].NextAttempt, "the delay doubles")
+
+	r.status = http.StatusOK
+	f.now = f.now.Add(2 * time.Second)
+	require.NoError(t, d.Flush(ctx))
+	assert.Empty(t, f.pending(t, ctx))
+
+	attempts, err := f.repo.Attempts(ctx, w.ID, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, attempts, 3)
+	assert.Equal(t, http.StatusServiceUnavailable, attempts[0].StatusCode)
+	assert.Contains(t, attempts[0].Error, "503")
+	assert.Equal(t, []int{1, 2, 3}, []int{attempts[0].Attempt, attempts[1].Attempt, attempts[2].Attempt})
+	assert.True(t, attempts[2].Succeeded())
+
+	stored, err := f.repo.GetByID(ctx, w.ID)
+	require.NoError(t, err)
+	assert.Zero(t, stored.Failures)
+}
+
+func TestFlush_GivesUp(t *testing.T) {
+	f := newFixture(t)
+	r := newReceiver(t, func() time.Time { return f.now })
+	r.status = http.StatusInternalServerError
+	d := f.dispatcher(WithMaxAttempts(2))
+	ctx := context.Background()
+	f.createWebhook(t, ctx, r.srv.URL)
+	f.publish(t, tenant.Default, model.EventUserCreated)
+
+	require.NoError(t, d.Flush(ctx))
+	f.now = f.now.Add(time.Hour)
+	require.NoError(t, d.Flush(ctx))
+
+	assert.Equal(t, 2, r.calls)
+	assert.Empty(t, f.pending(t, ctx))
+}
+
+func TestFlush_DisablesFailingWebhooks(t *testing.T) {
+	f := newFixture(t)
+	r := newReceiver(t, func() time.Time { return f.now })
+	r.status = http.StatusInternalServerError
+	d := f.dispatcher(WithDisableAfter(3))
+	ctx := context.Background()
+	w := f.createWebhook(t, ctx, r.srv.URL)
+	for i := 0; i < 4; i++ {
+		f.publish(t, tenant.Default, model.EventUserUpdated)
+	}
+
+	require.NoError(t, d.Flush(ctx))
+	assert.Equal(t, 3, r.calls, "no requests are made once the webhook is disabled")
+
+	stored, err := f.repo.GetByID(ctx, w.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.DisabledAt)
+	assert.Equal(t, f.now, *stored.DisabledAt)
+	assert.Empty(t, f.pending(t, ctx), "disabling drops the queued deliveries")
+
+	f.publish(t, tenant.Default, model.EventUserUpdated)
+	assert.Empty(t, f.pending(t, ctx), "disabled webhooks receive no events")
+}
+
+func TestFlush_EveryOrganization(t *testing.T) {
+	f := newFixture(t)
+	r := newReceiver(t, func() time.Time { return f.now })
+	d := f.dispatcher()
+	org := &model.Organization{ID: uuid.New(), Name: "Acme"}
+	require.NoError(t, f.orgs.Create(context.Background(), org))
+	f.createWebhook(t, tenant.NewContext(context.Background(), org.ID), r.srv.URL)
+
+	f.publish(t, org.ID, model.EventUserCreated)
+	require.NoError(t, d.Flush(context.Background()))
+
+	require.Len(t, r.events, 1)
+	assert.Equal(t, org.ID, r.events[0].OrganizationID)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	body := []byte(`{"type":"UserCreated"}`)
+	header := http.Header{}
+	header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	header.Set(SignatureHeader, Sign(testSecret, now, body))
+
+	require.NoError(t, Verify(testSecret, header, body, now.Add(time.Minute), 5*time.Minute))
+	assert.ErrorIs(t, Verify("another secret", header, body, now, 5*time.Minute), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(testSecret, header, []byte(`{"type":"UserDeleted"}`), now, 5*time.Minute), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(testSecret, header, body, now.Add(time.Hour), 5*time.Minute), ErrInvalidSignature, "stale requests are replays")
+
+	header.Set(TimestampHeader, strconv.FormatInt(now.Add(time.Second).Unix(), 10))
+	assert.ErrorIs(t, Verify(testSecret, header, body, now, 5*time.Minute), ErrInvalidSignature, "the timestamp is signed")
+}
+
+func TestJitter(t *testing.T) {
+	d := NewDispatcher(nil, nil).(*dispatcher)
+	for i := 0; i < 100; i++ {
+		delay := d.jitter(time.Minute)
+		assert.GreaterOrEqual(t, delay, 30*time.Second)
+		assert.LessOrEqual(t, delay, time.Minute)
+	}
+}
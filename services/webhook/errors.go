@@ -0,0 +1,41 @@
+package webhook
+
+import (
+	"errors"
+
+	"github.com/sergey4qb/mf1-test/repository/webhook"
+)
+
+// Error kinds returned by the service.
+var (
+	ErrNotFound   = webhook.ErrWebhookNotFound
+	ErrValidation = errors.New("validation failed")
+	// ErrInvalidSignature is returned by Verify for requests that were not
+	// signed with the secret or whose timestamp is out of tolerance.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+var (
+	errInvalidURL       = newValidationError("url must be an absolute http or https URL")
+	errSecretTooShort   = newValidationError("secret must be at least 16 characters")
+	errInvalidPageSize  = newValidationError("page size cannot be negative")
+	errInvalidPageToken = newValidationError("invalid page token")
+
+	errUnexpectedStatus = errors.New("unexpected response status")
+)
+
+type validationError struct {
+	msg string
+}
+
+func newValidationError(msg string) error {
+	return &validationError{msg: msg}
+}
+
+func (e *validationError) Error() string {
+	return e.msg
+}
+
+func (e *validationError) Is(target error) bool {
+	return target == ErrValidation
+}
@@ -0,0 +1,178 @@
+// Package webhook lets partners subscribe HTTP endpoints to the user events
+// of their organization. New manages the subscriptions; NewSink queues the
+// events published by the outbox for them and NewDispatcher delivers the
+// queued events, signed with the subscription secret.
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/webhook"
+)
+
+const minSecretLength = 16
+
+// eventTypes are the events webhooks may subscribe to.
+var eventTypes = []model.EventType{model.EventUserCreated, model.EventUserUpdated, model.EventUserDeleted}
+
+type Webhook interface {
+	// Create subscribes a webhook, generating a secret unless one is set.
+	Create(ctx context.Context, w *model.Webhook) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error)
+	GetAll(ctx context.Context) ([]model.Webhook, error)
+	Update(ctx context.Context, dto *dto.UpdateWebhookDTO) (*model.Webhook, error)
+	// Delete removes a webhook with its queued deliveries and attempts.
+	Delete(ctx context.Context, id uuid.UUID) error
+	// ListAttempts pages through the delivery attempts of a webhook,
+	// oldest first.
+	ListAttempts(ctx context.Context, req *dto.ListWebhookAttemptsDTO) (*dto.WebhookAttemptsPage, error)
+}
+
+type service struct {
+	repo webhook.Repository
+	now  func() time.Time
+}
+
+// New returns the webhook service. Like the user service it works on the
+// organization in the context.
+func New(repo webhook.Repository) Webhook {
+	return &service{repo: repo, now: time.Now}
+}
+
+func (s *service) Create(ctx context.Context, w *model.Webhook) error {
+	if w.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return err
+		}
+		w.Secret = secret
+	}
+	if err := validate(w); err != nil {
+		return err
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+	w.ID = id
+	w.Failures = 0
+	w.DisabledAt = nil
+	w.CreatedAt = s.now().UTC()
+	return s.repo.Create(ctx, w)
+}
+
+func (s *service) GetByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *service) GetAll(ctx context.Context) ([]model.Webhook, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *service) Update(ctx context.Context, dto *dto.UpdateWebhookDTO) (*model.Webhook, error) {
+	w, err := s.repo.GetByID(ctx, dto.ID)
+	if err != nil {
+		return nil, err
+	}
+	if dto.URL != nil {
+		w.URL = *dto.URL
+	}
+	if dto.EventTypes != nil {
+		w.EventTypes = *dto.EventTypes
+	}
+	if dto.Secret != nil {
+		w.Secret = *dto.Secret
+	}
+	if dto.Enabled != nil {
+		switch {
+		case *dto.Enabled:
+			w.DisabledAt = nil
+			w.Failures = 0
+		case w.DisabledAt == nil:
+			now := s.now().UTC()
+			w.DisabledAt = &now
+		}
+	}
+	if err := validate(w); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (s *service) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *service) ListAttempts(ctx context.Context, req *dto.ListWebhookAttemptsDTO) (*dto.WebhookAttemptsPage, error) {
+	if req.PageSize < 0 {
+		return nil, errInvalidPageSize
+	}
+	after, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	size := defaultPageSize
+	if req.PageSize > 0 {
+		size = min(req.PageSize, maxPageSize)
+	}
+
+	// One attempt more than the page tells whether there is a next one.
+	attempts, err := s.repo.Attempts(ctx, req.WebhookID, after, size+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &dto.WebhookAttemptsPage{Attempts: attempts}
+	if len(attempts) > size {
+		page.Attempts = attempts[:size]
+		page.NextPageToken = encodePageToken(attempts[size-1].Seq)
+	}
+	return page, nil
+}
+
+// validate checks the fields set by callers and drops repeated event types.
+func validate(w *model.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errInvalidURL
+	}
+	if len(w.Secret) < minSecretLength {
+		return errSecretTooShort
+	}
+
+	var types []model.EventType
+	for _, t := range w.EventTypes {
+		if !slices.Contains(eventTypes, t) {
+			return newValidationError(fmt.Sprintf("unknown event type %q", t))
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	w.EventTypes = types
+	return nil
+}
+
+// newSecret returns 32 random bytes, hex encoded.
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
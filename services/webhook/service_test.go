@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergey4qb/mf1-test/dto"
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/webhook"
+)
+
+func newRepo(t *testing.T) webhook.Repository {
+	repo, err := webhook.NewFile(filepath.Join(t.TempDir(), "webhooks.json"))
+	require.NoError(t, err)
+	return repo
+}
+
+func TestCreate(t *testing.T) {
+	srv := New(newRepo(t))
+	ctx := context.Background()
+
+	w := &model.Webhook{
+		URL:        "https://partner.example.com/hooks",
+		EventTypes: []model.EventType{model.EventUserCreated, model.EventUserCreated},
+	}
+	require.NoError(t, srv.Create(ctx, w))
+	assert.NotEqual(t, uuid.Nil, w.ID)
+	assert.Len(t, w.Secret, 64, "a secret is generated when none is set")
+	assert.Equal(t, []model.EventType{model.EventUserCreated}, w.EventTypes)
+	assert.False(t, w.CreatedAt.IsZero())
+
+	for name, w := range map[string]*model.Webhook{
+		"relative url":       {URL: "/hooks"},
+		"unsupported scheme": {URL: "ftp://partner.example.com/hooks"},
+		"short secret":       {URL: "https://partner.example.com/hooks", Secret: "short"},
+		"unknown event type": {URL: "https://partner.example.com/hooks", EventTypes: []model.EventType{"UserRenamed"}},
+	} {
+		assert.ErrorIs(t, srv.Create(ctx, w), ErrValidation, name)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	srv := New(newRepo(t))
+	ctx := context.Background()
+	w := &model.Webhook{URL: "https://partner.example.com/hooks"}
+	require.NoError(t, srv.Create(ctx, w))
+
+	url, secret, disabled := "https://partner.example.com/v2/hooks", "0123456789abcdef", false
+	types := []model.EventType{model.EventUserDeleted}
+	updated, err := srv.Update(ctx, &dto.UpdateWebhookDTO{ID: w.ID, URL: &url, EventTypes: &types, Secret: &secret, Enabled: &disabled})
+	require.NoError(t, err)
+	assert.Equal(t, url, updated.URL)
+	assert.Equal(t, types, updated.EventTypes)
+	assert.Equal(t, secret, updated.Secret)
+	require.NotNil(t, updated.DisabledAt)
+
+	enabled := true
+	updated, err = srv.Update(ctx, &dto.UpdateWebhookDTO{ID: w.ID, Enabled: &enabled})
+	require.NoError(t, err)
+	assert.Nil(t, updated.DisabledAt)
+
+	url = "not a url"
+	_, err = srv.Update(ctx, &dto.UpdateWebhookDTO{ID: w.ID, URL: &url})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = srv.Update(ctx, &dto.UpdateWebhookDTO{ID: uuid.New()})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestDelete_DropsDeliveriesAndAttempts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	repo, err := webhook.NewFile(path)
+	require.NoError(t, err)
+	srv := New(repo)
+	ctx := context.Background()
+
+	deleted := &model.Webhook{URL: "https://partner.example.com/hooks"}
+	kept := &model.Webhook{URL: "https://crm.example.com/hooks"}
+	retry := time.Now().Add(time.Hour)
+	for _, w := range []*model.Webhook{deleted, kept} {
+		require.NoError(t, srv.Create(ctx, w))
+		delivery := model.WebhookDelivery{ID: uuid.New(), WebhookID: w.ID, Event: model.Event{ID: uuid.New(), Type: model.EventUserCreated}}
+		require.NoError(t, repo.Enqueue(ctx, []model.WebhookDelivery{delivery}))
+		_, err := repo.Record(ctx, &model.WebhookAttempt{WebhookID: w.ID, DeliveryID: delivery.ID, Attempt: 1, Error: "timeout"}, &retry)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, srv.Delete(ctx, deleted.ID))
+	_, err = srv.GetByID(ctx, deleted.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, srv.Delete(ctx, deleted.ID), ErrNotFound)
+
+	pending, err := repo.Pending(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, kept.ID, pending[0].WebhookID)
+	page, err := srv.ListAttempts(ctx, &dto.ListWebhookAttemptsDTO{WebhookID: kept.ID})
+	require.NoError(t, err)
+	assert.Len(t, page.Attempts, 1)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), deleted.ID.String(), "nothing of the webhook is left behind")
+}
+
+func TestListAttempts_Pages(t *testing.T) {
+	repo := newRepo(t)
+	srv := New(repo)
+	ctx := context.Background()
+	w := &model.Webhook{URL: "https://partner.example.com/hooks"}
+	require.NoError(t, srv.Create(ctx, w))
+
+	for n := 1; n <= 5; n++ {
+		_, err := repo.Record(ctx, &model.WebhookAttempt{WebhookID: w.ID, Attempt: n, Error: "timeout"}, nil)
+		require.NoError(t, err)
+	}
+
+	var attempts []int
+	req := &dto.ListWebhookAttemptsDTO{WebhookID: w.ID, PageSize: 2}
+	for {
+		page, err := srv.ListAttempts(ctx, req)
+		require.NoError(t, err)
+		for _, a := range page.Attempts {
+			attempts = append(attempts, a.Attempt)
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		req.PageToken = page.NextPageToken
+	}
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, attempts)
+
+	_, err := srv.ListAttempts(ctx, &dto.ListWebhookAttemptsDTO{WebhookID: w.ID, PageToken: "bogus"})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = srv.ListAttempts(ctx, &dto.ListWebhookAttemptsDTO{WebhookID: uuid.New()})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
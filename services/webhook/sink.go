@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/sergey4qb/mf1-test/model"
+	"github.com/sergey4qb/mf1-test/repository/webhook"
+	"github.com/sergey4qb/mf1-test/services/outbox"
+	"github.com/sergey4qb/mf1-test/tenant"
+)
+
+type sink struct {
+	repo webhook.Repository
+}
+
+// NewSink returns an outbox.Sink queuing each event for the enabled
+// webhooks of its organization that receive its type. Queuing is all the
+// sink does, so a slow or failing endpoint holds up neither the outbox nor
+// the other webhooks.
+func NewSink(repo webhook.Repository) outbox.Sink {
+	return &sink{repo: repo}
+}
+
+func (s *sink) Name() string {
+	return "webhooks"
+}
+
+func (s *sink) Deliver(ctx context.Context, event *model.Event) error {
+	ctx = tenant.NewContext(ctx, event.OrganizationID)
+	webhooks, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []model.WebhookDelivery
+	for _, w := range webhooks {
+		if !w.Receives(event.Type) {
+			continue
+		}
+		id, err := uuid.NewV7()
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			ID:          id,
+			WebhookID:   w.ID,
+			Event:       *event,
+			NextAttempt: event.At,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return s.repo.Enqueue(ctx, deliveries)
+}
@@ -0,0 +1,29 @@
+// Package tenant carries the organization a request acts for through a
+// context. Repositories holding per-organization data, such as users, scope
+// every call by it.
+package tenant
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Default is the organization of requests that name none. It owns the data
+// stored before organizations were introduced.
+var Default = uuid.Nil
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx acting for organization.
+func NewContext(ctx context.Context, organization uuid.UUID) context.Context {
+	return context.WithValue(ctx, contextKey{}, organization)
+}
+
+// FromContext returns the organization stored in ctx, or Default.
+func FromContext(ctx context.Context) uuid.UUID {
+	if organization, ok := ctx.Value(contextKey{}).(uuid.UUID); ok {
+		return organization
+	}
+	return Default
+}